@@ -0,0 +1,108 @@
+package rangearray
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// OrderError reports a value pushed onto an AppendOnly that is not
+// after every value already in it.
+type OrderError struct {
+	// Value is the value pushed, and Max the largest value already in
+	// the array.
+	Value, Max uint32
+}
+
+func (e *OrderError) Error() string {
+	if e.Value == e.Max {
+		return fmt.Sprintf("rangearray: value %d repeats the last value", e.Value)
+	}
+	return fmt.Sprintf("rangearray: value %d is before the last value %d", e.Value, e.Max)
+}
+
+var errFull = errors.New("rangearray: array already holds MaxLen values")
+
+// AppendOnly is a rangearray for real-time feeds, whose values must
+// arrive in strictly increasing order.  Push treats a value out of
+// order, or a repeated one, as a data error instead of inserting it
+// before the end, so it never searches the array, and each Push takes
+// O(1) time.  The zero value is an empty array ready to use.  Like
+// Uint32, an AppendOnly is not safe for concurrent use while it is
+// being modified.
+type AppendOnly struct {
+	r Uint32
+}
+
+// Push adds x to a.  It returns an *OrderError, leaving a unchanged, if
+// x is not greater than a.Max().
+func (a *AppendOnly) Push(x uint32) error {
+	if n := len(a.r.runs) - 1; n >= 0 {
+		last := a.r.runs[n]
+		if end := uint64(last.Value) + uint64(last.Count); uint64(x) < end {
+			return &OrderError{Value: x, Max: uint32(end - 1)}
+		}
+	}
+	if !a.r.appendRun(x, 1) {
+		return errFull
+	}
+	return nil
+}
+
+// Min returns the minimum value in a.  Panics if a is empty.
+func (a *AppendOnly) Min() uint32 {
+	return a.r.Min()
+}
+
+// Max returns the maximum value in a.  Panics if a is empty.
+func (a *AppendOnly) Max() uint32 {
+	return a.r.Max()
+}
+
+// Len returns the number of elements in a.
+func (a *AppendOnly) Len() uint32 {
+	return a.r.Len()
+}
+
+// NumRuns returns the number of runs in a.
+func (a *AppendOnly) NumRuns() int {
+	return len(a.r.runs)
+}
+
+// IndexOf returns the number of elements in a that are less than x.
+func (a *AppendOnly) IndexOf(x uint32) uint32 {
+	return a.r.IndexOf(x)
+}
+
+// LowerBound returns the index of the run in a that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (a *AppendOnly) LowerBound(x uint32) int {
+	return a.r.LowerBound(x)
+}
+
+// Contains reports whether x is in a.
+func (a *AppendOnly) Contains(x uint32) bool {
+	return a.r.Contains(x)
+}
+
+// All returns an iterator over the values in a, in increasing order.
+func (a *AppendOnly) All() iter.Seq[uint32] {
+	return a.r.All()
+}
+
+// Runs returns an iterator over the runs in a, in increasing order.
+func (a *AppendOnly) Runs() iter.Seq[Uint32Run] {
+	return a.r.Runs()
+}
+
+// Uint32 returns a copy of a as an ordinary array.  The copy shares
+// a's runs until either is modified, as with Fork.
+func (a *AppendOnly) Uint32() Uint32 {
+	return a.r.Fork()
+}
+
+// String returns a in the format of Uint32.String.
+func (a *AppendOnly) String() string {
+	return a.r.String()
+}
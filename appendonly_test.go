@@ -0,0 +1,51 @@
+package rangearray
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAppendOnly(t *testing.T) {
+	var a AppendOnly
+	for _, x := range []uint32{3, 4, 5, 10, 0xffffffff} {
+		if err := a.Push(x); err != nil {
+			t.Errorf("Expected Push(%d) to succeed, got %v", x, err)
+		}
+	}
+	if a.String() != "3-5,10,4294967295" || a.Len() != 5 || a.NumRuns() != 3 {
+		t.Errorf("Expected 3-5,10,4294967295, got %v", &a)
+	}
+
+	var b AppendOnly
+	b.Push(10)
+	b.Push(11)
+	for _, x := range []uint32{11, 5} {
+		var oe *OrderError
+		if err := b.Push(x); !errors.As(err, &oe) || oe.Value != x || oe.Max != 11 {
+			t.Errorf("Expected Push(%d) to fail with an OrderError, got %v", x, err)
+		}
+	}
+	if b.String() != "10-11" {
+		t.Errorf("Expected failed pushes to leave b unchanged, got %v", &b)
+	}
+
+	// Changes to a copy must not reach b.
+	r := b.Uint32()
+	r.Push(12)
+	b.Push(20)
+	if r.String() != "10-12" || b.String() != "10-11,20" {
+		t.Errorf("Expected independent copies, got %v and %v", r, &b)
+	}
+
+	c := AppendOnly{r: almostFull(0xffffffff)}
+	if err := c.Push(0xffffffff); err == nil || c.Len() != MaxLen {
+		t.Errorf("Expected Push() onto a full array to fail, got %v", err)
+	}
+}
+
+func BenchmarkAppendOnlyPush(b *testing.B) {
+	var a AppendOnly
+	for i := 0; i < b.N; i++ {
+		a.Push(uint32(i) * 2)
+	}
+}
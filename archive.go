@@ -0,0 +1,133 @@
+package rangearray
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// WriteZip writes c to w as a zip archive, holding the files and
+// manifest that Save would write to a directory, at the root of the
+// archive.  ReadZip reads it back, as does LoadFS on a zip.Reader, or
+// Load on the directory that unpacking the archive makes.
+func (c *Collection) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	if err := c.saveFS(zipWriteFS{zw}, manifest{Version: manifestVersion, Generation: 1}); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadZip replaces the contents of c with the collection in the zip
+// archive in r, which is size bytes long, as written by WriteZip.  To
+// load only some of the keys, pass a zip.Reader to LoadFS instead.
+func (c *Collection) ReadZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	return c.LoadFS(zr, nil)
+}
+
+// zipWriteFS is a WriteFS that adds files to a zip archive.
+type zipWriteFS struct {
+	zw *zip.Writer
+}
+
+func (z zipWriteFS) Create(name string) (io.WriteCloser, error) {
+	w, err := z.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// nopWriteCloser is an io.Writer with a Close method that does
+// nothing.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// WriteTar writes c to w as a tar archive, holding the files and
+// manifest that Save would write to a directory, at the root of the
+// archive.  ReadTar reads it back, as does Load on the directory that
+// unpacking the archive makes.
+func (c *Collection) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	if err := c.saveFS(tarWriteFS{tw}, manifest{Version: manifestVersion, Generation: 1}); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// ReadTar replaces the contents of c with the collection in the tar
+// archive read from r, as written by WriteTar.  Since a tar archive
+// can only be read in order, ReadTar holds the whole archive in memory
+// while it loads.
+func (c *Collection) ReadTar(r io.Reader) error {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		files[h.Name] = data
+	}
+
+	return c.load(func(name string) ([]byte, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return data, nil
+	}, nil)
+}
+
+// tarWriteFS is a WriteFS that adds files to a tar archive.  Since a
+// tar header holds the size of its file, each file is buffered until
+// it is closed.
+type tarWriteFS struct {
+	tw *tar.Writer
+}
+
+type tarFile struct {
+	bytes.Buffer
+	tw   *tar.Writer
+	name string
+}
+
+func (t tarWriteFS) Create(name string) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	return &tarFile{tw: t.tw, name: name}, nil
+}
+
+func (f *tarFile) Close() error {
+	err := f.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     f.name,
+		Mode:     0o644,
+		Size:     int64(f.Len()),
+	})
+	if err == nil {
+		_, err = f.tw.Write(f.Bytes())
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package rangearray
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// testArchiveCollection returns a Collection for the archive tests.
+func testArchiveCollection() *Collection {
+	var c Collection
+	for i := uint32(0); i < 5; i++ {
+		for x := uint32(0); x <= i; x++ {
+			c.Push(string(rune('a'+i)), x*2)
+		}
+	}
+	return &c
+}
+
+func TestCollectionZip(t *testing.T) {
+	c := testArchiveCollection()
+	var buf bytes.Buffer
+	if err := c.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Collection
+	if err := got.ReadZip(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != nil {
+		t.Fatal(err)
+	}
+	testEqualCollection(t, &got, c)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.LoadFS(zr, func(key string) bool { return key == "c" }); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Keys(), []string{"c"}) {
+		t.Errorf("Expected only key c, got %v", got.Keys())
+	}
+
+	if err := got.ReadZip(bytes.NewReader([]byte("junk")), 4); err == nil {
+		t.Errorf("Expected an error for a bad archive")
+	}
+}
+
+func TestCollectionTar(t *testing.T) {
+	c := testArchiveCollection()
+	var buf bytes.Buffer
+	if err := c.WriteTar(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Collection
+	if err := got.ReadTar(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	testEqualCollection(t, &got, c)
+
+	// An empty collection makes an archive with just a manifest.
+	var empty bytes.Buffer
+	if err := (&Collection{}).WriteTar(&empty); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.ReadTar(bytes.NewReader(empty.Bytes())); err != nil || got.Len() != 0 {
+		t.Errorf("Expected an empty collection, got %v, %v", got.Keys(), err)
+	}
+	if err := got.ReadTar(bytes.NewReader(buf.Bytes()[:1024])); err == nil {
+		t.Errorf("Expected an error for a truncated archive")
+	}
+}
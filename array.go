@@ -0,0 +1,330 @@
+package rangearray
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Backend names a way for an Array to store its runs.  Each suits a
+// different phase of a workload, and Array.Convert moves an array
+// between them in O(n) time:
+//
+//   - BackendSlice (a Uint32) is the most compact mutable form, with
+//     the fastest appends at the end and the fastest iteration, but a
+//     Push before the end takes O(n) time.  Use it for bulk loading in
+//     order.
+//   - BackendBackfill (a Backfill) takes O(log n) time to fix up the
+//     counts after a Push anywhere, plus a copy of at most a few
+//     hundred runs, at a small cost to queries.  Use it for loading
+//     data that is mostly out of order.
+//   - BackendLean (a Lean) drops the Index of each run, for two thirds
+//     of the memory of BackendSlice, and a Push before the end moves
+//     the later runs without fixing up their indexes.  Queries rebuild
+//     the index sums after changes, so it suits alternating phases of
+//     loading and of reading.
+//   - BackendTree (a Tree) takes O(log n) time for a Push anywhere, but
+//     uses several times the memory and iterates slowest.  Use it for
+//     arbitrary insertion order over a long time.
+//   - BackendTiered (a Tiered) compresses all but its most recent runs,
+//     and makes queries on older runs slower.  Use it for long-lived
+//     indexes that are mostly appended to.
+//   - BackendFrozen (a Frozen) is read-only and searches fastest.  Use
+//     it for serving queries.
+//   - BackendFlat (a FlatView) is read-only and answers queries from
+//     serialized bytes, such as a memory-mapped file.
+//
+// A Push to an Array with a read-only backend first converts it to
+// BackendSlice.
+type Backend int
+
+const (
+	BackendSlice Backend = iota
+	BackendBackfill
+	BackendTree
+	BackendFrozen
+	BackendFlat
+	BackendLean
+	BackendTiered
+)
+
+// String returns the name of b.
+func (b Backend) String() string {
+	switch b {
+	case BackendSlice:
+		return "slice"
+	case BackendBackfill:
+		return "backfill"
+	case BackendTree:
+		return "tree"
+	case BackendFrozen:
+		return "frozen"
+	case BackendFlat:
+		return "flat"
+	case BackendLean:
+		return "lean"
+	case BackendTiered:
+		return "tiered"
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+// backend is the contract that each form of an Array implements.
+type backend interface {
+	Reader
+	NumRuns() int
+
+	// push adds x and reports whether it was added, as Uint32.Push
+	// does.  ok is false if the backend is read-only.
+	push(x uint32) (added, ok bool)
+
+	// uint32 returns the runs as an ordinary array, which the caller
+	// may modify.
+	uint32() Uint32
+}
+
+// Array is a rangearray whose storage can be chosen when it is created
+// and changed later, so that one logical array can move between the
+// phases of a pipeline (bulk load, random backfill, read-only serving)
+// without the code that uses it changing.  The zero value is an empty
+// array with BackendSlice.
+//
+// Like Uint32, an Array is not safe for concurrent use while it is
+// being modified.
+type Array struct {
+	b    backend
+	kind Backend
+}
+
+// NewArray returns an empty Array with the given backend.
+func NewArray(kind Backend) *Array {
+	return ArrayOf(Uint32{}, kind)
+}
+
+// ArrayOf returns an Array with the given backend that holds a copy of
+// the values in r.  It panics if kind is not a known Backend.
+func ArrayOf(r Uint32, kind Backend) *Array {
+	return &Array{b: newBackend(r, kind), kind: kind}
+}
+
+// FlatArray returns an Array that answers queries from v, such as a
+// view of a memory-mapped file, without copying it.
+func FlatArray(v FlatView) *Array {
+	return &Array{b: flatBackend{v}, kind: BackendFlat}
+}
+
+// newBackend returns a backend of the given kind that holds r.
+func newBackend(r Uint32, kind Backend) backend {
+	switch kind {
+	case BackendSlice:
+		return &sliceBackend{Uint32{runs: slices.Clone(r.runs)}}
+	case BackendBackfill:
+		return backfillBackend{BackfillOf(r)}
+	case BackendTree:
+		return treeBackend{TreeOf(r)}
+	case BackendFrozen:
+		return frozenBackend{r.Freeze()}
+	case BackendLean:
+		return leanBackend{LeanOf(r)}
+	case BackendTiered:
+		return tieredBackend{TieredOf(r)}
+	case BackendFlat:
+		v, err := NewFlatView(r.AppendFlat(nil))
+		if err != nil {
+			panic(err)
+		}
+		return flatBackend{v}
+	}
+	panic(fmt.Sprintf("rangearray: unknown %v", kind))
+}
+
+// backend returns the backend of a, creating one for the zero Array.
+func (a *Array) backend() backend {
+	if a.b == nil {
+		a.b = &sliceBackend{}
+	}
+	return a.b
+}
+
+// Backend returns the kind of storage a uses.
+func (a *Array) Backend() Backend {
+	return a.kind
+}
+
+// Convert changes the storage of a to the given backend, keeping its
+// values.  It takes O(n) time unless a already uses that backend.
+func (a *Array) Convert(kind Backend) {
+	if a.b != nil && kind == a.kind {
+		return
+	}
+	a.b = newBackend(a.backend().uint32(), kind)
+	a.kind = kind
+}
+
+// Push adds x to a, and reports whether x was added, as Uint32.Push
+// does.  If a has a read-only backend, Push first converts it to
+// BackendSlice.
+func (a *Array) Push(x uint32) bool {
+	added, ok := a.backend().push(x)
+	if !ok {
+		a.Convert(BackendSlice)
+		added, _ = a.b.push(x)
+	}
+	return added
+}
+
+// Min returns the minimum value in a.  Panics if a is empty.
+func (a *Array) Min() uint32 {
+	return a.backend().Min()
+}
+
+// Max returns the maximum value in a.  Panics if a is empty.
+func (a *Array) Max() uint32 {
+	return a.backend().Max()
+}
+
+// Len returns the number of elements in a.
+func (a *Array) Len() uint32 {
+	return a.backend().Len()
+}
+
+// NumRuns returns the number of runs in a.
+func (a *Array) NumRuns() int {
+	return a.backend().NumRuns()
+}
+
+// IndexOf returns the number of elements in a that are less than x.
+func (a *Array) IndexOf(x uint32) uint32 {
+	return a.backend().IndexOf(x)
+}
+
+// LowerBound returns the index of the run in a that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (a *Array) LowerBound(x uint32) int {
+	return a.backend().LowerBound(x)
+}
+
+// Contains reports whether x is in a.
+func (a *Array) Contains(x uint32) bool {
+	return a.backend().Contains(x)
+}
+
+// All returns an iterator over the values in a, in increasing order.
+func (a *Array) All() iter.Seq[uint32] {
+	return a.backend().All()
+}
+
+// Runs returns an iterator over the runs in a, in increasing order.
+func (a *Array) Runs() iter.Seq[Uint32Run] {
+	return a.backend().Runs()
+}
+
+// Uint32 returns a copy of a as an ordinary array.
+func (a *Array) Uint32() Uint32 {
+	return a.backend().uint32()
+}
+
+// String returns a in the format of Uint32.String.
+func (a *Array) String() string {
+	return a.Uint32().String()
+}
+
+// sliceBackend stores an Array as a Uint32.
+type sliceBackend struct {
+	Uint32
+}
+
+func (s *sliceBackend) NumRuns() int {
+	return len(s.runs)
+}
+
+func (s *sliceBackend) push(x uint32) (added, ok bool) {
+	return s.Push(x), true
+}
+
+func (s *sliceBackend) uint32() Uint32 {
+	return s.Fork()
+}
+
+// backfillBackend stores an Array as a Backfill.
+type backfillBackend struct {
+	*Backfill
+}
+
+func (b backfillBackend) push(x uint32) (added, ok bool) {
+	return b.Push(x), true
+}
+
+func (b backfillBackend) uint32() Uint32 {
+	return b.Uint32()
+}
+
+// treeBackend stores an Array as a Tree.
+type treeBackend struct {
+	*Tree
+}
+
+func (t treeBackend) push(x uint32) (added, ok bool) {
+	return t.Push(x), true
+}
+
+func (t treeBackend) uint32() Uint32 {
+	return t.Uint32()
+}
+
+// leanBackend stores an Array as a Lean.
+type leanBackend struct {
+	*Lean
+}
+
+func (l leanBackend) push(x uint32) (added, ok bool) {
+	return l.Push(x), true
+}
+
+func (l leanBackend) uint32() Uint32 {
+	return l.Uint32()
+}
+
+// tieredBackend stores an Array as a Tiered.
+type tieredBackend struct {
+	*Tiered
+}
+
+func (t tieredBackend) push(x uint32) (added, ok bool) {
+	return t.Push(x), true
+}
+
+func (t tieredBackend) uint32() Uint32 {
+	return t.Uint32()
+}
+
+// frozenBackend stores an Array as a Frozen.
+type frozenBackend struct {
+	*Frozen
+}
+
+func (f frozenBackend) push(uint32) (added, ok bool) {
+	return false, false
+}
+
+func (f frozenBackend) uint32() Uint32 {
+	return f.Thaw()
+}
+
+// flatBackend stores an Array as a FlatView.
+type flatBackend struct {
+	FlatView
+}
+
+func (f flatBackend) push(uint32) (added, ok bool) {
+	return false, false
+}
+
+func (f flatBackend) uint32() Uint32 {
+	r := Uint32{runs: make([]Uint32Run, 0, f.NumRuns())}
+	for s := range f.Runs() {
+		r.runs = append(r.runs, s)
+	}
+	return r
+}
@@ -0,0 +1,53 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestArray(t *testing.T) {
+	kinds := []Backend{BackendSlice, BackendBackfill, BackendTree, BackendFrozen, BackendFlat, BackendLean, BackendTiered}
+	rng := rand.New(rand.NewPCG(9, 10))
+	var want Uint32
+	var a Array
+	for i := 0; i < 3000; i++ {
+		// Switch backends as the phases of a workload would.
+		if i%300 == 0 {
+			a.Convert(kinds[i/300%len(kinds)])
+		}
+		x := rng.Uint32N(4000)
+		a.Push(x)
+		want.Push(x)
+	}
+	testEqualUint32(t, "a", a.Uint32(), want)
+
+	for _, kind := range kinds {
+		a := ArrayOf(want, kind)
+		if a.Backend() != kind {
+			t.Errorf("Expected backend %v, got %v", kind, a.Backend())
+		}
+		testEqualUint32(t, kind.String(), a.Uint32(), want)
+		if a.Len() != want.Len() || a.NumRuns() != len(want.runs) || a.Min() != want.Min() || a.Max() != want.Max() {
+			t.Errorf("Expected %v to have %d values in %d runs, got %d in %d", kind, want.Len(), len(want.runs), a.Len(), a.NumRuns())
+		}
+		for x := uint32(0); x < 4010; x += 7 {
+			if a.Contains(x) != want.Contains(x) || a.IndexOf(x) != want.IndexOf(x) || a.LowerBound(x) != want.LowerBound(x) {
+				t.Fatalf("Expected %v queries for %d to match, got %v, %d, %d", kind, x, a.Contains(x), a.IndexOf(x), a.LowerBound(x))
+			}
+		}
+
+		a.Push(5000)
+		if !a.Contains(5000) || a.String() != ArrayOf(a.Uint32(), BackendSlice).String() {
+			t.Errorf("Expected %v to contain 5000 after Push(), got %v", kind, a)
+		}
+		if (kind == BackendFrozen || kind == BackendFlat) != (a.Backend() == BackendSlice && kind != BackendSlice) {
+			t.Errorf("Expected Push() to convert only read-only backends, got %v from %v", a.Backend(), kind)
+		}
+	}
+
+	if NewArray(BackendFlat).Len() != 0 || Backend(99).String() != "Backend(99)" {
+		t.Errorf("Expected an empty flat Array and a numbered unknown backend")
+	}
+	v, _ := NewFlatView(want.AppendFlat(nil))
+	testEqualUint32(t, "FlatArray()", FlatArray(v).Uint32(), want)
+}
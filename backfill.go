@@ -0,0 +1,244 @@
+package rangearray
+
+import (
+	"iter"
+	"slices"
+)
+
+// Backfill is a rangearray for loading values out of order.  A Push
+// before the end of a Uint32 fixes up the Index of every later run, so
+// loading n runs in random order takes O(n²) time.  Backfill instead
+// splits its runs into blocks of at most 2*backfillBlockRuns runs, each
+// with indexes relative to the block, and keeps a Fenwick tree over
+// the blocks' value and run counts.  A Push anywhere then fixes up at
+// most one block plus O(log n) tree nodes, and IndexOf and LowerBound
+// still take O(log n) time.
+//
+// The zero value is empty.  Like Uint32, a Backfill is not safe for
+// concurrent use while it is being modified.  Once loading is done,
+// Uint32 returns an ordinary array with the same values.
+type Backfill struct {
+	blocks []Uint32
+	firsts []uint32 // firsts[i] is blocks[i].Min()
+	values fenwick  // value counts of the blocks
+	runs   fenwick  // run counts of the blocks
+}
+
+// backfillBlockRuns is the number of runs in each block after a split.
+const backfillBlockRuns = 128
+
+// BackfillOf returns a Backfill holding the values in r.
+func BackfillOf(r Uint32) *Backfill {
+	b := &Backfill{}
+	for i := 0; i < len(r.runs); i += backfillBlockRuns {
+		b.blocks = append(b.blocks, rebaseRuns(r.runs[i:min(i+backfillBlockRuns, len(r.runs))]))
+	}
+	b.rebuild()
+	return b
+}
+
+// rebaseRuns returns a Uint32 holding a copy of s, with indexes that
+// count from the start of s.
+func rebaseRuns(s []Uint32Run) Uint32 {
+	out := Uint32{runs: slices.Clone(s)}
+	for i := range out.runs {
+		out.runs[i].Index -= s[0].Index
+	}
+	return out
+}
+
+// rebuild recomputes firsts and the Fenwick trees from blocks.
+func (b *Backfill) rebuild() {
+	b.firsts = b.firsts[:0]
+	values := make([]uint64, len(b.blocks))
+	runs := make([]uint64, len(b.blocks))
+	for i, blk := range b.blocks {
+		b.firsts = append(b.firsts, blk.runs[0].Value)
+		values[i] = uint64(blk.Len())
+		runs[i] = uint64(len(blk.runs))
+	}
+	b.values = newFenwick(values)
+	b.runs = newFenwick(runs)
+}
+
+// block returns the index of the block that x belongs in: the last
+// block that starts at or before x, or the first block if x is before
+// them all.
+func (b *Backfill) block(x uint32) int {
+	i, found := slices.BinarySearch(b.firsts, x)
+	if !found && i > 0 {
+		i--
+	}
+	return i
+}
+
+// Push adds x to b, and reports whether x was added, as Uint32.Push
+// does.
+func (b *Backfill) Push(x uint32) bool {
+	if b.Len() == MaxLen {
+		return false
+	}
+	if len(b.blocks) == 0 {
+		b.blocks = append(b.blocks, Uint32{})
+		b.blocks[0].Push(x)
+		b.rebuild()
+		return true
+	}
+
+	i := b.block(x)
+	blk := &b.blocks[i]
+	runs := len(blk.runs)
+	if !blk.Push(x) {
+		return false
+	}
+	b.firsts[i] = blk.runs[0].Value
+	b.values.add(i, 1)
+	b.runs.add(i, len(blk.runs)-runs)
+
+	// x may have closed the gap to the next block, whose first run then
+	// belongs at the end of this one.
+	if i+1 < len(b.blocks) {
+		next := &b.blocks[i+1]
+		last := &blk.runs[len(blk.runs)-1]
+		if uint64(last.Value)+uint64(last.Count) == uint64(next.runs[0].Value) {
+			count := next.runs[0].Count
+			last.Count += count
+			b.values.add(i, int(count))
+			b.values.add(i+1, -int(count))
+			b.runs.add(i+1, -1)
+			if len(next.runs) == 1 {
+				b.blocks = slices.Delete(b.blocks, i+1, i+2)
+				b.rebuild()
+			} else {
+				next.unpin()
+				next.dropIndex()
+				next.runs = slices.Delete(next.runs, 0, 1)
+				for j := range next.runs {
+					next.runs[j].Index -= count
+				}
+				b.firsts[i+1] = next.runs[0].Value
+			}
+		}
+	}
+
+	if len(blk.runs) > 2*backfillBlockRuns {
+		b.blocks = slices.Insert(b.blocks, i+1, rebaseRuns(blk.runs[backfillBlockRuns:]))
+		b.blocks[i].runs = slices.Clip(blk.runs[:backfillBlockRuns])
+		b.rebuild()
+	}
+	if debugChecks {
+		checkReader("Backfill.Push", b)
+	}
+	return true
+}
+
+// Min returns the minimum value in b.  Panics if b is empty.
+func (b *Backfill) Min() uint32 {
+	return b.firsts[0]
+}
+
+// Max returns the maximum value in b.  Panics if b is empty.
+func (b *Backfill) Max() uint32 {
+	return b.blocks[len(b.blocks)-1].Max()
+}
+
+// Len returns the number of elements in b.
+func (b *Backfill) Len() uint32 {
+	return uint32(b.values.sum(len(b.blocks)))
+}
+
+// NumRuns returns the number of runs in b.
+func (b *Backfill) NumRuns() int {
+	return int(b.runs.sum(len(b.blocks)))
+}
+
+// IndexOf returns the number of elements in b that are less than x.
+func (b *Backfill) IndexOf(x uint32) uint32 {
+	if len(b.blocks) == 0 {
+		return 0
+	}
+	i := b.block(x)
+	return uint32(b.values.sum(i)) + b.blocks[i].IndexOf(x)
+}
+
+// LowerBound returns the index of the run in b that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (b *Backfill) LowerBound(x uint32) int {
+	if len(b.blocks) == 0 {
+		return 0
+	}
+	i := b.block(x)
+	return int(b.runs.sum(i)) + b.blocks[i].LowerBound(x)
+}
+
+// Contains reports whether x is in b.
+func (b *Backfill) Contains(x uint32) bool {
+	return len(b.blocks) > 0 && b.blocks[b.block(x)].Contains(x)
+}
+
+// All returns an iterator over the values in b, in increasing order.
+func (b *Backfill) All() iter.Seq[uint32] {
+	return valuesOf(b.Runs())
+}
+
+// Runs returns an iterator over the runs in b, in increasing order.
+func (b *Backfill) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		var base uint32
+		for _, blk := range b.blocks {
+			for _, s := range blk.runs {
+				s.Index += base
+				if !yield(s) {
+					return
+				}
+			}
+			base += blk.Len()
+		}
+	}
+}
+
+// Uint32 returns a mutable copy of b.
+func (b *Backfill) Uint32() Uint32 {
+	out := Uint32{runs: make([]Uint32Run, 0, b.NumRuns())}
+	for s := range b.Runs() {
+		out.runs = append(out.runs, s)
+	}
+	return out
+}
+
+// String returns b in the format of Uint32.String.
+func (b *Backfill) String() string {
+	return b.Uint32().String()
+}
+
+// fenwick is a Fenwick (binary indexed) tree of counts, which updates
+// one count and sums a prefix of the counts in O(log n) time.
+type fenwick []uint64
+
+// newFenwick returns a tree holding counts, in O(n) time.
+func newFenwick(counts []uint64) fenwick {
+	t := fenwick(counts)
+	for i := range t {
+		if j := i | (i + 1); j < len(t) {
+			t[j] += t[i]
+		}
+	}
+	return t
+}
+
+// add adds delta to the i'th count.  The count must not go negative.
+func (t fenwick) add(i, delta int) {
+	for ; i < len(t); i |= i + 1 {
+		t[i] += uint64(delta)
+	}
+}
+
+// sum returns the total of the first n counts.
+func (t fenwick) sum(n int) uint64 {
+	var total uint64
+	for ; n > 0; n &= n - 1 {
+		total += t[n-1]
+	}
+	return total
+}
@@ -0,0 +1,72 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBackfill(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	var b Backfill
+	var want Uint32
+	for i := 0; i < 20000; i++ {
+		// Mostly sparse values, so there are many blocks, and then
+		// values that fill the gaps between them.
+		x := 3 * rng.Uint32N(5000)
+		if i > 10000 {
+			x = rng.Uint32N(15000)
+		}
+		b.Push(x)
+		want.Push(x)
+		if i%1000 == 0 {
+			testEqualUint32(t, "b", b.Uint32(), want)
+		}
+	}
+
+	testEqualUint32(t, "b", b.Uint32(), want)
+	if b.Len() != want.Len() || b.NumRuns() != len(want.runs) || b.Min() != want.Min() || b.Max() != want.Max() {
+		t.Errorf("Expected %d values in %d runs, got %d in %d", want.Len(), len(want.runs), b.Len(), b.NumRuns())
+	}
+	for x := uint32(0); x < 15010; x++ {
+		if b.Contains(x) != want.Contains(x) || b.IndexOf(x) != want.IndexOf(x) || b.LowerBound(x) != want.LowerBound(x) {
+			t.Fatalf("Expected queries for %d to match, got %v, %d, %d", x, b.Contains(x), b.IndexOf(x), b.LowerBound(x))
+		}
+	}
+
+	c := BackfillOf(want)
+	c.Push(15005)
+	want.Push(15005)
+	testEqualUint32(t, "BackfillOf()", c.Uint32(), want)
+	if c.String() != want.String() {
+		t.Errorf("Expected %v, got %v", want, c)
+	}
+}
+
+func TestBackfillEdges(t *testing.T) {
+	var b Backfill
+	if b.Len() != 0 || b.NumRuns() != 0 || b.Contains(0) || b.IndexOf(5) != 0 || b.LowerBound(5) != 0 {
+		t.Errorf("Expected an empty Backfill, got %v", &b)
+	}
+	b.Push(0xffffffff)
+	b.Push(0)
+	b.Push(0)
+	if b.Len() != 2 || b.Max() != 0xffffffff || b.IndexOf(0xffffffff) != 1 {
+		t.Errorf("Expected [0 4294967295], got %v", &b)
+	}
+}
+
+func TestFenwick(t *testing.T) {
+	counts := []uint64{3, 1, 4, 1, 5, 9, 2, 6}
+	tree := newFenwick(append([]uint64(nil), counts...))
+	tree.add(2, -2)
+	counts[2] -= 2
+	var total uint64
+	for i := 0; i <= len(counts); i++ {
+		if got := tree.sum(i); got != total {
+			t.Errorf("Expected sum(%d) = %d, got %d", i, total, got)
+		}
+		if i < len(counts) {
+			total += counts[i]
+		}
+	}
+}
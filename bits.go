@@ -0,0 +1,87 @@
+package rangearray
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// ToBits returns a dense bit vector covering [lo, hi), in which bit i
+// (bit i%64 of word i/64) is set if lo+i is in r.  Bits past hi in the
+// last word are clear.  Panics if hi < lo.
+func (r Uint32) ToBits(lo, hi uint32) []uint64 {
+	if hi < lo {
+		panic("rangearray: ToBits with hi < lo")
+	}
+
+	out := make([]uint64, (uint64(hi-lo)+63)/64)
+	for i := r.LowerBound(lo); i < len(r.runs) && r.runs[i].Value < hi; i++ {
+		s := r.runs[i]
+		start := uint64(max(s.Value, lo) - lo)
+		end := min(uint64(s.Value)+uint64(s.Count), uint64(hi)) - uint64(lo)
+		setBits(out, start, end)
+	}
+	return out
+}
+
+// setBits sets bits [start, end) of b.
+func setBits(b []uint64, start, end uint64) {
+	first, last := start/64, (end-1)/64
+	lowMask := ^uint64(0) << (start % 64)
+	highMask := ^uint64(0) >> (63 - (end-1)%64)
+	if first == last {
+		b[first] |= lowMask & highMask
+		return
+	}
+	b[first] |= lowMask
+	for w := first + 1; w < last; w++ {
+		b[w] = ^uint64(0)
+	}
+	b[last] |= highMask
+}
+
+var errBitsRange = errors.New("rangearray: bit vector extends past the uint32 range")
+
+// FromBits returns the rangearray holding lo+i for each set bit i in b,
+// numbering bits as ToBits does.  It returns an error if a set bit
+// would represent a value above math.MaxUint32.
+func FromBits(b []uint64, lo uint32) (Uint32, error) {
+	var r Uint32
+	var start uint64
+	inRun := false
+	for w, word := range b {
+		base := uint64(w) * 64
+		pos := uint64(0)
+		for pos < 64 {
+			var n int
+			if inRun {
+				n = bits.TrailingZeros64(^(word >> pos))
+			} else {
+				n = bits.TrailingZeros64(word >> pos)
+			}
+			if uint64(n) >= 64-pos {
+				break
+			}
+			pos += uint64(n)
+			if inRun {
+				if !r.appendBits(lo, start, base+pos) {
+					return Uint32{}, errBitsRange
+				}
+			} else {
+				start = base + pos
+			}
+			inRun = !inRun
+		}
+	}
+	if inRun && !r.appendBits(lo, start, uint64(len(b))*64) {
+		return Uint32{}, errBitsRange
+	}
+	return r, nil
+}
+
+// appendBits appends the values for bits [start, end) relative to lo.
+func (r *Uint32) appendBits(lo uint32, start, end uint64) bool {
+	if uint64(lo)+end > 1<<32 {
+		return false
+	}
+	return r.appendRun(lo+uint32(start), uint32(end-start))
+}
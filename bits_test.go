@@ -0,0 +1,61 @@
+package rangearray
+
+import (
+	"testing"
+)
+
+func TestToBits(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 3, 5)
+	pushRange(&r, 60, 130)
+	pushRange(&r, 200, 300)
+
+	b := r.ToBits(2, 202)
+	if len(b) != 4 {
+		t.Fatalf("Expected 4 words, got %d", len(b))
+	}
+	for i := uint32(0); i < 256; i++ {
+		want := i < 200 && r.Contains(2+i)
+		if got := b[i/64]&(1<<(i%64)) != 0; got != want {
+			t.Errorf("Expected bit %d == %v, got %v", i, want, got)
+		}
+	}
+
+	if b := r.ToBits(131, 131); len(b) != 0 {
+		t.Errorf("Expected empty ToBits(), got %v", b)
+	}
+
+	top := Uint32{runs: []Uint32Run{{Value: 0xfffffff0, Count: 15}}}
+	if b := top.ToBits(0xffffffc0, 0xffffffff); len(b) != 1 || b[0] != 0x7fff<<48 {
+		t.Errorf("Expected ToBits() at the top == %x, got %x", uint64(0x7fff)<<48, b)
+	}
+}
+
+func TestFromBits(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 10, 10)
+	pushRange(&r, 12, 75)
+	pushRange(&r, 127, 128)
+	pushRange(&r, 191, 200)
+
+	for _, lo := range []uint32{0, 10} {
+		got, err := FromBits(r.ToBits(lo, lo+256), lo)
+		if err != nil {
+			t.Fatalf("FromBits() failed: %v", err)
+		}
+		testEqualUint32(t, "FromBits(ToBits())", got, r)
+	}
+
+	got, err := FromBits([]uint64{^uint64(0), ^uint64(0)}, 5)
+	if err != nil || got.String() != "5-132" {
+		t.Errorf("Expected FromBits() == 5-132, got %v, %v", got, err)
+	}
+
+	if _, err := FromBits([]uint64{0, 1}, 0xffffffc0); err == nil {
+		t.Errorf("Expected FromBits() past the uint32 range to fail")
+	}
+	got, err = FromBits([]uint64{1 << 63}, 0xffffffc0)
+	if err != nil || got.String() != "4294967295" {
+		t.Errorf("Expected FromBits() at the top == 4294967295, got %v, %v", got, err)
+	}
+}
@@ -0,0 +1,378 @@
+package rangearray
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// The block file format splits the runs of a rangearray into blocks,
+// so that a point query only needs to read one block.  A block file
+// has four parts:
+//
+// A five-byte header, the same as the binary format's except that the
+// magic bytes are "RB".
+//
+// The blocks.  Each block holds up to Encoder.BlockRuns runs, stored
+// with the header's Encoding.  Varint deltas restart from zero at the
+// start of each block.  If the flagCompressed bit is set in the
+// header, each block is compressed separately by a Codec.  If the
+// flagChecksum bit is set, each block is followed by the CRC-32C
+// checksum of its stored bytes, as a little-endian uint32, and that
+// checksum is included in the block's length.
+//
+// The directory, with one 32-byte entry per block.  An entry holds, as
+// little-endian uint32s, the minimum and maximum values in the block,
+// the number of elements before the block, the number of elements in
+// the block, the number of runs in the block, and the length of the
+// block in bytes; then the offset of the block from the start of the
+// file, as a little-endian uint64.  If the flagChecksum bit is set, the
+// directory is followed by its CRC-32C checksum.
+//
+// A 16-byte footer, holding the offset of the directory as a
+// little-endian uint64, the number of blocks as a little-endian
+// uint32, and the magic bytes "RBIX".
+
+const (
+	// DefaultBlockRuns is the number of runs per block that
+	// EncodeBlocks uses when Encoder.BlockRuns is zero.
+	DefaultBlockRuns = 1024
+
+	blockDirEntryLen = 32
+	blockFooterLen   = 16
+)
+
+var errBadBlockFile = corruptf("rangearray: not a rangearray block file")
+
+// blockDirEntry describes one block of a block file.
+type blockDirEntry struct {
+	min, max     uint32
+	index, count uint32
+	runs, length uint32
+	offset       uint64
+}
+
+// EncodeBlocks writes r to w in the block file format, which
+// OpenBlockFile can query without reading the whole file.  It returns
+// the number of bytes written.
+func (e Encoder) EncodeBlocks(w io.Writer, r Uint32) (int64, error) {
+	return e.EncodeBlocksContext(context.Background(), w, r)
+}
+
+// EncodeBlocksContext is like EncodeBlocks, but stops with ctx's error
+// if ctx is done before the file is written.
+func (e Encoder) EncodeBlocksContext(ctx context.Context, w io.Writer, r Uint32) (_ int64, err error) {
+	done := startTrace(ctx, e.Tracer, OpEncodeBlocks)
+	defer func() { done(len(r.runs), err) }()
+
+	blockRuns := e.BlockRuns
+	if blockRuns <= 0 {
+		blockRuns = DefaultBlockRuns
+	}
+
+	pr := e.Progress.start(OpEncodeBlocks, int64(len(r.runs)))
+	cw := &countingWriter{ctx: ctx, w: w}
+	cw.Write(e.header("RB"))
+
+	sc := getScratch()
+	buf, packed := sc.buf, sc.packed
+	defer func() { sc.release(buf, packed, nil) }()
+	var dir []byte
+	for i := 0; i < len(r.runs) && cw.err == nil; i += blockRuns {
+		block := r.runs[i:min(i+blockRuns, len(r.runs))]
+		if buf, packed, err = e.encodeBlock(buf, packed, block); err != nil {
+			return cw.n, err
+		}
+		dir = newBlockDirEntry(block, len(buf), cw.n).append(dir)
+		cw.Write(buf)
+		pr.add(len(block))
+	}
+
+	cw.Write(e.blockTrailer(dir, cw.n))
+	if cw.err == nil {
+		pr.finish()
+	}
+	return cw.n, cw.err
+}
+
+// encodeBlock stores the runs of block in buf, as one block of a block
+// file, using packed as scratch space for compression.  It returns
+// both buffers, which may have been swapped or grown.
+func (e Encoder) encodeBlock(buf, packed []byte, block []Uint32Run) ([]byte, []byte, error) {
+	buf = buf[:0]
+	var end uint32
+	for _, s := range block {
+		buf = appendRun(buf, e.Encoding, end, s)
+		end = s.Value + s.Count
+	}
+
+	if e.Codec != nil {
+		var err error
+		if packed, err = e.Codec.Compress(packed[:0], buf); err != nil {
+			return buf, packed, err
+		}
+		buf, packed = packed, buf
+	}
+	if e.Checksum {
+		buf = binary.LittleEndian.AppendUint32(buf, crc32.Checksum(buf, crcTable))
+	}
+	return buf, packed, nil
+}
+
+// newBlockDirEntry returns the directory entry for block, which is
+// stored in length bytes at offset.
+func newBlockDirEntry(block []Uint32Run, length int, offset int64) blockDirEntry {
+	last := block[len(block)-1]
+	return blockDirEntry{
+		min:    block[0].Value,
+		max:    last.Value + last.Count - 1,
+		index:  block[0].Index,
+		count:  last.Index + last.Count - block[0].Index,
+		runs:   uint32(len(block)),
+		length: uint32(length),
+		offset: uint64(offset),
+	}
+}
+
+// blockTrailer returns the directory and footer of a block file whose
+// directory entries are dir and starts at offset.
+func (e Encoder) blockTrailer(dir []byte, offset int64) []byte {
+	n := len(dir) / blockDirEntryLen
+	if e.Checksum {
+		dir = binary.LittleEndian.AppendUint32(dir, crc32.Checksum(dir, crcTable))
+	}
+	dir = binary.LittleEndian.AppendUint64(dir, uint64(offset))
+	dir = binary.LittleEndian.AppendUint32(dir, uint32(n))
+	return append(dir, 'R', 'B', 'I', 'X')
+}
+
+// append appends the directory entry for b to dir.
+func (b blockDirEntry) append(dir []byte) []byte {
+	dir = binary.LittleEndian.AppendUint32(dir, b.min)
+	dir = binary.LittleEndian.AppendUint32(dir, b.max)
+	dir = binary.LittleEndian.AppendUint32(dir, b.index)
+	dir = binary.LittleEndian.AppendUint32(dir, b.count)
+	dir = binary.LittleEndian.AppendUint32(dir, b.runs)
+	dir = binary.LittleEndian.AppendUint32(dir, b.length)
+	return binary.LittleEndian.AppendUint64(dir, b.offset)
+}
+
+// BlockFile is a rangearray in the block file format.  It keeps only
+// the block directory in memory, and reads blocks from the underlying
+// file as queries need them.
+type BlockFile struct {
+	r        io.ReaderAt
+	d        Decoder
+	encoding Encoding
+	flags    byte
+	dir      []blockDirEntry
+}
+
+// OpenBlockFile reads the header and directory of the block file in r,
+// which is size bytes long.
+func OpenBlockFile(r io.ReaderAt, size int64) (*BlockFile, error) {
+	return Decoder{}.OpenBlockFile(r, size)
+}
+
+// OpenBlockFile reads the header and directory of the block file in r,
+// which is size bytes long.  The BlockFile uses d to decode blocks.
+func (d Decoder) OpenBlockFile(r io.ReaderAt, size int64) (*BlockFile, error) {
+	if size < binaryHeaderLen+blockFooterLen {
+		return nil, errBadBlockFile
+	}
+
+	var h [binaryHeaderLen]byte
+	if _, err := r.ReadAt(h[:], 0); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	e, flags, err := d.checkHeader(h[:], "RB")
+	if err == errBadMagic {
+		return nil, errBadBlockFile
+	} else if err != nil {
+		return nil, err
+	}
+
+	var footer [blockFooterLen]byte
+	if _, err := r.ReadAt(footer[:], size-blockFooterLen); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if string(footer[12:]) != "RBIX" {
+		return nil, errBadBlockFile
+	}
+	dirOffset := binary.LittleEndian.Uint64(footer[0:])
+	n := uint64(binary.LittleEndian.Uint32(footer[8:]))
+	dirLen := n * blockDirEntryLen
+	if flags&flagChecksum != 0 {
+		dirLen += 4
+	}
+	end := uint64(size - blockFooterLen)
+	if dirOffset < binaryHeaderLen || dirOffset > end || dirLen != end-dirOffset {
+		return nil, errBadBlockFile
+	}
+
+	buf := make([]byte, dirLen)
+	if _, err := r.ReadAt(buf, int64(dirOffset)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if flags&flagChecksum != 0 {
+		k := len(buf) - 4
+		if binary.LittleEndian.Uint32(buf[k:]) != crc32.Checksum(buf[:k], crcTable) {
+			return nil, &ChecksumError{Block: -1}
+		}
+	}
+
+	f := &BlockFile{r: r, d: d, encoding: e, flags: flags, dir: make([]blockDirEntry, n)}
+	offset := uint64(binaryHeaderLen)
+	var index uint64
+	for i := range f.dir {
+		b := buf[i*blockDirEntryLen:]
+		ent := blockDirEntry{
+			min:    binary.LittleEndian.Uint32(b[0:]),
+			max:    binary.LittleEndian.Uint32(b[4:]),
+			index:  binary.LittleEndian.Uint32(b[8:]),
+			count:  binary.LittleEndian.Uint32(b[12:]),
+			runs:   binary.LittleEndian.Uint32(b[16:]),
+			length: binary.LittleEndian.Uint32(b[20:]),
+			offset: binary.LittleEndian.Uint64(b[24:]),
+		}
+		if ent.offset != offset || ent.min > ent.max || uint64(ent.index) != index ||
+			ent.runs == 0 || ent.runs > ent.count || ent.count-1 > ent.max-ent.min ||
+			index+uint64(ent.count) > MaxLen || (i > 0 && ent.min <= f.dir[i-1].max) {
+			return nil, corruptf("rangearray: invalid directory entry for block %d", i)
+		}
+		f.dir[i] = ent
+		offset += uint64(ent.length)
+		index += uint64(ent.count)
+	}
+	if offset != dirOffset {
+		return nil, errBadBlockFile
+	}
+
+	return f, nil
+}
+
+// Blocks returns the number of blocks in f.
+func (f *BlockFile) Blocks() int {
+	return len(f.dir)
+}
+
+// Len returns the number of elements in f.
+func (f *BlockFile) Len() uint32 {
+	if len(f.dir) == 0 {
+		return 0
+	}
+
+	n := len(f.dir) - 1
+	return f.dir[n].index + f.dir[n].count
+}
+
+// Contains reports whether x is in f.  It reads at most one block.
+func (f *BlockFile) Contains(x uint32) (bool, error) {
+	i := f.search(x)
+	if i == len(f.dir) || x < f.dir[i].min {
+		return false, nil
+	}
+
+	b, err := f.Block(i)
+	if err != nil {
+		return false, err
+	}
+	return b.Contains(x), nil
+}
+
+// IndexOf returns the number of elements in f that are less than x.
+// It reads at most one block.
+func (f *BlockFile) IndexOf(x uint32) (uint32, error) {
+	i := f.search(x)
+	if i == len(f.dir) {
+		return f.Len(), nil
+	}
+	if x <= f.dir[i].min {
+		return f.dir[i].index, nil
+	}
+
+	b, err := f.Block(i)
+	if err != nil {
+		return 0, err
+	}
+	return f.dir[i].index + b.IndexOf(x), nil
+}
+
+// Block reads and returns the i'th block of f.  The Index fields of
+// the result count from the start of the block, not the start of f.
+func (f *BlockFile) Block(i int) (Uint32, error) {
+	ent := f.dir[i]
+	buf := make([]byte, ent.length)
+	if _, err := f.r.ReadAt(buf, int64(ent.offset)); err != nil {
+		return Uint32{}, unexpectedEOF(err)
+	}
+
+	if f.flags&flagChecksum != 0 {
+		k := len(buf) - 4
+		if k < 0 || binary.LittleEndian.Uint32(buf[k:]) != crc32.Checksum(buf[:k], crcTable) {
+			return Uint32{}, &ChecksumError{Block: i}
+		}
+		buf = buf[:k]
+	}
+
+	var err error
+	if f.flags&flagCompressed != 0 {
+		if buf, err = f.d.decompress(nil, buf, maxRunBytes(f.encoding, uint64(ent.runs))); err != nil {
+			return Uint32{}, fmt.Errorf("rangearray: block %d: %w", i, err)
+		}
+	}
+
+	out := Uint32{runs: make([]Uint32Run, 0, min(int(ent.runs), len(buf)/2))}
+	err = readRunBytes(buf, f.encoding, uint64(ent.runs), &out)
+	if err == nil && (out.Min() != ent.min || out.Max() != ent.max || out.Len() != ent.count) {
+		err = corruptf("rangearray: block does not match its directory entry")
+	}
+	if err != nil {
+		return Uint32{}, fmt.Errorf("rangearray: block %d: %w", i, err)
+	}
+	return out, nil
+}
+
+// ReadAll reads every block of f and returns the whole rangearray.
+func (f *BlockFile) ReadAll() (Uint32, error) {
+	return f.ReadAllContext(context.Background())
+}
+
+// ReadAllContext is like ReadAll, but stops with ctx's error if ctx is
+// done before every block is read.
+func (f *BlockFile) ReadAllContext(ctx context.Context) (out Uint32, err error) {
+	done := startTrace(ctx, f.d.Tracer, OpReadBlocks)
+	defer func() { done(len(out.runs), err) }()
+
+	var runs int64
+	for _, ent := range f.dir {
+		runs += int64(ent.runs)
+	}
+	pr := f.d.Progress.start(OpReadBlocks, runs)
+	for i := range f.dir {
+		if err := ctx.Err(); err != nil {
+			return Uint32{}, err
+		}
+		b, err := f.Block(i)
+		if err != nil {
+			return Uint32{}, err
+		}
+		for _, s := range b.runs {
+			out.appendRun(s.Value, s.Count)
+		}
+		pr.add(len(b.runs))
+	}
+	pr.finish()
+	return out, nil
+}
+
+// search returns the index of the first block in f whose maximum is at
+// least x, or len(f.dir) if there is none.
+func (f *BlockFile) search(x uint32) int {
+	return sort.Search(len(f.dir), func(i int) bool {
+		return x <= f.dir[i].max
+	})
+}
@@ -0,0 +1,170 @@
+package rangearray
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// countingReaderAt counts the calls to ReadAt.
+type countingReaderAt struct {
+	r     *bytes.Reader
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads++
+	return c.r.ReadAt(p, off)
+}
+
+func testBlockArray() Uint32 {
+	r := &Uint32{}
+	for i := uint32(0); i < 100; i++ {
+		pushRange(r, 10*i, 10*i+i%7)
+	}
+	return *r
+}
+
+func TestBlockFile(t *testing.T) {
+	r := testBlockArray()
+	d := Decoder{Codec: flateCodec{}}
+	for _, e := range []Encoder{{BlockRuns: 16}, {Encoding: Varint, BlockRuns: 7}, {}, {BlockRuns: 10, Codec: flateCodec{}}} {
+		var buf bytes.Buffer
+		n, err := e.EncodeBlocks(&buf, r)
+		if err != nil {
+			t.Fatalf("EncodeBlocks() failed: %v", err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("Expected EncodeBlocks() to return %d, got %d", buf.Len(), n)
+		}
+
+		c := &countingReaderAt{r: bytes.NewReader(buf.Bytes())}
+		f, err := d.OpenBlockFile(c, int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("OpenBlockFile() failed: %v", err)
+		}
+		if x := f.Len(); x != r.Len() {
+			t.Errorf("Expected f.Len() == %d, got %d", r.Len(), x)
+		}
+
+		for x := uint32(0); x < 1010; x++ {
+			c.reads = 0
+			idx, err := f.IndexOf(x)
+			if err != nil || idx != r.IndexOf(x) {
+				t.Errorf("Expected f.IndexOf(%d) == %d, got %d, %v", x, r.IndexOf(x), idx, err)
+			}
+			found, err := f.Contains(x)
+			if err != nil || found != r.Contains(x) {
+				t.Errorf("Expected f.Contains(%d) == %v, got %v, %v", x, r.Contains(x), found, err)
+			}
+			if c.reads > 2 {
+				t.Errorf("Expected at most one block read per query, got %d reads", c.reads)
+			}
+		}
+
+		all, err := f.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() failed: %v", err)
+		}
+		testEqualUint32(t, "all", all, r)
+	}
+}
+
+func TestBlockFileEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := (Encoder{}).EncodeBlocks(&buf, Uint32{}); err != nil {
+		t.Fatalf("EncodeBlocks() failed: %v", err)
+	}
+	f, err := OpenBlockFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenBlockFile() failed: %v", err)
+	}
+	if x, _ := f.IndexOf(5); x != 0 || f.Len() != 0 {
+		t.Errorf("Expected an empty block file, got Len() == %d, IndexOf(5) == %d", f.Len(), x)
+	}
+}
+
+func TestBlockFileCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	(Encoder{BlockRuns: 16}).EncodeBlocks(&buf, testBlockArray())
+	b := buf.Bytes()
+
+	if _, err := OpenBlockFile(bytes.NewReader(b[:len(b)-1]), int64(len(b)-1)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected OpenBlockFile() of a truncated file to fail with ErrCorrupt, got %v", err)
+	}
+
+	// A directory entry that claims more elements than its range
+	// holds must be rejected before any block is read.
+	dir := int(binary.LittleEndian.Uint64(b[len(b)-blockFooterLen:]))
+	bad := bytes.Clone(b)
+	binary.LittleEndian.PutUint32(bad[dir+12:], 0xffffffff)
+	if _, err := OpenBlockFile(bytes.NewReader(bad), int64(len(bad))); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected OpenBlockFile() with a bad directory entry to fail with ErrCorrupt, got %v", err)
+	}
+
+	b[binaryHeaderLen+8] ^= 0xff
+	f, err := OpenBlockFile(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("OpenBlockFile() failed: %v", err)
+	}
+	if _, err := f.Block(0); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected f.Block(0) of a corrupt block to fail with ErrCorrupt, got %v", err)
+	}
+}
+
+func TestBlockFileChecksum(t *testing.T) {
+	var buf bytes.Buffer
+	(Encoder{BlockRuns: 16, Checksum: true}).EncodeBlocks(&buf, testBlockArray())
+	b := buf.Bytes()
+
+	f, err := OpenBlockFile(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("OpenBlockFile() failed: %v", err)
+	}
+	all, err := f.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	testEqualUint32(t, "all", all, testBlockArray())
+
+	// Change the Count of the first run of block 2.
+	b[f.dir[2].offset+4]++
+	_, err = f.Block(2)
+	if e, ok := err.(*ChecksumError); !ok || e.Block != 2 {
+		t.Errorf("Expected f.Block(2) to return a *ChecksumError for block 2, got %v", err)
+	}
+	if _, err := f.IndexOf(f.dir[2].min + 1); err == nil {
+		t.Errorf("Expected f.IndexOf() in a corrupt block to fail")
+	}
+
+	// Corrupt the directory.
+	dir := len(b) - blockFooterLen - 4 - blockDirEntryLen
+	b[dir]++
+	if _, err := OpenBlockFile(bytes.NewReader(b), int64(len(b))); err == nil {
+		t.Errorf("Expected OpenBlockFile() with a corrupt directory to fail")
+	}
+}
+
+func TestBlockFileContext(t *testing.T) {
+	r := testBlockArray()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (Encoder{BlockRuns: 16}).EncodeBlocksContext(ctx, io.Discard, r); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected EncodeBlocksContext() to be canceled, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := (Encoder{BlockRuns: 16}).EncodeBlocks(&buf, r); err != nil {
+		t.Fatalf("EncodeBlocks() failed: %v", err)
+	}
+	f, err := OpenBlockFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenBlockFile() failed: %v", err)
+	}
+	if _, err := f.ReadAllContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ReadAllContext() to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package rangearray
+
+import "iter"
+
+// DefaultMaxPending is the number of pending runs at which a Buffered
+// merges them into its main runs, if MaxPending is zero.
+const DefaultMaxPending = 1024
+
+// Buffered is a rangearray for values that arrive slightly out of
+// order.  Values after the end go straight onto a main Uint32, as with
+// Uint32.Push.  Values before the end go into a small sorted side
+// buffer instead, and once it holds MaxPending runs, they are merged
+// into the main runs in one linear pass.  That replaces an O(n) insert
+// into the middle of the main runs for each late value with one merge
+// per MaxPending late runs.  Queries consult both parts.
+//
+// The zero value is empty and uses DefaultMaxPending.  Like Uint32, a
+// Buffered is not safe for concurrent use while it is being modified.
+type Buffered struct {
+	// MaxPending is the number of pending runs that triggers a merge.
+	// If it is zero, DefaultMaxPending is used.
+	MaxPending int
+
+	main, pending Uint32
+
+	// spare holds storage from before the last merge, to be reused by
+	// the next one.
+	spare []Uint32Run
+}
+
+// Push adds x to b, and reports whether x was added, as Uint32.Push
+// does.  It returns false if b already holds MaxLen values, counting
+// the pending runs.
+func (b *Buffered) Push(x uint32) bool {
+	if uint64(b.main.Len())+uint64(b.pending.Len()) >= MaxLen {
+		return false
+	}
+	n := len(b.main.runs) - 1
+	if n < 0 || uint64(x) >= uint64(b.main.runs[n].Value)+uint64(b.main.runs[n].Count) {
+		return b.main.Push(x)
+	}
+	if b.main.Contains(x) || !b.pending.Push(x) {
+		return false
+	}
+
+	limit := b.MaxPending
+	if limit <= 0 {
+		limit = DefaultMaxPending
+	}
+	if len(b.pending.runs) >= limit {
+		b.Flush()
+	}
+	return true
+}
+
+// Flush merges any pending runs into the main runs of b.
+func (b *Buffered) Flush() {
+	if len(b.pending.runs) == 0 {
+		return
+	}
+	merged := AppendUnion(b.spare[:0], b.main, b.pending)
+	old := b.main.runs
+	b.main = Uint32{runs: merged}
+	b.pending.runs = b.pending.runs[:0]
+	if debugChecks {
+		b.main.check("Buffered.Flush", 0, 0)
+	}
+	b.spare = old
+}
+
+// Pending returns the number of runs waiting to be merged.
+func (b *Buffered) Pending() int {
+	return len(b.pending.runs)
+}
+
+// Min returns the minimum value in b.  Panics if b is empty.
+func (b *Buffered) Min() uint32 {
+	if len(b.pending.runs) > 0 {
+		return min(b.main.Min(), b.pending.Min())
+	}
+	return b.main.Min()
+}
+
+// Max returns the maximum value in b.  Panics if b is empty.
+func (b *Buffered) Max() uint32 {
+	return b.main.Max()
+}
+
+// Len returns the number of elements in b.
+func (b *Buffered) Len() uint32 {
+	return b.main.Len() + b.pending.Len()
+}
+
+// IndexOf returns the number of elements in b that are less than x.
+func (b *Buffered) IndexOf(x uint32) uint32 {
+	return b.main.IndexOf(x) + b.pending.IndexOf(x)
+}
+
+// Contains reports whether x is in b.
+func (b *Buffered) Contains(x uint32) bool {
+	return b.main.Contains(x) || b.pending.Contains(x)
+}
+
+// LowerBound returns the index of the run in b that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x.  If x is after b.Max(), returns b.NumRuns().  A pending run
+// that touches a main run is counted as part of it, as Runs merges
+// them.
+func (b *Buffered) LowerBound(x uint32) int {
+	k := b.pending.LowerBound(x)
+	n := b.main.LowerBound(x) + k
+	for _, p := range b.pending.runs[:k] {
+		n -= b.touches(p, uint64(x))
+	}
+
+	// The run that holds x may begin with a run counted above.
+	if i := b.main.LowerBound(x); i < len(b.main.runs) && x >= b.main.runs[i].Value {
+		if s := b.main.runs[i].Value; s > 0 && b.pending.Contains(s-1) {
+			n--
+		}
+	} else if k < len(b.pending.runs) && x >= b.pending.runs[k].Value {
+		if s := b.pending.runs[k].Value; s > 0 && b.main.Contains(s-1) {
+			n--
+		}
+	}
+	return n
+}
+
+// NumRuns returns the number of runs in b, once pending runs that
+// touch main runs are merged with them.
+func (b *Buffered) NumRuns() int {
+	n := len(b.main.runs) + len(b.pending.runs)
+	for _, p := range b.pending.runs {
+		n -= b.touches(p, 1<<32)
+	}
+	return n
+}
+
+// touches returns the number of main runs that end before x and touch
+// the pending run p on either side.
+func (b *Buffered) touches(p Uint32Run, x uint64) int {
+	n := 0
+	if p.Value > 0 && b.main.Contains(p.Value-1) {
+		n++
+	}
+	if end := uint64(p.Value) + uint64(p.Count); end < x && b.main.Contains(uint32(end)) {
+		i := b.main.LowerBound(uint32(end))
+		if uint64(b.main.runs[i].Value)+uint64(b.main.runs[i].Count) <= x {
+			n++
+		}
+	}
+	return n
+}
+
+// All returns an iterator over the values in b, in increasing order.
+func (b *Buffered) All() iter.Seq[uint32] {
+	return valuesOf(b.Runs())
+}
+
+// Runs returns an iterator over the runs in b, in increasing order,
+// merging pending runs with the main runs as it goes.
+func (b *Buffered) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		unionRuns([]Uint32{b.main, b.pending}, yield)
+	}
+}
+
+// Uint32 returns a copy of the values in b as an ordinary array.
+func (b *Buffered) Uint32() Uint32 {
+	return Uint32{runs: AppendUnion(nil, b.main, b.pending)}
+}
+
+// String returns b in the format of Uint32.String.
+func (b *Buffered) String() string {
+	return b.Uint32().String()
+}
@@ -0,0 +1,93 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBuffered(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	b := Buffered{MaxPending: 16}
+	var want Uint32
+	for i := uint32(0); i < 20000; i++ {
+		// Values arrive up to 50 places late.
+		x := 2*i - min(2*i, rng.Uint32N(100))
+		b.Push(x)
+		want.Push(x)
+		if b.Pending() >= 16 {
+			t.Fatalf("Expected at most 15 pending runs, got %d", b.Pending())
+		}
+		if i%997 == 0 {
+			testEqualUint32(t, "b", b.Uint32(), want)
+			for y := x - min(x, 200); y < x+5; y++ {
+				if b.Contains(y) != want.Contains(y) || b.IndexOf(y) != want.IndexOf(y) || b.LowerBound(y) != want.LowerBound(y) {
+					t.Fatalf("Expected queries for %d to match, got %v, %d and %d", y, b.Contains(y), b.IndexOf(y), b.LowerBound(y))
+				}
+			}
+		}
+	}
+	if b.NumRuns() != want.NumRuns() {
+		t.Errorf("Expected %d runs, got %d", want.NumRuns(), b.NumRuns())
+	}
+	if b.Len() != want.Len() || b.Min() != want.Min() || b.Max() != want.Max() {
+		t.Errorf("Expected %d values from %d to %d, got %d from %d to %d", want.Len(), want.Min(), want.Max(), b.Len(), b.Min(), b.Max())
+	}
+
+	var runs Uint32
+	for s := range b.Runs() {
+		runs.runs = append(runs.runs, s)
+	}
+	testEqualUint32(t, "b.Runs()", runs, want)
+	b.Flush()
+	if b.Pending() != 0 {
+		t.Errorf("Expected no pending runs after Flush(), got %d", b.Pending())
+	}
+	testEqualUint32(t, "b after Flush()", b.Uint32(), want)
+}
+
+func TestBufferedEdges(t *testing.T) {
+	var b Buffered
+	if b.Len() != 0 || b.Contains(0) || b.IndexOf(5) != 0 || b.String() != (Uint32{}).String() {
+		t.Errorf("Expected an empty Buffered, got %v", &b)
+	}
+	b.Push(10)
+	b.Push(3)
+	b.Push(3)
+	b.Push(10)
+	if b.Len() != 2 || b.Min() != 3 || b.Pending() != 1 {
+		t.Errorf("Expected [3 10] with one pending run, got %v with %d", &b, b.Pending())
+	}
+
+	// Pending values count toward MaxLen.
+	full := Buffered{main: Uint32{runs: []Uint32Run{{Value: 0, Count: 5}, {Value: 7, Index: 5, Count: 0xfffffff8}}}}
+	if !full.Push(5) || !full.Push(6) || full.Pending() != 1 {
+		t.Errorf("Expected two late values to be pending, got %d", full.Pending())
+	}
+	if full.Push(0xffffffff) || full.Len() != MaxLen {
+		t.Errorf("Expected Push() past MaxLen to fail, got %d values", full.Len())
+	}
+}
+
+func TestBufferedLowerBound(t *testing.T) {
+	// Pending runs that touch main runs on one side, on both, and not
+	// at all, at both ends of the range.
+	var b Buffered
+	for _, x := range []uint32{0, 4, 5, 10, 11, 20, 0xfffffffe} {
+		b.Push(x)
+	}
+	for _, x := range []uint32{1, 3, 6, 7, 8, 9, 12, 15, 0xfffffffd, 0xffffffff} {
+		b.Push(x)
+	}
+	if b.Pending() == 0 {
+		t.Fatalf("Expected pending runs")
+	}
+	want := b.Uint32()
+	if b.NumRuns() != want.NumRuns() {
+		t.Errorf("Expected %d runs, got %d", want.NumRuns(), b.NumRuns())
+	}
+	for _, x := range []uint32{0, 1, 2, 3, 4, 5, 6, 9, 10, 11, 12, 13, 15, 16, 20, 21, 0xfffffffc, 0xfffffffd, 0xfffffffe, 0xffffffff} {
+		if got := b.LowerBound(x); got != want.LowerBound(x) {
+			t.Errorf("Expected LowerBound(%d) to be %d, got %d", x, want.LowerBound(x), got)
+		}
+	}
+}
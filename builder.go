@@ -0,0 +1,60 @@
+package rangearray
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Builder merges batches of values submitted by many goroutines into
+// one rangearray.  Each goroutine sorts its own batches, so the only
+// shared work is appending runs to a list; the batches are unioned in
+// parallel by Build.  The result depends only on the values submitted,
+// not on the order in which batches arrive.
+//
+// The zero value is an empty Builder ready to use.
+type Builder struct {
+	mu    sync.Mutex
+	parts []Uint32
+}
+
+// Submit adds the values in batch, which must be sorted in
+// non-decreasing order; duplicates are ignored.  Submit does not retain
+// batch, and may be called from any goroutine.
+func (b *Builder) Submit(batch []uint32) error {
+	runs, bad := sortedRuns(batch)
+	if bad >= 0 {
+		return fmt.Errorf("rangearray: batch value at index %d is out of order", bad)
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+	var part Uint32
+	part.stitchRuns(runs)
+
+	b.mu.Lock()
+	b.parts = append(b.parts, part)
+	b.mu.Unlock()
+	return nil
+}
+
+// SubmitArray adds the values in r.  The Builder forks r rather than
+// copying it.
+func (b *Builder) SubmitArray(r *Uint32) {
+	part := r.Fork()
+	b.mu.Lock()
+	b.parts = append(b.parts, part)
+	b.mu.Unlock()
+}
+
+// Build returns the union of every batch submitted so far, computed by
+// workers goroutines as in UnionAll.  The batches are replaced by the
+// result, so later Submit calls add to it and Build may be called
+// again.
+func (b *Builder) Build(workers int) Uint32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := UnionAll(b.parts, workers)
+	clear(b.parts)
+	b.parts = append(b.parts[:0], out.Fork())
+	return out
+}
@@ -0,0 +1,58 @@
+package rangearray
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	var b Builder
+	if r := b.Build(0); r.Len() != 0 {
+		t.Errorf("Expected an empty build, got %v", r)
+	}
+
+	var wg sync.WaitGroup
+	for w := uint32(0); w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := uint32(0); k < 10; k++ {
+				batch := make([]uint32, 0, 100)
+				for i := uint32(0); i < 100; i++ {
+					batch = append(batch, (k*8+w)*100+i)
+				}
+				if err := b.Submit(batch); err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	r := b.Build(3)
+	if r.Len() != 8000 || len(r.runs) != 1 {
+		t.Errorf("Expected 8000 values in one run, got %v", r)
+	}
+
+	if err := b.Submit([]uint32{9000, 9000, 9001}); err != nil {
+		t.Errorf("Expected duplicates to be allowed, got %v", err)
+	}
+	extra := Uint32{runs: []Uint32Run{{Value: 20000, Count: 5}}}
+	b.SubmitArray(&extra)
+	extra.Push(30000)
+	r = b.Build(0)
+	expected := Uint32{runs: []Uint32Run{
+		{Value: 0, Count: 8000},
+		{Value: 9000, Index: 8000, Count: 2},
+		{Value: 20000, Index: 8002, Count: 5},
+	}}
+	testEqualUint32(t, "Build()", r, expected)
+	for i, part := range b.parts[1:cap(b.parts)] {
+		if part.runs != nil {
+			t.Errorf("Expected Build() to drop batch %d, got %v", i+1, part)
+		}
+	}
+
+	if err := b.Submit([]uint32{3, 2}); err == nil {
+		t.Errorf("Expected an error for an unsorted batch")
+	}
+}
@@ -0,0 +1,136 @@
+package rangearray
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// The canonical format is a fixed byte encoding for content addressing,
+// signatures, and deduplication.  Unlike the binary format written by
+// MarshalBinary, it has no options and will never change: equal sets of
+// values always have the same canonical encoding, and different sets
+// always have different encodings.
+//
+// The encoding is the three bytes "RC\x01", the number of runs, and
+// then for each run its gap and its length minus one.  The gap of the
+// first run is its first value; the gap of each later run is the number
+// of values between the end of the previous run and its start, minus
+// one, so that adjacent runs cannot be written separately.  Every
+// number is a uvarint in its shortest form.  Runs are merged and empty
+// runs are dropped before encoding, so the input need not be
+// normalized.
+
+const canonicalMagic = "RC\x01"
+
+var errCanonical = corruptf("rangearray: invalid canonical encoding")
+
+// MarshalCanonical returns the canonical encoding of r.  It returns an
+// error if the runs of r are out of order or overlap.
+func (r Uint32) MarshalCanonical() ([]byte, error) {
+	return r.AppendCanonical(make([]byte, 0, len(canonicalMagic)+binary.MaxVarintLen32+6*len(r.runs)))
+}
+
+// AppendCanonical appends the canonical encoding of r to b.  On error,
+// b is returned unchanged.
+func (r Uint32) AppendCanonical(b []byte) ([]byte, error) {
+	n, ok := r.canonicalRuns(nil)
+	if !ok {
+		return b, errors.New("rangearray: runs are out of order or overlap")
+	}
+
+	b = append(b, canonicalMagic...)
+	b = binary.AppendUvarint(b, uint64(n))
+	var end uint64
+	r.canonicalRuns(func(value, count uint64) {
+		gap := value - end
+		if end > 0 {
+			gap--
+		}
+		b = binary.AppendUvarint(b, gap)
+		b = binary.AppendUvarint(b, count-1)
+		end = value + count
+	})
+	return b, nil
+}
+
+// canonicalRuns calls f, if it is not nil, for each run of r after
+// merging adjacent runs and dropping empty ones.  It returns the
+// number of merged runs, and false if the runs are out of order or
+// overlap.
+func (r Uint32) canonicalRuns(f func(value, count uint64)) (int, bool) {
+	n := 0
+	var value, count uint64
+	for _, s := range r.runs {
+		if s.Count == 0 {
+			continue
+		}
+		v := uint64(s.Value)
+		if count > 0 && v < value+count {
+			return n, false
+		}
+		if count > 0 && v == value+count {
+			count += uint64(s.Count)
+			continue
+		}
+		if count > 0 && f != nil {
+			f(value, count)
+		}
+		n++
+		value, count = v, uint64(s.Count)
+	}
+	if count > 0 && f != nil {
+		f(value, count)
+	}
+	return n, value+count <= 1<<32
+}
+
+// UnmarshalCanonical replaces the contents of r with the rangearray in
+// the canonical encoding data.  Any encoding other than the one that
+// MarshalCanonical would produce is rejected.
+func (r *Uint32) UnmarshalCanonical(data []byte) error {
+	if len(data) < len(canonicalMagic) || string(data[:len(canonicalMagic)]) != canonicalMagic {
+		return errBadMagic
+	}
+	data = data[len(canonicalMagic):]
+
+	n, data, ok := canonicalUvarint(data)
+	if !ok || n > uint64(len(data))/2 {
+		return errCanonical
+	}
+
+	out := Uint32{runs: make([]Uint32Run, 0, n)}
+	var end uint64
+	for i := uint64(0); i < n; i++ {
+		var gap, count uint64
+		var ok1, ok2 bool
+		gap, data, ok1 = canonicalUvarint(data)
+		count, data, ok2 = canonicalUvarint(data)
+		if !ok1 || !ok2 {
+			return errCanonical
+		}
+		value := end + gap
+		if i > 0 {
+			value++
+		}
+		if gap >= 1<<32 || value+count >= 1<<32 || !out.appendRun(uint32(value), uint32(count+1)) {
+			return corruptf("rangearray: canonical run %d is out of range", i)
+		}
+		end = value + count + 1
+	}
+	if len(data) != 0 {
+		return errTrailing
+	}
+
+	*r = out
+	return nil
+}
+
+// canonicalUvarint reads a uvarint in its shortest form from the start
+// of b, and returns it with the rest of b.
+func canonicalUvarint(b []byte) (uint64, []byte, bool) {
+	x, n := binary.Uvarint(b)
+	if n <= 0 || (n > 1 && b[n-1] == 0) {
+		return 0, b, false
+	}
+	return x, b[n:], true
+}
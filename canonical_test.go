@@ -0,0 +1,70 @@
+package rangearray
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCanonicalUint32(t *testing.T) {
+	// These encodings must never change.
+	for _, s := range []struct {
+		r    Uint32
+		want string
+	}{
+		{Uint32{}, "RC\x01\x00"},
+		{testEncodingArray(), "RC\x01\x04\x64\x63\x95\x01\x63\xa5\x04\x00\x95\xf8\xff\xff\x0f\x00"},
+		{Uint32{runs: []Uint32Run{{Value: 0, Count: 1}, {Value: 2, Index: 1, Count: 3}}}, "RC\x01\x02\x00\x00\x00\x02"},
+	} {
+		b, err := s.r.MarshalCanonical()
+		if err != nil {
+			t.Fatalf("MarshalCanonical() failed: %v", err)
+		}
+		if string(b) != s.want {
+			t.Errorf("Expected MarshalCanonical(%v) == %q, got %q", s.r, s.want, b)
+		}
+
+		var out Uint32
+		if err := out.UnmarshalCanonical(b); err != nil {
+			t.Fatalf("UnmarshalCanonical(%q) failed: %v", b, err)
+		}
+		testEqualUint32(t, "UnmarshalCanonical()", out, s.r)
+	}
+}
+
+func TestCanonicalNormalizes(t *testing.T) {
+	want, _ := Uint32{runs: []Uint32Run{{Value: 5, Count: 10}}}.MarshalCanonical()
+	split := Uint32{runs: []Uint32Run{{Value: 5, Count: 3}, {Value: 8, Count: 0}, {Value: 8, Count: 7}}}
+	b, err := split.MarshalCanonical()
+	if err != nil || !bytes.Equal(b, want) {
+		t.Errorf("Expected MarshalCanonical() to merge runs, got %q, %v", b, err)
+	}
+
+	b, err = split.AppendCanonical([]byte("x"))
+	if err != nil || string(b[1:]) != string(want) {
+		t.Errorf("Expected AppendCanonical() to append, got %q, %v", b, err)
+	}
+
+	bad := Uint32{runs: []Uint32Run{{Value: 5, Count: 3}, {Value: 6, Count: 1}}}
+	if b, err := bad.AppendCanonical([]byte("x")); err == nil || string(b) != "x" {
+		t.Errorf("Expected AppendCanonical() of overlapping runs to fail, got %q, %v", b, err)
+	}
+}
+
+func TestCanonicalErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"RA\x01\x00",
+		"RC\x01\x80\x00",
+		"RC\x01\x01\x00",
+		"RC\x01\x01\x80\x00\x00",
+		"RC\x01\x01\x00\x00\x00",
+		"RC\x01\x01\xff\xff\xff\xff\x0f\x01",
+		"RC\x01\x01\x80\x80\x80\x80\x10\x00",
+		"RC\x01\x02\x00\x00\xff\xff\xff\xff\x0f\x00",
+	} {
+		var r Uint32
+		if err := r.UnmarshalCanonical([]byte(s)); err == nil {
+			t.Errorf("Expected UnmarshalCanonical(%q) to fail, got %v", s, r)
+		}
+	}
+}
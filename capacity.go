@@ -0,0 +1,113 @@
+package rangearray
+
+import "sync/atomic"
+
+// A GrowthPolicy chooses how much room to give an array's runs when a
+// Push or bulk load needs more than they have.  It is passed the
+// current capacity of the runs and the capacity needed, and returns the
+// new capacity, which is raised to needed if it is less.
+type GrowthPolicy func(capacity, needed int) int
+
+// GrowExact returns needed, so that runs never hold unused capacity.
+// Every new run then copies all the runs before it, so loading n runs
+// this way takes O(n²) time.
+func GrowExact(capacity, needed int) int {
+	return needed
+}
+
+// GrowQuarter grows runs by a quarter of their capacity at a time.
+func GrowQuarter(capacity, needed int) int {
+	return capacity + capacity/4
+}
+
+// GrowDouble doubles the capacity of runs each time they grow.
+func GrowDouble(capacity, needed int) int {
+	return 2 * capacity
+}
+
+// growthPolicy is the policy set by SetGrowthPolicy, or nil to let
+// append choose.
+var growthPolicy atomic.Pointer[GrowthPolicy]
+
+// SetGrowthPolicy sets how every array in the program grows its runs
+// when Push, or a bulk load that appends runs, fills their capacity.
+// Memory-constrained programs can use GrowQuarter or GrowExact to waste
+// less than append does.  A nil policy restores append's growth.  It is
+// meant to be called during initialization, but is safe to call at any
+// time.
+//
+// The policy is process-wide, not per array: it applies to every
+// Uint32 in the process, including those that other packages and
+// libraries use, and the last call wins.  Libraries should therefore
+// leave it to the main program.  To control the capacity of one array,
+// use Reserve, ReserveValues and ShrinkToFit instead.
+func SetGrowthPolicy(p GrowthPolicy) {
+	if p == nil {
+		growthPolicy.Store(nil)
+		return
+	}
+	growthPolicy.Store(&p)
+}
+
+// grow makes room in r.runs for one more run, as the growth policy says.
+func (r *Uint32) grow() {
+	if len(r.runs) < cap(r.runs) {
+		return
+	}
+	p := growthPolicy.Load()
+	if p == nil {
+		return
+	}
+	needed := len(r.runs) + 1
+	s := make([]Uint32Run, len(r.runs), max((*p)(cap(r.runs), needed), needed))
+	copy(s, r.runs)
+	r.runs = s
+}
+
+// Reserve makes room for at least runs more runs in r, so that bulk
+// loads can append them without repeatedly growing r's storage.
+func (r *Uint32) Reserve(runs int) {
+	if runs <= 0 {
+		return
+	}
+	if !r.shared && !r.pinned && cap(r.runs)-len(r.runs) >= runs {
+		return
+	}
+	s := make([]Uint32Run, len(r.runs), len(r.runs)+runs)
+	copy(s, r.runs)
+	r.runs = s
+	r.shared = false
+	r.pinned = false
+}
+
+// Cap returns the number of runs that r has room for without growing
+// its storage.
+func (r Uint32) Cap() int {
+	return cap(r.runs)
+}
+
+// ReserveValues makes room for values more elements in r, in runs that
+// are about runLen elements long.
+func (r *Uint32) ReserveValues(values, runLen int) {
+	runLen = max(runLen, 1)
+	r.Reserve((values + runLen - 1) / runLen)
+}
+
+// ShrinkToFit releases the unused capacity of r, by moving its runs
+// into storage of exactly the right size.  Long-lived arrays that were
+// built by appending can otherwise hold up to twice the memory they
+// need.
+func (r *Uint32) ShrinkToFit() {
+	n := len(r.runs)
+	if cap(r.runs) == n {
+		return
+	}
+	var s []Uint32Run
+	if n > 0 {
+		s = make([]Uint32Run, n)
+		copy(s, r.runs)
+	}
+	r.runs = s
+	r.shared = false
+	r.pinned = false
+}
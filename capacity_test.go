@@ -0,0 +1,145 @@
+package rangearray
+
+import (
+	"testing"
+)
+
+func TestReserve(t *testing.T) {
+	var r Uint32
+	r.Reserve(100)
+	if r.Cap() != 100 {
+		t.Errorf("Expected room for 100 runs, got %d", r.Cap())
+	}
+	allocs := testing.AllocsPerRun(1, func() {
+		r = Uint32{runs: r.runs[:0]}
+		for i := uint32(0); i < 100; i++ {
+			r.Push(2 * i)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations after Reserve(), got %v", allocs)
+	}
+
+	// Reserve must not write into runs shared by Fork.
+	f := r.Fork()
+	r.Reserve(1)
+	r.Push(1000)
+	if f.Len() != 100 || r.Len() != 101 || cap(r.runs) < 101 {
+		t.Errorf("Expected Reserve() to copy shared runs, got %d and %d", f.Len(), r.Len())
+	}
+	r.Reserve(0)
+	r.Reserve(-5)
+
+	var v Uint32
+	v.ReserveValues(1000, 100)
+	if cap(v.runs) != 10 {
+		t.Errorf("Expected room for 10 runs, got %d", cap(v.runs))
+	}
+	v.ReserveValues(5, 0)
+	if cap(v.runs) != 10 {
+		t.Errorf("Expected room for 10 runs, got %d", cap(v.runs))
+	}
+}
+
+func TestShrinkToFit(t *testing.T) {
+	var r Uint32
+	for i := uint32(0); i < 100; i++ {
+		r.Push(2 * i)
+	}
+	f := r.Fork()
+	r.ShrinkToFit()
+	if cap(r.runs) != 100 {
+		t.Errorf("Expected capacity 100, got %d", cap(r.runs))
+	}
+	r.Push(1)
+	if f.Contains(1) || !r.Contains(1) || f.Len() != 100 {
+		t.Errorf("Expected ShrinkToFit() to leave forks alone")
+	}
+	if allocs := testing.AllocsPerRun(1, r.ShrinkToFit); allocs != 0 || cap(r.runs) != len(r.runs) {
+		t.Errorf("Expected a second ShrinkToFit() to do nothing, got %v allocations", allocs)
+	}
+
+	small := &Uint32{}
+	small.Reserve(50)
+	small.Push(3)
+	small.Push(7)
+	small.ShrinkToFit()
+	if cap(small.runs) != 2 {
+		t.Errorf("Expected capacity 2, got %d", cap(small.runs))
+	}
+	testEqualUint32(t, "ShrinkToFit()", *small, Uint32{runs: []Uint32Run{{Value: 3, Count: 1}, {Value: 7, Index: 1, Count: 1}}})
+	small.ShrinkToFit()
+
+	var forked Uint32
+	forked.Push(10)
+	forked.Push(20)
+	f = forked.Fork()
+	forked.Push(21)
+	forked.ShrinkToFit()
+	forked.Push(22)
+	if got := f.String(); got != "10,20" {
+		t.Errorf("Expected ShrinkToFit() to leave a fork of inline runs alone, got %s", got)
+	}
+	if got := forked.String(); got != "10,20-22" {
+		t.Errorf("Expected 10,20-22, got %s", got)
+	}
+
+	var captured Uint32
+	captured.Push(10)
+	captured.Push(20)
+	s := captured.Capture()
+	captured.Push(11)
+	captured.ShrinkToFit()
+	captured.Push(12)
+	if got := s.String(); got != "10,20" {
+		t.Errorf("Expected ShrinkToFit() to leave a Snapshot of inline runs alone, got %s", got)
+	}
+	if got := captured.String(); got != "10-12,20" {
+		t.Errorf("Expected 10-12,20, got %s", got)
+	}
+
+	var empty Uint32
+	empty.ShrinkToFit()
+	if empty.Len() != 0 {
+		t.Errorf("Expected an empty array, got %v", empty)
+	}
+
+	var c Collection
+	c.Push("a", 1)
+	c.Set("b", r)
+	c.ShrinkToFit()
+	if b, _ := c.Get("b"); cap(b.runs) != len(b.runs) {
+		t.Errorf("Expected Collection.ShrinkToFit() to shrink every array")
+	}
+}
+
+func TestGrowthPolicy(t *testing.T) {
+	defer SetGrowthPolicy(nil)
+	for _, tc := range []struct {
+		name   string
+		policy GrowthPolicy
+		want   int
+	}{
+		{"GrowExact", GrowExact, 101},
+		{"GrowQuarter", GrowQuarter, 121},
+		{"GrowDouble", GrowDouble, 128},
+		{"custom", func(capacity, needed int) int { return needed + 10 }, 111},
+	} {
+		SetGrowthPolicy(tc.policy)
+		var r Uint32
+		for x := uint32(0); x < 101; x++ {
+			r.Push(2 * x)
+		}
+		if cap(r.runs) != tc.want {
+			t.Errorf("Expected %s to grow 101 runs to capacity %d, got %d", tc.name, tc.want, cap(r.runs))
+		}
+	}
+
+	// Middle inserts grow too.
+	SetGrowthPolicy(GrowExact)
+	r := Uint32{runs: []Uint32Run{{Value: 0, Count: 1}, {Value: 10, Index: 1, Count: 1}}}
+	r.Push(5)
+	if cap(r.runs) != 3 || r.IndexOf(10) != 2 {
+		t.Errorf("Expected an exact capacity of 3 after a middle insert, got %d and %v", cap(r.runs), r)
+	}
+}
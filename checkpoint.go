@@ -0,0 +1,172 @@
+package rangearray
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// A checkpoint stream is a series of checkpoints of one array.  Each
+// starts with the magic bytes "RC" and the number of runs that it
+// keeps from the checkpoint before it, as a uvarint; the first
+// checkpoint, or any full one, keeps none.  The runs after those
+// follow, as one array in the binary format.
+
+var errBadCheckpoint = corruptf("rangearray: not a rangearray checkpoint")
+
+// Checkpointer writes checkpoints of an array for fast restarts:
+// first a full one, then incremental ones that hold only the runs that
+// changed since the checkpoint before.  For an array that is mostly
+// appended to, that is just the last few runs.  Restore reads the
+// resulting stream back.
+//
+// Each checkpoint is of a Snapshot, so it captures the state of the
+// array at one instant, even while other goroutines go on pushing to
+// a SafeUint32.  The zero value writes a full checkpoint first.
+type Checkpointer struct {
+	// Encoder sets how the runs in each checkpoint are stored.
+	Encoder Encoder
+
+	prev   Snapshot
+	primed bool
+}
+
+// Reset makes the next checkpoint a full one, as when starting a new
+// stream.
+func (c *Checkpointer) Reset() {
+	c.prev, c.primed = Snapshot{}, false
+}
+
+// Checkpoint writes a checkpoint of s to w, holding the runs that
+// changed since the previous checkpoint, or every run after Reset.  It
+// returns the number of bytes written.
+func (c *Checkpointer) Checkpoint(w io.Writer, s Snapshot) (int64, error) {
+	keep := 0
+	if c.primed {
+		keep = commonRuns(c.prev, s)
+	}
+
+	// The runs after keep, with indexes counted from the first of them.
+	n := s.NumRuns()
+	tail := Uint32{runs: make([]Uint32Run, 0, n-keep)}
+	for i := keep; i < n; i++ {
+		r := s.run(i)
+		tail.appendRun(r.Value, r.Count)
+	}
+
+	b := binary.AppendUvarint([]byte("RC"), uint64(keep))
+	m, err := w.Write(b)
+	if err != nil {
+		return int64(m), err
+	}
+	k, err := c.Encoder.Encode(w, tail)
+	if err != nil {
+		return int64(m) + k, err
+	}
+	c.prev, c.primed = s, true
+	return int64(m) + k, nil
+}
+
+// commonRuns returns the number of leading runs that a and b share.
+func commonRuns(a, b Snapshot) int {
+	n := min(a.NumRuns(), b.NumRuns())
+	i := 0
+
+	// Snapshots of the same array share the runs before the last one
+	// until a change other than an append copies them.
+	if len(a.head.runs) > 0 && len(b.head.runs) > 0 && &a.head.runs[0] == &b.head.runs[0] {
+		i = min(len(a.head.runs), len(b.head.runs))
+	}
+	for i < n && a.run(i) == b.run(i) {
+		i++
+	}
+	return i
+}
+
+// Restore reads a stream of checkpoints, as written by a Checkpointer,
+// to the end of rd, and returns the array as of the last of them.
+func Restore(rd io.Reader, d Decoder) (Uint32, error) {
+	br, ok := rd.(io.ByteReader)
+	if !ok {
+		b := bufio.NewReader(rd)
+		rd, br = b, b
+	}
+
+	var r Uint32
+	for first := true; ; first = false {
+		var magic [2]byte
+		if _, err := io.ReadFull(rd, magic[:]); err == io.EOF && !first {
+			return r, nil
+		} else if err != nil {
+			return Uint32{}, unexpectedEOF(err)
+		}
+		if string(magic[:]) != "RC" {
+			return Uint32{}, errBadCheckpoint
+		}
+		keep, err := binary.ReadUvarint(br)
+		if err != nil {
+			return Uint32{}, unexpectedEOF(err)
+		}
+		if keep > uint64(len(r.runs)) {
+			return Uint32{}, errBadCheckpoint
+		}
+
+		var tail Uint32
+		if _, err := d.Decode(rd, &tail); err != nil {
+			return Uint32{}, unexpectedEOF(err)
+		}
+		next := Uint32{runs: make([]Uint32Run, 0, int(keep)+len(tail.runs))}
+		next.runs = append(next.runs, r.runs[:keep]...)
+		for _, s := range tail.runs {
+			if !next.appendRun(s.Value, s.Count) {
+				return Uint32{}, errBadCheckpoint
+			}
+		}
+		r = next
+	}
+}
+
+// Checkpoint writes a full checkpoint of the current contents of s to
+// w, which Restore or s.Restore can read.  Pushes to s may continue
+// while it is written.
+func (s *SafeUint32) Checkpoint(w io.Writer) (int64, error) {
+	var c Checkpointer
+	return c.Checkpoint(w, s.Capture())
+}
+
+// Restore replaces the contents of s with the array in a stream of
+// checkpoints read from rd.
+func (s *SafeUint32) Restore(rd io.Reader) error {
+	r, err := Restore(rd, Decoder{})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.r = r
+	if s.cache != nil {
+		s.cache = newIndexCache(cap(s.cache.keys))
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Checkpoint writes a full checkpoint of the most recently published
+// snapshot to w.  Unlike Push, it is safe to call from any goroutine.
+func (p *Publisher) Checkpoint(w io.Writer) (int64, error) {
+	r := p.Snapshot().Thaw()
+	var c Checkpointer
+	return c.Checkpoint(w, r.Capture())
+}
+
+// Restore replaces the array with the one in a stream of checkpoints
+// read from rd, and publishes it.  Only the writer goroutine may call
+// Restore.
+func (p *Publisher) Restore(rd io.Reader) error {
+	r, err := Restore(rd, Decoder{})
+	if err != nil {
+		return err
+	}
+	p.r = r
+	p.Publish()
+	return nil
+}
@@ -0,0 +1,94 @@
+package rangearray
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCheckpointer(t *testing.T) {
+	var r Uint32
+	var c Checkpointer
+	var stream bytes.Buffer
+	var sizes []int64
+	for x := uint32(10); x < 30000; x += 3 {
+		r.Push(x)
+	}
+	for i := uint32(0); i < 5; i++ {
+		for x := 30000 + 100*i; x < 30000+100*i+30; x += 3 {
+			r.Push(x)
+		}
+		if i == 3 {
+			// A change before the end is written from that run on.
+			r.Push(1)
+		}
+		n, err := c.Checkpoint(&stream, r.Capture())
+		if err != nil {
+			t.Fatalf("Checkpoint() failed: %v", err)
+		}
+		sizes = append(sizes, n)
+
+		got, err := Restore(bytes.NewReader(stream.Bytes()), Decoder{})
+		if err != nil {
+			t.Fatalf("Restore() after checkpoint %d failed: %v", i, err)
+		}
+		testEqualUint32(t, "Restore()", got, r)
+	}
+	if 10*sizes[1] >= sizes[0] || 10*sizes[2] >= sizes[3] {
+		t.Errorf("Expected incremental checkpoints to be smaller than full ones, got sizes %v", sizes)
+	}
+
+	// An unchanged array needs almost nothing.
+	before := stream.Len()
+	c.Checkpoint(&stream, r.Capture())
+	if n := stream.Len() - before; n > 16 {
+		t.Errorf("Expected a small checkpoint of an unchanged array, got %d bytes", n)
+	}
+
+	c.Reset()
+	var full bytes.Buffer
+	c.Checkpoint(&full, r.Capture())
+	got, err := Restore(&full, Decoder{})
+	if err != nil {
+		t.Fatalf("Restore() of a full checkpoint failed: %v", err)
+	}
+	testEqualUint32(t, "full", got, r)
+
+	for _, bad := range [][]byte{nil, []byte("RX"), stream.Bytes()[:len(stream.Bytes())-1]} {
+		if _, err := Restore(bytes.NewReader(bad), Decoder{}); err == nil {
+			t.Errorf("Expected Restore() of %q to fail", bad)
+		}
+	}
+}
+
+func TestCheckpointSafe(t *testing.T) {
+	var s SafeUint32
+	for x := uint32(0); x < 100; x += 2 {
+		s.Push(x)
+	}
+	var buf bytes.Buffer
+	if _, err := s.Checkpoint(&buf); err != nil {
+		t.Fatalf("Checkpoint() failed: %v", err)
+	}
+	s.Push(1000)
+
+	var u SafeUint32
+	if err := u.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	if u.Len() != 50 || u.Contains(1000) {
+		t.Errorf("Expected the checkpoint to hold 50 values, got %v", &u)
+	}
+
+	p := NewPublisher(PublisherOptions{})
+	if err := p.Restore(io.MultiReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Publisher.Restore() failed: %v", err)
+	}
+	if p.Snapshot().Len() != 50 {
+		t.Errorf("Expected Restore() to publish 50 values, got %v", p.Snapshot())
+	}
+	var again bytes.Buffer
+	if _, err := p.Checkpoint(&again); err != nil || !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Errorf("Expected Publisher.Checkpoint() to match the original, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+// Command rangearray inspects, merges, compares, and converts
+// serialized rangearrays.
+//
+// Usage:
+//
+//	rangearray stats FILE...
+//	rangearray ranges FILE
+//	rangearray merge [-format FORMAT] [-o OUTPUT] FILE...
+//	rangearray diff FILE1 FILE2
+//	rangearray convert [-format FORMAT] [-o OUTPUT] FILE
+//
+// Input files may be in the binary, block, flat, canonical, text, or
+// JSON format; the format is detected from the contents.  A file name
+// of "-" means standard input.  FORMAT is one of binary, varint, text,
+// or json, and defaults to text.  Output goes to standard output unless
+// -o is given.
+//
+// The diff command prints the ranges only in FILE1 with a "-" prefix
+// and the ranges only in FILE2 with a "+" prefix, and exits with status
+// 1 if there are any.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	rangearray "github/com/entrope/rangearray/v2"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+const usage = `usage:
+	rangearray stats FILE...
+	rangearray ranges FILE
+	rangearray merge [-format FORMAT] [-o OUTPUT] FILE...
+	rangearray diff FILE1 FILE2
+	rangearray convert [-format FORMAT] [-o OUTPUT] FILE
+`
+
+// errDiffer is returned by the diff command when its inputs differ.
+var errDiffer = errors.New("arrays differ")
+
+// run executes the command in args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	c := &command{stdin: stdin, stdout: stdout}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	var minArgs, maxArgs int
+	var do func([]string) error
+	switch args[0] {
+	case "stats":
+		minArgs, maxArgs, do = 1, -1, c.stats
+	case "ranges":
+		minArgs, maxArgs, do = 1, 1, c.ranges
+	case "merge":
+		minArgs, maxArgs, do = 1, -1, c.merge
+		c.outputFlags(fs)
+	case "diff":
+		minArgs, maxArgs, do = 2, 2, c.diff
+	case "convert":
+		minArgs, maxArgs, do = 1, 1, c.merge
+		c.outputFlags(fs)
+	default:
+		fmt.Fprintf(stderr, "rangearray: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if n := fs.NArg(); n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fs.Usage()
+		return 2
+	}
+	if err := do(fs.Args()); err == errDiffer {
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(stderr, "rangearray %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// command holds the state shared by the subcommands.
+type command struct {
+	stdin  io.Reader
+	stdout io.Writer
+	format string
+	output string
+}
+
+// outputFlags defines the flags for commands that write an array.
+func (c *command) outputFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.format, "format", "text", "output `format`: binary, varint, text, or json")
+	fs.StringVar(&c.output, "o", "", "write to `file` instead of standard output")
+}
+
+// stats prints summary statistics for each file.
+func (c *command) stats(names []string) error {
+	for _, name := range names {
+		r, err := c.read(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "%s: %d values, %d runs", name, r.Len(), r.NumRuns())
+		if r.NumRuns() > 0 {
+			fmt.Fprintf(c.stdout, ", min %d, max %d", r.Min(), r.Max())
+		}
+		fmt.Fprintln(c.stdout)
+	}
+	return nil
+}
+
+// ranges prints the runs of a file, one per line.
+func (c *command) ranges(names []string) error {
+	r, err := c.read(names[0])
+	if err != nil {
+		return err
+	}
+	return writeRanges(c.stdout, "", r.AppendRuns(nil))
+}
+
+// merge writes the union of the files.  With one file, it converts the
+// file to the output format.
+func (c *command) merge(names []string) error {
+	arrays := make([]rangearray.Uint32, len(names))
+	for i, name := range names {
+		var err error
+		if arrays[i], err = c.read(name); err != nil {
+			return err
+		}
+	}
+
+	r := arrays[0]
+	if len(arrays) > 1 {
+		r = rangearray.NewUnionView(arrays...).Materialize()
+	}
+	return c.write(r)
+}
+
+// diff prints the ranges that are in only one of two files.
+func (c *command) diff(names []string) error {
+	a, err := c.read(names[0])
+	if err != nil {
+		return err
+	}
+	b, err := c.read(names[1])
+	if err != nil {
+		return err
+	}
+
+	onlyA := rangearray.AppendDifference(nil, a, b)
+	onlyB := rangearray.AppendDifference(nil, b, a)
+	if err := writeRanges(c.stdout, "-", onlyA); err != nil {
+		return err
+	}
+	if err := writeRanges(c.stdout, "+", onlyB); err != nil {
+		return err
+	}
+	if len(onlyA) > 0 || len(onlyB) > 0 {
+		return errDiffer
+	}
+	return nil
+}
+
+// read returns the array in the named file, detecting its format.
+func (c *command) read(name string) (rangearray.Uint32, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return rangearray.Uint32{}, err
+	}
+
+	r, err := decode(data)
+	if err != nil {
+		return rangearray.Uint32{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return r, nil
+}
+
+// decode parses data in any supported format.
+func decode(data []byte) (rangearray.Uint32, error) {
+	var r rangearray.Uint32
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("RA")):
+		err = r.UnmarshalBinary(data)
+	case bytes.HasPrefix(data, []byte("RB")):
+		var f *rangearray.BlockFile
+		if f, err = rangearray.OpenBlockFile(bytes.NewReader(data), int64(len(data))); err == nil {
+			r, err = f.ReadAll()
+		}
+	case bytes.HasPrefix(data, []byte("RF")):
+		var v rangearray.FlatView
+		if v, err = rangearray.NewFlatView(data); err == nil {
+			r, err = rangearray.FromRuns(slices.Collect(v.Runs()))
+		}
+	case bytes.HasPrefix(data, []byte("RC")):
+		err = r.UnmarshalCanonical(data)
+	default:
+		text := bytes.TrimSpace(data)
+		if bytes.HasPrefix(text, []byte("[")) {
+			err = r.UnmarshalJSON(text)
+		} else {
+			err = r.UnmarshalText(text)
+		}
+	}
+	return r, err
+}
+
+// write writes r in the selected output format.
+func (c *command) write(r rangearray.Uint32) error {
+	var b []byte
+	var err error
+	switch c.format {
+	case "binary":
+		b, err = r.MarshalBinary()
+	case "varint":
+		b, err = rangearray.Encoder{Encoding: rangearray.Varint}.Marshal(r)
+	case "text":
+		b, err = r.MarshalText()
+		b = append(b, '\n')
+	case "json":
+		b, err = r.MarshalJSON()
+		b = append(b, '\n')
+	default:
+		return fmt.Errorf("unknown format %q", c.format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.output != "" {
+		return os.WriteFile(c.output, b, 0o666)
+	}
+	_, err = c.stdout.Write(b)
+	return err
+}
+
+// writeRanges writes runs to w, one per line, each preceded by prefix.
+func writeRanges(w io.Writer, prefix string, runs []rangearray.Uint32Run) error {
+	for _, s := range runs {
+		var err error
+		if s.Count == 1 {
+			_, err = fmt.Fprintf(w, "%s%d\n", prefix, s.Value)
+		} else {
+			_, err = fmt.Fprintf(w, "%s%d-%d\n", prefix, s.Value, s.Value+s.Count-1)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rangearray "github/com/entrope/rangearray/v2"
+)
+
+// testFile writes data to a file in a temporary directory and returns
+// its name.
+func testFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testRun runs the command in args and returns its exit status and
+// output.
+func testRun(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestStats(t *testing.T) {
+	var r rangearray.Uint32
+	r.UnmarshalText([]byte("5,100-199"))
+	bin, _ := r.MarshalBinary()
+	path := testFile(t, "a.bin", bin)
+
+	status, out, _ := testRun("", "stats", path)
+	if want := path + ": 101 values, 2 runs, min 5, max 199\n"; status != 0 || out != want {
+		t.Errorf("Expected stats to print %q, got %d, %q", want, status, out)
+	}
+
+	status, out, _ = testRun("[]", "stats", "-")
+	if want := "-: 0 values, 0 runs\n"; status != 0 || out != want {
+		t.Errorf("Expected stats to print %q, got %d, %q", want, status, out)
+	}
+}
+
+func TestRanges(t *testing.T) {
+	status, out, _ := testRun("[[5,1],[100,100]]", "ranges", "-")
+	if want := "5\n100-199\n"; status != 0 || out != want {
+		t.Errorf("Expected ranges to print %q, got %d, %q", want, status, out)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := testFile(t, "a.txt", []byte("1-5,20\n"))
+	b := testFile(t, "b.json", []byte("[[6,4],[30,1]]"))
+	status, out, _ := testRun("", "merge", a, b)
+	if want := "1-9,20,30\n"; status != 0 || out != want {
+		t.Errorf("Expected merge to print %q, got %d, %q", want, status, out)
+	}
+
+	dst := filepath.Join(t.TempDir(), "out.bin")
+	if status, _, errs := testRun("", "merge", "-format", "varint", "-o", dst, a, b); status != 0 {
+		t.Fatalf("merge -o failed: %s", errs)
+	}
+	status, out, _ = testRun("", "convert", "-format", "json", dst)
+	if want := "[[1,9],[20,1],[30,1]]\n"; status != 0 || out != want {
+		t.Errorf("Expected convert to print %q, got %d, %q", want, status, out)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var r rangearray.Uint32
+	r.UnmarshalText([]byte("7-8,4294967295"))
+	flat := r.AppendFlat(nil)
+	canonical, _ := r.MarshalCanonical()
+	var blocks bytes.Buffer
+	rangearray.Encoder{}.EncodeBlocks(&blocks, r)
+
+	for _, data := range [][]byte{flat, canonical, blocks.Bytes()} {
+		status, out, errs := testRun(string(data), "convert", "-")
+		if want := "7-8,4294967295\n"; status != 0 || out != want {
+			t.Errorf("Expected convert to print %q, got %d, %q, %q", want, status, out, errs)
+		}
+	}
+
+	bin, _ := r.MarshalBinary()
+	status, out, _ := testRun("7-8,4294967295", "convert", "-format", "binary", "-")
+	if status != 0 || out != string(bin) {
+		t.Errorf("Expected convert -format binary to write binary, got %d, %q", status, out)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := testFile(t, "a.txt", []byte("1-10,20-30,50"))
+	b := testFile(t, "b.txt", []byte("3-4,8-25,60"))
+	status, out, _ := testRun("", "diff", a, b)
+	if want := "-1-2\n-5-7\n-26-30\n-50\n+11-19\n+60\n"; status != 1 || out != want {
+		t.Errorf("Expected diff to print %q, got %d, %q", want, status, out)
+	}
+
+	status, out, _ = testRun("", "diff", a, a)
+	if status != 0 || out != "" {
+		t.Errorf("Expected diff of equal files to print nothing, got %d, %q", status, out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"frob"},
+		{"ranges"},
+		{"diff", "-"},
+		{"convert", "-format", "yaml", "-"},
+		{"ranges", filepath.Join(t.TempDir(), "missing")},
+	} {
+		if status, _, errs := testRun("1", args...); status == 0 || errs == "" {
+			t.Errorf("Expected %q to fail with a message, got %d, %q", args, status, errs)
+		}
+	}
+
+	if status, _, errs := testRun("9-1", "ranges", "-"); status != 1 || !strings.Contains(errs, "-:") {
+		t.Errorf("Expected a bad input to name the file, got %d, %q", status, errs)
+	}
+}
@@ -0,0 +1,277 @@
+package rangearray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// A Collection maps string keys, such as satellite or station IDs, to
+// rangearrays.  The zero value is an empty collection ready to use.
+// Like Uint32, a Collection is not safe for concurrent modification.
+//
+// The binary form of a Collection is the magic bytes "RK", a format
+// version, and the number of keys as a uvarint.  Then, for each key in
+// increasing order, come the length of the key as a uvarint, the key,
+// and its array in the binary format with the Varint encoding.
+//
+// A Collection allocates its arrays in chunks, and Compact packs their
+// runs into one allocation, so that a Collection of many small arrays
+// does not need an allocation for each one.
+type Collection struct {
+	m map[string]*Uint32
+
+	// free is the unused part of the last chunk of arrays allocated
+	// for m.
+	free []Uint32
+}
+
+// collectionChunk is the number of arrays a Collection allocates at
+// once.
+const collectionChunk = 64
+
+// newArray returns an unused array from c's current chunk, allocating
+// a new chunk if needed.
+func (c *Collection) newArray() *Uint32 {
+	if len(c.free) == 0 {
+		c.free = make([]Uint32, collectionChunk)
+	}
+	r := &c.free[0]
+	c.free = c.free[1:]
+	return r
+}
+
+// KeyStats summarizes one array in a Collection.  Min and Max are zero
+// for an empty array.
+type KeyStats struct {
+	Key      string
+	Len      uint32
+	Runs     int
+	Min, Max uint32
+}
+
+// Push adds x to the array under key, creating it if needed, and
+// reports whether x was added, as Uint32.Push does.
+func (c *Collection) Push(key string, x uint32) bool {
+	r := c.m[key]
+	if r == nil {
+		if c.m == nil {
+			c.m = make(map[string]*Uint32)
+		}
+		r = c.newArray()
+		c.m[key] = r
+	}
+	return r.Push(x)
+}
+
+// Get returns the array under key, and whether there is one.  The
+// result shares memory with c until one of them is modified; use Fork
+// on it before modifying it.
+func (c *Collection) Get(key string) (Uint32, bool) {
+	if r := c.m[key]; r != nil {
+		return r.Fork(), true
+	}
+	return Uint32{}, false
+}
+
+// Set replaces the array under key with r.  c takes ownership of r.
+func (c *Collection) Set(key string, r Uint32) {
+	if c.m == nil {
+		c.m = make(map[string]*Uint32)
+	}
+	p := c.m[key]
+	if p == nil {
+		p = c.newArray()
+		c.m[key] = p
+	}
+	*p = r
+}
+
+// ShrinkToFit releases the unused capacity of every array in c, as
+// Uint32.ShrinkToFit does.
+func (c *Collection) ShrinkToFit() {
+	for _, r := range c.m {
+		r.ShrinkToFit()
+	}
+}
+
+// Compact packs the arrays of c into two allocations: one for the
+// arrays and one for their runs, in key order.  Compact is meant to be called once a
+// Collection has been loaded; arrays that grow afterwards move their
+// runs to new allocations of their own.
+func (c *Collection) Compact() {
+	total := 0
+	for _, r := range c.m {
+		total += len(r.runs)
+	}
+
+	arrays := make([]Uint32, len(c.m))
+	runs := make([]Uint32Run, 0, total)
+	for i, key := range c.Keys() {
+		r, p := c.m[key], &arrays[i]
+		if n := len(r.runs); n > 0 {
+			runs = append(runs, r.runs...)
+			p.runs = runs[len(runs)-n : len(runs) : len(runs)]
+		}
+		c.m[key] = p
+	}
+	c.free = nil
+}
+
+// Delete removes the array under key.
+func (c *Collection) Delete(key string) {
+	delete(c.m, key)
+}
+
+// Len returns the number of keys in c.
+func (c *Collection) Len() int {
+	return len(c.m)
+}
+
+// Keys returns the keys of c in increasing order.
+func (c *Collection) Keys() []string {
+	keys := slices.AppendSeq(make([]string, 0, len(c.m)), maps.Keys(c.m))
+	slices.Sort(keys)
+	return keys
+}
+
+// All returns an iterator over the keys and arrays of c, in increasing
+// order of key.  The arrays share memory with c, as with Get.
+func (c *Collection) All() iter.Seq2[string, Uint32] {
+	return func(yield func(string, Uint32) bool) {
+		for _, key := range c.Keys() {
+			if r := c.m[key]; r != nil && !yield(key, r.Fork()) {
+				return
+			}
+		}
+	}
+}
+
+// Union returns the union of every array in c.
+func (c *Collection) Union() Uint32 {
+	arrays := make([]Uint32, 0, len(c.m))
+	for _, r := range c.m {
+		arrays = append(arrays, *r)
+	}
+	return NewUnionView(arrays...).Materialize()
+}
+
+// Stats returns statistics for each array in c, in increasing order of
+// key.
+func (c *Collection) Stats() []KeyStats {
+	out := make([]KeyStats, 0, len(c.m))
+	for _, key := range c.Keys() {
+		r := c.m[key]
+		s := KeyStats{Key: key, Len: r.Len(), Runs: len(r.runs)}
+		if len(r.runs) > 0 {
+			s.Min, s.Max = r.Min(), r.Max()
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (c *Collection) MarshalBinary() ([]byte, error) {
+	b := []byte{'R', 'K', binaryVersion}
+	b = binary.AppendUvarint(b, uint64(len(c.m)))
+	e := Encoder{Encoding: Varint}
+	for _, key := range c.Keys() {
+		b = binary.AppendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+		var err error
+		if b, err = e.Append(b, *c.m[key]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+var errBadCollection = corruptf("rangearray: not a binary collection")
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.  It replaces
+// the contents of c.
+func (c *Collection) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 || string(data[:2]) != "RK" {
+		return errBadCollection
+	}
+	if data[2] != binaryVersion {
+		return versionf("rangearray: unsupported collection version %d", data[2])
+	}
+
+	rd := bytes.NewReader(data[3:])
+	n, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	m := make(map[string]*Uint32, min(n, uint64(rd.Len())))
+	prev := ""
+	for i := uint64(0); i < n; i++ {
+		klen, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if klen > uint64(rd.Len()) {
+			return errTruncated
+		}
+		key := make([]byte, klen)
+		rd.Read(key)
+		if i > 0 && string(key) <= prev {
+			return corruptf("rangearray: collection key %q is out of order", key)
+		}
+		prev = string(key)
+
+		r := &Uint32{}
+		if _, err := (Decoder{}).Decode(rd, r); err != nil {
+			return fmt.Errorf("rangearray: collection key %q: %w", key, unexpectedEOF(err))
+		}
+		m[prev] = r
+	}
+	if rd.Len() != 0 {
+		return errTrailing
+	}
+
+	c.m = m
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.  A collection is encoded as an
+// object whose members are the arrays, in the format of
+// Uint32.MarshalJSON.
+func (c *Collection) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+	for i, key := range c.Keys() {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, k...)
+		b = append(b, ':')
+		v, _ := c.m[key].MarshalJSON()
+		b = append(b, v...)
+	}
+	return append(b, '}'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.  It accepts the format
+// written by MarshalJSON, and replaces the contents of c.
+func (c *Collection) UnmarshalJSON(data []byte) error {
+	var m map[string]*Uint32
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for key, r := range m {
+		if r == nil {
+			m[key] = &Uint32{}
+		}
+	}
+	c.m = m
+	return nil
+}
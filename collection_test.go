@@ -0,0 +1,180 @@
+package rangearray
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testCollection returns a collection with three keys.
+func testCollection() *Collection {
+	var c Collection
+	for i := uint32(0); i < 10; i++ {
+		c.Push("G02", 100+i)
+		c.Push("G01", 2*i)
+	}
+	c.Set("E11", Uint32{})
+	return &c
+}
+
+func TestCollection(t *testing.T) {
+	c := testCollection()
+	if c.Len() != 3 || !slices.Equal(c.Keys(), []string{"E11", "G01", "G02"}) {
+		t.Errorf("Expected keys E11, G01, G02, got %v", c.Keys())
+	}
+
+	r, ok := c.Get("G02")
+	if !ok || r.String() != "100-109" {
+		t.Errorf("Expected Get(G02) == 100-109, got %v, %v", r, ok)
+	}
+	r.Push(200)
+	c.Push("G02", 110)
+	if r2, _ := c.Get("G02"); r2.String() != "100-110" || r.String() != "100-109,200" {
+		t.Errorf("Expected Get() results to be independent of c, got %v and %v", r, r2)
+	}
+	if _, ok := c.Get("R01"); ok {
+		t.Errorf("Expected Get(R01) to fail")
+	}
+
+	var keys []string
+	for key := range c.All() {
+		keys = append(keys, key)
+		if key == "G01" {
+			break
+		}
+	}
+	if !slices.Equal(keys, []string{"E11", "G01"}) {
+		t.Errorf("Expected All() to stop after G01, got %v", keys)
+	}
+
+	if u := c.Union(); u.String() != "0,2,4,6,… (11 runs, 21 values)" {
+		t.Errorf("Expected Union() to merge every array, got %v", u)
+	}
+
+	want := []KeyStats{{"E11", 0, 0, 0, 0}, {"G01", 10, 10, 0, 18}, {"G02", 11, 1, 100, 110}}
+	if got := c.Stats(); !slices.Equal(got, want) {
+		t.Errorf("Expected Stats() == %v, got %v", want, got)
+	}
+
+	c.Delete("E11")
+	if c.Len() != 2 {
+		t.Errorf("Expected Delete() to remove a key, got %v", c.Keys())
+	}
+
+	var zero Collection
+	if zero.Len() != 0 || len(zero.Keys()) != 0 || zero.Union().Len() != 0 {
+		t.Errorf("Expected the zero Collection to be empty")
+	}
+}
+
+func testEqualCollection(t *testing.T, got, want *Collection) {
+	t.Helper()
+	if !slices.Equal(got.Keys(), want.Keys()) {
+		t.Fatalf("Expected keys %v, got %v", want.Keys(), got.Keys())
+	}
+	for key, r := range want.All() {
+		g, _ := got.Get(key)
+		testEqualUint32(t, key, g, r)
+	}
+}
+
+func TestCollectionBinary(t *testing.T) {
+	c := testCollection()
+	b, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() failed: %v", err)
+	}
+	var out Collection
+	if err := out.UnmarshalBinary(b); err != nil {
+		t.Fatalf("UnmarshalBinary() failed: %v", err)
+	}
+	testEqualCollection(t, &out, c)
+
+	for i := range len(b) {
+		if err := out.UnmarshalBinary(b[:i]); err == nil {
+			t.Errorf("Expected UnmarshalBinary() of %d bytes to fail", i)
+		}
+	}
+	if err := out.UnmarshalBinary(append(b, 0)); err == nil {
+		t.Errorf("Expected UnmarshalBinary() with trailing data to fail")
+	}
+}
+
+func TestCollectionJSON(t *testing.T) {
+	c := testCollection()
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if want := `{"E11":[],"G01":[[0,1],[2,1],[4,1],[6,1],[8,1],[10,1],[12,1],[14,1],[16,1],[18,1]],"G02":[[100,10]]}`; string(b) != want {
+		t.Errorf("Expected Marshal() == %s, got %s", want, b)
+	}
+
+	var out Collection
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	testEqualCollection(t, &out, c)
+
+	if err := json.Unmarshal([]byte(`{"a":null}`), &out); err != nil || out.Len() != 1 {
+		t.Errorf("Expected a null array to unmarshal as empty, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"a":[[5,1],[1,1]]}`), &out); err == nil {
+		t.Errorf("Expected Unmarshal() of a bad array to fail")
+	}
+}
+
+func TestCollectionCompact(t *testing.T) {
+	var c Collection
+	for k := range 200 {
+		key := fmt.Sprintf("sat%03d", k)
+		for i := range uint32(k % 5) {
+			c.Push(key, 10*i)
+		}
+	}
+	before := c.Stats()
+
+	allocs := testing.AllocsPerRun(1, c.Compact)
+	if allocs > 3 {
+		t.Errorf("Expected Compact() to allocate at most 3 times, got %v", allocs)
+	}
+	after := c.Stats()
+	if len(after) != len(before) {
+		t.Fatalf("Expected %d keys, got %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("Expected %+v, got %+v", before[i], after[i])
+		}
+	}
+
+	// Growing one packed array must not disturb its neighbours.
+	c.Push("sat003", 1000)
+	c.Push("sat004", 1000)
+	c.Compact()
+	for _, key := range []string{"sat003", "sat004", "sat008", "sat009"} {
+		r, _ := c.Get(key)
+		want := uint32(key[5]-'0') % 5
+		if key == "sat003" || key == "sat004" {
+			want++
+		}
+		if r.Len() != want {
+			t.Errorf("Expected %s to hold %d values, got %v", key, want, r)
+		}
+	}
+
+	keys := make([]string, collectionChunk)
+	for k := range keys {
+		keys[k] = fmt.Sprint(k)
+	}
+	allocs = testing.AllocsPerRun(10, func() {
+		var c Collection
+		for _, key := range keys {
+			c.Set(key, Uint32{})
+		}
+	})
+	if allocs > collectionChunk/4 {
+		t.Errorf("Expected arrays to be allocated in chunks, got %v allocations", allocs)
+	}
+}
@@ -0,0 +1,126 @@
+package rangearray
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// CSVOptions controls how ReadCSV finds and parses timestamps.
+type CSVOptions struct {
+	// Column is the name of the timestamp column.  If it is not
+	// empty, the first record is a header row that names the columns.
+	// Otherwise, there is no header row, and Index selects the column.
+	Column string
+
+	// Index is the zero-based index of the timestamp column, when
+	// Column is empty.
+	Index int
+
+	// Comma is the field delimiter.  If it is zero, ',' is used.
+	Comma rune
+
+	// Epoch, if not zero, means the column holds RFC 3339 timestamps
+	// rather than uint32 values.  Each timestamp becomes the number of
+	// Ticks since Epoch, and must be a whole number of Ticks after it.
+	Epoch time.Time
+
+	// Tick is the time step for RFC 3339 timestamps.  If it is zero,
+	// one second is used.
+	Tick time.Duration
+
+	// Tracer, if not nil, observes the load.
+	Tracer Tracer
+
+	// Progress reports the records read.
+	Progress Progress
+}
+
+// ReadCSV returns a rangearray holding the timestamps in the CSV data
+// from rd.  Errors report the line of the offending field.
+func ReadCSV(rd io.Reader, opts CSVOptions) (r Uint32, err error) {
+	done := startTrace(context.Background(), opts.Tracer, OpReadCSV)
+	defer func() { done(len(r.runs), err) }()
+
+	pr := opts.Progress.start(OpReadCSV, -1)
+	cr := csv.NewReader(rd)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	tick := opts.Tick
+	if tick == 0 {
+		tick = time.Second
+	}
+
+	col := opts.Index
+	if opts.Column != "" {
+		header, err := cr.Read()
+		if err != nil {
+			return Uint32{}, csvError(err)
+		}
+		if col = slices.Index(header, opts.Column); col < 0 {
+			return Uint32{}, fmt.Errorf("rangearray: no CSV column %q", opts.Column)
+		}
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			pr.finish()
+			return r, nil
+		} else if err != nil {
+			return Uint32{}, csvError(err)
+		}
+
+		if col >= len(record) {
+			line, _ := cr.FieldPos(0)
+			return Uint32{}, fmt.Errorf("rangearray: line %d: no column %d", line, col)
+		}
+
+		x, err := parseTimestamp(record[col], opts.Epoch, tick)
+		if err != nil {
+			line, _ := cr.FieldPos(col)
+			return Uint32{}, fmt.Errorf("rangearray: line %d: %w", line, err)
+		}
+		r.Push(x)
+		pr.add(1)
+	}
+}
+
+// parseTimestamp parses one timestamp for ReadCSV or ReadNDJSON.
+func parseTimestamp(field string, epoch time.Time, tick time.Duration) (uint32, error) {
+	if epoch.IsZero() {
+		x, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		return uint32(x), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, field)
+	if err != nil {
+		return 0, err
+	}
+	d := t.Sub(epoch)
+	if d < 0 || d%tick != 0 || d/tick > 0xffffffff {
+		return 0, fmt.Errorf("timestamp %s is not a whole number of %v ticks within range of the epoch", field, tick)
+	}
+	return uint32(d / tick), nil
+}
+
+// csvError adds the package prefix to errors from encoding/csv, which
+// already report their line.
+func csvError(err error) error {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return fmt.Errorf("rangearray: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package rangearray
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := "station,epoch,value\nA,100,1\nA,101,2\nB,102,3\nB,200,4\n"
+	r, err := ReadCSV(strings.NewReader(data), CSVOptions{Column: "epoch"})
+	if err != nil {
+		t.Fatalf("ReadCSV() failed: %v", err)
+	}
+	if r.String() != "100-102,200" {
+		t.Errorf("Expected ReadCSV() == 100-102,200, got %v", r)
+	}
+
+	r, err = ReadCSV(strings.NewReader("5;x\n6;y\n"), CSVOptions{Comma: ';'})
+	if err != nil || r.String() != "5-6" {
+		t.Errorf("Expected ReadCSV() with Index 0 == 5-6, got %v, %v", r, err)
+	}
+}
+
+func TestReadCSVTime(t *testing.T) {
+	opts := CSVOptions{
+		Index: 1,
+		Epoch: time.Date(1980, 1, 6, 0, 0, 0, 0, time.UTC),
+		Tick:  30 * time.Second,
+	}
+	data := "x,1980-01-06T00:00:00Z\nx,1980-01-06T00:00:30Z\nx,1980-01-06T01:00:00+01:00\nx,1980-01-06T00:05:00Z\n"
+	r, err := ReadCSV(strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatalf("ReadCSV() failed: %v", err)
+	}
+	if r.String() != "0-1,10" {
+		t.Errorf("Expected ReadCSV() == 0-1,10, got %v", r)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	epoch := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []struct {
+		data string
+		opts CSVOptions
+		want string
+	}{
+		{"epoch\n1\nx\n", CSVOptions{Column: "epoch"}, "line 3"},
+		{"a,b\n1,2\n", CSVOptions{Column: "epoch"}, "no CSV column"},
+		{"1,2\n3\n", CSVOptions{Index: 1}, "line 2"},
+		{"1\n\"2\n", CSVOptions{}, "line 2"},
+		{"1\n-1\n", CSVOptions{}, "line 2"},
+		{"2000-01-01T00:00:01.5Z\n", CSVOptions{Epoch: epoch}, "line 1"},
+		{"1999-12-31T23:59:59Z\n", CSVOptions{Epoch: epoch}, "line 1"},
+	} {
+		_, err := ReadCSV(strings.NewReader(s.data), s.opts)
+		if err == nil || !strings.Contains(err.Error(), s.want) {
+			t.Errorf("Expected ReadCSV(%q) to fail with %q, got %v", s.data, s.want, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package rangearray
+
+// Cursor answers queries against a rangearray faster when each query
+// is near the previous one, as when values are looked up in nearly
+// increasing order.  It remembers the run that answered the last query
+// and checks it and its neighbours before falling back to a binary
+// search.
+//
+// A Cursor always gives the same answers as the array it was made
+// from, even after the array changes, though queries are only fast
+// while the remembered run is still nearby.  Like the array itself, a
+// Cursor is not safe for concurrent use.
+type Cursor struct {
+	r *Uint32
+	i int
+}
+
+// Cursor returns a Cursor over r.
+func (r *Uint32) Cursor() *Cursor {
+	return &Cursor{r: r}
+}
+
+// isBound reports whether i is the LowerBound of x in s.
+func isBound(s []Uint32Run, i int, x uint32) bool {
+	if i < 0 || i > len(s) {
+		return false
+	}
+	if i < len(s) && uint64(x) >= uint64(s[i].Value)+uint64(s[i].Count) {
+		return false
+	}
+	return i == 0 || uint64(x) >= uint64(s[i-1].Value)+uint64(s[i-1].Count)
+}
+
+// LowerBound returns the same result as c's array's LowerBound.
+func (c *Cursor) LowerBound(x uint32) int {
+	s := c.r.runs
+	for _, i := range [...]int{c.i, c.i + 1, c.i - 1} {
+		if isBound(s, i, x) {
+			c.i = i
+			return i
+		}
+	}
+	c.i = c.r.LowerBound(x)
+	return c.i
+}
+
+// IndexOf returns the number of elements in c's array that are less
+// than x.
+func (c *Cursor) IndexOf(x uint32) uint32 {
+	i := c.LowerBound(x)
+	if i < len(c.r.runs) {
+		s := c.r.runs[i]
+		if x <= s.Value {
+			return s.Index
+		}
+		return x - s.Value + s.Index
+	}
+	return c.r.Len()
+}
+
+// Contains reports whether x is in c's array.
+func (c *Cursor) Contains(x uint32) bool {
+	i := c.LowerBound(x)
+	return i < len(c.r.runs) && x >= c.r.runs[i].Value
+}
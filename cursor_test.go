@@ -0,0 +1,42 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	var r Uint32
+	for i := uint32(0); i < 200; i++ {
+		pushRange(&r, 10*i, 10*i+i%5)
+	}
+
+	c := r.Cursor()
+	check := func(x uint32) {
+		if got, want := c.LowerBound(x), r.LowerBound(x); got != want {
+			t.Errorf("Expected LowerBound(%d) = %d, got %d", x, want, got)
+		}
+		if got, want := c.IndexOf(x), r.IndexOf(x); got != want {
+			t.Errorf("Expected IndexOf(%d) = %d, got %d", x, want, got)
+		}
+		if got, want := c.Contains(x), r.Contains(x); got != want {
+			t.Errorf("Expected Contains(%d) = %v, got %v", x, want, got)
+		}
+	}
+	for x := uint32(0); x < 2100; x++ {
+		check(x)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 1000 {
+		check(rng.Uint32N(2100))
+	}
+
+	// The cursor must stay correct when the array changes under it.
+	r.Push(5000)
+	r.Push(3)
+	check(5000)
+	check(3)
+	check(4)
+	r = Uint32{}
+	check(7)
+}
@@ -0,0 +1,8 @@
+//go:build !rangearray_debug
+
+package rangearray
+
+// debugChecks makes the methods that modify an array check its
+// invariants, and panic if they are broken.  It is set by the
+// rangearray_debug build tag.
+const debugChecks = false
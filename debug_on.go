@@ -0,0 +1,7 @@
+//go:build rangearray_debug
+
+package rangearray
+
+// debugChecks makes the methods that modify an array check its
+// invariants, and panic if they are broken.
+const debugChecks = true
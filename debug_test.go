@@ -0,0 +1,61 @@
+//go:build rangearray_debug
+
+package rangearray
+
+import "testing"
+
+func TestDebugChecks(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 10, 19)
+	pushRange(&r, 30, 39)
+	r.runs[1].Index = 5
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Push() onto a broken array to panic")
+		}
+	}()
+	r.Push(25)
+}
+
+func TestDebugChecksRemove(t *testing.T) {
+	var p Persistent
+	for _, lo := range []uint32{10, 30, 50} {
+		for x := lo; x < lo+10; x++ {
+			p = p.Push(x)
+		}
+	}
+	var walk func(n *pnode)
+	walk = func(n *pnode) {
+		if n == nil {
+			return
+		}
+		if n.value == 30 {
+			n.count = 25
+		}
+		walk(n.left)
+		walk(n.right)
+	}
+	walk(p.root)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Remove() from a broken array to panic")
+		}
+	}()
+	p.Remove(12)
+}
+
+func TestDebugChecksUint64(t *testing.T) {
+	var r Uint64
+	r.Push(10)
+	r.Push(20)
+	r.runs[1].Index = 5
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Push() onto a broken Uint64 to panic")
+		}
+	}()
+	r.Push(15)
+}
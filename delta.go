@@ -0,0 +1,107 @@
+package rangearray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// The delta format stores a rangearray as its difference from a base
+// array.  It starts with a four-byte header: the magic bytes "RD", a
+// format version, and a reserved byte that must be zero.  Then come
+// the fingerprint of the base array, as a little-endian uint32, and two
+// arrays in the binary format: the values removed from the base, then
+// the values added to it.  The fingerprint is the CRC-32C checksum of
+// the base's runs, each stored as its Value and Count in little-endian
+// order, so that a delta is never applied to the wrong base.
+
+const deltaHeaderLen = 4
+
+var (
+	errBadDelta  = corruptf("rangearray: not a rangearray delta")
+	errDeltaBase = errors.New("rangearray: delta was made against a different base")
+)
+
+// fingerprint returns the fingerprint of r for the delta format.
+func fingerprint(r Uint32) uint32 {
+	var crc uint32
+	var buf [8 * 64]byte
+	for i := 0; i < len(r.runs); i += 64 {
+		b := buf[:0]
+		for _, s := range r.runs[i:min(i+64, len(r.runs))] {
+			b = binary.LittleEndian.AppendUint32(b, s.Value)
+			b = binary.LittleEndian.AppendUint32(b, s.Count)
+		}
+		crc = crc32.Update(crc, crcTable, b)
+	}
+	return crc
+}
+
+// MarshalDelta returns r in the delta format, as its difference from
+// base.  When r is a later version of base, such as the next day's
+// index, the delta is typically far smaller than r itself.
+func (e Encoder) MarshalDelta(base, r Uint32) ([]byte, error) {
+	return e.AppendDelta(nil, base, r)
+}
+
+// AppendDelta appends r in the delta format, as its difference from
+// base, to b.  The removed and added values are stored with e's
+// options.
+func (e Encoder) AppendDelta(b []byte, base, r Uint32) ([]byte, error) {
+	b = append(b, 'R', 'D', binaryVersion, 0)
+	b = binary.LittleEndian.AppendUint32(b, fingerprint(base))
+	removed := Uint32{runs: AppendDifference(nil, base, r)}
+	added := Uint32{runs: AppendDifference(nil, r, base)}
+	b, err := e.Append(b, removed)
+	if err != nil {
+		return nil, err
+	}
+	return e.Append(b, added)
+}
+
+// UnmarshalDelta replaces the contents of r with the array stored in
+// data, in the delta format, as a difference from base.  It fails if
+// base is not the array the delta was made against.
+func (d Decoder) UnmarshalDelta(data []byte, base Uint32, r *Uint32) error {
+	if len(data) < deltaHeaderLen+4 || string(data[:2]) != "RD" {
+		return errBadDelta
+	}
+	if data[2] != binaryVersion || data[3] != 0 {
+		return versionf("rangearray: unsupported delta version %d", data[2])
+	}
+	if binary.LittleEndian.Uint32(data[deltaHeaderLen:]) != fingerprint(base) {
+		return errDeltaBase
+	}
+
+	rd := bytes.NewReader(data[deltaHeaderLen+4:])
+	var removed, added Uint32
+	if _, err := d.Decode(rd, &removed); err != nil {
+		return unexpectedEOF(err)
+	}
+	if _, err := d.Decode(rd, &added); err != nil {
+		return unexpectedEOF(err)
+	}
+	if rd.Len() != 0 {
+		return errTrailing
+	}
+
+	kept := Uint32{runs: AppendDifference(nil, base, removed)}
+	*r = Uint32{runs: AppendUnion(nil, kept, added)}
+	if debugChecks {
+		r.check("UnmarshalDelta", 0, 0)
+	}
+	return nil
+}
+
+// MarshalDelta returns r in the delta format, as its difference from
+// base, with the Varint encoding.
+func (r Uint32) MarshalDelta(base Uint32) ([]byte, error) {
+	return Encoder{Encoding: Varint}.MarshalDelta(base, r)
+}
+
+// UnmarshalDelta replaces the contents of r with the array stored in
+// data, in the delta format, as a difference from base.
+func (r *Uint32) UnmarshalDelta(data []byte, base Uint32) error {
+	return Decoder{}.UnmarshalDelta(data, base, r)
+}
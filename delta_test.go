@@ -0,0 +1,61 @@
+package rangearray
+
+import "testing"
+
+func TestDelta(t *testing.T) {
+	var base Uint32
+	for x := uint32(0); x < 10000; x += 3 {
+		base.Push(x)
+	}
+	next := base.Fork()
+	next.Push(1)
+	next.Push(10001)
+	next.Push(20000)
+	var r Uint32
+	for s := range next.All() {
+		if s != 300 && s != 9999 {
+			r.Push(s)
+		}
+	}
+
+	data, err := r.MarshalDelta(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, _ := (Encoder{Encoding: Varint}).Marshal(r)
+	if len(data) >= len(full)/10 {
+		t.Errorf("Expected the delta to be much smaller than %d bytes, got %d", len(full), len(data))
+	}
+
+	var got Uint32
+	if err := got.UnmarshalDelta(data, base); err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "got", got, r)
+
+	if err := got.UnmarshalDelta(data, r); err != errDeltaBase {
+		t.Errorf("Expected errDeltaBase, got %v", err)
+	}
+	if err := got.UnmarshalDelta(data[:len(data)-1], base); err == nil {
+		t.Errorf("Expected an error for a truncated delta")
+	}
+	if err := got.UnmarshalDelta(append(data, 0), base); err != errTrailing {
+		t.Errorf("Expected errTrailing, got %v", err)
+	}
+	if err := got.UnmarshalDelta(full, base); err != errBadDelta {
+		t.Errorf("Expected errBadDelta, got %v", err)
+	}
+
+	// A delta against an empty base holds the whole array.
+	data, err = (Encoder{Checksum: true}).MarshalDelta(Uint32{}, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.UnmarshalDelta(data, Uint32{}); err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "got", got, r)
+	if info, err := Identify(data); err != nil || info.Magic != "RD" {
+		t.Errorf("Expected Identify to see a delta, got %+v, %v", info, err)
+	}
+}
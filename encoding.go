@@ -0,0 +1,566 @@
+package rangearray
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// The binary format starts with a five-byte header: the magic bytes
+// "RA", a format version, an Encoding, and a flags byte.  The header is
+// followed by the number of runs as a uvarint, then the runs.  Index is
+// not stored, since it can be recomputed from the counts.
+//
+// If the flagCompressed bit is set, the runs are split into chunks
+// that are compressed separately by a Codec.  Each chunk is stored as
+// the number of runs in it and the length of its compressed form, both
+// as uvarints, followed by the compressed runs.  Varint deltas carry
+// over from one chunk to the next.
+//
+// If the flagChecksum bit is set, the data ends with the CRC-32C
+// (Castagnoli) checksum of everything before it, including the header,
+// as a little-endian uint32.
+
+const (
+	binaryVersion   = 1
+	binaryHeaderLen = 5
+
+	// flagCompressed marks data whose runs were compressed by a Codec.
+	flagCompressed = 1 << 0
+
+	// flagChecksum marks data that carries CRC-32C checksums.
+	flagChecksum = 1 << 1
+)
+
+// crcTable is the CRC-32C table used for checksums.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorrupt, ErrVersion and ErrTruncated classify the errors that
+// every decoder in this package returns for bad input, which callers
+// can tell apart with errors.Is.  The errors themselves say more about
+// what is wrong.
+var (
+	// ErrCorrupt matches errors for data that is not in the expected
+	// format or breaks its rules: wrong magic bytes, lengths that do
+	// not fit the data, runs that are empty, overlap or are out of
+	// order, checksum mismatches, and trailing bytes.
+	ErrCorrupt = errors.New("rangearray: corrupt data")
+
+	// ErrVersion matches errors for data in a version of its format,
+	// or with an encoding or flags, that this package does not know.
+	ErrVersion = errors.New("rangearray: unsupported format version")
+
+	// ErrTruncated matches errors for data that ends partway through.
+	// Such errors also match io.ErrUnexpectedEOF.
+	ErrTruncated = errors.New("rangearray: truncated data")
+)
+
+// formatError is err, made to match kind, which is one of ErrCorrupt,
+// ErrVersion and ErrTruncated, as well.
+type formatError struct {
+	kind error
+	err  error
+}
+
+func (e *formatError) Error() string {
+	return e.err.Error()
+}
+
+func (e *formatError) Unwrap() error {
+	return e.err
+}
+
+func (e *formatError) Is(target error) bool {
+	return target == e.kind || (e.kind == ErrTruncated && target == io.ErrUnexpectedEOF)
+}
+
+// corruptf is like fmt.Errorf, but returns an error that also matches
+// ErrCorrupt.
+func corruptf(format string, args ...any) error {
+	return &formatError{kind: ErrCorrupt, err: fmt.Errorf(format, args...)}
+}
+
+// versionf is like corruptf, for ErrVersion.
+func versionf(format string, args ...any) error {
+	return &formatError{kind: ErrVersion, err: fmt.Errorf(format, args...)}
+}
+
+// truncatedf is like corruptf, for ErrTruncated.
+func truncatedf(format string, args ...any) error {
+	return &formatError{kind: ErrTruncated, err: fmt.Errorf(format, args...)}
+}
+
+var (
+	errBadMagic  = corruptf("rangearray: not a binary rangearray")
+	errTrailing  = corruptf("rangearray: trailing data after binary rangearray")
+	errTruncated = truncatedf("rangearray: unexpected end of data")
+	errNoCodec   = errors.New("rangearray: compressed data needs a Codec to decode")
+)
+
+// Encoding identifies how runs are stored in the binary format.
+type Encoding byte
+
+const (
+	// Fixed stores each run as its Value and Count, in that order, as
+	// little-endian uint32s.
+	Fixed Encoding = iota
+
+	// Varint stores each run as the uvarint distance from the end of
+	// the previous run (or from zero, for the first run) to its Value,
+	// followed by its Count as a uvarint.  This is typically several
+	// times smaller than Fixed.
+	Varint
+)
+
+// Codec compresses encoded runs.  It lets callers use any compression
+// library without this package depending on it.
+type Codec interface {
+	// Compress appends the compressed form of src to dst and returns
+	// the extended buffer.
+	Compress(dst, src []byte) ([]byte, error)
+
+	// Decompress appends the decompressed form of src to dst and
+	// returns the extended buffer.  It must fail, rather than keep
+	// going, once the decompressed form is longer than max bytes, so
+	// that a small chunk cannot expand without bound.
+	Decompress(dst, src []byte, max int) ([]byte, error)
+}
+
+// Encoder writes rangearrays in the binary format, with options that
+// MarshalBinary and WriteTo do not offer.  The zero Encoder produces
+// the same output as MarshalBinary.
+type Encoder struct {
+	// Encoding selects how runs are stored.
+	Encoding Encoding
+
+	// BlockRuns is the number of runs in each block written by
+	// EncodeBlocks.  If it is zero, DefaultBlockRuns is used.
+	BlockRuns int
+
+	// Codec, if not nil, compresses the encoded runs.  Data written
+	// with a Codec can only be read by a Decoder with the same Codec.
+	Codec Codec
+
+	// Checksum adds CRC-32C checksums, which decoders always verify.
+	// Encode adds one checksum for the whole rangearray; EncodeBlocks
+	// adds one for each block and one for the directory.
+	Checksum bool
+
+	// Tracer, if not nil, observes each encoding.
+	Tracer Tracer
+
+	// Progress reports the runs encoded.
+	Progress Progress
+}
+
+// ChecksumError reports encoded data that does not match its checksum.
+type ChecksumError struct {
+	// Block is the index of the corrupt block in a block file, or -1
+	// if the corrupt data is not a block.
+	Block int
+}
+
+// Is reports whether target is ErrCorrupt, which a checksum mismatch
+// also is.
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Block < 0 {
+		return "rangearray: checksum mismatch"
+	}
+	return fmt.Sprintf("rangearray: checksum mismatch in block %d", e.Block)
+}
+
+// Decoder reads rangearrays in the binary format.  Every Encoding is
+// recognized automatically, so the options only supply the Codec
+// needed for compressed data, observe decoding with a Tracer or
+// Progress, and choose whether to Upgrade data in an older format.  The
+// zero Decoder behaves like UnmarshalBinary and ReadFrom.
+type Decoder struct {
+	// Codec decompresses data that was written with a Codec.
+	Codec Codec
+
+	// Tracer, if not nil, observes each decoding, including reads of
+	// whole block files.
+	Tracer Tracer
+
+	// Progress reports the runs decoded.
+	Progress Progress
+
+	// Upgrade lets Unmarshal read data written in an older version of
+	// the binary format, by upgrading it with Migrate first.
+	Upgrade bool
+}
+
+// Marshal returns the binary encoding of r.
+func (e Encoder) Marshal(r Uint32) ([]byte, error) {
+	var b []byte
+	if e.Encoding == Fixed && e.Codec == nil {
+		b = make([]byte, 0, binaryHeaderLen+binary.MaxVarintLen64+8*len(r.runs)+4)
+	}
+	b, err := e.Append(b, r)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Append appends the binary encoding of r to b and returns the
+// extended buffer.  If the Codec fails, b is returned unchanged.
+func (e Encoder) Append(b []byte, r Uint32) (_ []byte, err error) {
+	done := startTrace(context.Background(), e.Tracer, OpEncode)
+	defer func() { done(len(r.runs), err) }()
+
+	pr := e.Progress.start(OpEncode, int64(len(r.runs)))
+	start := len(b)
+	b = append(b, e.header("RA")...)
+	b = binary.AppendUvarint(b, uint64(len(r.runs)))
+
+	var end uint32
+	if e.Codec == nil {
+		for _, s := range r.runs {
+			b = appendRun(b, e.Encoding, end, s)
+			end = s.Value + s.Count
+			pr.add(1)
+		}
+	} else {
+		sc := getScratch()
+		buf, packed := sc.buf, sc.packed
+		defer func() { sc.release(buf, packed, nil) }()
+		for i := 0; i < len(r.runs); {
+			chunk := r.runs[i:min(i+streamChunkRuns, len(r.runs))]
+			buf = buf[:0]
+			for _, s := range chunk {
+				buf = appendRun(buf, e.Encoding, end, s)
+				end = s.Value + s.Count
+			}
+
+			var err error
+			if packed, err = e.Codec.Compress(packed[:0], buf); err != nil {
+				return b[:start], err
+			}
+			b = binary.AppendUvarint(b, uint64(len(chunk)))
+			b = binary.AppendUvarint(b, uint64(len(packed)))
+			b = append(b, packed...)
+			i += len(chunk)
+			pr.add(len(chunk))
+		}
+	}
+
+	if e.Checksum {
+		b = binary.LittleEndian.AppendUint32(b, crc32.Checksum(b[start:], crcTable))
+	}
+	pr.finish()
+	return b, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, using the Fixed
+// encoding.
+func (r Uint32) MarshalBinary() ([]byte, error) {
+	return Encoder{}.Marshal(r)
+}
+
+// AppendBinary implements encoding.BinaryAppender.  It appends the
+// encoding written by MarshalBinary to b.
+func (r Uint32) AppendBinary(b []byte) ([]byte, error) {
+	return Encoder{}.Append(b, r)
+}
+
+// Unmarshal replaces the contents of r with the binary rangearray in
+// data.
+func (d Decoder) Unmarshal(data []byte, r *Uint32) error {
+	return d.UnmarshalContext(context.Background(), data, r)
+}
+
+// UnmarshalContext is like Unmarshal, but stops with ctx's error if ctx
+// is done before data is decoded.
+func (d Decoder) UnmarshalContext(ctx context.Context, data []byte, r *Uint32) (err error) {
+	done := startTrace(ctx, d.Tracer, OpDecode)
+	defer func() { done(len(r.runs), err) }()
+
+	if info, err := Identify(data); d.Upgrade && err == nil && info.Version < info.Current {
+		if data, err = Migrate(data); err != nil {
+			return err
+		}
+	}
+	rd := bytes.NewReader(data)
+	out, err := d.read(&countingReader{ctx: ctx, r: rd}, uint64(len(data))/2)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if rd.Len() != 0 {
+		return errTrailing
+	}
+
+	*r = out
+	return nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.  It replaces
+// the contents of r.
+func (r *Uint32) UnmarshalBinary(data []byte) error {
+	return Decoder{}.Unmarshal(data, r)
+}
+
+// GobEncode implements gob.GobEncoder.  It uses the binary format with
+// the Varint encoding, so the gob form of a rangearray does not depend
+// on its fields.
+func (r Uint32) GobEncode() ([]byte, error) {
+	return Encoder{Encoding: Varint}.Marshal(r)
+}
+
+// GobDecode implements gob.GobDecoder.  It replaces the contents of r.
+func (r *Uint32) GobDecode(data []byte) error {
+	return Decoder{}.Unmarshal(data, r)
+}
+
+// EncodeString returns the binary form of r, with the Varint encoding,
+// as unpadded URL-safe base64.  The result can be embedded in URLs and
+// configuration files.
+func (r Uint32) EncodeString() string {
+	b, _ := Encoder{Encoding: Varint}.Marshal(r)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeString returns the rangearray encoded in s by EncodeString.
+func DecodeString(s string) (Uint32, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Uint32{}, fmt.Errorf("rangearray: %w", err)
+	}
+	var r Uint32
+	if err := r.UnmarshalBinary(b); err != nil {
+		return Uint32{}, err
+	}
+	return r, nil
+}
+
+// header returns the binary header for data written by e, with the
+// given magic bytes.
+func (e Encoder) header(magic string) []byte {
+	var flags byte
+	if e.Codec != nil {
+		flags |= flagCompressed
+	}
+	if e.Checksum {
+		flags |= flagChecksum
+	}
+	return []byte{magic[0], magic[1], binaryVersion, byte(e.Encoding), flags}
+}
+
+// appendRun appends s to b with the given encoding, where end is the
+// end of the previous run.
+func appendRun(b []byte, e Encoding, end uint32, s Uint32Run) []byte {
+	if e == Varint {
+		b = binary.AppendUvarint(b, uint64(s.Value-end))
+		return binary.AppendUvarint(b, uint64(s.Count))
+	}
+
+	b = binary.LittleEndian.AppendUint32(b, s.Value)
+	return binary.LittleEndian.AppendUint32(b, s.Count)
+}
+
+// checkHeader returns the encoding and flags from h, or an error if h
+// is not a header with the given magic bytes that d can decode.
+func (d Decoder) checkHeader(h []byte, magic string) (Encoding, byte, error) {
+	if string(h[:2]) != magic {
+		return 0, 0, errBadMagic
+	}
+	if h[2] != binaryVersion {
+		return 0, 0, versionf("rangearray: unsupported binary version %d", h[2])
+	}
+	if h[3] > byte(Varint) {
+		return 0, 0, versionf("rangearray: unsupported binary encoding %d", h[3])
+	}
+	if h[4]&^(flagCompressed|flagChecksum) != 0 {
+		return 0, 0, versionf("rangearray: unsupported binary flags %#x", h[4])
+	}
+	if h[4]&flagCompressed != 0 && d.Codec == nil {
+		return 0, 0, errNoCodec
+	}
+	return Encoding(h[3]), h[4], nil
+}
+
+// read reads one binary rangearray from cr.  It preallocates space for
+// at most maxRuns runs.
+func (d Decoder) read(cr *countingReader, maxRuns uint64) (Uint32, error) {
+	var h [binaryHeaderLen]byte
+	if _, err := io.ReadFull(cr, h[:]); err == io.ErrUnexpectedEOF {
+		return Uint32{}, errTruncated
+	} else if err != nil {
+		return Uint32{}, err
+	}
+	e, flags, err := d.checkHeader(h[:], "RA")
+	if err != nil {
+		return Uint32{}, err
+	}
+	if flags&flagChecksum != 0 {
+		cr.hash = true
+		cr.crc = crc32.Update(0, crcTable, h[:])
+	}
+
+	n, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return Uint32{}, unexpectedEOF(err)
+	}
+
+	pr := d.Progress.start(OpDecode, int64(min(n, 1<<62)))
+	out := Uint32{runs: make([]Uint32Run, 0, min(n, maxRuns))}
+	if flags&flagCompressed == 0 {
+		err = readRuns(cr, e, n, &out, pr)
+	} else {
+		err = d.readChunks(cr, e, n, &out, pr)
+	}
+	if err != nil {
+		return Uint32{}, err
+	}
+
+	if flags&flagChecksum != 0 {
+		cr.hash = false
+		var sum [4]byte
+		if _, err := io.ReadFull(cr, sum[:]); err != nil {
+			return Uint32{}, unexpectedEOF(err)
+		}
+		if binary.LittleEndian.Uint32(sum[:]) != cr.crc {
+			return Uint32{}, &ChecksumError{Block: -1}
+		}
+	}
+	pr.finish()
+	return out, nil
+}
+
+// readChunks reads n runs with the given encoding, in compressed
+// chunks, from cr and appends them to out.
+func (d Decoder) readChunks(cr *countingReader, e Encoding, n uint64, out *Uint32, pr *progress) error {
+	sc := getScratch()
+	raw, packed := sc.buf, sc.packed
+	defer func() { sc.release(raw, packed, nil) }()
+	for i := uint64(0); i < n; {
+		runs, err := binary.ReadUvarint(cr)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		length, err := binary.ReadUvarint(cr)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if runs == 0 || runs > n-i || length > uint64(maxChunkLen) {
+			return corruptf("rangearray: invalid chunk (%d runs, %d bytes)", runs, length)
+		}
+
+		if uint64(cap(packed)) < length {
+			packed = make([]byte, length)
+		}
+		packed = packed[:length]
+		if _, err := io.ReadFull(cr, packed); err != nil {
+			return unexpectedEOF(err)
+		}
+		if raw, err = d.decompress(raw[:0], packed, maxRunBytes(e, runs)); err != nil {
+			return err
+		}
+		if err := readRunBytes(raw, e, runs, out); err != nil {
+			return err
+		}
+		i += runs
+		pr.add(int(runs))
+	}
+	return nil
+}
+
+// decompress appends the decompressed form of src to dst, which may
+// be at most max bytes long.
+func (d Decoder) decompress(dst, src []byte, max int) ([]byte, error) {
+	out, err := d.Codec.Decompress(dst, src, max)
+	if err != nil {
+		return nil, corruptf("rangearray: decompressing: %w", err)
+	}
+	if len(out)-len(dst) > max {
+		return nil, corruptf("rangearray: decompressed data is longer than %d bytes", max)
+	}
+	return out, nil
+}
+
+// maxRunBytes returns the most bytes that n runs can take with the
+// given encoding.
+func maxRunBytes(e Encoding, n uint64) int {
+	if e == Varint {
+		return int(n) * 2 * binary.MaxVarintLen32
+	}
+	return int(n) * 8
+}
+
+// readRunBytes decodes exactly n runs with the given encoding from b
+// and appends them to out.
+func readRunBytes(b []byte, e Encoding, n uint64, out *Uint32) error {
+	rd := bytes.NewReader(b)
+	if err := readRuns(&countingReader{r: rd}, e, n, out, nil); err != nil {
+		return err
+	}
+	if rd.Len() != 0 {
+		return errTrailing
+	}
+	return nil
+}
+
+// readRuns reads n runs with the given encoding from cr and appends
+// them to out.  Varint deltas continue from the end of out.
+func readRuns(cr *countingReader, e Encoding, n uint64, out *Uint32, pr *progress) error {
+	if e == Varint {
+		var end uint64
+		if k := len(out.runs) - 1; k >= 0 {
+			end = uint64(out.runs[k].Value) + uint64(out.runs[k].Count)
+		}
+		for i := 0; uint64(i) < n; i++ {
+			delta, err := binary.ReadUvarint(cr)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			count, err := binary.ReadUvarint(cr)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if end+delta > 0xffffffff || count > 0xffffffff ||
+				!out.appendRun(uint32(end+delta), uint32(count)) {
+				return invalidRun(i, uint32(end+delta), uint32(count))
+			}
+			end += delta + count
+			pr.add(1)
+		}
+		return nil
+	}
+
+	sc := getScratch()
+	buf := sc.buf
+	defer func() { sc.release(buf, nil, nil) }()
+	buf = slices.Grow(buf, 8*int(min(n, streamChunkRuns)))
+	buf = buf[:cap(buf)]
+	for i := 0; uint64(i) < n; {
+		chunk := int(min(n-uint64(i), streamChunkRuns))
+		if _, err := io.ReadFull(cr, buf[:8*chunk]); err != nil {
+			return unexpectedEOF(err)
+		}
+		for j := 0; j < chunk; j, i = j+1, i+1 {
+			value := binary.LittleEndian.Uint32(buf[8*j:])
+			count := binary.LittleEndian.Uint32(buf[8*j+4:])
+			if !out.appendRun(value, count) {
+				return invalidRun(i, value, count)
+			}
+		}
+		pr.add(chunk)
+	}
+	return nil
+}
+
+// invalidRun returns the error for the i'th run of an encoded array
+// being out of order or empty.
+func invalidRun(i int, value, count uint32) error {
+	return corruptf("rangearray: invalid run %d (value %d, count %d)", i, value, count)
+}
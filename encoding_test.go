@@ -0,0 +1,308 @@
+package rangearray
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testEqualUint32(t *testing.T, name string, got, want Uint32) {
+	if len(got.runs) != len(want.runs) {
+		t.Errorf("Expected len(%s.runs) == %d, got %d", name, len(want.runs), len(got.runs))
+		return
+	}
+	for i := range want.runs {
+		if got.runs[i] != want.runs[i] {
+			t.Errorf("Expected %s.runs[%d] == %+v, got %+v", name, i, want.runs[i], got.runs[i])
+		}
+	}
+}
+
+func testEncodingArray() Uint32 {
+	r := &Uint32{}
+	pushRange(r, 100, 199)
+	pushRange(r, 350, 449)
+	r.Push(1000)
+	r.Push(0xffffffff)
+	return *r
+}
+
+func TestBinaryUint32(t *testing.T) {
+	for _, r := range []Uint32{{}, testEncodingArray()} {
+		b, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary() failed: %v", err)
+		}
+
+		var x Uint32
+		if err := x.UnmarshalBinary(b); err != nil {
+			t.Fatalf("UnmarshalBinary() failed: %v", err)
+		}
+		testEqualUint32(t, "x", x, r)
+	}
+}
+
+func TestBinaryUint32Errors(t *testing.T) {
+	good, _ := testEncodingArray().MarshalBinary()
+	overlap, _ := testEncodingArray().MarshalBinary()
+	binary.LittleEndian.PutUint32(overlap[binaryHeaderLen+1+8:], 150)
+
+	huge := binary.AppendUvarint(good[:binaryHeaderLen:binaryHeaderLen], 1<<40)
+	huge = append(huge, good[binaryHeaderLen+1:]...)
+
+	for _, s := range []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrTruncated},
+		{"bad magic", append([]byte("XY"), good[2:]...), ErrCorrupt},
+		{"bad version", append([]byte{'R', 'A', 99}, good[3:]...), ErrVersion},
+		{"bad encoding", append([]byte{'R', 'A', binaryVersion, 99}, good[4:]...), ErrVersion},
+		{"truncated", good[:len(good)-1], ErrTruncated},
+		{"trailing", append(good, 0), ErrCorrupt},
+		{"overlap", overlap, ErrCorrupt},
+		{"huge count", huge, ErrTruncated},
+	} {
+		var x Uint32
+		if err := x.UnmarshalBinary(s.data); !errors.Is(err, s.want) {
+			t.Errorf("Expected UnmarshalBinary(%s) to fail with %v, got %v", s.name, s.want, err)
+		}
+	}
+}
+
+func TestVarintUint32(t *testing.T) {
+	r := testEncodingArray()
+	fixed, _ := r.MarshalBinary()
+	b, err := Encoder{Encoding: Varint}.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal(Varint) failed: %v", err)
+	}
+	if len(b) >= len(fixed) {
+		t.Errorf("Expected Varint encoding to be smaller than %d bytes, got %d", len(fixed), len(b))
+	}
+
+	var x Uint32
+	if err := x.UnmarshalBinary(b); err != nil {
+		t.Fatalf("UnmarshalBinary(Varint) failed: %v", err)
+	}
+	testEqualUint32(t, "x", x, r)
+
+	if err := x.UnmarshalBinary(b[:len(b)-1]); err == nil {
+		t.Errorf("Expected UnmarshalBinary(truncated Varint) to fail")
+	}
+}
+
+// flateCodec is a Codec built on compress/flate.
+type flateCodec struct{}
+
+func (flateCodec) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, _ := flate.NewWriter(buf, flate.BestSpeed)
+	w.Write(src)
+	err := w.Close()
+	return buf.Bytes(), err
+}
+
+func (flateCodec) Decompress(dst, src []byte, max int) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	n, err := buf.ReadFrom(io.LimitReader(flate.NewReader(bytes.NewReader(src)), int64(max)+1))
+	if err == nil && n > int64(max) {
+		err = errors.New("flate: output too long")
+	}
+	return buf.Bytes(), err
+}
+
+func TestCodecUint32(t *testing.T) {
+	big := &Uint32{}
+	for i := uint32(0); i < 3*streamChunkRuns; i++ {
+		big.Push(3 * i)
+	}
+
+	for _, r := range []Uint32{{}, testEncodingArray(), *big} {
+		for _, e := range []Encoder{{Codec: flateCodec{}}, {Encoding: Varint, Codec: flateCodec{}}} {
+			b, err := e.Marshal(r)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+
+			var x Uint32
+			if err := x.UnmarshalBinary(b); err != errNoCodec {
+				t.Errorf("Expected UnmarshalBinary() of compressed data to fail with errNoCodec, got %v", err)
+			}
+			if err := (Decoder{Codec: flateCodec{}}).Unmarshal(b, &x); err != nil {
+				t.Fatalf("Unmarshal() failed: %v", err)
+			}
+			testEqualUint32(t, "x", x, r)
+		}
+	}
+
+	plain, _ := big.MarshalBinary()
+	packed, _ := Encoder{Codec: flateCodec{}}.Marshal(*big)
+	if len(packed) >= len(plain) {
+		t.Errorf("Expected compressed encoding to be smaller than %d bytes, got %d", len(plain), len(packed))
+	}
+
+	// A chunk of one run that inflates to a megabyte must be rejected
+	// without decompressing all of it.
+	one := Uint32{runs: []Uint32Run{{Value: 5, Count: 1}}}
+	b, _ := Encoder{Codec: flateCodec{}}.Marshal(one)
+	bomb, _ := flateCodec{}.Compress(nil, make([]byte, 1<<20))
+	b = binary.AppendUvarint(b[:binaryHeaderLen+1], 1)
+	b = binary.AppendUvarint(b, uint64(len(bomb)))
+	b = append(b, bomb...)
+	var x Uint32
+	if err := (Decoder{Codec: flateCodec{}}).Unmarshal(b, &x); !errors.Is(err, ErrCorrupt) || !strings.Contains(err.Error(), "decompressing") {
+		t.Errorf("Expected Unmarshal() of an oversized chunk to fail while decompressing, got %v", err)
+	}
+}
+
+func TestChecksumUint32(t *testing.T) {
+	r := testEncodingArray()
+	for _, e := range []Encoder{{Checksum: true}, {Encoding: Varint, Codec: flateCodec{}, Checksum: true}} {
+		d := Decoder{Codec: e.Codec}
+		b, _ := e.Marshal(r)
+		var x Uint32
+		if err := d.Unmarshal(b, &x); err != nil {
+			t.Fatalf("Unmarshal() failed: %v", err)
+		}
+		testEqualUint32(t, "x", x, r)
+
+		// Without a Codec, changing the first Count from 100 to 101
+		// leaves a valid array that only the checksum can catch.
+		b[binaryHeaderLen+5]++
+		err := d.Unmarshal(b, &x)
+		if _, ok := err.(*ChecksumError); e.Codec == nil && !ok {
+			t.Errorf("Expected Unmarshal() of corrupt data to return a *ChecksumError, got %v", err)
+		} else if !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected Unmarshal() of corrupt data to fail with ErrCorrupt, got %v", err)
+		}
+	}
+}
+
+func TestGobUint32(t *testing.T) {
+	type record struct {
+		Name   string
+		Epochs Uint32
+	}
+
+	var buf bytes.Buffer
+	in := record{Name: "G01", Epochs: testEncodingArray()}
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+
+	var out record
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	if out.Name != in.Name {
+		t.Errorf("Expected out.Name == %q, got %q", in.Name, out.Name)
+	}
+	testEqualUint32(t, "out.Epochs", out.Epochs, in.Epochs)
+
+	b, _ := in.Epochs.GobEncode()
+	if b[3] != byte(Varint) {
+		t.Errorf("Expected GobEncode() to use the Varint encoding, got %d", b[3])
+	}
+}
+
+func TestEncodeString(t *testing.T) {
+	for _, r := range []Uint32{{}, testEncodingArray()} {
+		s := r.EncodeString()
+		if strings.ContainsAny(s, "+/=") {
+			t.Errorf("Expected URL-safe EncodeString(), got %q", s)
+		}
+		out, err := DecodeString(s)
+		if err != nil {
+			t.Fatalf("DecodeString(%q) failed: %v", s, err)
+		}
+		testEqualUint32(t, "DecodeString()", out, r)
+	}
+
+	for _, s := range []string{"!!", "AAAA", Uint32{}.EncodeString() + "AA"} {
+		if _, err := DecodeString(s); err == nil {
+			t.Errorf("Expected DecodeString(%q) to fail", s)
+		}
+	}
+}
+
+func TestAppendBinary(t *testing.T) {
+	var _ encoding.BinaryAppender = Uint32{}
+
+	r := testEncodingArray()
+	for _, e := range []Encoder{{}, {Encoding: Varint}, {Codec: flateCodec{}}, {Encoding: Varint, Checksum: true}, {Codec: flateCodec{}, Checksum: true}} {
+		var buf bytes.Buffer
+		if _, err := e.Encode(&buf, r); err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+		b, err := e.Append([]byte("prefix"), r)
+		if err != nil {
+			t.Fatalf("Append() failed: %v", err)
+		}
+		if string(b[:6]) != "prefix" || !bytes.Equal(b[6:], buf.Bytes()) {
+			t.Errorf("Expected Append() to match Encode() for %+v", e)
+		}
+	}
+
+	want, _ := r.MarshalBinary()
+	b := make([]byte, 0, 2*len(want))
+	b, _ = r.AppendBinary(b)
+	b, _ = r.AppendBinary(b)
+	if !bytes.Equal(b[:len(want)], want) || !bytes.Equal(b[len(want):], want) {
+		t.Errorf("Expected AppendBinary() twice to repeat MarshalBinary()")
+	}
+	if n := testing.AllocsPerRun(10, func() { r.AppendBinary(b[:0]) }); n != 0 {
+		t.Errorf("Expected AppendBinary() into a large buffer not to allocate, got %v allocations", n)
+	}
+}
+
+func TestDecodeErrorKinds(t *testing.T) {
+	r := testEncodingArray()
+	var c Collection
+	c.Set("a", r)
+	c.Set("b", r)
+	canonical, _ := r.MarshalCanonical()
+	delta, _ := r.MarshalDelta(Uint32{})
+	coll, _ := c.MarshalBinary()
+	shared, _ := c.MarshalShared()
+	roaring, _ := r.MarshalRoaring()
+	newer := func(b []byte) []byte {
+		b = bytes.Clone(b)
+		b[2]++
+		return b
+	}
+
+	var x Uint32
+	var y Collection
+	for _, s := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"canonical trailing", x.UnmarshalCanonical(append(canonical, 0)), ErrCorrupt},
+		{"canonical truncated", x.UnmarshalCanonical(canonical[:len(canonical)-1]), ErrCorrupt},
+		{"delta version", x.UnmarshalDelta(newer(delta), Uint32{}), ErrVersion},
+		{"delta truncated", x.UnmarshalDelta(delta[:len(delta)-1], Uint32{}), ErrTruncated},
+		{"collection version", y.UnmarshalBinary(newer(coll)), ErrVersion},
+		{"collection truncated", y.UnmarshalBinary(coll[:len(coll)-1]), ErrTruncated},
+		{"shared version", y.UnmarshalShared(newer(shared)), ErrVersion},
+		{"shared truncated", y.UnmarshalShared(shared[:len(shared)-1]), ErrTruncated},
+		{"roaring truncated", x.UnmarshalRoaring(roaring[:len(roaring)-1]), ErrCorrupt},
+		{"JSON out of order", x.UnmarshalJSON([]byte("[[5,1],[3,1]]")), ErrCorrupt},
+		{"text out of order", x.UnmarshalText([]byte("5,3")), ErrCorrupt},
+	} {
+		if !errors.Is(s.err, s.want) {
+			t.Errorf("Expected %s to fail with %v, got %v", s.name, s.want, s.err)
+		}
+	}
+	if _, err := Migrate(newer(coll)); !errors.Is(err, ErrVersion) {
+		t.Errorf("Expected Migrate() of a newer version to fail with ErrVersion, got %v", err)
+	}
+}
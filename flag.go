@@ -0,0 +1,36 @@
+package rangearray
+
+import (
+	"flag"
+)
+
+// Flag is a flag.Value that holds a Uint32.  It parses its argument in
+// the format used by MarshalText, so a program can accept an argument
+// such as -epochs 100-199,350-449 with:
+//
+//	var epochs rangearray.Flag
+//	flag.Var(&epochs, "epochs", "epochs to process")
+type Flag struct {
+	Uint32
+}
+
+var _ flag.Getter = (*Flag)(nil)
+
+// String returns the value of f in the format used by MarshalText.
+// Unlike Uint32.String, it never summarizes.
+func (f *Flag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(f.appendText(nil))
+}
+
+// Set implements flag.Value.  It replaces the value of f.
+func (f *Flag) Set(s string) error {
+	return f.UnmarshalText([]byte(s))
+}
+
+// Get implements flag.Getter.  It returns the Uint32 held by f.
+func (f *Flag) Get() any {
+	return f.Uint32
+}
@@ -0,0 +1,31 @@
+package rangearray
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestFlag(t *testing.T) {
+	var epochs Flag
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&epochs, "epochs", "epochs to process")
+
+	if err := fs.Parse([]string{"-epochs", "100-199,350-449"}); err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if x := epochs.Len(); x != 200 {
+		t.Errorf("Expected epochs.Len() == 200, got %d", x)
+	}
+	if s := epochs.String(); s != "100-199,350-449" {
+		t.Errorf("Expected epochs.String() == \"100-199,350-449\", got %q", s)
+	}
+	if r, ok := epochs.Get().(Uint32); !ok || r.Len() != 200 {
+		t.Errorf("Expected epochs.Get() to return the Uint32, got %v", epochs.Get())
+	}
+
+	if err := fs.Parse([]string{"-epochs", "10-20,5"}); err == nil {
+		t.Errorf("Expected Parse() of out-of-order runs to fail")
+	}
+}
@@ -0,0 +1,305 @@
+package rangearray
+
+import (
+	"encoding/binary"
+	"io"
+	"iter"
+	"slices"
+	"sort"
+	"unsafe"
+)
+
+// The flat format stores a rangearray as fixed-size records, so that it
+// can be searched in place.  It starts with an eight-byte header: the
+// magic bytes "RF", a format version, a reserved byte that must be
+// zero, and the number of runs as a little-endian uint32.  Each run
+// follows as a 12-byte record holding its Value, Index, and Count, in
+// that order, as little-endian uint32s.  Since the header is eight
+// bytes long, every field of a flat rangearray is four-byte aligned if
+// the buffer is.
+
+const (
+	flatVersion   = 1
+	flatHeaderLen = 8
+	flatRunLen    = 12
+)
+
+var (
+	errBadFlat       = corruptf("rangearray: not a flat rangearray")
+	errTruncatedFlat = truncatedf("rangearray: truncated flat rangearray")
+)
+
+// AppendFlat appends r to b in the flat format and returns the extended
+// buffer.
+func (r Uint32) AppendFlat(b []byte) []byte {
+	b = appendFlatHeader(b, len(r.runs))
+	if nativeLittleEndian {
+		return append(b, runBytes(r.runs)...)
+	}
+	for _, s := range r.runs {
+		b = binary.LittleEndian.AppendUint32(b, s.Value)
+		b = binary.LittleEndian.AppendUint32(b, s.Index)
+		b = binary.LittleEndian.AppendUint32(b, s.Count)
+	}
+	return b
+}
+
+// appendFlatHeader appends the header of a flat rangearray with n runs
+// to b.
+func appendFlatHeader(b []byte, n int) []byte {
+	b = append(b, 'R', 'F', flatVersion, 0)
+	return binary.LittleEndian.AppendUint32(b, uint32(n))
+}
+
+// runBytes returns the memory of s as bytes.  On little-endian
+// machines, that is the run table of the flat format.
+func runBytes(s []Uint32Run) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*flatRunLen)
+}
+
+// WriteFlat writes r to w in the flat format, for fast local
+// checkpoints.  On little-endian machines, it writes the memory of r's
+// runs as it is, without encoding or copying it; elsewhere, it encodes
+// them in chunks.  Either way, the output is portable, and ReadFlat,
+// NewFlatView or WrapFlat can read it on any machine.
+func (r Uint32) WriteFlat(w io.Writer) (int64, error) {
+	n, err := w.Write(appendFlatHeader(nil, len(r.runs)))
+	total := int64(n)
+	if err != nil {
+		return total, err
+	}
+	if nativeLittleEndian {
+		n, err = w.Write(runBytes(r.runs))
+		return total + int64(n), err
+	}
+
+	buf := make([]byte, 0, min(len(r.runs), streamChunkRuns)*flatRunLen)
+	for i := 0; i < len(r.runs); i += streamChunkRuns {
+		buf = buf[:0]
+		for _, s := range r.runs[i:min(i+streamChunkRuns, len(r.runs))] {
+			buf = binary.LittleEndian.AppendUint32(buf, s.Value)
+			buf = binary.LittleEndian.AppendUint32(buf, s.Index)
+			buf = binary.LittleEndian.AppendUint32(buf, s.Count)
+		}
+		n, err = w.Write(buf)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ReadFlat replaces the contents of r with a flat rangearray read from
+// rd, as written by WriteFlat or AppendFlat.  On little-endian machines,
+// it reads the run table straight into the memory of r's runs.  It
+// grows r as the runs arrive rather than trusting the run count in the
+// header, and checks the runs with Validate once they are read.  If rd
+// is at its end, ReadFlat returns io.EOF.
+func (r *Uint32) ReadFlat(rd io.Reader) (int64, error) {
+	var header [flatHeaderLen]byte
+	n, err := io.ReadFull(rd, header[:])
+	total := int64(n)
+	if err != nil {
+		return total, err
+	}
+	if _, err := NewFlatView(header[:]); err != nil && err != errTruncatedFlat {
+		return total, err
+	}
+
+	runs := int(binary.LittleEndian.Uint32(header[4:]))
+	out := Uint32{}
+	var buf []byte
+	for i := 0; i < runs && err == nil; i += streamChunkRuns {
+		k := min(runs-i, streamChunkRuns)
+		out.runs = slices.Grow(out.runs, k)[:i+k]
+		if nativeLittleEndian {
+			n, err = io.ReadFull(rd, runBytes(out.runs[i:]))
+			total += int64(n)
+			continue
+		}
+
+		if buf == nil {
+			buf = make([]byte, k*flatRunLen)
+		}
+		chunk := buf[:k*flatRunLen]
+		n, err = io.ReadFull(rd, chunk)
+		total += int64(n)
+		for j := range out.runs[i:] {
+			b := chunk[j*flatRunLen:]
+			out.runs[i+j] = Uint32Run{
+				Value: binary.LittleEndian.Uint32(b[0:]),
+				Index: binary.LittleEndian.Uint32(b[4:]),
+				Count: binary.LittleEndian.Uint32(b[8:]),
+			}
+		}
+	}
+	if err != nil {
+		return total, unexpectedEOF(err)
+	}
+	if err := out.Validate(); err != nil {
+		return total, err
+	}
+	*r = out
+	return total, nil
+}
+
+// FlatView is a read-only rangearray that answers queries directly from
+// a buffer in the flat format, without decoding it.  Many flat
+// rangearrays can be stored back to back in one buffer, such as a
+// memory-mapped archive, and queried without copying.
+//
+// The buffer must not be modified while the view is in use.  Opening a
+// view only checks the header, so a corrupt buffer gives wrong answers,
+// but never makes a FlatView panic.
+type FlatView struct {
+	b []byte
+}
+
+// NewFlatView returns a view of the flat rangearray at the start of b.
+// b may continue past the end of the rangearray; see FlatView.Size.
+func NewFlatView(b []byte) (FlatView, error) {
+	if len(b) < flatHeaderLen || b[0] != 'R' || b[1] != 'F' {
+		return FlatView{}, errBadFlat
+	}
+	if b[2] != flatVersion || b[3] != 0 {
+		return FlatView{}, versionf("rangearray: unsupported flat version %d", b[2])
+	}
+
+	n := uint64(binary.LittleEndian.Uint32(b[4:]))
+	size := flatHeaderLen + n*flatRunLen
+	if uint64(len(b)) < size {
+		return FlatView{}, errTruncatedFlat
+	}
+	return FlatView{b: b[:size]}, nil
+}
+
+// WrapFlat returns the flat rangearray at the start of b as a Uint32.
+// On little-endian machines, when b is four-byte aligned, the result's
+// runs point into b instead of holding a copy, so a memory-mapped file
+// or embedded asset can be queried without reading all of it.
+// Otherwise, WrapFlat copies the runs.
+//
+// The result must not be used after b is changed or unmapped.  It
+// copies its runs before it is first modified, so b itself is never
+// written.  Like NewFlatView, WrapFlat only checks the header of b, so
+// that it need not read every run; call Validate on the result if b
+// may be corrupt.
+func WrapFlat(b []byte) (Uint32, error) {
+	v, err := NewFlatView(b)
+	if err != nil {
+		return Uint32{}, err
+	}
+
+	n := v.NumRuns()
+	if n == 0 {
+		return Uint32{}, nil
+	}
+
+	p := unsafe.Pointer(&v.b[flatHeaderLen])
+	if nativeLittleEndian && uintptr(p)%unsafe.Alignof(Uint32Run{}) == 0 {
+		return Uint32{runs: unsafe.Slice((*Uint32Run)(p), n)[:n:n], shared: true}, nil
+	}
+
+	return Uint32{runs: slices.Collect(v.Runs())}, nil
+}
+
+// nativeLittleEndian reports whether this machine is little-endian, so
+// that a flat run table has the same layout as a []Uint32Run.
+var nativeLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// Size returns the number of bytes of the buffer that v uses.  The next
+// flat rangearray in the buffer, if any, starts at that offset.
+func (v FlatView) Size() int {
+	return len(v.b)
+}
+
+// NumRuns returns the number of runs in v.
+func (v FlatView) NumRuns() int {
+	return (len(v.b) - flatHeaderLen) / flatRunLen
+}
+
+// Run returns the i'th run in v.  Panics if i is out of range.
+func (v FlatView) Run(i int) Uint32Run {
+	if i < 0 || i >= v.NumRuns() {
+		panic("rangearray: FlatView.Run index out of range")
+	}
+
+	b := v.b[flatHeaderLen+i*flatRunLen:]
+	return Uint32Run{
+		Value: binary.LittleEndian.Uint32(b[0:]),
+		Index: binary.LittleEndian.Uint32(b[4:]),
+		Count: binary.LittleEndian.Uint32(b[8:]),
+	}
+}
+
+// Min returns the minimum value in v.  Panics if v is empty.
+func (v FlatView) Min() uint32 {
+	return v.Run(0).Value
+}
+
+// Max returns the maximum value in v.  Panics if v is empty.
+func (v FlatView) Max() uint32 {
+	s := v.Run(v.NumRuns() - 1)
+	return s.Value + s.Count - 1
+}
+
+// Len returns the number of elements in v.
+func (v FlatView) Len() uint32 {
+	n := v.NumRuns()
+	if n == 0 {
+		return 0
+	}
+
+	s := v.Run(n - 1)
+	return s.Index + s.Count
+}
+
+// IndexOf returns the number of elements in v that are less than x.
+func (v FlatView) IndexOf(x uint32) uint32 {
+	i := v.LowerBound(x)
+	if i < v.NumRuns() {
+		s := v.Run(i)
+		if x <= s.Value {
+			return s.Index
+		}
+		return x - s.Value + s.Index
+	}
+
+	return v.Len()
+}
+
+// LowerBound returns the index of the run in v that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x.  If x is after v.Max(), returns v.NumRuns().
+func (v FlatView) LowerBound(x uint32) int {
+	return sort.Search(v.NumRuns(), func(i int) bool {
+		s := v.Run(i)
+		return uint64(x) < uint64(s.Value)+uint64(s.Count)
+	})
+}
+
+// Contains reports whether x is in v.
+func (v FlatView) Contains(x uint32) bool {
+	i := v.LowerBound(x)
+	return i < v.NumRuns() && x >= v.Run(i).Value
+}
+
+// All returns an iterator over the values in v, in increasing order.
+func (v FlatView) All() iter.Seq[uint32] {
+	return valuesOf(v.Runs())
+}
+
+// Runs returns an iterator over the runs in v, in increasing order.
+func (v FlatView) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		for i := 0; i < v.NumRuns(); i++ {
+			if !yield(v.Run(i)) {
+				return
+			}
+		}
+	}
+}
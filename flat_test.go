@@ -0,0 +1,169 @@
+package rangearray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestFlatView(t *testing.T) {
+	r := testBlockArray()
+	e := testEncodingArray()
+	b := r.AppendFlat(nil)
+	b = e.AppendFlat(b)
+	b = Uint32{}.AppendFlat(b)
+
+	v, err := NewFlatView(b)
+	if err != nil {
+		t.Fatalf("NewFlatView() failed: %v", err)
+	}
+	if v.NumRuns() != len(r.runs) || v.Len() != r.Len() || v.Min() != r.Min() || v.Max() != r.Max() {
+		t.Errorf("Expected v to match r, got %d runs, Len() == %d", v.NumRuns(), v.Len())
+	}
+	for x := uint32(0); x < 1010; x++ {
+		if y := v.IndexOf(x); y != r.IndexOf(x) {
+			t.Errorf("Expected v.IndexOf(%d) == %d, got %d", x, r.IndexOf(x), y)
+		}
+		if v.Contains(x) != r.Contains(x) {
+			t.Errorf("Expected v.Contains(%d) == %v", x, r.Contains(x))
+		}
+	}
+	if !slices.Equal(slices.Collect(v.All()), slices.Collect(r.All())) {
+		t.Errorf("Expected v.All() to match r.All()")
+	}
+
+	w, err := NewFlatView(b[v.Size():])
+	if err != nil {
+		t.Fatalf("NewFlatView() of second array failed: %v", err)
+	}
+	if !slices.Equal(slices.Collect(w.Runs()), e.runs) {
+		t.Errorf("Expected w.Runs() == %v, got %v", e.runs, slices.Collect(w.Runs()))
+	}
+
+	z, err := NewFlatView(b[v.Size()+w.Size():])
+	if err != nil || z.Len() != 0 || z.IndexOf(5) != 0 || z.Contains(5) {
+		t.Errorf("Expected an empty third array, got %v", err)
+	}
+}
+
+func TestFlatViewErrors(t *testing.T) {
+	b := testEncodingArray().AppendFlat(nil)
+	for _, s := range [][]byte{nil, b[:flatHeaderLen-1], b[:len(b)-1], append([]byte("XX"), b[2:]...)} {
+		if _, err := NewFlatView(s); err == nil {
+			t.Errorf("Expected NewFlatView(%x) to fail", s)
+		}
+	}
+}
+
+func TestReadFlatCorrupt(t *testing.T) {
+	b := testEncodingArray().AppendFlat(nil)
+	overlap := bytes.Clone(b)
+	binary.LittleEndian.PutUint32(overlap[flatHeaderLen+flatRunLen:], 150)
+	huge := bytes.Clone(b)
+	binary.LittleEndian.PutUint32(huge[4:], 0xffffffff)
+	newer := bytes.Clone(b)
+	newer[2] = flatVersion + 1
+
+	for _, s := range []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"bad magic", append([]byte("XX"), b[2:]...), ErrCorrupt},
+		{"newer version", newer, ErrVersion},
+		{"overlap", overlap, ErrCorrupt},
+		{"huge count", huge, ErrTruncated},
+	} {
+		var x Uint32
+		if _, err := x.ReadFlat(bytes.NewReader(s.data)); !errors.Is(err, s.want) {
+			t.Errorf("Expected ReadFlat(%s) to fail with %v, got %v", s.name, s.want, err)
+		}
+	}
+}
+
+func TestWrapFlat(t *testing.T) {
+	r := testBlockArray()
+
+	// Offset the flat rangearray by one byte to exercise the copy.
+	for _, pad := range []int{0, 4, 1} {
+		b := r.AppendFlat(make([]byte, pad, pad+flatHeaderLen+flatRunLen*len(r.runs)))[pad:]
+		x, err := WrapFlat(b)
+		if err != nil {
+			t.Fatalf("WrapFlat() failed: %v", err)
+		}
+		testEqualUint32(t, "x", x, r)
+
+		shared := &x.runs[0] == (*Uint32Run)(unsafe.Pointer(&b[flatHeaderLen]))
+		want := uintptr(unsafe.Pointer(&b[0]))%4 == 0 && nativeLittleEndian
+		if shared != want {
+			t.Errorf("Expected WrapFlat() with padding %d to share memory == %v, got %v", pad, want, shared)
+		}
+
+		// Changing x must leave b alone.
+		before := string(b)
+		x.Push(x.Max() + 1)
+		if string(b) != before {
+			t.Errorf("Expected Push() with padding %d to leave the flat rangearray unchanged", pad)
+		}
+	}
+
+	if x, err := WrapFlat(Uint32{}.AppendFlat(nil)); err != nil || x.Len() != 0 {
+		t.Errorf("Expected WrapFlat() of an empty array to be empty, got %v", err)
+	}
+}
+
+func TestWriteFlat(t *testing.T) {
+	r := testBlockArray()
+	native := nativeLittleEndian
+	defer func() { nativeLittleEndian = native }()
+
+	// Check both the fast path and the portable one.
+	for _, little := range []bool{native, false} {
+		nativeLittleEndian = little
+		var buf bytes.Buffer
+		for _, x := range []Uint32{r, {}} {
+			n, err := x.WriteFlat(&buf)
+			if err != nil || n != int64(len(x.AppendFlat(nil))) {
+				t.Fatalf("Expected WriteFlat() to write %d bytes, got %d, %v", len(x.AppendFlat(nil)), n, err)
+			}
+		}
+		if want := (Uint32{}).AppendFlat(r.AppendFlat(nil)); !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("Expected WriteFlat() to match AppendFlat()")
+		}
+
+		b := buf.Bytes()
+		var x Uint32
+		if _, err := x.ReadFlat(&buf); err != nil {
+			t.Fatalf("ReadFlat() failed: %v", err)
+		}
+		testEqualUint32(t, "x", x, r)
+		if _, err := x.ReadFlat(&buf); err != nil || len(x.runs) != 0 {
+			t.Errorf("Expected ReadFlat() to read an empty array, got %v, %v", x, err)
+		}
+		if _, err := x.ReadFlat(&buf); err != io.EOF {
+			t.Errorf("Expected ReadFlat() at the end to return io.EOF, got %v", err)
+		}
+		if _, err := x.ReadFlat(bytes.NewReader(b[:len(b)/2])); !errors.Is(err, ErrTruncated) {
+			t.Errorf("Expected ReadFlat() of a truncated dump to return ErrTruncated, got %v", err)
+		}
+	}
+}
+
+func TestFlatViewTopOfRange(t *testing.T) {
+	var r Uint32
+	r.Push(5)
+	pushRange(&r, 0xfffffff0, 0xfffffffe)
+	r.Push(0xffffffff)
+	v, err := NewFlatView(r.AppendFlat(nil))
+	if err != nil {
+		t.Fatalf("NewFlatView() failed: %v", err)
+	}
+	if v.LowerBound(0xffffffff) != 1 || !v.Contains(0xffffffff) || v.IndexOf(0xffffffff) != 16 {
+		t.Errorf("Expected 0xffffffff in run 1 at index 16, got run %d, %v, index %d",
+			v.LowerBound(0xffffffff), v.Contains(0xffffffff), v.IndexOf(0xffffffff))
+	}
+}
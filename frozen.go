@@ -0,0 +1,76 @@
+package rangearray
+
+import (
+	"iter"
+	"slices"
+)
+
+// Frozen is an immutable rangearray.  It offers the query methods of
+// Uint32 but no way to modify it, so it is safe for concurrent use
+// without locking.  The zero value is empty.
+type Frozen struct {
+	r Uint32
+}
+
+// Freeze returns a Frozen copy of r.
+func (r Uint32) Freeze() *Frozen {
+	f := &Frozen{r: Uint32{runs: slices.Clone(r.runs)}}
+	f.r.keepIndex()
+	return f
+}
+
+// Thaw returns a mutable copy of f.
+func (f *Frozen) Thaw() Uint32 {
+	return Uint32{runs: slices.Clone(f.r.runs)}
+}
+
+// Min returns the minimum value in f.  Panics if f is empty.
+func (f *Frozen) Min() uint32 {
+	return f.r.Min()
+}
+
+// Max returns the maximum value in f.  Panics if f is empty.
+func (f *Frozen) Max() uint32 {
+	return f.r.Max()
+}
+
+// Len returns the number of elements in f.
+func (f *Frozen) Len() uint32 {
+	return f.r.Len()
+}
+
+// NumRuns returns the number of runs in f.
+func (f *Frozen) NumRuns() int {
+	return len(f.r.runs)
+}
+
+// IndexOf returns the number of elements in f that are less than x.
+func (f *Frozen) IndexOf(x uint32) uint32 {
+	return f.r.IndexOf(x)
+}
+
+// LowerBound returns the index of the run in f that contains x, or of
+// the run that starts after x, as Uint32.LowerBound does.
+func (f *Frozen) LowerBound(x uint32) int {
+	return f.r.LowerBound(x)
+}
+
+// Contains reports whether x is in f.
+func (f *Frozen) Contains(x uint32) bool {
+	return f.r.Contains(x)
+}
+
+// All returns an iterator over the values in f, in increasing order.
+func (f *Frozen) All() iter.Seq[uint32] {
+	return f.r.All()
+}
+
+// Runs returns an iterator over the runs in f, in increasing order.
+func (f *Frozen) Runs() iter.Seq[Uint32Run] {
+	return f.r.Runs()
+}
+
+// String returns f in the format of Uint32.String.
+func (f *Frozen) String() string {
+	return f.r.String()
+}
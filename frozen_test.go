@@ -0,0 +1,40 @@
+package rangearray
+
+import (
+	"testing"
+)
+
+func TestFrozen(t *testing.T) {
+	r := testEncodingArray()
+	f := r.Freeze()
+	r.Push(500)
+	if f.Len() != 202 || f.NumRuns() != 4 || f.Contains(500) {
+		t.Errorf("Expected Freeze() to copy r, got %v", f)
+	}
+	if f.Min() != 100 || f.Max() != 0xffffffff || f.IndexOf(350) != 100 || f.LowerBound(300) != 1 {
+		t.Errorf("Expected Frozen queries to match Uint32, got %v", f)
+	}
+
+	n := 0
+	for range f.All() {
+		n++
+	}
+	runs := 0
+	for range f.Runs() {
+		runs++
+	}
+	if n != 202 || runs != 4 {
+		t.Errorf("Expected 202 values in 4 runs, got %d in %d", n, runs)
+	}
+
+	u := f.Thaw()
+	u.Push(2000)
+	if f.Contains(2000) || !u.Contains(2000) {
+		t.Errorf("Expected Thaw() to copy f")
+	}
+
+	var zero Frozen
+	if zero.Len() != 0 || zero.String() != "" {
+		t.Errorf("Expected the zero Frozen to be empty, got %v", &zero)
+	}
+}
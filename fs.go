@@ -0,0 +1,136 @@
+package rangearray
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFS is the writing counterpart of fs.FS, so that arrays can be
+// saved through any storage adapter: a directory, an archive, an
+// object store, or a map in a test.
+type WriteFS interface {
+	// Create creates or replaces the named file, and returns a writer
+	// for its contents.  The name is a path in the form that
+	// fs.ValidPath accepts.  The file is complete once the writer is
+	// closed without error.
+	Create(name string) (io.WriteCloser, error)
+}
+
+// DirWriteFS returns a WriteFS for the tree of files rooted at dir,
+// the writing counterpart of os.DirFS.  Create makes any missing parent
+// directories, and each file replaces the old one atomically when it
+// is closed, so a reader never sees a partial file.
+func DirWriteFS(dir string) WriteFS {
+	return dirWriteFS(dir)
+}
+
+type dirWriteFS string
+
+func (dir dirWriteFS) Create(name string) (io.WriteCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	path := filepath.Join(string(dir), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{f: f, path: path}, nil
+}
+
+// dirFile is a file being written by a dirWriteFS.  It is written
+// under a temporary name, and renamed when it is closed.
+type dirFile struct {
+	f    *os.File
+	path string
+	err  error
+}
+
+func (d *dirFile) Write(b []byte) (int, error) {
+	n, err := d.f.Write(b)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	return n, err
+}
+
+func (d *dirFile) Close() error {
+	err := d.err
+	if err == nil {
+		err = d.f.Sync()
+	}
+	if cerr := d.f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(d.f.Name(), d.path)
+	}
+	if err != nil {
+		os.Remove(d.f.Name())
+	}
+	return err
+}
+
+// syncDir syncs the directory that holds path, so that a file renamed
+// into it or removed from it stays that way after a crash.
+func syncDir(path string) error {
+	d, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// LoadFS reads the named file from fsys, such as an embed.FS, a zip
+// archive or an fstest.MapFS, and decodes it from the binary format.
+func LoadFS(fsys fs.FS, name string) (Uint32, error) {
+	return Decoder{}.LoadFS(fsys, name)
+}
+
+// LoadFS reads the named file from fsys, and decodes it from the
+// binary format with d.
+func (d Decoder) LoadFS(fsys fs.FS, name string) (Uint32, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Uint32{}, err
+	}
+	var r Uint32
+	if err := d.Unmarshal(data, &r); err != nil {
+		return Uint32{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return r, nil
+}
+
+// SaveFS writes r to the named file in fsys, in the binary format.
+func SaveFS(fsys WriteFS, name string, r Uint32) error {
+	return Encoder{}.SaveFS(fsys, name, r)
+}
+
+// SaveFS writes r to the named file in fsys, in the binary format with
+// e's options.  It encodes r before creating the file, so a failure to
+// encode never leaves a partial file behind.
+func (e Encoder) SaveFS(fsys WriteFS, name string, r Uint32) error {
+	data, err := e.Marshal(r)
+	if err != nil {
+		return err
+	}
+	w, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
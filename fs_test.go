@@ -0,0 +1,75 @@
+package rangearray
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// mapWriteFS is a WriteFS that saves files into an fstest.MapFS.
+type mapWriteFS fstest.MapFS
+
+type mapFile struct {
+	bytes.Buffer
+	fsys mapWriteFS
+	name string
+}
+
+func (m mapWriteFS) Create(name string) (io.WriteCloser, error) {
+	return &mapFile{fsys: m, name: name}, nil
+}
+
+func (f *mapFile) Close() error {
+	f.fsys[f.name] = &fstest.MapFile{Data: f.Bytes()}
+	return nil
+}
+
+func TestSaveLoadFS(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 10, 20)
+	pushRange(&r, 40, 41)
+
+	fsys := fstest.MapFS{}
+	if err := (Encoder{Encoding: Varint}).SaveFS(mapWriteFS(fsys), "idx/a.ra", r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFS(fsys, "idx/a.ra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "LoadFS", got, r)
+
+	fsys["bad.ra"] = &fstest.MapFile{Data: []byte("junk")}
+	if _, err := LoadFS(fsys, "bad.ra"); err == nil || !strings.Contains(err.Error(), "bad.ra") {
+		t.Errorf("Expected an error naming bad.ra, got %v", err)
+	}
+	if _, err := LoadFS(fsys, "missing.ra"); !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+}
+
+func TestDirWriteFS(t *testing.T) {
+	dir := t.TempDir()
+	var r Uint32
+	pushRange(&r, 5, 9)
+	if err := SaveFS(DirWriteFS(dir), "sub/b.ra", r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFS(os.DirFS(dir), "sub/b.ra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "LoadFS", got, r)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "sub", "*"))
+	if len(files) != 1 {
+		t.Errorf("Expected only the saved file, got %v", files)
+	}
+	if err := SaveFS(DirWriteFS(dir), "../escape.ra", r); err == nil {
+		t.Errorf("Expected an error for an invalid name")
+	}
+}
@@ -1 +1,3 @@
-module github/com/entrope/rangearray
+module github/com/entrope/rangearray/v2
+
+go 1.24
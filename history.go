@@ -0,0 +1,106 @@
+package rangearray
+
+// DefaultMaxVersions is the number of versions that a History keeps,
+// if MaxVersions is zero.
+const DefaultMaxVersions = 1 << 16
+
+// History is a rangearray that remembers its recent versions, so that
+// a pipeline can see exactly what the array held when some product was
+// made from it.  Every Push or Remove that changes the array gives it a
+// new sequence number, and AsOf returns the version with a given
+// number.  The versions are Persistent arrays, which share all but
+// O(log n) of their runs with each other, so keeping many of them is
+// cheap.
+//
+// The zero value is empty, at sequence number zero, and keeps
+// DefaultMaxVersions versions.  A History is not safe for concurrent
+// use while it is being modified, but the Persistent versions it
+// returns are.
+type History struct {
+	// MaxVersions is the number of recent versions to keep.  If it is
+	// zero, DefaultMaxVersions is used.
+	MaxVersions int
+
+	// versions[i] is the version with sequence number first+i.
+	versions []Persistent
+	first    uint64
+}
+
+// maxVersions returns the number of versions to keep.
+func (h *History) maxVersions() int {
+	if h.MaxVersions <= 0 {
+		return DefaultMaxVersions
+	}
+	return h.MaxVersions
+}
+
+// Seq returns the sequence number of the current version of h.
+func (h *History) Seq() uint64 {
+	return h.first + uint64(max(len(h.versions), 1)) - 1
+}
+
+// Current returns the current version of h.
+func (h *History) Current() Persistent {
+	if len(h.versions) == 0 {
+		return Persistent{}
+	}
+	return h.versions[len(h.versions)-1]
+}
+
+// Push adds x to h, and returns the sequence number of the resulting
+// version.  If x is already in h, the version does not change.
+func (h *History) Push(x uint32) uint64 {
+	return h.record(h.Current().Push(x))
+}
+
+// Remove removes x from h, and returns the sequence number of the
+// resulting version.  If x is not in h, the version does not change.
+func (h *History) Remove(x uint32) uint64 {
+	return h.record(h.Current().Remove(x))
+}
+
+// record makes p the current version of h, if it differs from the
+// current one, and returns its sequence number.
+func (h *History) record(p Persistent) uint64 {
+	if len(h.versions) == 0 {
+		h.versions = append(h.versions, Persistent{})
+	}
+	if p.root == h.Current().root {
+		return h.Seq()
+	}
+	h.versions = append(h.versions, p)
+
+	// Drop old versions in bulk, so that each Push does O(1) work on
+	// average; AsOf refuses the extra ones in the meantime.
+	if keep := h.maxVersions(); len(h.versions) >= 2*keep {
+		h.Prune(h.Seq() - uint64(keep) + 1)
+	}
+	return h.Seq()
+}
+
+// AsOf returns the version of h with sequence number seq.  It returns
+// false if seq is newer than the current version, or older than the
+// MaxVersions most recent versions or a call to Prune.
+func (h *History) AsOf(seq uint64) (Persistent, bool) {
+	cur := h.Seq()
+	if seq > cur || cur-seq >= uint64(h.maxVersions()) || seq < h.first {
+		return Persistent{}, false
+	}
+	if len(h.versions) == 0 {
+		return Persistent{}, true
+	}
+	return h.versions[seq-h.first], true
+}
+
+// Prune forgets the versions of h before sequence number seq, so that
+// their runs can be freed.  It never forgets the current version.
+func (h *History) Prune(seq uint64) {
+	seq = min(seq, h.Seq())
+	if seq <= h.first || len(h.versions) == 0 {
+		return
+	}
+	n := copy(h.versions, h.versions[seq-h.first:])
+	clear(h.versions[n:])
+	h.versions = h.versions[:n]
+	h.first = seq
+}
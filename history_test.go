@@ -0,0 +1,77 @@
+package rangearray
+
+import "testing"
+
+func TestHistory(t *testing.T) {
+	var h History
+	if p, ok := h.AsOf(0); !ok || p.Len() != 0 {
+		t.Errorf("Expected an empty version 0, got %v, %v", p.Uint32(), ok)
+	}
+
+	var want []Uint32
+	var r Uint32
+	want = append(want, r.Fork())
+	for _, x := range []uint32{5, 6, 10, 6, 7, 2} {
+		if !r.Contains(x) {
+			r.Push(x)
+			want = append(want, r.Fork())
+		}
+		if seq := h.Push(x); seq != uint64(len(want)-1) {
+			t.Errorf("Expected Push(%d) to return %d, got %d", x, len(want)-1, seq)
+		}
+	}
+	if seq := h.Remove(6); seq != 6 {
+		t.Errorf("Expected Remove to return 6, got %d", seq)
+	}
+	if seq := h.Remove(6); seq != 6 {
+		t.Errorf("Expected a second Remove to return 6, got %d", seq)
+	}
+	want = append(want, Uint32{runs: []Uint32Run{{2, 0, 1}, {5, 1, 1}, {7, 2, 1}, {10, 3, 1}}})
+
+	if h.Seq() != 6 {
+		t.Errorf("Expected Seq() == 6, got %d", h.Seq())
+	}
+	for seq, w := range want {
+		p, ok := h.AsOf(uint64(seq))
+		if !ok {
+			t.Errorf("Expected AsOf(%d) to succeed", seq)
+			continue
+		}
+		testEqualUint32(t, "AsOf", p.Uint32(), w)
+	}
+	if _, ok := h.AsOf(7); ok {
+		t.Errorf("Expected AsOf(7) to fail")
+	}
+
+	h.Prune(3)
+	if _, ok := h.AsOf(2); ok {
+		t.Errorf("Expected AsOf(2) to fail after Prune(3)")
+	}
+	if p, ok := h.AsOf(3); !ok {
+		t.Errorf("Expected AsOf(3) to succeed after Prune(3)")
+	} else {
+		testEqualUint32(t, "AsOf(3)", p.Uint32(), want[3])
+	}
+	h.Prune(100)
+	testEqualUint32(t, "Current", h.Current().Uint32(), want[6])
+}
+
+func TestHistoryMaxVersions(t *testing.T) {
+	h := History{MaxVersions: 10}
+	for x := uint32(0); x < 100; x += 2 {
+		h.Push(x)
+	}
+	if h.Seq() != 50 {
+		t.Errorf("Expected Seq() == 50, got %d", h.Seq())
+	}
+	if _, ok := h.AsOf(40); ok {
+		t.Errorf("Expected AsOf(40) to fail")
+	}
+	p, ok := h.AsOf(41)
+	if !ok || p.Len() != 41 {
+		t.Errorf("Expected AsOf(41) to hold 41 values, got %d, %v", p.Len(), ok)
+	}
+	if len(h.versions) >= 20 {
+		t.Errorf("Expected fewer than 20 versions kept, got %d", len(h.versions))
+	}
+}
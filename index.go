@@ -0,0 +1,184 @@
+package rangearray
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+const (
+	// indexStride is the number of runs between the samples in a
+	// sampled index.
+	indexStride = 64
+
+	// indexMinRuns is the fewest runs for which an array keeps a
+	// sampled index; smaller arrays are searched directly.
+	indexMinRuns = 4096
+)
+
+// runIndex samples the start value of every indexStride'th run of an
+// array, so that LowerBound can find the right stretch of runs with a
+// search over a small, cache-resident slice, and then search only that
+// stretch of the much larger run slice.
+type runIndex struct {
+	// base is the address of the first run the index was built from,
+	// so that an index for a copy of the runs is not used.
+	base *Uint32Run
+
+	// n is the number of runs sampled.  Runs appended after them are
+	// searched along with the last stretch, until there are enough to
+	// make rebuilding the index worthwhile.
+	n int
+
+	starts []uint32
+
+	// eytzinger, if not nil, holds the start value of every run in
+	// Eytzinger order, from OptimizeForReads, and position holds the
+	// index of each of those runs.  The root is at index 1, and the
+	// children of node k are at 2k and 2k+1.  This index is only used
+	// while n is the number of runs.
+	eytzinger []uint32
+	position  []uint32
+}
+
+// keepIndex gives r a sampled index once it has enough runs to need
+// one.  The index itself is built lazily, by the first search after r
+// changes.
+func (r *Uint32) keepIndex() {
+	if r.index == nil && len(r.runs) >= indexMinRuns {
+		r.index = new(atomic.Pointer[runIndex])
+	}
+}
+
+// dropIndex discards r's sampled index, if it has one, after a change
+// to a run that may have been sampled.
+func (r *Uint32) dropIndex() {
+	if r.index != nil {
+		r.index.Store(nil)
+	}
+}
+
+// OptimizeForReads rearranges a copy of the start values of r's runs
+// into Eytzinger (breadth-first) order, which makes searches cheaper
+// by keeping the first probes of every search close together in
+// memory, and makes their branches predictable.  The layout takes 8
+// bytes per run, and is used until r next changes; after that, r is
+// searched as if OptimizeForReads had not been called.
+func (r *Uint32) OptimizeForReads() {
+	if len(r.runs) == 0 {
+		return
+	}
+	if r.index == nil {
+		r.index = new(atomic.Pointer[runIndex])
+	}
+	idx := &runIndex{
+		base:      &r.runs[0],
+		n:         len(r.runs),
+		eytzinger: make([]uint32, len(r.runs)+1),
+		position:  make([]uint32, len(r.runs)+1),
+	}
+	idx.fillEytzinger(r.runs, 0, 1)
+	r.index.Store(idx)
+}
+
+// fillEytzinger fills the subtree of idx.eytzinger rooted at k with
+// the runs of s starting at i, in order, and returns the index of the
+// first run of s it did not use.
+func (idx *runIndex) fillEytzinger(s []Uint32Run, i, k int) int {
+	if k < len(idx.eytzinger) {
+		i = idx.fillEytzinger(s, i, 2*k)
+		idx.eytzinger[k] = s[i].Value
+		idx.position[k] = uint32(i)
+		i = idx.fillEytzinger(s, i+1, 2*k+1)
+	}
+	return i
+}
+
+// sampled returns an up-to-date index for r, or nil if r does not
+// keep one.  Concurrent searches may rebuild the index at the same
+// time; each builds the same index, and it does not matter which one
+// is kept.
+func (r Uint32) sampled() *runIndex {
+	if r.index == nil || len(r.runs) == 0 {
+		return nil
+	}
+	idx := r.index.Load()
+	if idx != nil && idx.base == &r.runs[0] {
+		if idx.eytzinger != nil && idx.n == len(r.runs) {
+			return idx
+		}
+		if idx.eytzinger == nil && idx.n <= len(r.runs) && len(r.runs)-idx.n <= indexStride {
+			return idx
+		}
+	}
+	if len(r.runs) < indexMinRuns {
+		return nil
+	}
+
+	idx = &runIndex{base: &r.runs[0], n: len(r.runs)}
+	idx.starts = make([]uint32, 0, (len(r.runs)+indexStride-1)/indexStride)
+	for i := 0; i < len(r.runs); i += indexStride {
+		idx.starts = append(idx.starts, r.runs[i].Value)
+	}
+	r.index.Store(idx)
+	return idx
+}
+
+// lowerBound returns the LowerBound of x in s, which idx samples.
+func (idx *runIndex) lowerBound(s []Uint32Run, x uint32) int {
+	if idx.eytzinger != nil {
+		return idx.lowerBoundEytzinger(s, x)
+	}
+
+	// Every run before the last sample that starts at or before x
+	// ends at or before x, so x's run is in the stretch that sample
+	// starts, or is the run that starts the next stretch.
+	j := searchStarts(idx.starts, x)
+	if j == 0 {
+		return 0
+	}
+	lo, hi := (j-1)*indexStride, len(s)
+	if j < len(idx.starts) {
+		hi = j * indexStride
+	}
+	return lo + searchRuns(s[lo:hi], x)
+}
+
+// searchStarts returns the number of values in starts that are at most
+// x, in the same way as searchRuns.
+func searchStarts(starts []uint32, x uint32) int {
+	base, n := 0, len(starts)
+	for n > 1 {
+		half := n / 2
+		if starts[base+half-1] <= x {
+			base += half
+		}
+		n -= half
+	}
+	if n == 1 && starts[base] <= x {
+		base++
+	}
+	return base
+}
+
+// lowerBoundEytzinger returns the LowerBound of x in s, using the
+// Eytzinger layout in idx.
+func (idx *runIndex) lowerBoundEytzinger(s []Uint32Run, x uint32) int {
+	// Descend to a leaf, going right past every start at or before x.
+	// The last node where the search went left holds the first run
+	// that starts after x; undo the right turns after it to find it.
+	e := idx.eytzinger
+	k := 1
+	for k < len(e) {
+		k = 2*k + 1 - int(uint64(int64(x)-int64(e[k]))>>63)
+	}
+	k >>= bits.TrailingZeros(^uint(k)) + 1
+
+	j := len(s)
+	if k > 0 {
+		j = int(idx.position[k])
+	}
+	if j > 0 && uint64(x) < uint64(s[j-1].Value)+uint64(s[j-1].Count) {
+		return j - 1
+	}
+	return j
+}
@@ -0,0 +1,120 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+func testIndexLowerBound(t *testing.T, name string, r Uint32, rng *rand.Rand) {
+	t.Helper()
+	plain := Uint32{runs: r.runs}
+	for range 2000 {
+		x := rng.Uint32N(r.Max() + 100)
+		if got, want := r.LowerBound(x), plain.LowerBound(x); got != want {
+			t.Errorf("%s: Expected LowerBound(%d) = %d, got %d", name, x, want, got)
+			return
+		}
+	}
+}
+
+func TestSampledIndex(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	var r Uint32
+	for x := uint32(0); len(r.runs) < indexMinRuns-1; x += 2 + rng.Uint32N(10) {
+		pushRange(&r, x, x+rng.Uint32N(3))
+	}
+	if r.index != nil || r.sampled() != nil {
+		t.Errorf("Expected no index below %d runs", indexMinRuns)
+	}
+
+	r.Push(r.Max() + 2)
+	idx := r.sampled()
+	if idx == nil || idx.n != indexMinRuns || len(idx.starts) != indexMinRuns/indexStride {
+		t.Fatalf("Expected an index of %d runs, got %+v", indexMinRuns, idx)
+	}
+	testIndexLowerBound(t, "built", r, rng)
+
+	// Appending a few runs keeps the index, and many rebuild it.
+	for range indexStride / 2 {
+		r.Push(r.Max() + 2)
+	}
+	if r.sampled() != idx {
+		t.Errorf("Expected a few appends to keep the index")
+	}
+	testIndexLowerBound(t, "appended", r, rng)
+	for range indexStride {
+		r.Push(r.Max() + 2)
+	}
+	if r.sampled() == idx {
+		t.Errorf("Expected many appends to rebuild the index")
+	}
+	testIndexLowerBound(t, "rebuilt", r, rng)
+
+	// Inserts may change sampled runs, so they drop the index.
+	r.Push(r.runs[indexStride].Value - 1)
+	if r.index.Load() != nil {
+		t.Errorf("Expected an insert to drop the index")
+	}
+	testIndexLowerBound(t, "inserted", r, rng)
+
+	// Forks get their own copy of the runs when they change.
+	f := r.Fork()
+	f.Push(1)
+	testIndexLowerBound(t, "fork", f, rng)
+	testIndexLowerBound(t, "original", r, rng)
+
+	frozen := r.Freeze()
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(uint64(w), 7))
+			for range 1000 {
+				x := rng.Uint32N(r.Max())
+				if frozen.Contains(x) != r.Contains(x) {
+					t.Errorf("Expected Frozen.Contains(%d) to match", x)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOptimizeForReads(t *testing.T) {
+	rng := rand.New(rand.NewPCG(8, 9))
+	for _, n := range []int{1, 2, 3, 7, 8, 100, 5000} {
+		var r Uint32
+		for x := uint32(5); len(r.runs) < n; x += 2 + rng.Uint32N(10) {
+			pushRange(&r, x, x+rng.Uint32N(3))
+		}
+		r.OptimizeForReads()
+		if idx := r.sampled(); idx == nil || idx.eytzinger == nil {
+			t.Fatalf("Expected an Eytzinger index for %d runs, got %+v", n, idx)
+		}
+		testIndexLowerBound(t, "optimized", r, rng)
+		for _, x := range []uint32{0, 5, r.Max(), r.Max() + 1} {
+			if got, want := r.LowerBound(x), (Uint32{runs: r.runs}).LowerBound(x); got != want {
+				t.Errorf("Expected LowerBound(%d) = %d for %d runs, got %d", x, want, n, got)
+			}
+		}
+
+		// Extending the last run keeps the layout; a new run ends it.
+		r.Push(r.Max() + 1)
+		if idx := r.sampled(); idx == nil || idx.eytzinger == nil {
+			t.Errorf("Expected extending the last run to keep the layout")
+		}
+		r.Push(r.Max() + 2)
+		if idx := r.sampled(); idx != nil && idx.eytzinger != nil {
+			t.Errorf("Expected a new run to end the layout")
+		}
+		testIndexLowerBound(t, "appended", r, rng)
+	}
+	var empty Uint32
+	empty.OptimizeForReads()
+	if empty.LowerBound(3) != 0 {
+		t.Errorf("Expected LowerBound() on an empty array to be 0")
+	}
+}
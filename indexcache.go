@@ -0,0 +1,58 @@
+package rangearray
+
+import "sync"
+
+// indexCache remembers the most recent answers to IndexOf, for arrays
+// whose queries repeat the same few values.
+type indexCache struct {
+	mu     sync.Mutex
+	keys   []uint32
+	values []uint32
+	next   int // slot to replace once the cache is full
+}
+
+// newIndexCache returns a cache of n answers.
+func newIndexCache(n int) *indexCache {
+	return &indexCache{keys: make([]uint32, 0, n), values: make([]uint32, 0, n)}
+}
+
+// get returns the cached IndexOf(x), if there is one.
+func (c *indexCache) get(x uint32) (uint32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, k := range c.keys {
+		if k == x {
+			return c.values[i], true
+		}
+	}
+	return 0, false
+}
+
+// put records that IndexOf(x) is index.
+func (c *indexCache) put(x, index uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.keys) < cap(c.keys) {
+		c.keys = append(c.keys, x)
+		c.values = append(c.values, index)
+		return
+	}
+	c.keys[c.next], c.values[c.next] = x, index
+	c.next = (c.next + 1) % len(c.keys)
+}
+
+// inserted forgets the answers that inserting x changes: those for
+// values after x.
+func (c *indexCache) inserted(x uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for i, k := range c.keys {
+		if k <= x {
+			c.keys[n], c.values[n] = k, c.values[i]
+			n++
+		}
+	}
+	c.keys, c.values = c.keys[:n], c.values[:n]
+	c.next = 0
+}
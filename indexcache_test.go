@@ -0,0 +1,29 @@
+package rangearray
+
+import "testing"
+
+func TestIndexCache(t *testing.T) {
+	c := newIndexCache(3)
+	for _, x := range []uint32{10, 20, 30, 40} {
+		c.put(x, x/10)
+	}
+	if _, ok := c.get(10); ok {
+		t.Errorf("Expected the oldest answer to be replaced")
+	}
+	if index, ok := c.get(40); !ok || index != 4 {
+		t.Errorf("Expected a cached answer of 4 for 40, got %d, %v", index, ok)
+	}
+
+	// Inserting 30 changes the answers for values after 30 only.
+	c.inserted(30)
+	for x, want := range map[uint32]bool{20: true, 30: true, 40: false} {
+		if _, ok := c.get(x); ok != want {
+			t.Errorf("Expected get(%d) to report %v after inserting 30, got %v", x, want, ok)
+		}
+	}
+	c.put(50, 5)
+	c.put(60, 6)
+	if _, ok := c.get(60); !ok {
+		t.Errorf("Expected the cache to refill after inserting")
+	}
+}
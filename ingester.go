@@ -0,0 +1,176 @@
+package rangearray
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// IngestPolicy selects what Ingester.Send does when the ingester's
+// buffer is full.
+type IngestPolicy int
+
+const (
+	// IngestBlock makes Send wait for room in the buffer.
+	IngestBlock IngestPolicy = iota
+
+	// IngestDrop makes Send discard the value and return false.
+	IngestDrop
+)
+
+// IngesterOptions configures an Ingester.
+type IngesterOptions struct {
+	// Buffer is the number of values that may wait to be applied.  If
+	// it is zero, 1024 is used.
+	Buffer int
+
+	// Batch is the most values applied at once.  If it is zero, 256 is
+	// used.
+	Batch int
+
+	// Policy selects what Send does when the buffer is full.
+	Policy IngestPolicy
+
+	// Publish controls how often the ingester publishes snapshots.
+	Publish PublisherOptions
+}
+
+// ingestItem is a value sent to an Ingester, or a flush request if ack
+// is not nil.
+type ingestItem struct {
+	x   uint32
+	ack chan struct{}
+}
+
+// Ingester collects values sent from any number of goroutines and
+// applies them to a rangearray on a single owner goroutine.  Values are
+// buffered and applied in batches, sorted within each batch so that
+// most become cheap appends.  Readers query Snapshot, which is updated
+// as in a Publisher.
+type Ingester struct {
+	opts    IngesterOptions
+	items   chan ingestItem
+	mu      sync.RWMutex
+	stopped bool
+	dropped atomic.Uint64
+	pub     *Publisher
+	done    chan struct{}
+}
+
+// NewIngester returns an Ingester with an empty array, and starts its
+// owner goroutine.  Call Stop to end the goroutine.
+func NewIngester(opts IngesterOptions) *Ingester {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 1024
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 256
+	}
+	g := &Ingester{
+		opts:  opts,
+		items: make(chan ingestItem, opts.Buffer),
+		pub:   NewPublisher(opts.Publish),
+		done:  make(chan struct{}),
+	}
+	go g.run()
+	return g
+}
+
+// Send queues x to be added to the array.  If the buffer is full, Send
+// waits or drops x according to the policy.  It returns false if x was
+// dropped or the ingester has stopped.  Send may be called from any
+// goroutine.
+func (g *Ingester) Send(x uint32) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.stopped {
+		return false
+	}
+
+	if g.opts.Policy == IngestDrop {
+		select {
+		case g.items <- ingestItem{x: x}:
+			return true
+		default:
+			g.dropped.Add(1)
+			return false
+		}
+	}
+	g.items <- ingestItem{x: x}
+	return true
+}
+
+// Dropped returns the number of values that Send has dropped.
+func (g *Ingester) Dropped() uint64 {
+	return g.dropped.Load()
+}
+
+// Flush waits until every value sent before it has been applied, and
+// then publishes a snapshot.
+func (g *Ingester) Flush() {
+	g.mu.RLock()
+	if g.stopped {
+		g.mu.RUnlock()
+		return
+	}
+	ack := make(chan struct{})
+	g.items <- ingestItem{ack: ack}
+	g.mu.RUnlock()
+	<-ack
+}
+
+// Stop applies every value already sent, stops the owner goroutine, and
+// returns the final array.  Later calls to Send return false.
+func (g *Ingester) Stop() *Frozen {
+	g.mu.Lock()
+	if !g.stopped {
+		g.stopped = true
+		close(g.items)
+	}
+	g.mu.Unlock()
+	<-g.done
+	return g.pub.Snapshot()
+}
+
+// Snapshot returns the most recently published snapshot of the array.
+func (g *Ingester) Snapshot() *Frozen {
+	return g.pub.Snapshot()
+}
+
+// run is the owner goroutine.
+func (g *Ingester) run() {
+	defer close(g.done)
+	batch := make([]uint32, 0, g.opts.Batch)
+	apply := func() {
+		slices.Sort(batch)
+		for _, x := range batch {
+			g.pub.Push(x)
+		}
+		batch = batch[:0]
+	}
+
+	for item := range g.items {
+		for {
+			if item.ack != nil {
+				apply()
+				g.pub.Publish()
+				close(item.ack)
+			} else {
+				batch = append(batch, item.x)
+			}
+			if len(batch) == cap(batch) {
+				break
+			}
+			var ok bool
+			select {
+			case item, ok = <-g.items:
+			default:
+			}
+			if !ok {
+				break
+			}
+		}
+		apply()
+	}
+	g.pub.Publish()
+}
@@ -0,0 +1,58 @@
+package rangearray
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestIngester(t *testing.T) {
+	g := NewIngester(IngesterOptions{Batch: 7, Publish: PublisherOptions{Every: 1000}})
+	var wg sync.WaitGroup
+	for w := uint32(0); w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w; i < 4000; i += 4 {
+				if !g.Send(i) {
+					t.Errorf("Expected Send(%d) to succeed", i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	g.Flush()
+	if snap := g.Snapshot(); snap.Len() != 4000 || snap.NumRuns() != 1 {
+		t.Errorf("Expected 4000 values in one run after Flush, got %v", snap)
+	}
+
+	g.Send(5000)
+	final := g.Stop()
+	if final.Len() != 4001 || !final.Contains(5000) {
+		t.Errorf("Expected Stop to apply pending values, got %v", final)
+	}
+	if g.Send(6000) {
+		t.Errorf("Expected Send after Stop to fail")
+	}
+	g.Flush()
+	g.Stop()
+	if g.Dropped() != 0 {
+		t.Errorf("Expected no drops, got %d", g.Dropped())
+	}
+}
+
+func TestIngesterDrop(t *testing.T) {
+	g := NewIngester(IngesterOptions{Buffer: 1, Policy: IngestDrop})
+	sent := 0
+	for i := uint32(0); i < 10000; i++ {
+		if g.Send(i) {
+			sent++
+		}
+	}
+	final := g.Stop()
+	if uint64(sent)+g.Dropped() != 10000 {
+		t.Errorf("Expected sent+dropped = 10000, got %d+%d", sent, g.Dropped())
+	}
+	if int(final.Len()) != sent {
+		t.Errorf("Expected %d values, got %v", sent, final)
+	}
+}
@@ -0,0 +1,251 @@
+package rangearray
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"slices"
+)
+
+// The shared form of a Collection stores each distinct block of runs
+// once, so that arrays with long identical stretches, such as
+// satellites that were tracked together, cost little more than one of
+// them.  It starts with the magic bytes "RS" and a format version.
+// Then come the number of blocks as a uvarint and the blocks: each is
+// its number of runs, then for each run the distance from the end of
+// the previous run in the block (or from zero, for the first run) to
+// its Value, and its Count, all as uvarints.  Then come the number of
+// keys as a uvarint and, for each key in increasing order, the length
+// of the key as a uvarint, the key, the number of blocks in its array
+// as a uvarint, and the number of each of those blocks as a uvarint.
+//
+// Blocks end after runs chosen by a hash of the run, rather than after
+// a fixed number of runs, so that after the arrays differ in a few
+// runs, their blocks line up again at the next shared boundary.
+
+const (
+	// sharedBlockMask sets the average number of runs in a block of the
+	// shared form: a block ends after a run whose hash has these bits
+	// clear.
+	sharedBlockMask = 1<<6 - 1
+
+	// sharedBlockMax is the most runs in a block of the shared form.
+	sharedBlockMax = 1024
+)
+
+var errBadShared = corruptf("rangearray: not a shared collection")
+
+// Intern is like Compact, but arrays whose runs are the same as, or the
+// start of, another array's runs share that array's storage instead of
+// having their own, until either one is modified.  In memory, arrays
+// can only share the runs at their start, since each run's Index counts
+// the values before it; MarshalShared also shares identical stretches
+// later in the arrays.
+func (c *Collection) Intern() {
+	keys := c.Keys()
+	slices.SortStableFunc(keys, func(a, b string) int {
+		return slices.CompareFunc(c.m[a].runs, c.m[b].runs, compareRuns)
+	})
+
+	// In that order, an array that starts another one comes right
+	// before it, or before an array that it also starts.  Working
+	// backwards, each array either shares the storage of the last
+	// array that did not, or has its own.
+	owner := make([]int, len(keys))
+	total := 0
+	for i := len(keys) - 1; i >= 0; i-- {
+		r := c.m[keys[i]]
+		owner[i] = i
+		if i+1 < len(keys) {
+			o := c.m[keys[owner[i+1]]]
+			if len(r.runs) > 0 && len(r.runs) <= len(o.runs) && slices.Equal(r.runs, o.runs[:len(r.runs)]) {
+				owner[i] = owner[i+1]
+				continue
+			}
+		}
+		total += len(r.runs)
+	}
+
+	arrays := make([]Uint32, len(keys))
+	runs := make([]Uint32Run, 0, total)
+	for i := len(keys) - 1; i >= 0; i-- {
+		r, p := c.m[keys[i]], &arrays[i]
+		switch n := len(r.runs); {
+		case n == 0:
+		case owner[i] == i:
+			runs = append(runs, r.runs...)
+			p.runs = runs[len(runs)-n : len(runs) : len(runs)]
+		default:
+			o := &arrays[owner[i]]
+			p.runs, p.shared = o.runs[:n:n], true
+			o.shared = true
+		}
+	}
+	for i, key := range keys {
+		c.m[key] = &arrays[i]
+	}
+	c.free = nil
+}
+
+// compareRuns orders runs by Value, then Count.  Index is left out,
+// since it follows from the runs before.
+func compareRuns(a, b Uint32Run) int {
+	if a.Value != b.Value {
+		return cmp.Compare(a.Value, b.Value)
+	}
+	return cmp.Compare(a.Count, b.Count)
+}
+
+// sharedBoundary reports whether a block of the shared form ends after
+// s.
+func sharedBoundary(s Uint32Run) bool {
+	h := (uint64(s.Value)<<32 | uint64(s.Count)) * 0x9e3779b97f4a7c15
+	return h>>58&sharedBlockMask == 0
+}
+
+// MarshalShared returns c in the shared form, which stores each block
+// of runs that several arrays have in common only once.
+// UnmarshalShared reads it back.
+func (c *Collection) MarshalShared() ([]byte, error) {
+	var blocks [][]byte
+	ids := map[string]uint64{}
+	refs := map[string][]uint64{}
+	keys := c.Keys()
+	for _, key := range keys {
+		r := c.m[key]
+		var block []byte
+		n := 0
+		end := uint32(0)
+		for i, s := range r.runs {
+			block = binary.AppendUvarint(block, uint64(s.Value-end))
+			block = binary.AppendUvarint(block, uint64(s.Count))
+			end = s.Value + s.Count
+			n++
+			if n < sharedBlockMax && !sharedBoundary(s) && i+1 < len(r.runs) {
+				continue
+			}
+
+			b := binary.AppendUvarint(nil, uint64(n))
+			b = append(b, block...)
+			id, ok := ids[string(b)]
+			if !ok {
+				id = uint64(len(blocks))
+				ids[string(b)] = id
+				blocks = append(blocks, b)
+			}
+			refs[key] = append(refs[key], id)
+			block, n, end = block[:0], 0, 0
+		}
+	}
+
+	b := []byte{'R', 'S', binaryVersion}
+	b = binary.AppendUvarint(b, uint64(len(blocks)))
+	for _, block := range blocks {
+		b = append(b, block...)
+	}
+	b = binary.AppendUvarint(b, uint64(len(keys)))
+	for _, key := range keys {
+		b = binary.AppendUvarint(b, uint64(len(key)))
+		b = append(b, key...)
+		b = binary.AppendUvarint(b, uint64(len(refs[key])))
+		for _, id := range refs[key] {
+			b = binary.AppendUvarint(b, id)
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalShared replaces the contents of c with the collection in
+// data, in the shared form, then interns its arrays as Intern does.
+func (c *Collection) UnmarshalShared(data []byte) error {
+	if len(data) < 3 || string(data[:2]) != "RS" {
+		return errBadShared
+	}
+	if data[2] != binaryVersion {
+		return versionf("rangearray: unsupported shared collection version %d", data[2])
+	}
+	rd := bytes.NewReader(data[3:])
+
+	n, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	blocks := make([][]Uint32Run, 0, min(n, uint64(rd.Len())))
+	for i := uint64(0); i < n; i++ {
+		runs, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if runs > uint64(rd.Len())/2 {
+			return errTruncated
+		}
+		block := make([]Uint32Run, runs)
+		end := uint64(0)
+		for j := range block {
+			gap, err := binary.ReadUvarint(rd)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			count, err := binary.ReadUvarint(rd)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if end+gap+count > 1<<32 {
+				return corruptf("rangearray: invalid run in shared block %d", i)
+			}
+			block[j] = Uint32Run{Value: uint32(end + gap), Count: uint32(count)}
+			end += gap + count
+		}
+		blocks = append(blocks, block)
+	}
+
+	nkeys, err := binary.ReadUvarint(rd)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	m := make(map[string]*Uint32, min(nkeys, uint64(rd.Len())))
+	prev := ""
+	for i := uint64(0); i < nkeys; i++ {
+		klen, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if klen > uint64(rd.Len()) {
+			return errTruncated
+		}
+		key := make([]byte, klen)
+		rd.Read(key)
+		if i > 0 && string(key) <= prev {
+			return corruptf("rangearray: collection key %q is out of order", key)
+		}
+		prev = string(key)
+
+		nrefs, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		r := &Uint32{}
+		for j := uint64(0); j < nrefs; j++ {
+			id, err := binary.ReadUvarint(rd)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if id >= uint64(len(blocks)) {
+				return corruptf("rangearray: collection key %q refers to missing block %d", key, id)
+			}
+			for _, s := range blocks[id] {
+				if !r.appendRun(s.Value, s.Count) {
+					return corruptf("rangearray: collection key %q has overlapping runs", key)
+				}
+			}
+		}
+		m[prev] = r
+	}
+	if rd.Len() != 0 {
+		return errTrailing
+	}
+
+	c.m, c.free = m, nil
+	c.Intern()
+	return nil
+}
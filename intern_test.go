@@ -0,0 +1,87 @@
+package rangearray
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testFleet returns a Collection of arrays that are identical except
+// for a few runs each.
+func testFleet() *Collection {
+	var c Collection
+	for i := uint32(0); i < 20; i++ {
+		key := fmt.Sprintf("sat%02d", i)
+		for x := uint32(0); x < 5000; x++ {
+			if x%7 != 0 && !(x > 2000 && x < 2000+i*3) {
+				c.Push(key, x*2)
+			}
+		}
+	}
+	return &c
+}
+
+func TestCollectionIntern(t *testing.T) {
+	var c Collection
+	var long, short Uint32
+	for x := uint32(0); x < 100; x++ {
+		long.Push(x * 2)
+		if x < 60 {
+			short.Push(x * 2)
+		}
+	}
+	c.Set("long", long.Fork())
+	c.Set("same", long.Fork())
+	c.Set("short", short)
+	c.Set("tiny", Uint32{runs: []Uint32Run{{1, 0, 1}}})
+	c.Intern()
+
+	l, _ := c.Get("long")
+	s, _ := c.Get("same")
+	p, _ := c.Get("short")
+	if &l.runs[0] != &s.runs[0] || &l.runs[0] != &p.runs[0] {
+		t.Errorf("Expected long, same and short to share their runs")
+	}
+	testEqualUint32(t, "short", p, short)
+
+	// Changes to one array do not show in the others.
+	c.Push("short", 1)
+	c.Push("same", 1000)
+	l, _ = c.Get("long")
+	testEqualUint32(t, "long", l, long)
+	if p, _ := c.Get("short"); p.Len() != 61 {
+		t.Errorf("Expected short to hold 61 values, got %d", p.Len())
+	}
+	if s, _ := c.Get("same"); s.Len() != 101 {
+		t.Errorf("Expected same to hold 101 values, got %d", s.Len())
+	}
+}
+
+func TestCollectionShared(t *testing.T) {
+	c := testFleet()
+	data, err := c.MarshalShared()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := c.MarshalBinary()
+	if len(data) > len(plain)/4 {
+		t.Errorf("Expected the shared form to be much smaller than %d bytes, got %d", len(plain), len(data))
+	}
+
+	var got Collection
+	if err := got.UnmarshalShared(data); err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != c.Len() {
+		t.Errorf("Expected %d keys, got %d", c.Len(), got.Len())
+	}
+	for key, r := range c.All() {
+		g, _ := got.Get(key)
+		testEqualUint32(t, key, g, r)
+	}
+
+	for _, bad := range [][]byte{nil, []byte("RS\x02"), data[:len(data)-1], append(data, 0)} {
+		if err := got.UnmarshalShared(bad); err == nil {
+			t.Errorf("Expected an error for %d bytes", len(bad))
+		}
+	}
+}
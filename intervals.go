@@ -0,0 +1,31 @@
+package rangearray
+
+import "fmt"
+
+// ToIntervals returns the runs of r as inclusive [first, last] pairs.
+func (r Uint32) ToIntervals() [][2]uint32 {
+	out := make([][2]uint32, len(r.runs))
+	for i, s := range r.runs {
+		out[i] = [2]uint32{s.Value, s.Value + (s.Count - 1)}
+	}
+	return out
+}
+
+// FromIntervals returns the rangearray holding the values in the
+// inclusive [first, last] pairs in iv.  The intervals must be in
+// increasing order and must not overlap; adjacent intervals are merged.
+func FromIntervals(iv [][2]uint32) (Uint32, error) {
+	out := Uint32{runs: make([]Uint32Run, 0, len(iv))}
+	for i, p := range iv {
+		if p[1] < p[0] {
+			return Uint32{}, fmt.Errorf("rangearray: interval %d [%d, %d] is backwards", i, p[0], p[1])
+		}
+		if uint64(p[1])-uint64(p[0]) == 0xffffffff {
+			return Uint32{}, fmt.Errorf("rangearray: interval %d [%d, %d] is too long", i, p[0], p[1])
+		}
+		if !out.appendRun(p[0], p[1]-p[0]+1) {
+			return Uint32{}, fmt.Errorf("rangearray: interval %d [%d, %d] overlaps or precedes the previous interval", i, p[0], p[1])
+		}
+	}
+	return out, nil
+}
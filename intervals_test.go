@@ -0,0 +1,43 @@
+package rangearray
+
+import (
+	"testing"
+)
+
+func TestIntervals(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 5, 5)
+	pushRange(&r, 100, 199)
+	pushRange(&r, 300, 301)
+
+	iv := r.ToIntervals()
+	if len(iv) != 3 || iv[0] != [2]uint32{5, 5} || iv[1] != [2]uint32{100, 199} || iv[2] != [2]uint32{300, 301} {
+		t.Errorf("Expected ToIntervals() == [[5 5] [100 199] [300 301]], got %v", iv)
+	}
+
+	got, err := FromIntervals(iv)
+	if err != nil {
+		t.Fatalf("FromIntervals() failed: %v", err)
+	}
+	testEqualUint32(t, "FromIntervals(ToIntervals())", got, r)
+
+	got, err = FromIntervals([][2]uint32{{1, 2}, {3, 4}, {0xfffffffe, 0xffffffff}})
+	if err != nil || got.String() != "1-4,4294967294-4294967295" {
+		t.Errorf("Expected merged FromIntervals() == 1-4,4294967294-4294967295, got %v, %v", got, err)
+	}
+
+	if got, err := FromIntervals(nil); err != nil || got.Len() != 0 {
+		t.Errorf("Expected FromIntervals(nil) to be empty, got %v, %v", got, err)
+	}
+
+	for _, iv := range [][][2]uint32{
+		{{2, 1}},
+		{{1, 5}, {5, 6}},
+		{{10, 20}, {1, 2}},
+		{{0, 0xffffffff}},
+	} {
+		if _, err := FromIntervals(iv); err == nil {
+			t.Errorf("Expected FromIntervals(%v) to fail", iv)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package rangearray
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// MarshalJSON implements json.Marshaler.  A rangearray is encoded as a
+// list of [start, count] pairs, one per run, such as [[100,100],[350,100]].
+func (r Uint32) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 2+24*len(r.runs))
+	b = append(b, '[')
+	for i, s := range r.runs {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, '[')
+		b = strconv.AppendUint(b, uint64(s.Value), 10)
+		b = append(b, ',')
+		b = strconv.AppendUint(b, uint64(s.Count), 10)
+		b = append(b, ']')
+	}
+	return append(b, ']'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.  It accepts the format
+// written by MarshalJSON, and replaces the contents of r.  The runs
+// must be in increasing order and must not overlap; adjacent runs are
+// merged.  A JSON null leaves r unchanged.
+func (r *Uint32) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var pairs [][]uint64
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return corruptf("rangearray: %w", err)
+	}
+
+	out := Uint32{runs: make([]Uint32Run, 0, len(pairs))}
+	for i, p := range pairs {
+		if len(p) != 2 {
+			return corruptf("rangearray: run %d has %d elements, not 2", i, len(p))
+		}
+		if p[0] > 0xffffffff || p[1] > 0xffffffff || !out.appendRun(uint32(p[0]), uint32(p[1])) {
+			return corruptf("rangearray: invalid run %d (value %d, count %d)", i, p[0], p[1])
+		}
+	}
+
+	*r = out
+	return nil
+}
@@ -0,0 +1,51 @@
+package rangearray
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONUint32(t *testing.T) {
+	r := testEncodingArray()
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	if want := "[[100,100],[350,100],[1000,1],[4294967295,1]]"; string(b) != want {
+		t.Errorf("Expected json.Marshal(r) == %s, got %s", want, b)
+	}
+	if b, _ := json.Marshal(Uint32{}); string(b) != "[]" {
+		t.Errorf("Expected json.Marshal(Uint32{}) == [], got %s", b)
+	}
+
+	var x Uint32
+	if err := json.Unmarshal(b, &x); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %v", err)
+	}
+	testEqualUint32(t, "x", x, r)
+
+	if err := json.Unmarshal([]byte("[[1,2],[3,4]]"), &x); err != nil {
+		t.Fatalf("json.Unmarshal() of adjacent runs failed: %v", err)
+	}
+	if len(x.runs) != 1 || x.Len() != 6 {
+		t.Errorf("Expected adjacent runs to merge, got %+v", x.runs)
+	}
+}
+
+func TestJSONUint32Errors(t *testing.T) {
+	for _, s := range []string{
+		`{}`,
+		`[[1]]`,
+		`[[1,2,3]]`,
+		`[[-1,2]]`,
+		`[[4294967296,1]]`,
+		`[[1,0]]`,
+		`[[10,5],[12,1]]`,
+		`[[10,5],[1,1]]`,
+	} {
+		var x Uint32
+		if err := json.Unmarshal([]byte(s), &x); err == nil {
+			t.Errorf("Expected json.Unmarshal(%s) to fail", s)
+		}
+	}
+}
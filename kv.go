@@ -0,0 +1,293 @@
+package rangearray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// KV is an embedded, ordered key/value store, such as bbolt, Badger or
+// Pebble, for a KVStore to keep its arrays in.  An adapter for one of
+// those is typically a few lines long; DirKV is a reference
+// implementation over a directory of files.
+type KV interface {
+	// Get returns the value under key, or nil if there is none.
+	Get(key []byte) ([]byte, error)
+
+	// Put stores value under key, replacing any value already there.
+	Put(key, value []byte) error
+
+	// Delete removes key and its value, if it exists.
+	Delete(key []byte) error
+
+	// Scan calls yield for each key from start up to but not including
+	// end, in increasing byte order, with its value, until yield
+	// returns false.  yield must not keep key or value after it
+	// returns.
+	Scan(start, end []byte, yield func(key, value []byte) bool) error
+}
+
+// DefaultKVPeriod is the number of values in each period of a KVStore,
+// if KVOptions.Period is zero: one day, for values in seconds.
+const DefaultKVPeriod = 86400
+
+// KVOptions configures a KVStore.
+type KVOptions struct {
+	// Period is the number of values in each period.  If it is zero,
+	// DefaultKVPeriod is used.
+	Period uint32
+
+	// Encoder sets how each period's values are stored, and Decoder how
+	// they are read.
+	Encoder Encoder
+	Decoder Decoder
+}
+
+var errKVName = errors.New("rangearray: KVStore name contains a NUL byte")
+
+// KVStore keeps named arrays in a KV store, with the values of each
+// array split into periods, such as days, each stored under its own
+// key.  An array's key for a period is its name, a NUL byte, and the
+// period number as a big-endian uint32, so the periods of an array are
+// adjacent and in order in the store, and LoadRange reads only the
+// periods it needs.  Each value is a rangearray in the binary format.
+//
+// A KVStore is safe for concurrent use if its KV is.
+type KVStore struct {
+	kv   KV
+	opts KVOptions
+}
+
+// NewKVStore returns a KVStore that keeps its arrays in kv.
+func NewKVStore(kv KV, opts KVOptions) *KVStore {
+	if opts.Period == 0 {
+		opts.Period = DefaultKVPeriod
+	}
+	return &KVStore{kv: kv, opts: opts}
+}
+
+// periodKey returns the key for the given period of the array name.
+func periodKey(name string, period uint32) []byte {
+	b := append([]byte(name), 0)
+	return binary.BigEndian.AppendUint32(b, period)
+}
+
+// nameRange returns the range of keys that Scan needs for the periods
+// first through last of the array name.
+func nameRange(name string, first, last uint32) (start, end []byte) {
+	start = periodKey(name, first)
+	if last == ^uint32(0) {
+		return start, append([]byte(name), 1)
+	}
+	return start, periodKey(name, last+1)
+}
+
+// Save replaces the array name in s with r.  It writes only the periods
+// whose values changed, and deletes those that are now empty.
+func (s *KVStore) Save(name string, r Uint32) error {
+	if strings.IndexByte(name, 0) >= 0 {
+		return errKVName
+	}
+
+	// Note the periods that exist now, and their contents.
+	old := map[uint32][]byte{}
+	start, end := nameRange(name, 0, ^uint32(0))
+	err := s.kv.Scan(start, end, func(key, value []byte) bool {
+		old[binary.BigEndian.Uint32(key[len(key)-4:])] = bytes.Clone(value)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	p := s.opts.Period
+	var part Uint32
+	flush := func(period uint32) error {
+		if len(part.runs) == 0 {
+			return nil
+		}
+		data, err := s.opts.Encoder.Marshal(part)
+		part = Uint32{}
+		if err != nil {
+			return err
+		}
+		prev, ok := old[period]
+		delete(old, period)
+		if ok && bytes.Equal(prev, data) {
+			return nil
+		}
+		return s.kv.Put(periodKey(name, period), data)
+	}
+
+	// Split the runs of r at period boundaries.
+	current := uint32(0)
+	for _, run := range r.runs {
+		value, end := uint64(run.Value), uint64(run.Value)+uint64(run.Count)
+		for value < end {
+			period := uint32(value / uint64(p))
+			if period != current {
+				if err := flush(current); err != nil {
+					return err
+				}
+				current = period
+			}
+			stop := min(end, (uint64(period)+1)*uint64(p))
+			part.appendRun(uint32(value), uint32(stop-value))
+			value = stop
+		}
+	}
+	if err := flush(current); err != nil {
+		return err
+	}
+
+	for period := range old {
+		if err := s.kv.Delete(periodKey(name, period)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load returns the whole array name from s.  An array that was never
+// saved is empty.
+func (s *KVStore) Load(name string) (Uint32, error) {
+	return s.LoadRange(name, 0, ^uint32(0))
+}
+
+// LoadRange returns the values of the array name from first through
+// last, inclusive, reading only the periods that hold them.
+func (s *KVStore) LoadRange(name string, first, last uint32) (Uint32, error) {
+	if strings.IndexByte(name, 0) >= 0 {
+		return Uint32{}, errKVName
+	}
+	var out Uint32
+	var derr error
+	start, end := nameRange(name, first/s.opts.Period, last/s.opts.Period)
+	err := s.kv.Scan(start, end, func(key, value []byte) bool {
+		var part Uint32
+		if derr = s.opts.Decoder.Unmarshal(value, &part); derr != nil {
+			return false
+		}
+		for iv := range part.IntervalsIn(first, last) {
+			if !out.appendRun(iv[0], iv[1]-iv[0]+1) {
+				derr = errors.New("rangearray: KVStore periods overlap")
+				return false
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = derr
+	}
+	if err != nil {
+		return Uint32{}, err
+	}
+	return out, nil
+}
+
+// Periods returns the numbers of the non-empty periods of the array
+// name, in increasing order.  Period n holds the values from n*Period
+// up to (n+1)*Period.
+func (s *KVStore) Periods(name string) ([]uint32, error) {
+	var out []uint32
+	start, end := nameRange(name, 0, ^uint32(0))
+	err := s.kv.Scan(start, end, func(key, value []byte) bool {
+		out = append(out, binary.BigEndian.Uint32(key[len(key)-4:]))
+		return true
+	})
+	return out, err
+}
+
+// Delete removes the array name from s.
+func (s *KVStore) Delete(name string) error {
+	periods, err := s.Periods(name)
+	if err != nil {
+		return err
+	}
+	for _, period := range periods {
+		if err := s.kv.Delete(periodKey(name, period)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DirKV returns a KV that keeps each key in its own file in dir,
+// creating dir if needed.  File names are the keys in hexadecimal, so
+// their order is the order of the keys.  Each Put replaces its file
+// atomically.  It suits small deployments and tests; larger ones
+// should use a real embedded store.
+func DirKV(dir string) (KV, error) {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, err
+	}
+	return dirKV(dir), nil
+}
+
+type dirKV string
+
+func (d dirKV) path(key []byte) string {
+	return filepath.Join(string(d), hex.EncodeToString(key)+".kv")
+}
+
+func (d dirKV) Get(key []byte) ([]byte, error) {
+	data, err := os.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (d dirKV) Put(key, value []byte) error {
+	w, err := DirWriteFS(string(d)).Create(filepath.Base(d.path(key)))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(value)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d dirKV) Delete(key []byte) error {
+	err := os.Remove(d.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (d dirKV) Scan(start, end []byte, yield func(key, value []byte) bool) error {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return err
+	}
+	var keys [][]byte
+	for _, ent := range entries {
+		name, ok := strings.CutSuffix(ent.Name(), ".kv")
+		key, err := hex.DecodeString(name)
+		if !ok || err != nil {
+			continue
+		}
+		if bytes.Compare(key, start) >= 0 && bytes.Compare(key, end) < 0 {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, bytes.Compare)
+	for _, key := range keys {
+		value, err := d.Get(key)
+		if err != nil {
+			return err
+		}
+		if value != nil && !yield(key, value) {
+			return nil
+		}
+	}
+	return nil
+}
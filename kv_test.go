@@ -0,0 +1,98 @@
+package rangearray
+
+import (
+	"slices"
+	"testing"
+)
+
+// countingKV counts the calls to a KV.
+type countingKV struct {
+	KV
+	puts, scanned int
+}
+
+func (c *countingKV) Put(key, value []byte) error {
+	c.puts++
+	return c.KV.Put(key, value)
+}
+
+func (c *countingKV) Scan(start, end []byte, yield func(key, value []byte) bool) error {
+	return c.KV.Scan(start, end, func(key, value []byte) bool {
+		c.scanned++
+		return yield(key, value)
+	})
+}
+
+func TestKVStore(t *testing.T) {
+	dir, err := DirKV(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := &countingKV{KV: dir}
+	s := NewKVStore(kv, KVOptions{Period: 100, Encoder: Encoder{Encoding: Varint}})
+
+	var r Uint32
+	pushRange(&r, 50, 250) // crosses two period boundaries
+	pushRange(&r, 520, 530)
+	pushRange(&r, 0xfffffff0, 0xfffffffe)
+	r.Push(0xffffffff)
+	if err := s.Save("sat-a", r); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("sat-b", testEncodingArray()); err != nil {
+		t.Fatal(err)
+	}
+
+	periods, err := s.Periods("sat-a")
+	if want := []uint32{0, 1, 2, 5, 0xffffffff / 100}; err != nil || !slices.Equal(periods, want) {
+		t.Errorf("Expected periods %v, got %v, %v", want, periods, err)
+	}
+	got, err := s.Load("sat-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "Load", got, r)
+
+	kv.scanned = 0
+	got, err = s.LoadRange("sat-a", 120, 199)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want Uint32
+	pushRange(&want, 120, 199)
+	testEqualUint32(t, "LoadRange", got, want)
+	if kv.scanned != 1 {
+		t.Errorf("Expected LoadRange to read 1 period, got %d", kv.scanned)
+	}
+
+	// Saving a changed array rewrites only the periods that changed.
+	kv.puts = 0
+	r.Push(600)
+	if err := s.Save("sat-a", r); err != nil {
+		t.Fatal(err)
+	}
+	if kv.puts != 1 {
+		t.Errorf("Expected 1 period to be written, got %d", kv.puts)
+	}
+	var smaller Uint32
+	pushRange(&smaller, 50, 60)
+	if err := s.Save("sat-a", smaller); err != nil {
+		t.Fatal(err)
+	}
+	if periods, _ := s.Periods("sat-a"); !slices.Equal(periods, []uint32{0}) {
+		t.Errorf("Expected only period 0 to remain, got %v", periods)
+	}
+
+	if err := s.Delete("sat-a"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Load("sat-a"); err != nil || len(got.runs) != 0 {
+		t.Errorf("Expected sat-a to be empty, got %v, %v", got, err)
+	}
+	if got, err := s.Load("sat-b"); err != nil || got.Len() != testEncodingArray().Len() {
+		t.Errorf("Expected sat-b to be unchanged, got %v, %v", got, err)
+	}
+	if err := s.Save("bad\x00name", r); err != errKVName {
+		t.Errorf("Expected errKVName, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package rangearray
+
+import (
+	"iter"
+	"sort"
+)
+
+// Lean is a mutable rangearray whose runs store only a start value and
+// a count.  Rather than an Index in every run, it keeps the number of
+// values before every leanStride'th run, and rebuilds those sums when a
+// query needs them after a change.  That makes each run two thirds the
+// size of a Uint32Run, and a Push before the end only moves the runs
+// after it, rather than also fixing up all their indexes.  Queries
+// still take O(log n) time once the sums are up to date, so Lean suits
+// workloads that alternate long phases of loading and of reading.
+//
+// Lean is an opt-in backend.  Uint32 and the types built on it keep
+// their Index fields; use LeanOf and Lean.Uint32 to convert between
+// the two.
+//
+// The zero value is empty.  Since queries can rebuild the sums, a Lean
+// is not safe for concurrent use, even by readers, unless Sync has been
+// called since the last change.
+type Lean struct {
+	runs []leanRun
+
+	// prefix[j] is the number of values before runs[j*leanStride].
+	// Entries from dirty on are out of date.
+	prefix []uint32
+	dirty  int
+
+	total uint32
+}
+
+// leanRun is a run in a Lean.
+type leanRun struct {
+	value, count uint32
+}
+
+// end returns the value after the last value of s.
+func (s leanRun) end() uint64 {
+	return uint64(s.value) + uint64(s.count)
+}
+
+// leanStride is the number of runs between the sums that a Lean keeps.
+const leanStride = 16
+
+// LeanOf returns a Lean holding the values in r.
+func LeanOf(r Uint32) *Lean {
+	l := &Lean{runs: make([]leanRun, 0, len(r.runs))}
+	for _, s := range r.runs {
+		l.runs = append(l.runs, leanRun{value: s.Value, count: s.Count})
+		l.total += s.Count
+	}
+	return l
+}
+
+// Push adds x to l, and reports whether x was added, as Uint32.Push
+// does.
+func (l *Lean) Push(x uint32) bool {
+	if l.total == MaxLen {
+		return false
+	}
+	n := len(l.runs) - 1
+	if n < 0 || l.runs[n].end() < uint64(x) {
+		// A new run at the end keeps the sums up to date.
+		if len(l.runs)%leanStride == 0 && l.dirty == len(l.prefix) && len(l.prefix) == len(l.runs)/leanStride {
+			l.prefix = append(l.prefix, l.total)
+			l.dirty++
+		}
+		l.runs = append(l.runs, leanRun{value: x, count: 1})
+		l.total++
+		return true
+	}
+	if l.runs[n].end() == uint64(x) {
+		l.runs[n].count++
+		l.total++
+		return true
+	}
+
+	i := l.LowerBound(x)
+	if x >= l.runs[i].value {
+		return false
+	}
+	l.total++
+
+	afterPrev := i > 0 && uint64(x) == l.runs[i-1].end()
+	switch {
+	case x+1 == l.runs[i].value && afterPrev:
+		l.runs[i-1].count += l.runs[i].count + 1
+		l.runs = append(l.runs[:i], l.runs[i+1:]...)
+		i--
+	case x+1 == l.runs[i].value:
+		l.runs[i].value--
+		l.runs[i].count++
+	case afterPrev:
+		l.runs[i-1].count++
+		i--
+	default:
+		l.runs = append(l.runs, leanRun{})
+		copy(l.runs[i+1:], l.runs[i:])
+		l.runs[i] = leanRun{value: x, count: 1}
+	}
+
+	// The sums up to and including run i are unchanged.
+	l.dirty = min(l.dirty, i/leanStride+1)
+	if debugChecks {
+		checkReader("Lean.Push", l)
+	}
+	return true
+}
+
+// Sync brings the sums in l up to date, so that queries do not change
+// l until it is next modified.
+func (l *Lean) Sync() {
+	want := (len(l.runs) + leanStride - 1) / leanStride
+	if l.dirty >= want && len(l.prefix) == want {
+		return
+	}
+	l.prefix = l.prefix[:min(len(l.prefix), l.dirty, want)]
+	if len(l.prefix) == 0 && want > 0 {
+		l.prefix = append(l.prefix, 0)
+	}
+	for j := len(l.prefix); j < want; j++ {
+		sum := l.prefix[j-1]
+		for _, s := range l.runs[(j-1)*leanStride : j*leanStride] {
+			sum += s.count
+		}
+		l.prefix = append(l.prefix, sum)
+	}
+	l.dirty = want
+}
+
+// index returns the number of values before runs[i].
+func (l *Lean) index(i int) uint32 {
+	if i == len(l.runs) {
+		return l.total
+	}
+	l.Sync()
+	j := i / leanStride
+	sum := l.prefix[j]
+	for _, s := range l.runs[j*leanStride : i] {
+		sum += s.count
+	}
+	return sum
+}
+
+// Min returns the minimum value in l.  Panics if l is empty.
+func (l *Lean) Min() uint32 {
+	return l.runs[0].value
+}
+
+// Max returns the maximum value in l.  Panics if l is empty.
+func (l *Lean) Max() uint32 {
+	last := l.runs[len(l.runs)-1]
+	return last.value + (last.count - 1)
+}
+
+// Len returns the number of elements in l.
+func (l *Lean) Len() uint32 {
+	return l.total
+}
+
+// NumRuns returns the number of runs in l.
+func (l *Lean) NumRuns() int {
+	return len(l.runs)
+}
+
+// IndexOf returns the number of elements in l that are less than x.
+func (l *Lean) IndexOf(x uint32) uint32 {
+	i := l.LowerBound(x)
+	index := l.index(i)
+	if i < len(l.runs) && x > l.runs[i].value {
+		index += x - l.runs[i].value
+	}
+	return index
+}
+
+// LowerBound returns the index of the run in l that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.  It does not need the sums,
+// so it never changes l.
+func (l *Lean) LowerBound(x uint32) int {
+	return sort.Search(len(l.runs), func(i int) bool {
+		return l.runs[i].end() > uint64(x)
+	})
+}
+
+// Contains reports whether x is in l.
+func (l *Lean) Contains(x uint32) bool {
+	i := l.LowerBound(x)
+	return i < len(l.runs) && x >= l.runs[i].value
+}
+
+// All returns an iterator over the values in l, in increasing order.
+func (l *Lean) All() iter.Seq[uint32] {
+	return valuesOf(l.Runs())
+}
+
+// Runs returns an iterator over the runs in l, in increasing order.
+func (l *Lean) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		var index uint32
+		for _, s := range l.runs {
+			if !yield(Uint32Run{Value: s.value, Index: index, Count: s.count}) {
+				return
+			}
+			index += s.count
+		}
+	}
+}
+
+// Uint32 returns a copy of l as an ordinary array.
+func (l *Lean) Uint32() Uint32 {
+	out := Uint32{runs: make([]Uint32Run, 0, len(l.runs))}
+	for s := range l.Runs() {
+		out.runs = append(out.runs, s)
+	}
+	return out
+}
+
+// String returns l in the format of Uint32.String.
+func (l *Lean) String() string {
+	return l.Uint32().String()
+}
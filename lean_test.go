@@ -0,0 +1,52 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+	"unsafe"
+)
+
+func TestLean(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	var l Lean
+	var want Uint32
+	for i := 0; i < 6000; i++ {
+		// Load mostly in order, with some late values.
+		x := uint32(3 * i)
+		if i%4 == 0 {
+			x = rng.Uint32N(uint32(3*i + 1))
+		}
+		l.Push(x)
+		want.Push(x)
+		if i%250 == 0 {
+			for y := uint32(0); y < x+5; y += 11 {
+				if l.IndexOf(y) != want.IndexOf(y) {
+					t.Fatalf("Expected IndexOf(%d) = %d after %d pushes, got %d", y, want.IndexOf(y), i, l.IndexOf(y))
+				}
+			}
+		}
+	}
+
+	testEqualUint32(t, "l", l.Uint32(), want)
+	if l.Len() != want.Len() || l.NumRuns() != len(want.runs) || l.Min() != want.Min() || l.Max() != want.Max() {
+		t.Errorf("Expected %d values in %d runs, got %d in %d", want.Len(), len(want.runs), l.Len(), l.NumRuns())
+	}
+	l.Sync()
+	for x := uint32(0); x < 18010; x++ {
+		if l.Contains(x) != want.Contains(x) || l.IndexOf(x) != want.IndexOf(x) || l.LowerBound(x) != want.LowerBound(x) {
+			t.Fatalf("Expected queries for %d to match, got %v, %d, %d", x, l.Contains(x), l.IndexOf(x), l.LowerBound(x))
+		}
+	}
+
+	m := LeanOf(want)
+	m.Push(20000)
+	want.Push(20000)
+	testEqualUint32(t, "LeanOf()", m.Uint32(), want)
+	if m.IndexOf(20000) != want.IndexOf(20000) || m.String() != want.String() {
+		t.Errorf("Expected %v, got %v", want, m)
+	}
+
+	if size := unsafe.Sizeof(leanRun{}); 3*size != 2*unsafe.Sizeof(Uint32Run{}) {
+		t.Errorf("Expected a leanRun to be two thirds of a Uint32Run, got %d bytes", size)
+	}
+}
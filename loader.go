@@ -0,0 +1,131 @@
+package rangearray
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LoadOptions configures LoadFiles, LoadFilesFS and MergeFiles.
+type LoadOptions struct {
+	// Workers is the number of files decoded at once.  If it is zero or
+	// negative, GOMAXPROCS is used.
+	Workers int
+
+	// Key returns the collection key for a path.  If it is nil, the
+	// base name of the path without its extension is used.
+	Key func(path string) string
+
+	// Decode decodes the contents of a file.  If it is nil, the binary
+	// format is decoded with a zero Decoder.
+	Decode func(data []byte) (Uint32, error)
+
+	// Progress reports the files loaded.  Func is called from the
+	// worker goroutines, but never concurrently.
+	Progress Progress
+}
+
+// LoadFiles reads and decodes the files at paths concurrently, and
+// returns them as a Collection.  If any file fails, the files not yet
+// started are skipped and the first error is returned.  Two paths with
+// the same key are an error.
+func LoadFiles(paths []string, opts LoadOptions) (*Collection, error) {
+	return LoadFilesContext(context.Background(), paths, opts)
+}
+
+// LoadFilesContext is like LoadFiles, but stops with ctx's error if ctx
+// is done before every file is loaded.  The default decoder also stops
+// partway through a file.
+func LoadFilesContext(ctx context.Context, paths []string, opts LoadOptions) (*Collection, error) {
+	return loadFiles(ctx, os.ReadFile, paths, opts)
+}
+
+// LoadFilesFS is like LoadFiles, but reads the files from fsys, such
+// as an embed.FS or a zip archive.
+func LoadFilesFS(fsys fs.FS, paths []string, opts LoadOptions) (*Collection, error) {
+	return LoadFilesFSContext(context.Background(), fsys, paths, opts)
+}
+
+// LoadFilesFSContext is like LoadFilesContext, but reads the files from
+// fsys.
+func LoadFilesFSContext(ctx context.Context, fsys fs.FS, paths []string, opts LoadOptions) (*Collection, error) {
+	return loadFiles(ctx, func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}, paths, opts)
+}
+
+// loadFiles implements LoadFilesContext and LoadFilesFSContext, using
+// readFile to read each file.
+func loadFiles(ctx context.Context, readFile func(string) ([]byte, error), paths []string, opts LoadOptions) (*Collection, error) {
+	key := opts.Key
+	if key == nil {
+		key = func(path string) string {
+			base := filepath.Base(path)
+			return strings.TrimSuffix(base, filepath.Ext(base))
+		}
+	}
+	decode := opts.Decode
+	if decode == nil {
+		decode = func(data []byte) (r Uint32, err error) {
+			err = Decoder{}.UnmarshalContext(ctx, data, &r)
+			return
+		}
+	}
+
+	keys := make(map[string]string, len(paths))
+	for _, path := range paths {
+		k := key(path)
+		if prev, ok := keys[k]; ok {
+			return nil, fmt.Errorf("rangearray: %s and %s have the same key %q", prev, path, k)
+		}
+		keys[k] = path
+	}
+
+	pr := opts.Progress.start(OpLoadFiles, int64(len(paths)))
+	var (
+		mu       sync.Mutex
+		firstErr error
+		out      Collection
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+	err := parallelFor(ctx, len(paths), opts.Workers, func(i int) {
+		if failed() {
+			return
+		}
+		data, err := readFile(paths[i])
+		var r Uint32
+		if err == nil {
+			r, err = decode(data)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", paths[i], err)
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		out.Set(key(paths[i]), r)
+		pr.add(1)
+	})
+	if firstErr == nil {
+		firstErr = err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	pr.finish()
+	return &out, nil
+}
@@ -0,0 +1,104 @@
+package rangearray
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := uint32(0); i < 20; i++ {
+		var r Uint32
+		pushRange(&r, i*10, i*10+i)
+		data, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "sat"+string(rune('a'+i))+".ra")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	c, err := LoadFiles(paths, LoadOptions{Workers: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if c.Len() != 20 {
+		t.Errorf("Expected 20 keys, got %d", c.Len())
+	}
+	if r, ok := c.Get("satc"); !ok || r.Len() != 3 || !r.Contains(22) {
+		t.Errorf("Expected satc to hold 20-22, got %v", r)
+	}
+
+	bad := filepath.Join(dir, "bad.ra")
+	if err := os.WriteFile(bad, []byte("junk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadFiles(append(paths, bad), LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "bad.ra") {
+		t.Errorf("Expected an error naming bad.ra, got %v", err)
+	}
+	_, err = LoadFiles([]string{filepath.Join(dir, "missing.ra")}, LoadOptions{})
+	if err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+	_, err = LoadFiles([]string{paths[0], filepath.Join(dir, "x", "sata.ra")}, LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "same key") {
+		t.Errorf("Expected a duplicate key error, got %v", err)
+	}
+
+	c, err = LoadFiles(paths[:2], LoadOptions{Key: filepath.Base})
+	if err != nil || c.Len() != 2 {
+		t.Errorf("Expected 2 keys, got %v", err)
+	}
+	if _, ok := c.Get("sata.ra"); !ok {
+		t.Errorf("Expected a custom key, got %v", c.Keys())
+	}
+}
+
+func TestLoadFilesContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	path := filepath.Join(t.TempDir(), "a.ra")
+	data, _ := Uint32{}.MarshalBinary()
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFilesContext(ctx, []string{path}, LoadOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected LoadFilesContext() to be canceled, got %v", err)
+	}
+}
+
+func TestLoadFilesFS(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := uint32(0); i < 5; i++ {
+		var r Uint32
+		pushRange(&r, i, i*2+1)
+		data, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys["data/"+string(rune('a'+i))+".ra"] = &fstest.MapFile{Data: data}
+	}
+	paths, err := fs.Glob(fsys, "data/*.ra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadFilesFS(fsys, paths, LoadOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if r, ok := c.Get("c"); c.Len() != 5 || !ok || r.Len() != 4 {
+		t.Errorf("Expected 5 keys with c holding 4 values, got %d, %v", c.Len(), r)
+	}
+}
@@ -0,0 +1,179 @@
+package rangearray
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A saved Collection is a directory holding one file per key and a
+// manifest.  The manifest, named "MANIFEST", is a JSON object:
+//
+//	{"version": 1, "generation": 3, "keys": [
+//		{"key": "sat-a", "file": "3-0.ra", "len": 120, "runs": 4},
+//		...
+//	]}
+//
+// Each key's file holds its array in the binary format, with the
+// Varint encoding and a checksum.  Files are named for the generation
+// of the Save that wrote them, which increases by one each time, and
+// the position of their key in the manifest.  A Save writes every file,
+// then replaces the manifest atomically, then removes the files of
+// earlier generations, so a crash at any point leaves a directory that
+// loads as either the old or the new collection.
+
+const (
+	manifestName    = "MANIFEST"
+	manifestVersion = 1
+)
+
+// manifest is the parsed form of a collection directory's manifest.
+type manifest struct {
+	Version    int             `json:"version"`
+	Generation uint64          `json:"generation"`
+	Keys       []manifestEntry `json:"keys"`
+}
+
+// manifestEntry describes one key in a manifest.
+type manifestEntry struct {
+	Key  string `json:"key"`
+	File string `json:"file"`
+	Len  uint32 `json:"len"`
+	Runs int    `json:"runs"`
+}
+
+// readManifest reads and checks a manifest, using readFile.
+func readManifest(readFile func(string) ([]byte, error)) (manifest, error) {
+	data, err := readFile(manifestName)
+	if err != nil {
+		return manifest{}, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return manifest{}, corruptf("rangearray: %s: %w", manifestName, err)
+	}
+	if m.Version != manifestVersion {
+		return manifest{}, versionf("rangearray: unsupported manifest version %d", m.Version)
+	}
+	for _, ent := range m.Keys {
+		if !fs.ValidPath(ent.File) || strings.Contains(ent.File, "/") || ent.File == manifestName {
+			return manifest{}, corruptf("rangearray: invalid file name %q in %s", ent.File, manifestName)
+		}
+	}
+	return m, nil
+}
+
+// Save writes c to dir, creating dir if needed, in the layout
+// described above.  It replaces any collection saved there before,
+// atomically: a crash during Save leaves either the old or the new
+// collection.  Files in dir that are not part of a saved collection
+// are left alone.
+func (c *Collection) Save(dir string) error {
+	m := manifest{Version: manifestVersion, Generation: 1}
+	old, err := readManifest(func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, name))
+	})
+	if err == nil {
+		m.Generation = old.Generation + 1
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := c.saveFS(DirWriteFS(dir), m); err != nil {
+		return err
+	}
+
+	// Remove the files of earlier generations, which are now unused.
+	for _, ent := range old.Keys {
+		if err := os.Remove(filepath.Join(dir, ent.File)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveFS writes the files of c and then its manifest to fsys, with the
+// version and generation in m.
+func (c *Collection) saveFS(fsys WriteFS, m manifest) error {
+	e := Encoder{Encoding: Varint, Checksum: true}
+	for i, key := range c.Keys() {
+		r := c.m[key]
+		ent := manifestEntry{
+			Key:  key,
+			File: fmt.Sprintf("%d-%d.ra", m.Generation, i),
+			Len:  r.Len(),
+			Runs: len(r.runs),
+		}
+		if err := e.SaveFS(fsys, ent.File, *r); err != nil {
+			return err
+		}
+		m.Keys = append(m.Keys, ent)
+	}
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	w, err := fsys.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Load replaces the contents of c with the collection saved in dir by
+// Save.
+func (c *Collection) Load(dir string) error {
+	return c.LoadFS(os.DirFS(dir), nil)
+}
+
+// LoadKeys is like Load, but loads only the keys for which keep
+// returns true, and does not read the other files at all.
+func (c *Collection) LoadKeys(dir string, keep func(key string) bool) error {
+	return c.LoadFS(os.DirFS(dir), keep)
+}
+
+// LoadFS replaces the contents of c with the collection saved at the
+// root of fsys, such as a directory embedded with embed.FS or unpacked
+// from an archive.  If keep is not nil, only the keys for which it
+// returns true are loaded.  The files are read concurrently.
+func (c *Collection) LoadFS(fsys fs.FS, keep func(key string) bool) error {
+	return c.load(func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}, keep)
+}
+
+// load implements LoadFS, using readFile to read the manifest and the
+// files of the collection.
+func (c *Collection) load(readFile func(string) ([]byte, error), keep func(key string) bool) error {
+	m, err := readManifest(readFile)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]string, len(m.Keys))
+	var paths []string
+	for _, ent := range m.Keys {
+		if keep == nil || keep(ent.Key) {
+			keys[ent.File] = ent.Key
+			paths = append(paths, ent.File)
+		}
+	}
+
+	out, err := loadFiles(context.Background(), readFile, paths, LoadOptions{
+		Key: func(path string) string { return keys[path] },
+	})
+	if err != nil {
+		return err
+	}
+	*c = *out
+	return nil
+}
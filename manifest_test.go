@@ -0,0 +1,86 @@
+package rangearray
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCollectionSaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "coll")
+	var c Collection
+	for i := uint32(0); i < 10; i++ {
+		for x := uint32(0); x < i; x++ {
+			c.Push("sat/"+string(rune('a'+i)), x*3)
+		}
+	}
+	c.Set("empty", Uint32{})
+	if err := c.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Collection
+	if err := got.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got.Keys(), c.Keys()) {
+		t.Errorf("Expected keys %v, got %v", c.Keys(), got.Keys())
+	}
+	for key, r := range c.All() {
+		g, _ := got.Get(key)
+		testEqualUint32(t, key, g, r)
+	}
+
+	// Saving again replaces the files of the first save.
+	c.Delete("empty")
+	c.Push("sat/a", 100)
+	if err := c.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != c.Len()+1 {
+		t.Errorf("Expected %d files, got %v", c.Len()+1, files)
+	}
+	if err := got.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Get("empty"); ok || got.Len() != c.Len() {
+		t.Errorf("Expected the second save's %d keys, got %v", c.Len(), got.Keys())
+	}
+	if r, _ := got.Get("sat/a"); r.Len() != 1 || !r.Contains(100) {
+		t.Errorf("Expected sat/a to hold 100, got %v", r)
+	}
+
+	var part Collection
+	err := part.LoadKeys(dir, func(key string) bool { return key >= "sat/h" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"sat/h", "sat/i", "sat/j"}; !slices.Equal(part.Keys(), want) {
+		t.Errorf("Expected keys %v, got %v", want, part.Keys())
+	}
+}
+
+func TestCollectionLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	var c Collection
+	if err := c.Load(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error, got %v", err)
+	}
+
+	manifest := filepath.Join(dir, "MANIFEST")
+	for _, data := range []string{
+		`{"version": 2}`,
+		`{"version": 1, "keys": [{"key": "a", "file": "../a.ra"}]}`,
+		`{"version": 1, "keys": [{"key": "a", "file": "missing.ra"}]}`,
+		`not json`,
+	} {
+		if err := os.WriteFile(manifest, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Load(dir); err == nil {
+			t.Errorf("Expected an error for manifest %s", data)
+		}
+	}
+}
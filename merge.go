@@ -0,0 +1,110 @@
+package rangearray
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// MergeStats describes how the chunks that MergeChunks merges overlap.
+// For chunks that each cover a separate range of values, only Chunks
+// and Values are non-zero.
+type MergeStats struct {
+	// Chunks is the number of chunks merged, and Values the total
+	// number of values in them, counting each copy of a value.
+	Chunks int
+	Values uint64
+
+	// Duplicates is the number of extra copies of values that are in
+	// more than one chunk.
+	Duplicates uint64
+
+	// Overlapping is the number of chunks whose range, from their
+	// minimum to their maximum, overlaps that of another chunk.
+	Overlapping int
+
+	// Conflicts is the number of values that are within the range of a
+	// chunk but missing from it, though another chunk has them, and
+	// ConflictRanges the number of runs of such values.  Each chunk's
+	// missing values count separately.  Conflicts usually mean that a
+	// chunk is incomplete, or that the feeds disagree.
+	Conflicts      uint64
+	ConflictRanges int
+}
+
+// MergeChunks returns the union of chunks, such as the same period
+// downloaded twice or collected by redundant feeds, and statistics on
+// where they overlap and disagree.
+func MergeChunks(chunks ...Uint32) (Uint32, MergeStats) {
+	out := NewUnionView(chunks...).Materialize()
+	stats := MergeStats{Chunks: len(chunks)}
+
+	type span struct{ min, max uint32 }
+	var spans []span
+	for _, c := range chunks {
+		if len(c.runs) == 0 {
+			continue
+		}
+		stats.Values += uint64(c.Len())
+
+		// Every value of c is in out, so the difference between the two
+		// within the range of c is what c lacks.
+		var within Uint32
+		for iv := range out.IntervalsIn(c.Min(), c.Max()) {
+			within.appendRun(iv[0], iv[1]-iv[0]+1)
+		}
+		missing := AppendDifference(nil, within, c)
+		stats.ConflictRanges += len(missing)
+		for _, s := range missing {
+			stats.Conflicts += uint64(s.Count)
+		}
+		spans = append(spans, span{c.Min(), c.Max()})
+	}
+	stats.Duplicates = stats.Values - uint64(out.Len())
+
+	// A span overlaps another if it starts before the end of the spans
+	// that start before it, or if a span that starts after it starts
+	// before its end.
+	sort.Slice(spans, func(i, j int) bool { return spans[i].min < spans[j].min })
+	var end uint64
+	for i, s := range spans {
+		prev := i > 0 && uint64(s.min) < end
+		next := i+1 < len(spans) && spans[i+1].min <= s.max
+		if prev || next {
+			stats.Overlapping++
+		}
+		end = max(end, uint64(s.max)+1)
+	}
+	return out, stats
+}
+
+// MergeFiles reads and decodes the files at paths concurrently, as
+// LoadFiles does, and merges them into one array with MergeChunks.
+// opts.Key is not used.
+func MergeFiles(paths []string, opts LoadOptions) (Uint32, MergeStats, error) {
+	return mergeFiles(os.ReadFile, paths, opts)
+}
+
+// MergeFilesFS is like MergeFiles, but reads the files from fsys.
+func MergeFilesFS(fsys fs.FS, paths []string, opts LoadOptions) (Uint32, MergeStats, error) {
+	return mergeFiles(func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}, paths, opts)
+}
+
+// mergeFiles implements MergeFiles and MergeFilesFS, using readFile to
+// read each file.
+func mergeFiles(readFile func(string) ([]byte, error), paths []string, opts LoadOptions) (Uint32, MergeStats, error) {
+	opts.Key = func(path string) string { return path }
+	c, err := loadFiles(context.Background(), readFile, paths, opts)
+	if err != nil {
+		return Uint32{}, MergeStats{}, err
+	}
+	chunks := make([]Uint32, len(paths))
+	for i, path := range paths {
+		chunks[i], _ = c.Get(path)
+	}
+	out, stats := MergeChunks(chunks...)
+	return out, stats, nil
+}
@@ -0,0 +1,67 @@
+package rangearray
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeChunks(t *testing.T) {
+	var a, b, c Uint32
+	pushRange(&a, 0, 99)
+	pushRange(&b, 50, 59)   // duplicates part of a
+	pushRange(&b, 70, 149)  // overlaps a, and has 60-69 missing
+	pushRange(&c, 200, 209) // separate from the others
+
+	out, stats := MergeChunks(a, b, c)
+	var want Uint32
+	pushRange(&want, 0, 149)
+	pushRange(&want, 200, 209)
+	testEqualUint32(t, "out", out, want)
+
+	wantStats := MergeStats{
+		Chunks:         3,
+		Values:         100 + 90 + 10,
+		Duplicates:     40,
+		Overlapping:    2,
+		Conflicts:      10,
+		ConflictRanges: 1,
+	}
+	if stats != wantStats {
+		t.Errorf("Expected %+v, got %+v", wantStats, stats)
+	}
+
+	if _, stats := MergeChunks(a, c, Uint32{}); stats != (MergeStats{Chunks: 3, Values: 110}) {
+		t.Errorf("Expected only Chunks and Values for separate chunks, got %+v", stats)
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := uint32(0); i < 4; i++ {
+		var r Uint32
+		pushRange(&r, i*10, i*10+14)
+		data, _ := r.MarshalBinary()
+		path := filepath.Join(dir, "chunk"+string(rune('0'+i)))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	out, stats, err := MergeFiles(paths, LoadOptions{Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 45 || len(out.runs) != 1 {
+		t.Errorf("Expected one run of 45 values, got %v", out)
+	}
+	if stats.Duplicates != 15 || stats.Overlapping != 4 {
+		t.Errorf("Expected 15 duplicates in 4 overlapping chunks, got %+v", stats)
+	}
+
+	if _, _, err := MergeFiles(append(paths, filepath.Join(dir, "missing")), LoadOptions{}); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
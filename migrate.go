@@ -0,0 +1,130 @@
+package rangearray
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Every long-lived format in this package starts with two magic bytes
+// that name the format, then a version byte: "RA" for the binary
+// format, "RB" for block files, "RK" and "RS" for binary and shared
+// collections, "RF" for the flat format, and "RD" for deltas.  A saved
+// Collection directory has a version in its manifest instead.  A change
+// to a format that older readers cannot read increments its version,
+// and adds a migration from the old version to the new one, so that
+// Migrate can upgrade data written by any earlier release.  Write-ahead logs and checkpoint streams are
+// unversioned, since they are rewritten whenever they are compacted;
+// an upgrade only needs to compact them first.
+
+// FormatInfo identifies serialized rangearray data.
+type FormatInfo struct {
+	// Magic is the magic bytes of the format, such as "RA".
+	Magic string
+
+	// Version is the version of the format that the data uses, and
+	// Current the version that this package writes.
+	Version, Current int
+}
+
+// migration upgrades data in one format from one version to the next.
+type migration struct {
+	magic   string
+	version int
+	upgrade func(data []byte) ([]byte, error)
+}
+
+// currentVersions holds the version that this package writes for each
+// versioned format.
+var currentVersions = map[string]int{
+	"RA": binaryVersion,
+	"RB": binaryVersion,
+	"RK": binaryVersion,
+	"RS": binaryVersion,
+	"RF": flatVersion,
+	"RD": binaryVersion,
+}
+
+// migrations holds the upgrade from each old version of each format.
+// Every format is still at its first version, so there are none yet.
+var migrations []migration
+
+var errUnknownFormat = corruptf("rangearray: not a versioned rangearray format")
+
+// Identify returns the format and version of data.
+func Identify(data []byte) (FormatInfo, error) {
+	if len(data) < 3 {
+		return FormatInfo{}, errUnknownFormat
+	}
+	magic := string(data[:2])
+	current, ok := currentVersions[magic]
+	if !ok {
+		return FormatInfo{}, errUnknownFormat
+	}
+	return FormatInfo{Magic: magic, Version: int(data[2]), Current: current}, nil
+}
+
+// Migrate upgrades data written by an earlier release, in any of the
+// versioned formats, to the current version of its format.  It returns
+// data itself if it is already current.  It fails if data is in a
+// version newer than this package knows, or in an old version that was
+// never released.
+func Migrate(data []byte) ([]byte, error) {
+	info, err := Identify(data)
+	if err != nil {
+		return nil, err
+	}
+	if info.Version > info.Current {
+		return nil, versionf("rangearray: %s version %d is newer than this package supports", info.Magic, info.Version)
+	}
+
+	for info.Version < info.Current {
+		i := 0
+		for i < len(migrations) && (migrations[i].magic != info.Magic || migrations[i].version != info.Version) {
+			i++
+		}
+		if i == len(migrations) {
+			return nil, versionf("rangearray: unknown %s version %d", info.Magic, info.Version)
+		}
+		if data, err = migrations[i].upgrade(data); err != nil {
+			return nil, fmt.Errorf("rangearray: migrating %s version %d: %w", info.Magic, info.Version, err)
+		}
+
+		prev := info.Version
+		if info, err = Identify(data); err != nil {
+			return nil, err
+		}
+		if info.Version <= prev {
+			return nil, fmt.Errorf("rangearray: migrating %s version %d did not upgrade it", info.Magic, prev)
+		}
+	}
+	return data, nil
+}
+
+// MigrateFile upgrades the file at path in place, as Migrate does, and
+// reports whether it needed to.  The upgraded file replaces the old
+// one atomically.
+func MigrateFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if info, err := Identify(data); err == nil && info.Version == info.Current {
+		return false, nil
+	}
+	out, err := Migrate(data)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir, name := filepath.Split(path)
+	w, err := DirWriteFS(filepath.Clean(dir)).Create(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = w.Write(out)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err == nil, err
+}
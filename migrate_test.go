@@ -0,0 +1,100 @@
+package rangearray
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withTestMigration registers a migration from a made-up version 0 of
+// the binary format, which differs from version 1 only in its version
+// byte, for the length of a test.
+func withTestMigration(t *testing.T) {
+	saved := migrations
+	migrations = append(migrations, migration{
+		magic:   "RA",
+		version: 0,
+		upgrade: func(data []byte) ([]byte, error) {
+			out := bytes.Clone(data)
+			out[2] = 1
+			return out, nil
+		},
+	})
+	t.Cleanup(func() { migrations = saved })
+}
+
+func TestIdentify(t *testing.T) {
+	r := testEncodingArray()
+	data, _ := r.MarshalBinary()
+	info, err := Identify(data)
+	if err != nil || info != (FormatInfo{Magic: "RA", Version: 1, Current: 1}) {
+		t.Errorf("Expected RA version 1, got %+v, %v", info, err)
+	}
+	if info, err := Identify(r.AppendFlat(nil)); err != nil || info.Magic != "RF" {
+		t.Errorf("Expected RF, got %+v, %v", info, err)
+	}
+	if _, err := Identify([]byte("XX\x01")); err != errUnknownFormat {
+		t.Errorf("Expected errUnknownFormat, got %v", err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	withTestMigration(t)
+	r := testEncodingArray()
+	data, _ := r.MarshalBinary()
+
+	if out, err := Migrate(data); err != nil || &out[0] != &data[0] {
+		t.Errorf("Expected current data to be returned as is, got %v", err)
+	}
+
+	old := bytes.Clone(data)
+	old[2] = 0
+	var got Uint32
+	if err := got.UnmarshalBinary(old); err == nil {
+		t.Errorf("Expected UnmarshalBinary to reject version 0")
+	}
+	out, err := Migrate(old)
+	if err != nil || !bytes.Equal(out, data) {
+		t.Errorf("Expected Migrate to upgrade version 0, got %v", err)
+	}
+	if err := (Decoder{Upgrade: true}).Unmarshal(old, &got); err != nil {
+		t.Errorf("Expected Unmarshal with Upgrade to read version 0, got %v", err)
+	}
+	testEqualUint32(t, "got", got, r)
+
+	newer := bytes.Clone(data)
+	newer[2] = 2
+	if _, err := Migrate(newer); err == nil {
+		t.Errorf("Expected an error for a newer version")
+	}
+	flat := r.AppendFlat(nil)
+	flat[2] = 0
+	if _, err := Migrate(flat); err == nil {
+		t.Errorf("Expected an error for a version with no migration")
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	withTestMigration(t)
+	data, _ := testEncodingArray().MarshalBinary()
+	path := filepath.Join(t.TempDir(), "a.ra")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := MigrateFile(path); changed || err != nil {
+		t.Errorf("Expected a current file to be left alone, got %v, %v", changed, err)
+	}
+
+	old := bytes.Clone(data)
+	old[2] = 0
+	if err := os.WriteFile(path, old, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := MigrateFile(path); !changed || err != nil {
+		t.Errorf("Expected the file to be upgraded, got %v, %v", changed, err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, data) {
+		t.Errorf("Expected the upgraded file to be current, got % x", got[:5])
+	}
+}
@@ -0,0 +1,220 @@
+package rangearray
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sort"
+)
+
+// DuplicatePolicy selects what Multiset.Push does with a value that is
+// already in the array.
+type DuplicatePolicy int
+
+const (
+	// DuplicateIgnore makes Push ignore the value, as Uint32.Push does.
+	DuplicateIgnore DuplicatePolicy = iota
+
+	// DuplicateReject makes Push return a *DuplicateError.
+	DuplicateReject
+
+	// DuplicateCount makes Push count the value again, so that the
+	// array is a multiset.
+	DuplicateCount
+)
+
+// DuplicateError reports a value pushed onto a Multiset that is already
+// in it, under DuplicateReject.
+type DuplicateError struct {
+	Value uint32
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("rangearray: duplicate value %d", e.Value)
+}
+
+// Multiset is a rangearray that handles duplicate pushes according to
+// a DuplicatePolicy.  Under DuplicateCount it keeps the multiplicity of
+// each value, for observables with several records per epoch: Len and
+// IndexOf count every copy, and All yields each value as many times as
+// it was pushed.  The distinct values are stored as a Uint32, and only
+// the values pushed more than once take extra space.
+//
+// The zero value is an empty Multiset with DuplicateIgnore.  Like
+// Uint32, a Multiset is not safe for concurrent use while it is being
+// modified.
+type Multiset struct {
+	policy DuplicatePolicy
+	set    Uint32
+
+	// dups holds the values pushed more than once, in increasing
+	// order, and extra the number of copies beyond the first.
+	dups  []multiDup
+	extra uint64
+
+	// sums[i] is the number of extra copies in dups[:i], for every i
+	// up to len(dups), or nil if dups is empty.  Push keeps it up to
+	// date so that queries only read it.
+	sums []uint64
+}
+
+// multiDup is a value in a Multiset, and the number of extra copies of
+// it.
+type multiDup struct {
+	value uint32
+	extra uint64
+}
+
+// NewMultiset returns an empty Multiset with the given policy.
+func NewMultiset(policy DuplicatePolicy) *Multiset {
+	return &Multiset{policy: policy}
+}
+
+// Push adds x to m.  If x is already in m, Push applies m's policy,
+// and returns a *DuplicateError under DuplicateReject.  Push also
+// returns an error if x is new but m already holds MaxLen distinct
+// values.
+func (m *Multiset) Push(x uint32) error {
+	if m.set.Push(x) {
+		if debugChecks {
+			m.check("Multiset.Push")
+		}
+		return nil
+	}
+	if !m.set.Contains(x) {
+		return errFull
+	}
+
+	switch m.policy {
+	case DuplicateReject:
+		return &DuplicateError{Value: x}
+	case DuplicateCount:
+		i := m.search(x)
+		if len(m.sums) == 0 {
+			m.sums = append(m.sums, 0)
+		}
+		if i == len(m.dups) || m.dups[i].value != x {
+			m.dups = slices.Insert(m.dups, i, multiDup{value: x})
+			m.sums = slices.Insert(m.sums, i+1, m.sums[i])
+		}
+		m.dups[i].extra++
+		m.extra++
+		for j := i + 1; j < len(m.sums); j++ {
+			m.sums[j]++
+		}
+		if debugChecks {
+			m.check("Multiset.Push")
+		}
+	}
+	return nil
+}
+
+// check panics if the distinct values of m break an invariant, or if
+// m.dups is out of order, holds a value that is not in m, or disagrees
+// with m.extra or m.sums.
+func (m *Multiset) check(op string) {
+	m.set.check(op, 0, 0)
+	var extra uint64
+	if len(m.dups) > 0 && len(m.sums) != len(m.dups)+1 {
+		panic(fmt.Sprintf("%s: %d sums for %d duplicates", op, len(m.sums), len(m.dups)))
+	}
+	for i, d := range m.dups {
+		if i > 0 && d.value <= m.dups[i-1].value || !m.set.Contains(d.value) || d.extra == 0 {
+			panic(fmt.Sprintf("%s: bad duplicate %d of %d: %+v", op, i, len(m.dups), d))
+		}
+		if m.sums[i] != extra {
+			panic(fmt.Sprintf("%s: sums[%d] is %d, but the duplicates before it hold %d", op, i, m.sums[i], extra))
+		}
+		extra += d.extra
+	}
+	if len(m.dups) > 0 && m.sums[len(m.dups)] != extra {
+		panic(fmt.Sprintf("%s: sums[%d] is %d, but the duplicates hold %d", op, len(m.dups), m.sums[len(m.dups)], extra))
+	}
+	if extra != m.extra {
+		panic(fmt.Sprintf("%s: extra is %d, but the duplicates hold %d", op, m.extra, extra))
+	}
+}
+
+// search returns the position of the first entry in m.dups whose value
+// is at least x.
+func (m *Multiset) search(x uint32) int {
+	return sort.Search(len(m.dups), func(i int) bool {
+		return m.dups[i].value >= x
+	})
+}
+
+// extraBefore returns the number of extra copies in m.dups[:i].
+func (m *Multiset) extraBefore(i int) uint64 {
+	if len(m.sums) == 0 {
+		return 0
+	}
+	return m.sums[i]
+}
+
+// Policy returns m's duplicate policy.
+func (m *Multiset) Policy() DuplicatePolicy {
+	return m.policy
+}
+
+// Min returns the minimum value in m.  Panics if m is empty.
+func (m *Multiset) Min() uint32 {
+	return m.set.Min()
+}
+
+// Max returns the maximum value in m.  Panics if m is empty.
+func (m *Multiset) Max() uint32 {
+	return m.set.Max()
+}
+
+// Len returns the number of elements in m, counting every copy.
+func (m *Multiset) Len() uint64 {
+	return uint64(m.set.Len()) + m.extra
+}
+
+// Count returns the number of copies of x in m.
+func (m *Multiset) Count(x uint32) uint64 {
+	if !m.set.Contains(x) {
+		return 0
+	}
+	if i := m.search(x); i < len(m.dups) && m.dups[i].value == x {
+		return 1 + m.dups[i].extra
+	}
+	return 1
+}
+
+// IndexOf returns the number of elements in m that are less than x,
+// counting every copy.
+func (m *Multiset) IndexOf(x uint32) uint64 {
+	return uint64(m.set.IndexOf(x)) + m.extraBefore(m.search(x))
+}
+
+// Contains reports whether x is in m.
+func (m *Multiset) Contains(x uint32) bool {
+	return m.set.Contains(x)
+}
+
+// All returns an iterator over the values in m, in increasing order,
+// with each value repeated as many times as it is in m.
+func (m *Multiset) All() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		i := 0
+		for x := range m.set.All() {
+			n := uint64(1)
+			if i < len(m.dups) && m.dups[i].value == x {
+				n += m.dups[i].extra
+				i++
+			}
+			for ; n > 0; n-- {
+				if !yield(x) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Set returns the distinct values in m, as a Uint32 that shares m's
+// runs until either is modified, as with Fork.
+func (m *Multiset) Set() Uint32 {
+	return m.set.Fork()
+}
@@ -0,0 +1,90 @@
+package rangearray
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestMultisetPolicies(t *testing.T) {
+	var ignore Multiset
+	for _, x := range []uint32{5, 6, 5} {
+		if err := ignore.Push(x); err != nil {
+			t.Errorf("Expected Push(%d) to succeed, got %v", x, err)
+		}
+	}
+	if ignore.Len() != 2 || ignore.Count(5) != 1 {
+		t.Errorf("Expected duplicates to be ignored, got %d values", ignore.Len())
+	}
+
+	strict := NewMultiset(DuplicateReject)
+	strict.Push(5)
+	var de *DuplicateError
+	if err := strict.Push(5); !errors.As(err, &de) || de.Value != 5 {
+		t.Errorf("Expected a DuplicateError for 5, got %v", err)
+	}
+	if strict.Len() != 1 {
+		t.Errorf("Expected a rejected duplicate not to count, got %d values", strict.Len())
+	}
+}
+
+func TestMultisetCount(t *testing.T) {
+	m := NewMultiset(DuplicateCount)
+	for _, x := range []uint32{10, 10, 11, 20, 10, 5, 20, 7} {
+		if err := m.Push(x); err != nil {
+			t.Errorf("Expected Push(%d) to succeed, got %v", x, err)
+		}
+	}
+
+	want := []uint32{5, 7, 10, 10, 10, 11, 20, 20}
+	if got := slices.Collect(m.All()); !slices.Equal(got, want) {
+		t.Errorf("Expected All() == %v, got %v", want, got)
+	}
+	if m.Len() != 8 || m.Set().String() != "5,7,10-11,20" {
+		t.Errorf("Expected 8 values in 5,7,10-11,20, got %d in %v", m.Len(), m.Set())
+	}
+	for x, n := range map[uint32]uint64{5: 1, 10: 3, 20: 2, 8: 0} {
+		if m.Count(x) != n {
+			t.Errorf("Expected Count(%d) == %d, got %d", x, n, m.Count(x))
+		}
+	}
+	for _, x := range want {
+		if got := m.IndexOf(x); got != uint64(slices.Index(want, x)) {
+			t.Errorf("Expected IndexOf(%d) == %d, got %d", x, slices.Index(want, x), got)
+		}
+	}
+	if m.IndexOf(0) != 0 || m.IndexOf(30) != 8 || m.IndexOf(12) != 6 {
+		t.Errorf("Expected IndexOf 0, 8 and 6, got %d, %d and %d", m.IndexOf(0), m.IndexOf(30), m.IndexOf(12))
+	}
+
+	// A duplicate before the others moves the sums after it.
+	m.Push(5)
+	if m.IndexOf(20) != 7 || m.Min() != 5 || m.Max() != 20 {
+		t.Errorf("Expected IndexOf(20) == 7, got %d", m.IndexOf(20))
+	}
+}
+
+func TestMultisetConcurrentReads(t *testing.T) {
+	m := NewMultiset(DuplicateCount)
+	for x := uint32(0); x < 1000; x++ {
+		m.Push(x)
+		m.Push(x / 2)
+	}
+
+	// Queries must only read m, so that they can run at once; go test
+	// -race reports any that do not.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for x := uint32(0); x < 1000; x += 7 {
+				if got, want := m.IndexOf(x), uint64(x)+2*uint64(min(x, 500)); got != want {
+					t.Errorf("Expected IndexOf(%d) == %d, got %d", x, want, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
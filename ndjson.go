@@ -0,0 +1,138 @@
+package rangearray
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultMaxLineLen is the longest NDJSON record ReadNDJSON accepts
+// when NDJSONOptions.MaxLineLen is zero.
+const DefaultMaxLineLen = 1 << 20
+
+// NDJSONOptions controls how ReadNDJSON finds and parses timestamps.
+type NDJSONOptions struct {
+	// Field names the timestamp field.  Dots separate the keys of
+	// nested objects, so "event.time" selects the "time" member of the
+	// "event" object.
+	Field string
+
+	// Epoch and Tick have the same meaning as in CSVOptions.  If Epoch
+	// is zero, the field holds uint32 values; otherwise, it holds RFC
+	// 3339 strings.
+	Epoch time.Time
+	Tick  time.Duration
+
+	// MaxLineLen bounds the length of one record.  If it is zero,
+	// DefaultMaxLineLen is used.
+	MaxLineLen int
+
+	// SkipMissing causes records without the field (or with a null
+	// value for it) to be ignored rather than reported as errors.
+	SkipMissing bool
+
+	// Tracer, if not nil, observes the load.
+	Tracer Tracer
+
+	// Progress reports the records read, not counting blank lines and
+	// skipped records.
+	Progress Progress
+}
+
+// ReadNDJSON reads newline-delimited JSON records from rd and pushes
+// the timestamp from each one onto r.  It holds at most one record in
+// memory at a time.  Blank lines are ignored.  Errors report the line
+// of the offending record; values pushed before an error are kept.
+func (r *Uint32) ReadNDJSON(rd io.Reader, opts NDJSONOptions) (err error) {
+	done := startTrace(context.Background(), opts.Tracer, OpReadNDJSON)
+	defer func() { done(len(r.runs), err) }()
+
+	maxLen := opts.MaxLineLen
+	if maxLen == 0 {
+		maxLen = DefaultMaxLineLen
+	}
+	tick := opts.Tick
+	if tick == 0 {
+		tick = time.Second
+	}
+	path := strings.Split(opts.Field, ".")
+
+	pr := opts.Progress.start(OpReadNDJSON, -1)
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 0, min(maxLen, 64*1024)), maxLen)
+	for line := 1; sc.Scan(); line++ {
+		b := sc.Bytes()
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+
+		raw, err := ndjsonField(b, path)
+		if err == errNoField && opts.SkipMissing {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("rangearray: line %d: %w", line, err)
+		}
+
+		var s string
+		if raw[0] == '"' {
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("rangearray: line %d: %w", line, err)
+			}
+		} else if opts.Epoch.IsZero() {
+			s = string(raw)
+		} else {
+			return fmt.Errorf("rangearray: line %d: expected RFC 3339 string, got %s", line, raw)
+		}
+
+		x, err := parseTimestamp(s, opts.Epoch, tick)
+		if err != nil {
+			return fmt.Errorf("rangearray: line %d: %w", line, err)
+		}
+		r.Push(x)
+		pr.add(1)
+	}
+
+	if err := sc.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("rangearray: NDJSON record longer than %d bytes", maxLen)
+		}
+		return err
+	}
+	pr.finish()
+	return nil
+}
+
+// ReadNDJSON is like (*Uint32).ReadNDJSON, but returns a new array.
+func ReadNDJSON(rd io.Reader, opts NDJSONOptions) (Uint32, error) {
+	var r Uint32
+	if err := r.ReadNDJSON(rd, opts); err != nil {
+		return Uint32{}, err
+	}
+	return r, nil
+}
+
+var errNoField = errors.New("timestamp field missing")
+
+// ndjsonField returns the raw JSON value at path within the record b.
+func ndjsonField(b []byte, path []string) (json.RawMessage, error) {
+	raw := json.RawMessage(b)
+	for _, key := range path {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		if raw = obj[key]; raw == nil {
+			return nil, errNoField
+		}
+	}
+	if string(raw) == "null" {
+		return nil, errNoField
+	}
+	return raw, nil
+}
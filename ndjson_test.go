@@ -0,0 +1,66 @@
+package rangearray
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadNDJSON(t *testing.T) {
+	data := `{"ts": 100, "msg": "a"}
+{"ts": 101}
+
+{"ts": "102"}
+{"ts": 200, "extra": {"ts": 5}}
+`
+	r, err := ReadNDJSON(strings.NewReader(data), NDJSONOptions{Field: "ts"})
+	if err != nil {
+		t.Fatalf("ReadNDJSON() failed: %v", err)
+	}
+	if r.String() != "100-102,200" {
+		t.Errorf("Expected ReadNDJSON() == 100-102,200, got %v", r)
+	}
+
+	data = `{"event": {"time": "2020-01-01T00:00:02Z"}}
+{"event": {}}
+{"event": {"time": null}}
+{"event": {"time": "2020-01-01T00:00:04Z"}}
+`
+	opts := NDJSONOptions{
+		Field:       "event.time",
+		Epoch:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tick:        2 * time.Second,
+		SkipMissing: true,
+	}
+	r, err = ReadNDJSON(strings.NewReader(data), opts)
+	if err != nil || r.String() != "1-2" {
+		t.Errorf("Expected nested ReadNDJSON() == 1-2, got %v, %v", r, err)
+	}
+}
+
+func TestReadNDJSONErrors(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []struct {
+		data string
+		opts NDJSONOptions
+		want string
+	}{
+		{"{\"ts\": 1}\n{\"x\": 2}\n", NDJSONOptions{Field: "ts"}, "line 2"},
+		{"{\"ts\": 1}\n\n{\"ts\": \n", NDJSONOptions{Field: "ts"}, "line 3"},
+		{"{\"ts\": 1.5}\n", NDJSONOptions{Field: "ts"}, "line 1"},
+		{"{\"ts\": 1}\n", NDJSONOptions{Field: "ts", Epoch: epoch}, "RFC 3339"},
+		{"{\"ts\": 1}\n", NDJSONOptions{Field: "ts.x"}, "line 1"},
+		{"{\"ts\": 12345678}\n", NDJSONOptions{Field: "ts", MaxLineLen: 8}, "longer than 8"},
+	} {
+		_, err := ReadNDJSON(strings.NewReader(s.data), s.opts)
+		if err == nil || !strings.Contains(err.Error(), s.want) {
+			t.Errorf("Expected ReadNDJSON(%q) to fail with %q, got %v", s.data, s.want, err)
+		}
+	}
+
+	var r Uint32
+	err := r.ReadNDJSON(strings.NewReader("{\"ts\": 7}\n{\"ts\": 6}\n{\"ts\": x}\n"), NDJSONOptions{Field: "ts"})
+	if err == nil || r.String() != "6-7" {
+		t.Errorf("Expected partial ReadNDJSON() == 6-7 with error, got %v, %v", r, err)
+	}
+}
@@ -0,0 +1,90 @@
+package rangearray
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The NumPy .npy format is a magic string, a version, and a header
+// holding a Python dict literal that describes the array, padded so
+// that the data starts at a multiple of 64 bytes.  The data follows
+// in C order.  A .npz file is a zip archive of .npy files.
+
+// WriteNPY writes the runs of r to w as a NumPy .npy file holding a
+// uint32 array with shape (runs, 2), where each row is the first value
+// and the count of one run.
+func (r Uint32) WriteNPY(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	writeNPYHeader(bw, fmt.Sprintf("(%d, 2)", len(r.runs)))
+	var b [8]byte
+	for _, s := range r.runs {
+		binary.LittleEndian.PutUint32(b[0:], s.Value)
+		binary.LittleEndian.PutUint32(b[4:], s.Count)
+		bw.Write(b[:])
+	}
+	bw.Flush()
+	return cw.n, cw.err
+}
+
+// WriteNPYValues writes every value in r to w as a NumPy .npy file
+// holding a one-dimensional uint32 array.
+func (r Uint32) WriteNPYValues(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	writeNPYHeader(bw, fmt.Sprintf("(%d,)", r.Len()))
+	var b [4]byte
+	for x := range r.All() {
+		binary.LittleEndian.PutUint32(b[:], x)
+		bw.Write(b[:])
+	}
+	bw.Flush()
+	return cw.n, cw.err
+}
+
+// WriteNPZ writes r to w as a NumPy .npz archive holding two
+// one-dimensional uint32 arrays, "starts" and "counts", with the first
+// value and the count of each run.
+func (r Uint32) WriteNPZ(w io.Writer) error {
+	z := zip.NewWriter(w)
+	for _, a := range []struct {
+		name  string
+		field func(Uint32Run) uint32
+	}{
+		{"starts.npy", func(s Uint32Run) uint32 { return s.Value }},
+		{"counts.npy", func(s Uint32Run) uint32 { return s.Count }},
+	} {
+		f, err := z.Create(a.name)
+		if err != nil {
+			return err
+		}
+		bw := bufio.NewWriter(f)
+		writeNPYHeader(bw, fmt.Sprintf("(%d,)", len(r.runs)))
+		var b [4]byte
+		for _, s := range r.runs {
+			binary.LittleEndian.PutUint32(b[:], a.field(s))
+			bw.Write(b[:])
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// writeNPYHeader writes the header of a .npy file holding a
+// little-endian uint32 array with the given shape.
+func writeNPYHeader(w *bufio.Writer, shape string) {
+	const prefix = "\x93NUMPY\x01\x00"
+	dict := fmt.Sprintf("{'descr': '<u4', 'fortran_order': False, 'shape': %s, }", shape)
+	n := len(prefix) + 2 + len(dict) + 1
+	dict += strings.Repeat(" ", (64-n%64)%64) + "\n"
+
+	w.WriteString(prefix)
+	binary.Write(w, binary.LittleEndian, uint16(len(dict)))
+	w.WriteString(dict)
+}
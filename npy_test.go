@@ -0,0 +1,83 @@
+package rangearray
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readNPY checks the header of a .npy file and returns its shape and
+// data.
+func readNPY(t *testing.T, b []byte) (string, []uint32) {
+	if len(b) < 10 || string(b[:8]) != "\x93NUMPY\x01\x00" {
+		t.Fatalf("Expected a .npy header, got %q", b)
+	}
+	n := 10 + int(binary.LittleEndian.Uint16(b[8:]))
+	if n%64 != 0 || b[n-1] != '\n' {
+		t.Errorf("Expected the .npy header to be padded to 64 bytes, got %d", n)
+	}
+
+	header := string(b[10:n])
+	if !strings.Contains(header, "'descr': '<u4'") || !strings.Contains(header, "'fortran_order': False") {
+		t.Errorf("Expected a little-endian uint32 C-order array, got %s", header)
+	}
+	shape := header[strings.Index(header, "'shape': ")+9 : strings.Index(header, ")")+1]
+
+	var data []uint32
+	for i := n; i+4 <= len(b); i += 4 {
+		data = append(data, binary.LittleEndian.Uint32(b[i:]))
+	}
+	return shape, data
+}
+
+func TestWriteNPY(t *testing.T) {
+	r := &Uint32{}
+	pushRange(r, 100, 102)
+	pushRange(r, 200, 201)
+
+	var buf bytes.Buffer
+	n, err := r.WriteNPY(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteNPY() failed: %d, %v", n, err)
+	}
+	shape, data := readNPY(t, buf.Bytes())
+	if shape != "(2, 2)" || len(data) != 4 || data[0] != 100 || data[1] != 3 || data[2] != 200 || data[3] != 2 {
+		t.Errorf("Expected runs [[100 3] [200 2]], got shape %s, %v", shape, data)
+	}
+
+	buf.Reset()
+	if _, err := r.WriteNPYValues(&buf); err != nil {
+		t.Fatalf("WriteNPYValues() failed: %v", err)
+	}
+	shape, data = readNPY(t, buf.Bytes())
+	if shape != "(5,)" || len(data) != 5 || data[2] != 102 || data[4] != 201 {
+		t.Errorf("Expected values [100 101 102 200 201], got shape %s, %v", shape, data)
+	}
+}
+
+func TestWriteNPZ(t *testing.T) {
+	r := testEncodingArray()
+	var buf bytes.Buffer
+	if err := r.WriteNPZ(&buf); err != nil {
+		t.Fatalf("WriteNPZ() failed: %v", err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() failed: %v", err)
+	}
+	if len(z.File) != 2 || z.File[0].Name != "starts.npy" || z.File[1].Name != "counts.npy" {
+		t.Fatalf("Expected starts.npy and counts.npy in the archive")
+	}
+	for i, want := range [][]uint32{{100, 350, 1000, 0xffffffff}, {100, 100, 1, 1}} {
+		f, _ := z.File[i].Open()
+		b, _ := io.ReadAll(f)
+		shape, data := readNPY(t, b)
+		if shape != "(4,)" || len(data) != 4 || data[0] != want[0] || data[1] != want[1] || data[3] != want[3] {
+			t.Errorf("Expected %s == %v, got shape %s, %v", z.File[i].Name, want, shape, data)
+		}
+	}
+}
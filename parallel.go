@@ -0,0 +1,332 @@
+package rangearray
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// parallelSamples is the number of run starts that UnionAll samples
+// from each input to choose its partition boundaries.
+const parallelSamples = 64
+
+// UnionAll returns the union of arrays, computed by workers goroutines
+// in parallel.  If workers is zero or negative, GOMAXPROCS is used.
+//
+// UnionAll splits the value domain into one range per worker, at
+// boundaries chosen so each range holds about the same number of input
+// runs.  Each worker unions the parts of the inputs in its range, and
+// the results are stitched together, merging runs that touch at the
+// boundaries.
+func UnionAll(arrays []Uint32, workers int) Uint32 {
+	out, _ := UnionAllContext(context.Background(), arrays, workers)
+	return out
+}
+
+// UnionAllContext is like UnionAll, but stops with ctx's error if ctx
+// is done before the union is complete.
+func UnionAllContext(ctx context.Context, arrays []Uint32, workers int) (Uint32, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	bounds := unionBounds(arrays, workers)
+
+	parts := make([][]Uint32Run, len(bounds)-1)
+	var wg sync.WaitGroup
+	for k := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parts[k] = unionWindow(ctx, arrays, bounds[k], bounds[k+1])
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return Uint32{}, err
+	}
+
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	// appendRunTo, unlike appendRun, clamps a union of every value to
+	// MaxLen rather than dropping the run that would complete it.
+	out := Uint32{runs: make([]Uint32Run, 0, n)}
+	for _, p := range parts {
+		for _, s := range p {
+			out.runs = appendRunTo(out.runs, uint64(s.Value), uint64(s.Value)+uint64(s.Count))
+		}
+	}
+	return out, nil
+}
+
+// unionBounds returns increasing partition boundaries for UnionAll,
+// starting at zero and ending at 1<<32, that split a sample of the run
+// starts in arrays into at most workers ranges.
+func unionBounds(arrays []Uint32, workers int) []uint64 {
+	var sample []uint32
+	for _, a := range arrays {
+		step := max(1, len(a.runs)/parallelSamples)
+		for i := 0; i < len(a.runs); i += step {
+			sample = append(sample, a.runs[i].Value)
+		}
+	}
+	slices.Sort(sample)
+
+	bounds := []uint64{0}
+	for k := 1; k < workers; k++ {
+		if len(sample) == 0 {
+			break
+		}
+		b := uint64(sample[k*len(sample)/workers])
+		if b > bounds[len(bounds)-1] {
+			bounds = append(bounds, b)
+		}
+	}
+	return append(bounds, 1<<32)
+}
+
+// unionWindow returns the runs of the union of arrays that lie within
+// [lo, hi), clipped to that range.  It stops early, returning a partial
+// result, if ctx is done.
+func unionWindow(ctx context.Context, arrays []Uint32, lo, hi uint64) []Uint32Run {
+	var window []Uint32
+	for _, a := range arrays {
+		i := sort.Search(len(a.runs), func(i int) bool {
+			return uint64(a.runs[i].Value)+uint64(a.runs[i].Count) > lo
+		})
+		j := sort.Search(len(a.runs), func(j int) bool {
+			return uint64(a.runs[j].Value) >= hi
+		})
+		if i < j {
+			window = append(window, Uint32{runs: a.runs[i:j]})
+		}
+	}
+
+	var out []Uint32Run
+	unionRuns(window, func(s Uint32Run) bool {
+		start := max(uint64(s.Value), lo)
+		end := min(uint64(s.Value)+uint64(s.Count), hi)
+		out = append(out, Uint32Run{Value: uint32(start), Count: uint32(end - start)})
+		return len(out)%contextCheckInterval != 0 || ctx.Err() == nil
+	})
+	return out
+}
+
+// parallelChunk is the number of values that FromSorted and ReadSorted
+// give each worker at a time.
+const parallelChunk = 1 << 20
+
+// sortedRuns returns the runs of the non-decreasing values in vals,
+// with indexes relative to the first.  If vals is not sorted, it
+// returns the position of the first value that is out of order.
+func sortedRuns(vals []uint32) ([]Uint32Run, int) {
+	var out []Uint32Run
+	for i, x := range vals {
+		if n := len(out) - 1; n >= 0 {
+			end := uint64(out[n].Value) + uint64(out[n].Count)
+			switch {
+			case uint64(x) == end:
+				if out[n].Count < MaxLen {
+					out[n].Count++
+				}
+				continue
+			case uint64(x)+1 == end:
+				continue
+			case uint64(x) < end:
+				return nil, i
+			}
+		}
+		out = append(out, Uint32Run{Value: x, Count: 1})
+	}
+	return out, -1
+}
+
+// stitchRuns appends runs to r, merging them with its last run if they
+// touch, and dropping duplicates of its last value.  It returns false
+// if runs start before r's last value.
+func (r *Uint32) stitchRuns(runs []Uint32Run) bool {
+	for i, s := range runs {
+		if n := len(r.runs) - 1; i == 0 && n >= 0 {
+			end := uint64(r.runs[n].Value) + uint64(r.runs[n].Count)
+			if uint64(s.Value)+1 == end {
+				if s.Count == 1 {
+					continue
+				}
+				s.Value++
+				s.Count--
+			} else if uint64(s.Value) < end {
+				return false
+			}
+		}
+		// Only the run that ends at 0xffffffff can overflow r; it
+		// loses its last value, as MaxLen says.
+		s.Count = uint32(min(uint64(s.Count), MaxLen-uint64(r.Len())))
+		r.appendRun(s.Value, s.Count)
+	}
+	return true
+}
+
+// sortedChunkError reports a value out of order in chunk k at position
+// i within it.
+func sortedChunkError(k, i int) error {
+	return fmt.Errorf("rangearray: value at index %d is out of order", k*parallelChunk+i)
+}
+
+// FromSorted returns the rangearray holding vals, which must be sorted
+// in non-decreasing order; duplicates are ignored.  It builds the runs
+// of chunks of vals on workers goroutines in parallel, then joins them.
+// If workers is zero or negative, GOMAXPROCS is used.
+func FromSorted(vals []uint32, workers int) (Uint32, error) {
+	return FromSortedContext(context.Background(), vals, workers)
+}
+
+// FromSortedContext is like FromSorted, but stops with ctx's error if
+// ctx is done before every chunk is built.
+func FromSortedContext(ctx context.Context, vals []uint32, workers int) (Uint32, error) {
+	chunks := make([][]uint32, 0, (len(vals)+parallelChunk-1)/parallelChunk)
+	for i := 0; i < len(vals); i += parallelChunk {
+		chunks = append(chunks, vals[i:min(i+parallelChunk, len(vals))])
+	}
+	parts := make([][]Uint32Run, len(chunks))
+	bad := make([]int, len(chunks))
+	err := parallelFor(ctx, len(chunks), workers, func(k int) {
+		parts[k], bad[k] = sortedRuns(chunks[k])
+	})
+	if err != nil {
+		return Uint32{}, err
+	}
+
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	var out Uint32
+	out.Reserve(n)
+	for k, p := range parts {
+		if bad[k] >= 0 {
+			return Uint32{}, sortedChunkError(k, bad[k])
+		}
+		if !out.stitchRuns(p) {
+			return Uint32{}, sortedChunkError(k, 0)
+		}
+	}
+	return out, nil
+}
+
+// ReadSorted returns the rangearray holding the values in rd, which
+// are little-endian uint32s sorted in non-decreasing order, as with
+// FromSorted.  It reads rd in chunks and builds the runs of each on
+// workers goroutines, so memory use is bounded by a few chunks per
+// worker rather than by the size of the input.
+func ReadSorted(rd io.Reader, workers int) (Uint32, error) {
+	return ReadSortedContext(context.Background(), rd, workers)
+}
+
+// ReadSortedContext is like ReadSorted, but stops with ctx's error if
+// ctx is done before rd is read to the end.
+func ReadSortedContext(ctx context.Context, rd io.Reader, workers int) (Uint32, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	type result struct {
+		runs []Uint32Run
+		bad  int
+	}
+	results := make(chan chan result, workers)
+	var readErr error
+	go func() {
+		defer close(results)
+		buf := make([]byte, 4*parallelChunk)
+		for {
+			if err := ctx.Err(); err != nil {
+				readErr = err
+				return
+			}
+			n, err := io.ReadFull(rd, buf)
+			if n%4 != 0 && err != nil {
+				readErr = errTruncated
+				return
+			}
+			if n > 0 {
+				vals := make([]uint32, n/4)
+				for i := range vals {
+					vals[i] = binary.LittleEndian.Uint32(buf[4*i:])
+				}
+				ch := make(chan result, 1)
+				results <- ch
+				go func() {
+					runs, bad := sortedRuns(vals)
+					ch <- result{runs, bad}
+				}()
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			} else if err != nil {
+				readErr = err
+				return
+			}
+		}
+	}()
+
+	var out Uint32
+	var err error
+	k := 0
+	for ch := range results {
+		res := <-ch
+		if err == nil && res.bad >= 0 {
+			err = sortedChunkError(k, res.bad)
+		} else if err == nil && !out.stitchRuns(res.runs) {
+			err = sortedChunkError(k, 0)
+		}
+		k++
+	}
+	if readErr != nil {
+		return Uint32{}, readErr
+	}
+	if err != nil {
+		return Uint32{}, err
+	}
+	return out, nil
+}
+
+// parallelFor calls f(0) through f(n-1) on workers goroutines.  If
+// workers is zero or negative, GOMAXPROCS is used.  If ctx is done
+// before every call has started, the rest are skipped and parallelFor
+// returns ctx's error.
+func parallelFor(ctx context.Context, n, workers int, f func(int)) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range next {
+				f(k)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for k := range n {
+		select {
+		case next <- k:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,164 @@
+package rangearray
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestUnionAll(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	arrays := make([]Uint32, 50)
+	for i := range arrays {
+		for x := uint32(0); x < 100000; x += 1 + rng.Uint32N(200) {
+			pushRange(&arrays[i], x, x+rng.Uint32N(50))
+		}
+	}
+	arrays = append(arrays, Uint32{}, Uint32{runs: []Uint32Run{{Value: 0xffffff00, Count: 0x100}}})
+
+	want := NewUnionView(arrays...).Materialize()
+	for _, workers := range []int{0, 1, 2, 7, 64} {
+		testEqualUint32(t, "UnionAll()", UnionAll(arrays, workers), want)
+	}
+}
+
+func TestUnionAllEdges(t *testing.T) {
+	if r := UnionAll(nil, 4); r.Len() != 0 {
+		t.Errorf("Expected UnionAll(nil) to be empty, got %v", r)
+	}
+
+	// Runs that cross every partition boundary must be clipped and
+	// merged back together.
+	var long Uint32
+	pushRange(&long, 10, 100000)
+	var short Uint32
+	for x := uint32(0); x < 100; x++ {
+		short.Push(1000 * x)
+	}
+	got := UnionAll([]Uint32{long, short}, 8)
+	if got.String() != "0,10-100000" {
+		t.Errorf("Expected UnionAll() to merge across boundaries, got %v", got)
+	}
+	testEqualUint32(t, "UnionAll()", got, NewUnionView(long, short).Materialize())
+
+	// A union of every value must be clamped to MaxLen, as the serial
+	// union is, rather than losing the run that completes it.
+	halves := Uint32{runs: []Uint32Run{
+		{Value: 0, Count: 0x80000000},
+		{Value: 0x80000001, Index: 0x80000000, Count: 0x7fffffff},
+	}}
+	middle := Uint32{runs: []Uint32Run{{Value: 0x80000000, Count: 1}}}
+	want := NewUnionView(halves, middle).Materialize()
+	for _, workers := range []int{1, 2, 8} {
+		got := UnionAll([]Uint32{halves, middle}, workers)
+		if got.Len() != MaxLen {
+			t.Errorf("Expected UnionAll() with %d workers to hold %d values, got %d", workers, uint32(MaxLen), got.Len())
+		}
+		testEqualUint32(t, "UnionAll()", got, want)
+	}
+}
+
+// testSortedValues returns about n sorted values with duplicates and
+// gaps, and the rangearray that holds them.
+func testSortedValues(n int) ([]uint32, Uint32) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	vals := make([]uint32, 0, n)
+	var want Uint32
+	x := uint32(0)
+	for len(vals) < n {
+		switch rng.IntN(10) {
+		case 0:
+			x += 2 + rng.Uint32N(100)
+		case 1:
+			vals = append(vals, x)
+		}
+		vals = append(vals, x)
+		want.Push(x)
+		x++
+	}
+	return vals, want
+}
+
+func TestFromSorted(t *testing.T) {
+	vals, want := testSortedValues(3*parallelChunk + 12345)
+	for _, workers := range []int{0, 1, 3} {
+		got, err := FromSorted(vals, workers)
+		if err != nil {
+			t.Fatalf("FromSorted() failed: %v", err)
+		}
+		testEqualUint32(t, "FromSorted()", got, want)
+	}
+
+	if r, err := FromSorted(nil, 0); err != nil || r.Len() != 0 {
+		t.Errorf("Expected FromSorted(nil) to be empty, got %v, %v", r, err)
+	}
+
+	// A duplicate at a chunk boundary is fine; a decrease is not.
+	vals[parallelChunk] = vals[parallelChunk-1]
+	if _, err := FromSorted(vals, 0); err != nil {
+		t.Errorf("Expected a duplicate at a chunk boundary to be allowed, got %v", err)
+	}
+	vals[parallelChunk] = vals[parallelChunk-1] - 1
+	if _, err := FromSorted(vals, 0); err == nil || !strings.Contains(err.Error(), fmt.Sprint(parallelChunk)) {
+		t.Errorf("Expected FromSorted() to report index %d, got %v", parallelChunk, err)
+	}
+	vals[5] = vals[4] - 1
+	if _, err := FromSorted(vals, 0); err == nil || !strings.Contains(err.Error(), "index 5 ") {
+		t.Errorf("Expected FromSorted() to report index 5, got %v", err)
+	}
+}
+
+func TestReadSorted(t *testing.T) {
+	vals, want := testSortedValues(2*parallelChunk + 777)
+	b := make([]byte, 0, 4*len(vals))
+	for _, x := range vals {
+		b = binary.LittleEndian.AppendUint32(b, x)
+	}
+
+	got, err := ReadSorted(bytes.NewReader(b), 2)
+	if err != nil {
+		t.Fatalf("ReadSorted() failed: %v", err)
+	}
+	testEqualUint32(t, "ReadSorted()", got, want)
+
+	if _, err := ReadSorted(bytes.NewReader(b[:len(b)-1]), 0); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Expected ReadSorted() of a partial value to fail with ErrTruncated, got %v", err)
+	}
+	binary.LittleEndian.PutUint32(b[4*(parallelChunk+1):], 0)
+	if _, err := ReadSorted(bytes.NewReader(b), 0); err == nil || !strings.Contains(err.Error(), fmt.Sprint(parallelChunk+1)) {
+		t.Errorf("Expected ReadSorted() to report index %d, got %v", parallelChunk+1, err)
+	}
+	if r, err := ReadSorted(bytes.NewReader(nil), 0); err != nil || r.Len() != 0 {
+		t.Errorf("Expected ReadSorted() of nothing to be empty, got %v, %v", r, err)
+	}
+}
+
+func TestParallelContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var big Uint32
+	for x := uint32(0); x < 4*contextCheckInterval; x++ {
+		big.Push(2 * x)
+	}
+	if _, err := UnionAllContext(ctx, []Uint32{big, big}, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected UnionAllContext() to be canceled, got %v", err)
+	}
+	if _, err := FromSortedContext(ctx, make([]uint32, 2*parallelChunk), 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected FromSortedContext() to be canceled, got %v", err)
+	}
+	if _, err := ReadSortedContext(ctx, bytes.NewReader(make([]byte, 64)), 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ReadSortedContext() to be canceled, got %v", err)
+	}
+
+	r, err := UnionAllContext(context.Background(), []Uint32{big, big}, 2)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	testEqualUint32(t, "UnionAllContext()", r, big)
+}
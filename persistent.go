@@ -0,0 +1,287 @@
+package rangearray
+
+import "iter"
+
+// Persistent is an immutable rangearray whose Push and Remove return
+// new versions rather than modifying the receiver.  Each new version
+// shares all but O(log n) of its runs with the version it came from,
+// so many versions can be kept cheaply, such as the coverage after each
+// step of a pipeline.  The zero value is empty.  Because it is
+// immutable, a Persistent is safe for concurrent use.
+//
+// The runs are kept in a treap, ordered by value, whose priorities are
+// a hash of each run's first value.  Every operation takes O(log n)
+// expected time.
+type Persistent struct {
+	root *pnode
+}
+
+// pnode is a run in a Persistent, with totals for its subtree.
+type pnode struct {
+	value, count uint32
+	prio         uint32
+	left, right  *pnode
+
+	// values and runs count the values and runs in the subtree.
+	values uint64
+	runs   int
+}
+
+// newPnode returns a node for the run of count values starting at
+// value, with the given subtrees.
+func newPnode(value, count uint32, left, right *pnode) *pnode {
+	n := &pnode{value: value, count: count, prio: pnodePrio(value), left: left, right: right}
+	n.values, n.runs = uint64(count), 1
+	if left != nil {
+		n.values += left.values
+		n.runs += left.runs
+	}
+	if right != nil {
+		n.values += right.values
+		n.runs += right.runs
+	}
+	return n
+}
+
+// with returns a copy of n with new subtrees.
+func (n *pnode) with(left, right *pnode) *pnode {
+	return newPnode(n.value, n.count, left, right)
+}
+
+// end returns the value after the last value of n's run.
+func (n *pnode) end() uint64 {
+	return uint64(n.value) + uint64(n.count)
+}
+
+// pnodePrio hashes value to a treap priority.
+func pnodePrio(value uint32) uint32 {
+	x := value
+	x ^= x >> 16
+	x *= 0x7feb352d
+	x ^= x >> 15
+	x *= 0x846ca68b
+	x ^= x >> 16
+	return x
+}
+
+// psplit splits t into the runs that start before value and the rest.
+func psplit(t *pnode, value uint64) (*pnode, *pnode) {
+	if t == nil {
+		return nil, nil
+	}
+	if uint64(t.value) < value {
+		l, r := psplit(t.right, value)
+		return t.with(t.left, l), r
+	}
+	l, r := psplit(t.left, value)
+	return l, t.with(r, t.right)
+}
+
+// pmerge joins a and b, where every run in a is before every run in b.
+func pmerge(a, b *pnode) *pnode {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.prio >= b.prio:
+		return a.with(a.left, pmerge(a.right, b))
+	default:
+		return b.with(pmerge(a, b.left), b.right)
+	}
+}
+
+// plast returns the last run in t, or nil if t is empty.
+func plast(t *pnode) *pnode {
+	for t != nil && t.right != nil {
+		t = t.right
+	}
+	return t
+}
+
+// pfirst returns the first run in t, or nil if t is empty.
+func pfirst(t *pnode) *pnode {
+	for t != nil && t.left != nil {
+		t = t.left
+	}
+	return t
+}
+
+// PersistentOf returns a Persistent holding the values in r.
+func PersistentOf(r Uint32) Persistent {
+	var root *pnode
+	for _, s := range r.runs {
+		if s.Count > 0 {
+			root = pmerge(root, newPnode(s.Value, s.Count, nil, nil))
+		}
+	}
+	return Persistent{root: root}
+}
+
+// Push returns a version of p that also holds x.  If x is already in
+// p, or p already holds MaxLen values, Push returns p.
+func (p Persistent) Push(x uint32) Persistent {
+	if p.Len() == MaxLen || p.Contains(x) {
+		return p
+	}
+
+	// Split around x, then detach the runs that end just before x and
+	// start just after it, so they can merge with x.
+	value, end := uint64(x), uint64(x)+1
+	l, r := psplit(p.root, uint64(x))
+	if last := plast(l); last != nil && last.end() == value {
+		l, _ = psplit(l, uint64(last.value))
+		value = uint64(last.value)
+	}
+	if first := pfirst(r); first != nil && uint64(first.value) == end {
+		_, r = psplit(r, uint64(first.value)+1)
+		end = first.end()
+	}
+	mid := newPnode(uint32(value), uint32(end-value), nil, nil)
+	out := Persistent{root: pmerge(pmerge(l, mid), r)}
+	if debugChecks {
+		checkReader("Persistent.Push", out)
+	}
+	return out
+}
+
+// Remove returns a version of p without x.  If x is not in p, Remove
+// returns p.
+func (p Persistent) Remove(x uint32) Persistent {
+	if !p.Contains(x) {
+		return p
+	}
+
+	l, r := psplit(p.root, uint64(x)+1)
+	run := plast(l)
+	l, _ = psplit(l, uint64(run.value))
+	if run.value < x {
+		l = pmerge(l, newPnode(run.value, x-run.value, nil, nil))
+	}
+	if end := run.end(); end > uint64(x)+1 {
+		r = pmerge(newPnode(x+1, uint32(end-uint64(x)-1), nil, nil), r)
+	}
+	out := Persistent{root: pmerge(l, r)}
+	if debugChecks {
+		checkReader("Persistent.Remove", out)
+	}
+	return out
+}
+
+// find returns the run in p with the greatest first value that is at
+// most x, and the number of values and runs before it.  It returns nil
+// if there is no such run.
+func (p Persistent) find(x uint32) (*pnode, uint64, int) {
+	var best *pnode
+	var values, bestValues uint64
+	var runs, bestRuns int
+	for t := p.root; t != nil; {
+		if t.value > x {
+			t = t.left
+			continue
+		}
+		before, beforeRuns := values, runs
+		if t.left != nil {
+			before += t.left.values
+			beforeRuns += t.left.runs
+		}
+		best, bestValues, bestRuns = t, before, beforeRuns
+		values, runs = before+uint64(t.count), beforeRuns+1
+		t = t.right
+	}
+	return best, bestValues, bestRuns
+}
+
+// Min returns the minimum value in p.  Panics if p is empty.
+func (p Persistent) Min() uint32 {
+	return pfirst(p.root).value
+}
+
+// Max returns the maximum value in p.  Panics if p is empty.
+func (p Persistent) Max() uint32 {
+	last := plast(p.root)
+	return last.value + (last.count - 1)
+}
+
+// Len returns the number of elements in p.
+func (p Persistent) Len() uint32 {
+	if p.root == nil {
+		return 0
+	}
+	return uint32(p.root.values)
+}
+
+// NumRuns returns the number of runs in p.
+func (p Persistent) NumRuns() int {
+	if p.root == nil {
+		return 0
+	}
+	return p.root.runs
+}
+
+// IndexOf returns the number of elements in p that are less than x.
+func (p Persistent) IndexOf(x uint32) uint32 {
+	n, values, _ := p.find(x)
+	if n == nil {
+		return 0
+	}
+	return uint32(values + min(uint64(x)-uint64(n.value), uint64(n.count)))
+}
+
+// LowerBound returns the index of the run in p that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (p Persistent) LowerBound(x uint32) int {
+	n, _, runs := p.find(x)
+	if n == nil {
+		return 0
+	}
+	if uint64(x) < n.end() {
+		return runs
+	}
+	return runs + 1
+}
+
+// Contains reports whether x is in p.
+func (p Persistent) Contains(x uint32) bool {
+	n, _, _ := p.find(x)
+	return n != nil && uint64(x) < n.end()
+}
+
+// All returns an iterator over the values in p, in increasing order.
+func (p Persistent) All() iter.Seq[uint32] {
+	return valuesOf(p.Runs())
+}
+
+// Runs returns an iterator over the runs in p, in increasing order.
+func (p Persistent) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		var index uint32
+		var walk func(*pnode) bool
+		walk = func(t *pnode) bool {
+			if t == nil {
+				return true
+			}
+			if !walk(t.left) || !yield(Uint32Run{Value: t.value, Index: index, Count: t.count}) {
+				return false
+			}
+			index += t.count
+			return walk(t.right)
+		}
+		walk(p.root)
+	}
+}
+
+// Uint32 returns a mutable copy of p.
+func (p Persistent) Uint32() Uint32 {
+	out := Uint32{runs: make([]Uint32Run, 0, p.NumRuns())}
+	for s := range p.Runs() {
+		out.runs = append(out.runs, s)
+	}
+	return out
+}
+
+// String returns p in the format of Uint32.String.
+func (p Persistent) String() string {
+	return p.Uint32().String()
+}
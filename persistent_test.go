@@ -0,0 +1,84 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// testPersistentMatches checks that p holds the same values as want.
+func testPersistentMatches(t *testing.T, p Persistent, want map[uint32]bool) {
+	t.Helper()
+	var model Uint32
+	for x := uint32(0); x < 300; x++ {
+		if want[x] {
+			model.Push(x)
+		}
+	}
+	testEqualUint32(t, "p", p.Uint32(), model)
+	for x := uint32(0); x < 310; x++ {
+		if p.Contains(x) != want[x] || p.IndexOf(x) != model.IndexOf(x) || p.LowerBound(x) != model.LowerBound(x) {
+			t.Fatalf("Expected queries for %d to match %v, got %v, %d, %d", x, model, p.Contains(x), p.IndexOf(x), p.LowerBound(x))
+		}
+	}
+}
+
+func TestPersistent(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var p Persistent
+	want := map[uint32]bool{}
+	var versions []Persistent
+	var wants []map[uint32]bool
+	for i := 0; i < 2000; i++ {
+		x := rng.Uint32N(300)
+		if rng.IntN(3) == 0 {
+			p = p.Remove(x)
+			delete(want, x)
+		} else {
+			p = p.Push(x)
+			want[x] = true
+		}
+		if i%100 == 0 {
+			versions = append(versions, p)
+			snapshot := map[uint32]bool{}
+			for k := range want {
+				snapshot[k] = true
+			}
+			wants = append(wants, snapshot)
+		}
+	}
+
+	testPersistentMatches(t, p, want)
+	for i, v := range versions {
+		testPersistentMatches(t, v, wants[i])
+	}
+}
+
+func TestPersistentEdges(t *testing.T) {
+	var p Persistent
+	if p.Len() != 0 || p.NumRuns() != 0 || p.Contains(0) || p.IndexOf(5) != 0 || p.LowerBound(5) != 0 {
+		t.Errorf("Expected an empty Persistent, got %v", p)
+	}
+	if q := p.Remove(3); q.root != nil {
+		t.Errorf("Expected Remove() from an empty Persistent to be empty")
+	}
+
+	p = p.Push(0xffffffff).Push(0xfffffffe).Push(0)
+	if p.String() != "0,4294967294-4294967295" || p.Min() != 0 || p.Max() != 0xffffffff {
+		t.Errorf("Expected 0,4294967294-4294967295, got %v", p)
+	}
+	if q := p.Remove(0xffffffff); q.String() != "0,4294967294" {
+		t.Errorf("Expected Remove(4294967295) == 0,4294967294, got %v", q)
+	}
+	same := p.Push(0)
+	if same.root != p.root {
+		t.Errorf("Expected Push() of a present value to return p")
+	}
+
+	r := testEncodingArray()
+	q := PersistentOf(r)
+	testEqualUint32(t, "PersistentOf(r).Uint32()", q.Uint32(), r)
+	q2 := q.Remove(150)
+	if q.Len() != 202 || q2.Len() != 201 || q2.NumRuns() != 5 {
+		t.Errorf("Expected Remove() to leave the old version intact, got %v and %v", q, q2)
+	}
+}
@@ -0,0 +1,39 @@
+package rangearray
+
+import "sync"
+
+// scratch holds buffers for encoding and decoding, which are pooled so
+// that encoding or decoding arrays in a loop does not allocate new
+// buffers each time.
+type scratch struct {
+	buf, packed, frame []byte
+}
+
+// maxPooled is the largest buffer that is returned to scratchPool;
+// larger ones are left for the garbage collector, so that one huge
+// operation does not pin its buffers in memory.
+const maxPooled = 2 * maxChunkLen
+
+var scratchPool = sync.Pool{New: func() any { return new(scratch) }}
+
+// getScratch returns a scratch from the pool.  Its buffers have length
+// zero.
+func getScratch() *scratch {
+	return scratchPool.Get().(*scratch)
+}
+
+// release returns s to the pool, keeping the buffers it was last given
+// if they are small enough.
+func (s *scratch) release(buf, packed, frame []byte) {
+	s.buf, s.packed, s.frame = nil, nil, nil
+	if cap(buf) <= maxPooled {
+		s.buf = buf[:0]
+	}
+	if cap(packed) <= maxPooled {
+		s.packed = packed[:0]
+	}
+	if cap(frame) <= maxPooled {
+		s.frame = frame[:0]
+	}
+	scratchPool.Put(s)
+}
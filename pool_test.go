@@ -0,0 +1,27 @@
+package rangearray
+
+import (
+	"io"
+	"testing"
+)
+
+func TestScratchPool(t *testing.T) {
+	r := &Uint32{}
+	for i := uint32(0); i < 3*streamChunkRuns; i++ {
+		r.Push(3 * i)
+	}
+	r.WriteTo(io.Discard)
+	allocs := testing.AllocsPerRun(20, func() {
+		r.WriteTo(io.Discard)
+	})
+	// What remains is the writer and checksum state, not the buffers.
+	if allocs > 4 {
+		t.Errorf("Expected WriteTo() to reuse pooled buffers, got %v allocations", allocs)
+	}
+
+	s := getScratch()
+	s.release(make([]byte, 10), make([]byte, maxPooled+1), nil)
+	if s.buf == nil || len(s.buf) != 0 || s.packed != nil {
+		t.Errorf("Expected release() to keep only small buffers, got %d and %d bytes", cap(s.buf), cap(s.packed))
+	}
+}
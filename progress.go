@@ -0,0 +1,72 @@
+package rangearray
+
+// DefaultProgressEvery is the number of units between progress reports
+// when Progress.Every is zero.
+const DefaultProgressEvery = 1 << 16
+
+// Progress reports how far a bulk operation has got, so that callers
+// can show progress bars and estimate completion times.  Options
+// structs and views that accept a Progress call it only when Func is
+// not nil.
+type Progress struct {
+	// Func is called with the name of the operation, one of the Op
+	// constants, and the number of units it has processed: runs when
+	// encoding, decoding or materializing a view, records when reading
+	// CSV or NDJSON, and files when loading files.  total is the number
+	// of units expected, or -1 if it is not known.  When the operation
+	// succeeds, Func is called a last time with done equal to total.
+	Func func(op string, done, total int64)
+
+	// Every is the number of units processed between calls to Func.
+	// If it is zero, DefaultProgressEvery is used.
+	Every int64
+}
+
+// progress counts the units processed by one operation.  A nil
+// *progress ignores every call, so operations can report progress
+// without checking whether anyone is listening.
+type progress struct {
+	Progress
+	op       string
+	total    int64
+	done     int64
+	reported int64
+}
+
+// start returns a counter for op, which expects total units, or nil if
+// p.Func is nil.
+func (p Progress) start(op string, total int64) *progress {
+	if p.Func == nil {
+		return nil
+	}
+	if p.Every <= 0 {
+		p.Every = DefaultProgressEvery
+	}
+	return &progress{Progress: p, op: op, total: total}
+}
+
+// add counts n more units, and reports them if enough have been
+// counted since the last report.
+func (p *progress) add(n int) {
+	if p == nil {
+		return
+	}
+	p.done += int64(n)
+	if p.done-p.reported >= p.Every {
+		p.reported = p.done
+		p.Func(p.op, p.done, p.total)
+	}
+}
+
+// finish reports the final count.
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	if p.total < 0 {
+		p.total = p.done
+	} else if p.reported == p.done && p.done > 0 {
+		return
+	}
+	p.Func(p.op, p.done, p.total)
+}
@@ -0,0 +1,92 @@
+package rangearray
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// progressLog records the reports made to a Progress.
+type progressLog []string
+
+func (l *progressLog) progress(every int64) Progress {
+	return Progress{
+		Func: func(op string, done, total int64) {
+			*l = append(*l, fmt.Sprintf("%s %d/%d", strings.TrimPrefix(op, "rangearray."), done, total))
+		},
+		Every: every,
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var log progressLog
+	pr := log.progress(10).start("op", 25)
+	for range 25 {
+		pr.add(1)
+	}
+	pr.finish()
+	expected := progressLog{"op 10/25", "op 20/25", "op 25/25"}
+	if !slices.Equal(log, expected) {
+		t.Errorf("Expected %v, got %v", expected, log)
+	}
+
+	log = nil
+	pr = log.progress(0).start("op", -1)
+	pr.add(DefaultProgressEvery)
+	pr.finish()
+	expected = progressLog{"op 65536/-1", "op 65536/65536"}
+	if !slices.Equal(log, expected) {
+		t.Errorf("Expected %v, got %v", expected, log)
+	}
+
+	log = nil
+	log.progress(0).start("op", 0).finish()
+	if !slices.Equal(log, progressLog{"op 0/0"}) {
+		t.Errorf("Expected an empty operation to report once, got %v", log)
+	}
+
+	pr = Progress{}.start("op", 5)
+	pr.add(5)
+	pr.finish()
+}
+
+func TestProgressOptions(t *testing.T) {
+	var r Uint32
+	for i := uint32(0); i < 1200; i++ {
+		r.Push(2 * i)
+	}
+
+	var log progressLog
+	e := Encoder{Progress: log.progress(500)}
+	data, err := e.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	e.Codec = flateCodec{}
+	e.Encode(&buf, r)
+	d := Decoder{Codec: flateCodec{}, Progress: log.progress(500)}
+	d.Unmarshal(data, &r)
+	d.Decode(&buf, &r)
+	expected := progressLog{
+		"Encode 500/1200", "Encode 1000/1200", "Encode 1200/1200",
+		"Encode 512/1200", "Encode 1024/1200", "Encode 1200/1200",
+		"Decode 512/1200", "Decode 1024/1200", "Decode 1200/1200",
+		"Decode 512/1200", "Decode 1024/1200", "Decode 1200/1200",
+	}
+	if !slices.Equal(log, expected) {
+		t.Errorf("Expected %v, got %v", expected, log)
+	}
+
+	log = nil
+	ReadCSV(strings.NewReader("1\n2\n3\n"), CSVOptions{Progress: log.progress(2)})
+	v := NewUnionView(r, Uint32{runs: []Uint32Run{{Value: 5000, Count: 1}}})
+	v.SetProgress(log.progress(1000))
+	v.Materialize()
+	expected = progressLog{"ReadCSV 2/-1", "ReadCSV 3/3", "Union 1000/-1", "Union 1201/1201"}
+	if !slices.Equal(log, expected) {
+		t.Errorf("Expected %v, got %v", expected, log)
+	}
+}
@@ -0,0 +1,84 @@
+package rangearray
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PublisherOptions controls how often a Publisher publishes.  If Every
+// and Interval are both zero, every Push is published.
+type PublisherOptions struct {
+	// Every, if not zero, publishes after each Every pushes.
+	Every int
+
+	// Interval, if not zero, publishes on the first Push at least
+	// Interval after the previous publication.
+	Interval time.Duration
+
+	// Now returns the current time.  If it is nil, time.Now is used.
+	Now func() time.Time
+}
+
+// Publisher lets one writer goroutine build a rangearray while any
+// number of readers query immutable snapshots of it without locking.
+// The writer calls Push and Publish; readers call Snapshot.
+//
+// Each publication copies the runs built so far, so publishing takes
+// time proportional to the number of runs; for large arrays with high
+// push rates, publish every N pushes or every interval instead of on
+// every push.
+type Publisher struct {
+	opts    PublisherOptions
+	r       Uint32
+	pending int
+	last    time.Time
+	snap    atomic.Pointer[Frozen]
+}
+
+// NewPublisher returns a Publisher whose array is initially empty.
+func NewPublisher(opts PublisherOptions) *Publisher {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	p := &Publisher{opts: opts}
+	p.snap.Store(&Frozen{})
+	if opts.Interval > 0 {
+		p.last = opts.Now()
+	}
+	return p
+}
+
+// Push adds x to the array, and publishes a new snapshot if the
+// options call for one.  Only the writer goroutine may call Push.  It
+// reports whether x was added, as Uint32.Push does.
+func (p *Publisher) Push(x uint32) bool {
+	added := p.r.Push(x)
+	p.pending++
+
+	switch {
+	case p.opts.Every == 0 && p.opts.Interval == 0:
+	case p.opts.Every > 0 && p.pending >= p.opts.Every:
+	case p.opts.Interval > 0 && p.opts.Now().Sub(p.last) >= p.opts.Interval:
+	default:
+		return added
+	}
+	p.Publish()
+	return added
+}
+
+// Publish publishes a snapshot of the array as it is now, even if the
+// options do not call for one.  Only the writer goroutine may call
+// Publish.
+func (p *Publisher) Publish() {
+	p.snap.Store(p.r.Freeze())
+	p.pending = 0
+	if p.opts.Interval > 0 {
+		p.last = p.opts.Now()
+	}
+}
+
+// Snapshot returns the most recently published snapshot.  It is safe
+// to call from any goroutine, and never blocks.
+func (p *Publisher) Snapshot() *Frozen {
+	return p.snap.Load()
+}
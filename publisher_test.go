@@ -0,0 +1,88 @@
+package rangearray
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPublisher(t *testing.T) {
+	p := NewPublisher(PublisherOptions{})
+	if p.Snapshot().Len() != 0 {
+		t.Errorf("Expected an empty initial snapshot, got %v", p.Snapshot())
+	}
+	p.Push(5)
+	snap := p.Snapshot()
+	p.Push(6)
+	if snap.Len() != 1 || p.Snapshot().Len() != 2 {
+		t.Errorf("Expected every Push to publish, got %v then %v", snap, p.Snapshot())
+	}
+
+	p = NewPublisher(PublisherOptions{Every: 3})
+	for i := uint32(0); i < 5; i++ {
+		p.Push(i)
+	}
+	if p.Snapshot().Len() != 3 {
+		t.Errorf("Expected publishing every 3 pushes, got %v", p.Snapshot())
+	}
+	p.Publish()
+	if p.Snapshot().Len() != 5 {
+		t.Errorf("Expected Publish() to publish, got %v", p.Snapshot())
+	}
+}
+
+func TestPublisherInterval(t *testing.T) {
+	now := time.Unix(1000, 0)
+	p := NewPublisher(PublisherOptions{
+		Interval: time.Second,
+		Now:      func() time.Time { return now },
+	})
+	p.Push(1)
+	now = now.Add(500 * time.Millisecond)
+	p.Push(2)
+	if p.Snapshot().Len() != 0 {
+		t.Errorf("Expected no publication within the interval, got %v", p.Snapshot())
+	}
+	now = now.Add(500 * time.Millisecond)
+	p.Push(3)
+	if p.Snapshot().Len() != 3 {
+		t.Errorf("Expected a publication after the interval, got %v", p.Snapshot())
+	}
+	now = now.Add(500 * time.Millisecond)
+	p.Push(4)
+	if p.Snapshot().Len() != 3 {
+		t.Errorf("Expected the interval to restart, got %v", p.Snapshot())
+	}
+}
+
+func TestPublisherConcurrent(t *testing.T) {
+	p := NewPublisher(PublisherOptions{Every: 10})
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint32
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s := p.Snapshot()
+				n := s.Len()
+				if n < last || (n > 0 && !s.Contains(n-1)) {
+					t.Errorf("Inconsistent snapshot %v after length %d", s, last)
+					return
+				}
+				last = n
+			}
+		}()
+	}
+	for i := uint32(0); i < 10000; i++ {
+		p.Push(i)
+	}
+	close(done)
+	wg.Wait()
+}
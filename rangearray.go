@@ -6,7 +6,8 @@ package rangearray
 // those runs.
 //
 // Note that the data structure is optimized for insertions only at the
-// end; insertions before the end can be very expensive.  A search over
+// end; insertions before the end can be very expensive.  (Backfill
+// loads values that arrive out of order more cheaply.)  A search over
 // n runs takes O(log(n)) time.
 
 // As motivation, the original application for this package was to
@@ -36,7 +37,11 @@ package rangearray
 // Each rangearray is a slice of RLE entries.
 
 import (
+	"fmt"
+	"iter"
+	"slices"
 	"sort"
+	"sync/atomic"
 )
 
 // Uint32Run is an RLE entry in a Uint32 rangearray.
@@ -53,127 +58,432 @@ type Uint32Run struct {
 
 // Uint32 is a semi-dense array of Uint32 values.  The zero value is an
 // empty rangearray.
+//
+// A Uint32 is not safe for concurrent use: any number of goroutines may
+// query it at once, but not while another goroutine modifies it.  Use
+// SafeUint32 to share one array between writers and readers.
 type Uint32 struct {
-	S []Uint32Run
+	// runs holds the runs of the array, in increasing order.  Each
+	// run's Index is the sum of the Counts before it.
+	runs []Uint32Run
+
+	// shared is set by Fork when runs may be shared with another
+	// array, so that runs must be copied before it is modified.
+	shared bool
+
+	// pinned is set by Capture when a Snapshot may share runs, so that
+	// runs must be copied before any run but the last is modified.
+	pinned bool
+
+	// index holds a sampled index of runs once there are many.
+	index *atomic.Pointer[runIndex]
 }
 
-// Min returns the minimum value in r.  Panics if r is empty.
+// MaxLen is the largest number of values that a Uint32 can hold: one
+// fewer than the number of uint32 values, so that Len and each run's
+// Count fit in a uint32.  An array can therefore never hold every
+// uint32 value.  Push ignores the value that would complete the set,
+// and the set operations and views leave 0xffffffff out of a result
+// that would otherwise hold every value.  Decoders reject such data.
+const MaxLen = 1<<32 - 1
+
+// Min returns the minimum value in r.  Panics if r is empty; MinOK
+// does not.
 func (r Uint32) Min() uint32 {
-	return r.S[0].Value
+	return r.runs[0].Value
 }
 
-// Max returns the maximum value in r.  Panics if r is empty.
+// Max returns the maximum value in r.  Panics if r is empty; MaxOK
+// does not.
 func (r Uint32) Max() uint32 {
-	n := len(r.S) - 1
-	return r.S[n].Value + r.S[n].Count - 1
+	n := len(r.runs) - 1
+	return r.runs[n].Value + r.runs[n].Count - 1
+}
+
+// MinOK returns the minimum value in r and true, or zero and false if
+// r is empty.
+func (r Uint32) MinOK() (uint32, bool) {
+	if len(r.runs) == 0 {
+		return 0, false
+	}
+	return r.Min(), true
+}
+
+// MaxOK returns the maximum value in r and true, or zero and false if
+// r is empty.
+func (r Uint32) MaxOK() (uint32, bool) {
+	if len(r.runs) == 0 {
+		return 0, false
+	}
+	return r.Max(), true
 }
 
 // Len returns the number of elements in r.
 func (r Uint32) Len() uint32 {
-	if r.S == nil {
+	if len(r.runs) == 0 {
 		return 0
 	}
 
-	n := len(r.S) - 1
-	return r.S[n].Index + r.S[n].Count
+	n := len(r.runs) - 1
+	return r.runs[n].Index + r.runs[n].Count
 }
 
 // IndexOf returns the number of elements in r that are less than x.
 func (r Uint32) IndexOf(x uint32) uint32 {
 	// Common case: x <= r.Max().
 	i := r.LowerBound(x)
-	if i < len(r.S) {
-		if x <= r.S[i].Value {
-			return r.S[i].Index
+	if i < len(r.runs) {
+		if x <= r.runs[i].Value {
+			return r.runs[i].Index
 		}
-		return x - r.S[i].Value + r.S[i].Index
+		return x - r.runs[i].Value + r.runs[i].Index
 	}
 
 	// Otherwise, r is empty or x > r.Max().
 	return r.Len()
 }
 
+// At returns the i'th smallest value in r, counting from zero, which
+// is the value x for which IndexOf(x) is i.  Panics if i is not less
+// than r.Len().
+func (r Uint32) At(i uint32) uint32 {
+	if i >= r.Len() {
+		panic(fmt.Sprintf("rangearray: Uint32.At index %d out of range [0:%d]", i, r.Len()))
+	}
+	n := sort.Search(len(r.runs), func(k int) bool {
+		return uint64(r.runs[k].Index)+uint64(r.runs[k].Count) > uint64(i)
+	})
+	return r.runs[n].Value + (i - r.runs[n].Index)
+}
+
+// IndicesOf returns IndexOf(x) for each x in xs.  When xs is sorted,
+// it walks the runs of r once alongside xs, taking O(r.NumRuns() +
+// len(xs)) time rather than a binary search per query; values that are
+// out of order fall back to a binary search.
+func (r Uint32) IndicesOf(xs []uint32) []uint32 {
+	out := make([]uint32, len(xs))
+	i := 0
+	for k, x := range xs {
+		if k > 0 && x < xs[k-1] {
+			i = r.LowerBound(x)
+		}
+		for i < len(r.runs) && uint64(x) >= uint64(r.runs[i].Value)+uint64(r.runs[i].Count) {
+			i++
+		}
+		switch {
+		case i == len(r.runs):
+			out[k] = r.Len()
+		case x <= r.runs[i].Value:
+			out[k] = r.runs[i].Index
+		default:
+			out[k] = x - r.runs[i].Value + r.runs[i].Index
+		}
+	}
+	return out
+}
+
 // LowerBound returns the index of the run in r that contains x.  If no
 // run contains x, LowerBound returns the index of the run that starts
-// after x.  If x is after r.Max(), returns len(r.S).
+// after x.  If x is after r.Max(), returns r.NumRuns().
 func (r Uint32) LowerBound(x uint32) int {
-	if r.S == nil {
+	if len(r.runs) == 0 {
 		return 0
 	}
+	if idx := r.sampled(); idx != nil {
+		return idx.lowerBound(r.runs, x)
+	}
+	return searchRuns(r.runs, x)
+}
+
+// searchRuns returns the index of the first run in s that ends after
+// x, or len(s) if there is none.  It is a binary search written out by
+// hand, rather than with sort.Search, so that each probe is inlined and
+// the choice of half is computed arithmetically instead of with a
+// branch that mispredicts half the time.  That is about twice as fast
+// as sort.Search when s fits in cache; larger arrays keep a sampled
+// index so that the final search is over a short stretch of s.
+func searchRuns(s []Uint32Run, x uint32) int {
+	base, n := 0, len(s)
+	for n > 1 {
+		half := n / 2
+		run := s[base+half-1]
+		// past is 1 if the run ends at or before x, and 0 otherwise.
+		past := 1 - int(uint64(int64(x)-int64(run.Value)-int64(run.Count))>>63)
+		base += half * past
+		n -= half
+	}
+	if n == 1 && uint64(s[base].Value)+uint64(s[base].Count) <= uint64(x) {
+		base++
+	}
+	return base
+}
 
-	return sort.Search(len(r.S), func(i int) bool {
-		return x < r.S[i].Value+r.S[i].Count
+// Contains reports whether x is in r.
+func (r Uint32) Contains(x uint32) bool {
+	i := r.LowerBound(x)
+	return i < len(r.runs) && x >= r.runs[i].Value
+}
+
+// All returns an iterator over the values in r, in increasing order.
+func (r Uint32) All() iter.Seq[uint32] {
+	return valuesOf(r.Runs())
+}
+
+// Runs returns an iterator over the runs in r, in increasing order.
+func (r Uint32) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		for _, s := range r.runs {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// NumRuns returns the number of runs in r.
+func (r Uint32) NumRuns() int {
+	return len(r.runs)
+}
+
+// Run returns the i'th run of r.  Panics if i is out of range.
+func (r Uint32) Run(i int) Uint32Run {
+	return r.runs[i]
+}
+
+// AppendRuns appends the runs of r to dst, in increasing order, and
+// returns the extended slice.  Changing the result does not change r.
+func (r Uint32) AppendRuns(dst []Uint32Run) []Uint32Run {
+	return append(dst, r.runs...)
+}
+
+// appendRun adds the count values starting at value to the end of r.
+// It returns false, leaving r unchanged, if count is zero, the run
+// does not come after every value already in r, or r would hold more
+// than MaxLen values.
+func (r *Uint32) appendRun(value, count uint32) bool {
+	if count == 0 || uint64(value)+uint64(count) > 1<<32 || uint64(r.Len())+uint64(count) > MaxLen {
+		return false
+	}
+	r.own()
+
+	n := len(r.runs) - 1
+	if n >= 0 {
+		end := uint64(r.runs[n].Value) + uint64(r.runs[n].Count)
+		if uint64(value) < end {
+			return false
+		}
+		if uint64(value) == end {
+			r.runs[n].Count += count
+			if debugChecks {
+				r.check("appendRun", n, 0)
+			}
+			return true
+		}
+	}
+
+	r.grow()
+	r.runs = append(r.runs, Uint32Run{
+		Value: value,
+		Index: r.Len(),
+		Count: count,
 	})
+	r.keepIndex()
+	if debugChecks {
+		r.check("appendRun", n, 0)
+	}
+	return true
+}
+
+// Fork returns a copy of r that shares r's runs until either array is
+// modified.  The first Push (or other change) to either array copies
+// its runs, so the two stay independent, and forking is cheap even for
+// a large array.  Plain assignment, by contrast, leaves both copies
+// modifying the same runs.
+func (r *Uint32) Fork() Uint32 {
+	r.shared = true
+	return Uint32{runs: r.runs, shared: true}
+}
+
+// own gives r its own copy of its runs if Fork may have shared them.
+func (r *Uint32) own() {
+	if r.shared {
+		r.runs = slices.Clone(r.runs)
+		r.shared = false
+		r.pinned = false
+	}
 }
 
-// Push adds x to r.
-func (r *Uint32) Push(x uint32) {
+// unpin gives r its own copy of its runs if Capture may have shared
+// them.  It is needed before changing any run but the last.
+func (r *Uint32) unpin() {
+	if r.pinned {
+		r.runs = slices.Clone(r.runs)
+		r.pinned = false
+	}
+}
+
+// Push adds x to r, and reports whether x was added: false if x was
+// already in r, or if r already holds MaxLen values, in which case
+// Push leaves r unchanged.  Ingest code can count the false results to
+// detect duplicate or replayed input.
+func (r *Uint32) Push(x uint32) bool {
+	added := r.push(x)
+	if debugChecks {
+		// Push changes only the runs from the one before x on.
+		r.check("Push", searchRuns(r.runs, x)-1, 0)
+	}
+	return added
+}
+
+// push implements Push, without checking the invariants of r, for the
+// arrays whose indexes do not start at zero.
+func (r *Uint32) push(x uint32) bool {
+	if r.Len() == MaxLen {
+		return false
+	}
+	r.own()
+
 	// Is this the first entry?
-	if r.S == nil {
-		r.S = append(r.S, Uint32Run{
+	if len(r.runs) == 0 {
+		r.runs = append(r.runs, Uint32Run{
 			Value: x,
 			Index: 0,
 			Count: 1,
 		})
-		return
+		return true
 	}
 
 	// Can we append to the last entry?
-	n := len(r.S) - 1
-	if r.S[n].Value+r.S[n].Count == x {
-		r.S[n].Count++
-		return
+	n := len(r.runs) - 1
+	end := uint64(r.runs[n].Value) + uint64(r.runs[n].Count)
+	if end == uint64(x) {
+		r.runs[n].Count++
+		return true
 	}
 
 	// Is it past the last entry?
-	if r.S[n].Value+r.S[n].Count < x {
-		r.S = append(r.S, Uint32Run{
+	if end < uint64(x) {
+		r.grow()
+		r.runs = append(r.runs, Uint32Run{
 			Value: x,
-			Index: r.S[n].Index + r.S[n].Count,
+			Index: r.runs[n].Index + r.runs[n].Count,
 			Count: 1,
 		})
-		return
+		r.keepIndex()
+		return true
 	}
 
 	// Find the insertion point.
 	n = r.LowerBound(x)
-	if x >= r.S[n].Value {
-		// either x is within r.S[n] and we silently ignore the dupe...
-		// or x is after r.S[n] and LowerBound() had a bug
-		return
+	if x >= r.runs[n].Value {
+		// either x is within r.runs[n] and we silently ignore the dupe...
+		// or x is after r.runs[n] and LowerBound() had a bug
+		return false
 	}
+	r.unpin()
+	r.dropIndex()
 
-	// Is x just after r.S[n-1]?
-	afterNm1 := n > 0 && x == r.S[n-1].Value+r.S[n-1].Count
+	// Is x just after r.runs[n-1]?
+	afterNm1 := n > 0 && x == r.runs[n-1].Value+r.runs[n-1].Count
 
-	// Is x just before r.S[n]?
-	if x+1 == r.S[n].Value {
+	// Is x just before r.runs[n]?
+	if x+1 == r.runs[n].Value {
 		if afterNm1 {
-			// Merge r.S[n] into r.S[n-1] and shrink the rest.
-			r.S[n-1].Count += r.S[n].Count + 1
-			copy(r.S[n:], r.S[n+1:])
-			r.S = r.S[:len(r.S)-1]
+			// Merge r.runs[n] into r.runs[n-1] and shrink the rest.
+			r.runs[n-1].Count += r.runs[n].Count + 1
+			copy(r.runs[n:], r.runs[n+1:])
+			r.runs = r.runs[:len(r.runs)-1]
 			n--
 		} else {
-			r.S[n].Value--
-			r.S[n].Count++
+			r.runs[n].Value--
+			r.runs[n].Count++
 		}
 	} else if afterNm1 {
-		r.S[n-1].Count++
+		r.runs[n-1].Count++
 		n--
 	} else {
-		l := len(r.S)
-		r.S = append(r.S, r.S[l-1])
-		copy(r.S[n+1:l], r.S[n:l-1])
-		r.S[n] = Uint32Run{
+		l := len(r.runs)
+		r.grow()
+		r.runs = append(r.runs, r.runs[l-1])
+		copy(r.runs[n+1:l], r.runs[n:l-1])
+		r.runs[n] = Uint32Run{
 			Value: x,
-			Index: r.S[n+1].Index,
+			Index: r.runs[n+1].Index,
 			Count: 1,
 		}
 	}
 
-	for n+1 < len(r.S) {
+	for n+1 < len(r.runs) {
 		n++
-		r.S[n].Index++
+		r.runs[n].Index++
+	}
+	return true
+}
+
+// ShiftOf reports whether o is r with every value shifted by the same
+// offset.  If it is, ShiftOf returns that offset, so each value x in r
+// corresponds to x+offset in o.  Two empty rangearrays are shifts of
+// each other with an offset of zero.
+func (r Uint32) ShiftOf(o Uint32) (int64, bool) {
+	if len(r.runs) != len(o.runs) {
+		return 0, false
+	}
+	if len(r.runs) == 0 {
+		return 0, true
+	}
+
+	offset := int64(o.runs[0].Value) - int64(r.runs[0].Value)
+	for i := range r.runs {
+		if r.runs[i].Count != o.runs[i].Count {
+			return 0, false
+		}
+		if int64(o.runs[i].Value)-int64(r.runs[i].Value) != offset {
+			return 0, false
+		}
+	}
+
+	return offset, true
+}
+
+// Shift returns a copy of r with offset added to every value, so that
+// r.ShiftOf(r.Shift(offset)) is offset.  Panics if a value would leave
+// the uint32 range.
+func (r Uint32) Shift(offset int64) Uint32 {
+	if len(r.runs) == 0 {
+		return Uint32{}
+	}
+	if int64(r.Min())+offset < 0 || int64(r.Max())+offset > 0xffffffff {
+		panic(fmt.Sprintf("rangearray: Shift(%d) moves a value out of the uint32 range", offset))
+	}
+
+	out := Uint32{runs: slices.Clone(r.runs)}
+	for i := range out.runs {
+		out.runs[i].Value = uint32(int64(out.runs[i].Value) + offset)
+	}
+	return out
+}
+
+// Scale returns the array holding x*factor for each value x in r, such
+// as to convert ticks to a finer rate.  Unless factor is 1, each value
+// of the result is a run of its own.  Panics if factor is zero, or if a
+// value would overflow a uint32.
+func (r Uint32) Scale(factor uint32) Uint32 {
+	if factor == 0 {
+		panic("rangearray: Scale factor is zero")
+	}
+	if len(r.runs) == 0 {
+		return Uint32{}
+	}
+	if uint64(r.Max())*uint64(factor) > 0xffffffff {
+		panic(fmt.Sprintf("rangearray: Scale(%d) overflows a uint32", factor))
+	}
+	if factor == 1 {
+		return Uint32{runs: slices.Clone(r.runs)}
+	}
+
+	out := Uint32{runs: make([]Uint32Run, 0, r.Len())}
+	for x := range r.All() {
+		out.runs = append(out.runs, Uint32Run{Value: x * factor, Index: uint32(len(out.runs)), Count: 1})
 	}
+	return out
 }
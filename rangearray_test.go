@@ -1,6 +1,8 @@
 package rangearray
 
 import (
+	"fmt"
+	"sort"
 	"testing"
 )
 
@@ -40,6 +42,25 @@ func TestEmptyUint32(t *testing.T) {
 	}
 }
 
+func TestMinMaxOK(t *testing.T) {
+	r := Uint32{}
+	if x, ok := r.MinOK(); ok || x != 0 {
+		t.Errorf("Expected Uint32{}.MinOK() == 0, false, got %d, %v", x, ok)
+	}
+	if x, ok := r.MaxOK(); ok || x != 0 {
+		t.Errorf("Expected Uint32{}.MaxOK() == 0, false, got %d, %v", x, ok)
+	}
+
+	r.Push(7)
+	r.Push(9)
+	if x, ok := r.MinOK(); !ok || x != 7 {
+		t.Errorf("Expected MinOK() == 7, true, got %d, %v", x, ok)
+	}
+	if x, ok := r.MaxOK(); !ok || x != 9 {
+		t.Errorf("Expected MaxOK() == 9, true, got %d, %v", x, ok)
+	}
+}
+
 type indexOfUint32 struct {
 	value, index uint32
 }
@@ -62,8 +83,8 @@ func TestSearchUint32(t *testing.T) {
 		r.Push(uint32(i))
 	}
 
-	if len(r.S) != 2 {
-		t.Errorf("Expected len(r.S) == 2, got %d", len(r.S))
+	if len(r.runs) != 2 {
+		t.Errorf("Expected len(r.runs) == 2, got %d", len(r.runs))
 	}
 
 	if x := r.LowerBound(50); x != 0 {
@@ -95,3 +116,389 @@ func TestSearchUint32(t *testing.T) {
 		{500, 205},
 	})
 }
+
+func pushRange(r *Uint32, first, last uint32) {
+	for i := first; i <= last; i++ {
+		r.Push(i)
+	}
+}
+
+func TestShiftOfUint32(t *testing.T) {
+	a := &Uint32{}
+	pushRange(a, 100, 199)
+	pushRange(a, 350, 449)
+
+	b := &Uint32{}
+	pushRange(b, 118, 217)
+	pushRange(b, 368, 467)
+
+	if x, ok := a.ShiftOf(*b); !ok || x != 18 {
+		t.Errorf("Expected a.ShiftOf(b) == 18, true, got %d, %v", x, ok)
+	}
+	if x, ok := b.ShiftOf(*a); !ok || x != -18 {
+		t.Errorf("Expected b.ShiftOf(a) == -18, true, got %d, %v", x, ok)
+	}
+	if x, ok := (Uint32{}).ShiftOf(Uint32{}); !ok || x != 0 {
+		t.Errorf("Expected Uint32{}.ShiftOf(Uint32{}) == 0, true, got %d, %v", x, ok)
+	}
+
+	b.Push(500)
+	if _, ok := a.ShiftOf(*b); ok {
+		t.Errorf("Expected a.ShiftOf(b) to fail after an extra run")
+	}
+
+	c := &Uint32{}
+	pushRange(c, 0, 99)
+	pushRange(c, 250, 348)
+	if _, ok := a.ShiftOf(*c); ok {
+		t.Errorf("Expected a.ShiftOf(c) to fail with a shorter run")
+	}
+}
+
+func TestContainsUint32(t *testing.T) {
+	r := &Uint32{}
+	pushRange(r, 100, 102)
+	pushRange(r, 200, 201)
+
+	for _, x := range []uint32{100, 101, 102, 200, 201} {
+		if !r.Contains(x) {
+			t.Errorf("Expected r.Contains(%d) == true", x)
+		}
+	}
+	for _, x := range []uint32{0, 99, 103, 199, 202} {
+		if r.Contains(x) {
+			t.Errorf("Expected r.Contains(%d) == false", x)
+		}
+	}
+
+	var got []uint32
+	for x := range r.All() {
+		got = append(got, x)
+	}
+	if len(got) != 5 || got[0] != 100 || got[3] != 200 || got[4] != 201 {
+		t.Errorf("Expected r.All() == [100 101 102 200 201], got %v", got)
+	}
+}
+
+func TestForkUint32(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 10, 19)
+	pushRange(&r, 30, 39)
+
+	f := r.Fork()
+	if &f.runs[0] != &r.runs[0] {
+		t.Errorf("Expected Fork() to share runs")
+	}
+	r.Push(20)
+	r.Push(5)
+	if f.String() != "10-19,30-39" || r.String() != "5,10-20,30-39" {
+		t.Errorf("Expected Push() to r not to change its fork, got %v and %v", r, f)
+	}
+
+	g := f.Fork()
+	g.Push(40)
+	f.Push(29)
+	if f.String() != "10-19,29-39" || g.String() != "10-19,30-40" {
+		t.Errorf("Expected forks to stay independent, got %v and %v", f, g)
+	}
+	if f.IndexOf(30) != 11 || g.IndexOf(40) != 20 {
+		t.Errorf("Expected forks to keep valid indexes, got %d and %d", f.IndexOf(30), g.IndexOf(40))
+	}
+}
+
+func TestRunAccessors(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 10, 19)
+	pushRange(&r, 30, 39)
+
+	if r.NumRuns() != 2 || r.Run(1) != (Uint32Run{30, 10, 10}) {
+		t.Errorf("Expected 2 runs ending with {30 10 10}, got %d and %v", r.NumRuns(), r.Run(1))
+	}
+	runs := r.AppendRuns([]Uint32Run{{1, 2, 3}})
+	if len(runs) != 3 || runs[1] != r.Run(0) || runs[2] != r.Run(1) {
+		t.Errorf("Expected AppendRuns() to append both runs, got %v", runs)
+	}
+	runs[1].Count = 1
+	if r.String() != "10-19,30-39" {
+		t.Errorf("Expected changing AppendRuns() not to change r, got %v", r)
+	}
+}
+
+func TestIndicesOfUint32(t *testing.T) {
+	r := &Uint32{}
+	pushRange(r, 100, 102)
+	pushRange(r, 200, 201)
+	r.runs = append(r.runs, Uint32Run{Value: 0xfffffff0, Index: 5, Count: 16})
+
+	xs := []uint32{0, 100, 101, 150, 201, 202, 0xfffffff0, 0xffffffff, 5, 101, 101}
+	want := []uint32{0, 0, 1, 3, 4, 5, 5, 20, 0, 1, 1}
+	got := r.IndicesOf(xs)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d indices, got %v", len(want), got)
+	}
+	for k := range want {
+		if got[k] != want[k] {
+			t.Errorf("Expected IndicesOf()[%d] == %d for %d, got %d", k, want[k], xs[k], got[k])
+		}
+	}
+
+	if len(r.IndicesOf(nil)) != 0 {
+		t.Errorf("Expected IndicesOf(nil) to be empty")
+	}
+	if got := (Uint32{}).IndicesOf([]uint32{3}); got[0] != 0 {
+		t.Errorf("Expected IndicesOf() on an empty array to be 0, got %v", got)
+	}
+}
+
+func TestSearchRuns(t *testing.T) {
+	r := &Uint32{}
+	for i := uint32(0); i < 100; i++ {
+		pushRange(r, 10*i, 10*i+i%4)
+	}
+	for n := 0; n <= len(r.runs); n++ {
+		s := r.runs[:n]
+		for x := uint32(0); x < 1010; x++ {
+			want := sort.Search(n, func(i int) bool {
+				return x < s[i].Value+s[i].Count
+			})
+			if got := searchRuns(s, x); got != want {
+				t.Fatalf("Expected searchRuns(%d runs, %d) = %d, got %d", n, x, want, got)
+			}
+		}
+	}
+
+	top := []Uint32Run{{Value: 5, Count: 1}, {Value: 0xfffffff0, Index: 1, Count: 16}}
+	if got := searchRuns(top, 0xffffffff); got != 1 {
+		t.Errorf("Expected the run ending at the top to hold 0xffffffff, got %d", got)
+	}
+}
+
+func BenchmarkLowerBound(b *testing.B) {
+	for _, runs := range []uint32{1 << 10, 1 << 20} {
+		r := &Uint32{}
+		for i := uint32(0); i < runs; i++ {
+			r.Push(3 * i)
+		}
+		plain := Uint32{runs: r.runs}
+		optimized := Uint32{runs: r.runs}
+		optimized.OptimizeForReads()
+
+		bench := func(name string, lowerBound func(uint32) int) {
+			b.Run(fmt.Sprintf("%s/%d", name, runs), func(b *testing.B) {
+				x := uint32(0)
+				for range b.N {
+					lowerBound(x)
+					x = (x + 0x9e3779b1>>8) % (3 * runs)
+				}
+			})
+		}
+		bench("Plain", plain.LowerBound)
+		bench("Sampled", r.LowerBound)
+		bench("Eytzinger", optimized.LowerBound)
+		bench("SortSearch", func(x uint32) int {
+			return sort.Search(len(r.runs), func(i int) bool {
+				return x < r.runs[i].Value+r.runs[i].Count
+			})
+		})
+	}
+}
+
+func TestCopyUint32(t *testing.T) {
+	// Copies of an array must not share storage with the variable they
+	// were copied from, or reusing it would change them.
+	var arrays []Uint32
+	var cur Uint32
+	for i := uint32(0); i < 3; i++ {
+		cur.Push(100 * i)
+		cur.Push(100*i + 1)
+		arrays = append(arrays, cur)
+		cur = Uint32{}
+	}
+	cur.Push(7)
+	for i, r := range arrays {
+		if want := fmt.Sprintf("%d-%d", 100*i, 100*i+1); r.String() != want {
+			t.Errorf("Expected copy %d to hold %s, got %s", i, want, r.String())
+		}
+	}
+
+	var c Collection
+	c.Set("a", arrays[0])
+	a, _ := c.Get("a")
+	c.Set("a", arrays[1])
+	if a.String() != "0-1" {
+		t.Errorf("Expected a copy from Get() to survive Set(), got %s", a.String())
+	}
+}
+
+func TestTopOfRangeUint32(t *testing.T) {
+	var r Uint32
+	r.Push(5)
+	pushRange(&r, 0xfffffff0, 0xfffffffe)
+	r.Push(0xffffffff)
+	if r.String() != "5,4294967280-4294967295" || r.Max() != 0xffffffff || r.Len() != 17 {
+		t.Errorf("Expected 5,4294967280-4294967295 with 17 values, got %v with max %#x and %d values", r, r.Max(), r.Len())
+	}
+	if r.LowerBound(0xffffffff) != 1 || !r.Contains(0xffffffff) || r.IndexOf(0xffffffff) != 16 {
+		t.Errorf("Expected 0xffffffff in run 1 at index 16, got run %d, %v, index %d",
+			r.LowerBound(0xffffffff), r.Contains(0xffffffff), r.IndexOf(0xffffffff))
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Expected a valid array, got %v", err)
+	}
+
+	u := NewUnionView(r, Uint32{})
+	if u.IndexOf(0xffffffff) != 16 || u.Len() != 17 {
+		t.Errorf("Expected the union to match r, got index %d and %d values", u.IndexOf(0xffffffff), u.Len())
+	}
+}
+
+// almostFull returns an array holding every value but x.
+func almostFull(x uint32) Uint32 {
+	var r Uint32
+	if x > 0 {
+		r.appendRun(0, x)
+	}
+	if x < 0xffffffff {
+		r.appendRun(x+1, 0xffffffff-x)
+	}
+	return r
+}
+
+func TestFullUint32(t *testing.T) {
+	for _, x := range []uint32{0, 12345, 0xffffffff} {
+		r := almostFull(x)
+		if r.Len() != MaxLen {
+			t.Fatalf("Expected %d values without %d, got %d", uint32(MaxLen), x, r.Len())
+		}
+		r.Push(x)
+		if r.Len() != MaxLen || r.Contains(x) || r.Validate() != nil {
+			t.Errorf("Expected Push(%d) to leave a full array unchanged, got %v", x, r)
+		}
+
+		l := LeanOf(almostFull(x))
+		l.Push(x)
+		if l.Len() != MaxLen || l.Contains(x) {
+			t.Errorf("Expected Lean.Push(%d) to leave a full array unchanged, got %d values", x, l.Len())
+		}
+		if p := PersistentOf(almostFull(x)).Push(x); p.Len() != MaxLen || p.Contains(x) {
+			t.Errorf("Expected Persistent.Push(%d) to leave a full array unchanged, got %d values", x, p.Len())
+		}
+		tr := TreeOf(almostFull(x))
+		tr.Push(x)
+		if tr.Len() != MaxLen || tr.Contains(x) {
+			t.Errorf("Expected Tree.Push(%d) to leave a full array unchanged, got %d values", x, tr.Len())
+		}
+		b := BackfillOf(almostFull(x))
+		b.Push(x)
+		if b.Len() != MaxLen || b.Contains(x) {
+			t.Errorf("Expected Backfill.Push(%d) to leave a full array unchanged, got %d values", x, b.Len())
+		}
+	}
+
+	var r Uint32
+	if !r.appendRun(0, 1<<31) || r.appendRun(1<<31, 1<<31) || r.String() != "0-2147483647" {
+		t.Errorf("Expected appendRun() to refuse to fill the array, got %v", r)
+	}
+}
+
+func TestPushReportsAdded(t *testing.T) {
+	arrays := map[string]interface{ Push(uint32) bool }{
+		"Uint32":     &Uint32{},
+		"Array":      NewArray(BackendFrozen),
+		"Backfill":   &Backfill{},
+		"Buffered":   &Buffered{},
+		"Lean":       &Lean{},
+		"Tiered":     &Tiered{},
+		"Tree":       &Tree{},
+		"SafeUint32": &SafeUint32{},
+		"Retained":   NewRetained(RetentionOptions{}),
+		"Publisher":  NewPublisher(PublisherOptions{}),
+	}
+	for name, r := range arrays {
+		for _, x := range []uint32{10, 12, 11, 5, 20} {
+			if !r.Push(x) {
+				t.Errorf("Expected %s.Push(%d) to add it", name, x)
+			}
+		}
+		for _, x := range []uint32{10, 11, 12, 5, 20} {
+			if r.Push(x) {
+				t.Errorf("Expected %s.Push(%d) to find it already there", name, x)
+			}
+		}
+	}
+
+	var c Collection
+	if !c.Push("a", 1) || c.Push("a", 1) || !c.Push("b", 1) {
+		t.Errorf("Expected Collection.Push() to report new values per key")
+	}
+}
+
+func TestAtUint32(t *testing.T) {
+	r := testEncodingArray()
+	for _, c := range []struct{ i, want uint32 }{{0, 100}, {99, 199}, {100, 350}, {200, 1000}, {201, 0xffffffff}} {
+		if x := r.At(c.i); x != c.want {
+			t.Errorf("Expected At(%d) == %d, got %d", c.i, c.want, x)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected At(Len()) to panic")
+		}
+	}()
+	r.At(r.Len())
+}
+
+func TestShiftUint32(t *testing.T) {
+	r := testBlockArray().Shift(100)
+	if r.Min() != 100 || r.Len() != testBlockArray().Len() {
+		t.Errorf("Expected Shift(100) to start at 100, got %v", r)
+	}
+	for _, offset := range []int64{0, 25, -10} {
+		s := r.Shift(offset)
+		if x, ok := r.ShiftOf(s); !ok || x != offset {
+			t.Errorf("Expected r.ShiftOf(r.Shift(%d)) == %d, true, got %d, %v", offset, offset, x, ok)
+		}
+		if err := s.Validate(); err != nil {
+			t.Errorf("Expected Shift(%d) to be valid, got %v", offset, err)
+		}
+	}
+	if s := (Uint32{}).Shift(-5); s.Len() != 0 {
+		t.Errorf("Expected an empty Shift(), got %v", s)
+	}
+
+	for _, offset := range []int64{-101, 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Shift(%d) to panic", offset)
+				}
+			}()
+			testEncodingArray().Shift(offset)
+		}()
+	}
+}
+
+func TestScaleUint32(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 3, 5)
+	r.Push(9)
+	if s := r.Scale(10).String(); s != "30,40,50,90" {
+		t.Errorf("Expected Scale(10) == 30,40,50,90, got %s", s)
+	}
+	if err := r.Scale(10).Validate(); err != nil {
+		t.Errorf("Expected Scale(10) to be valid, got %v", err)
+	}
+	testEqualUint32(t, "Scale(1)", r.Scale(1), r)
+
+	for _, factor := range []uint32{0, 1 << 30} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Scale(%d) to panic", factor)
+				}
+			}()
+			r.Scale(factor)
+		}()
+	}
+}
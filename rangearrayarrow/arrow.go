@@ -0,0 +1,103 @@
+// Package rangearrayarrow converts between rangearrays and Apache Arrow
+// run-end encoded arrays, so that coverage can travel in the same
+// record batches as the observations it describes.
+//
+// A rangearray is represented as a run-end encoded boolean array over
+// a window of values: element i is true if the i'th value of the window
+// is in the rangearray.  Each run or gap of the rangearray becomes one
+// physical run.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on Arrow.
+package rangearrayarrow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// ToRunEndEncoded returns a run-end encoded boolean array of length
+// hi-lo, with int64 run ends, in which element i is true if lo+i is in
+// r.  The caller must release the result.  Panics if hi < lo.
+func ToRunEndEncoded(mem memory.Allocator, r rangearray.Uint32, lo, hi uint32) *array.RunEndEncoded {
+	if hi < lo {
+		panic("rangearrayarrow: ToRunEndEncoded window is backwards")
+	}
+
+	b := array.NewRunEndEncodedBuilder(mem, arrow.PrimitiveTypes.Int64, arrow.FixedWidthTypes.Boolean)
+	defer b.Release()
+	values := b.ValueBuilder().(*array.BooleanBuilder)
+	appendRun := func(n uint64, v bool) {
+		b.Append(n)
+		values.Append(v)
+	}
+
+	pos := uint64(lo)
+	for i := r.LowerBound(lo); i < r.NumRuns(); i++ {
+		s := r.Run(i)
+		start := max(uint64(s.Value), uint64(lo))
+		end := min(uint64(s.Value)+uint64(s.Count), uint64(hi))
+		if start >= end {
+			break
+		}
+		if start > pos {
+			appendRun(start-pos, false)
+		}
+		appendRun(end-start, true)
+		pos = end
+	}
+	if pos < uint64(hi) {
+		appendRun(uint64(hi)-pos, false)
+	}
+
+	return b.NewRunEndEncodedArray()
+}
+
+// FromRunEndEncoded returns the rangearray holding lo+i for each element
+// i of a that is true.  Null elements are treated as false.  a must have
+// boolean values, and lo+a.Len() must not exceed 1<<32.
+func FromRunEndEncoded(a *array.RunEndEncoded, lo uint32) (rangearray.Uint32, error) {
+	values, ok := a.Values().(*array.Boolean)
+	if !ok {
+		return rangearray.Uint32{}, fmt.Errorf("rangearrayarrow: values have type %s, not bool", a.Values().DataType())
+	}
+	if uint64(lo)+uint64(a.Len()) > 1<<32 {
+		return rangearray.Uint32{}, errors.New("rangearrayarrow: array is too long for its starting value")
+	}
+
+	var runEnd func(i int) int64
+	switch ends := a.RunEndsArr().(type) {
+	case *array.Int16:
+		runEnd = func(i int) int64 { return int64(ends.Value(i)) }
+	case *array.Int32:
+		runEnd = func(i int) int64 { return int64(ends.Value(i)) }
+	case *array.Int64:
+		runEnd = ends.Value
+	default:
+		return rangearray.Uint32{}, fmt.Errorf("rangearrayarrow: run ends have type %s", a.RunEndsArr().DataType())
+	}
+
+	var runs []rangearray.Uint32Run
+	offset, length := int64(a.Data().Offset()), int64(a.Len())
+	first := a.GetPhysicalOffset()
+	start := int64(0)
+	for i := first; i < first+a.GetPhysicalLength(); i++ {
+		end := min(runEnd(i)-offset, length)
+		if values.IsValid(i) && values.Value(i) && end > start {
+			value, count := lo+uint32(start), uint32(end-start)
+			if n := len(runs) - 1; n >= 0 && runs[n].Value+runs[n].Count == value {
+				runs[n].Count += count
+			} else {
+				runs = append(runs, rangearray.Uint32Run{Value: value, Count: count})
+			}
+		}
+		start = end
+	}
+	return rangearray.FromRuns(runs)
+}
@@ -0,0 +1,89 @@
+package rangearrayarrow
+
+import (
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func testArray() rangearray.Uint32 {
+	r := &rangearray.Uint32{}
+	for _, v := range [][2]uint32{{100, 199}, {350, 449}, {1000, 1000}} {
+		for x := v[0]; x <= v[1]; x++ {
+			r.Push(x)
+		}
+	}
+	return *r
+}
+
+func TestRunEndEncoded(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.DefaultAllocator)
+	defer mem.AssertSize(t, 0)
+
+	r := testArray()
+	a := ToRunEndEncoded(mem, r, 50, 1100)
+	defer a.Release()
+	if a.Len() != 1050 {
+		t.Errorf("Expected a.Len() == 1050, got %d", a.Len())
+	}
+	if n := a.GetPhysicalLength(); n != 7 {
+		t.Errorf("Expected 7 physical runs, got %d", n)
+	}
+	if err := a.ValidateFull(); err != nil {
+		t.Errorf("ValidateFull() failed: %v", err)
+	}
+
+	x, err := FromRunEndEncoded(a, 50)
+	if err != nil {
+		t.Fatalf("FromRunEndEncoded() failed: %v", err)
+	}
+	if x.NumRuns() != r.NumRuns() {
+		t.Fatalf("Expected x.NumRuns() == %d, got %d", r.NumRuns(), x.NumRuns())
+	}
+	for i := range r.NumRuns() {
+		if x.Run(i) != r.Run(i) {
+			t.Errorf("Expected x.Run(%d) == %+v, got %+v", i, r.Run(i), x.Run(i))
+		}
+	}
+
+	// A window that cuts runs in half.
+	w := ToRunEndEncoded(mem, r, 150, 400)
+	defer w.Release()
+	y, _ := FromRunEndEncoded(w, 150)
+	if y.Len() != 100 || y.Min() != 150 || y.Max() != 399 {
+		t.Errorf("Expected the window to hold 150-199,350-399, got %v", y)
+	}
+}
+
+func TestFromRunEndEncodedSlice(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.DefaultAllocator)
+	defer mem.AssertSize(t, 0)
+
+	b := array.NewRunEndEncodedBuilder(mem, arrow.PrimitiveTypes.Int32, arrow.FixedWidthTypes.Boolean)
+	defer b.Release()
+	values := b.ValueBuilder().(*array.BooleanBuilder)
+	for _, run := range []struct {
+		n uint64
+		v bool
+	}{{10, true}, {5, true}, {5, false}, {10, true}} {
+		b.Append(run.n)
+		values.Append(run.v)
+	}
+	b.AppendNull()
+	a := b.NewRunEndEncodedArray()
+	defer a.Release()
+
+	s := array.NewSlice(a, 12, 27).(*array.RunEndEncoded)
+	defer s.Release()
+	x, err := FromRunEndEncoded(s, 1000)
+	if err != nil {
+		t.Fatalf("FromRunEndEncoded() failed: %v", err)
+	}
+	if x.String() != "1000-1002,1008-1014" {
+		t.Errorf("Expected FromRunEndEncoded() == 1000-1002,1008-1014, got %v", x)
+	}
+}
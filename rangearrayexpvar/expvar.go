@@ -0,0 +1,67 @@
+// Package rangearrayexpvar publishes rangearray statistics through the
+// expvar package, so they appear at /debug/vars.
+//
+// It is a separate package because importing expvar registers an HTTP
+// handler as a side effect.
+package rangearrayexpvar
+
+import (
+	"expvar"
+	"unsafe"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// Stats summarizes a rangearray.  It is the value published for each
+// array.
+type Stats struct {
+	Len  uint32  `json:"len"`
+	Runs int     `json:"runs"`
+	Min  *uint32 `json:"min,omitempty"`
+	Max  *uint32 `json:"max,omitempty"`
+
+	// Bytes estimates the memory held by the array's runs, including
+	// unused capacity.
+	Bytes uintptr `json:"bytes"`
+}
+
+// StatsOf returns the statistics for r.
+func StatsOf(r rangearray.Uint32) Stats {
+	s := Stats{
+		Len:   r.Len(),
+		Runs:  r.NumRuns(),
+		Bytes: unsafe.Sizeof(r) + uintptr(r.Cap())*unsafe.Sizeof(rangearray.Uint32Run{}),
+	}
+	if r.NumRuns() > 0 {
+		lo, hi := r.Min(), r.Max()
+		s.Min, s.Max = &lo, &hi
+	}
+	return s
+}
+
+// Func returns an expvar.Var that reports the statistics of the array
+// returned by get.  get is called each time the variable is read, so
+// it must be safe to call concurrently with updates to the array.
+func Func(get func() rangearray.Uint32) expvar.Func {
+	return func() any { return StatsOf(get()) }
+}
+
+// Publish publishes the statistics of the array returned by get under
+// name.  Like expvar.Publish, it panics if name is already in use.
+func Publish(name string, get func() rangearray.Uint32) {
+	expvar.Publish(name, Func(get))
+}
+
+// PublishMap publishes the statistics of each array in the collection
+// returned by get, as an object keyed like the collection, under name.
+// Like expvar.Publish, it panics if name is already in use.
+func PublishMap(name string, get func() map[string]rangearray.Uint32) {
+	expvar.Publish(name, expvar.Func(func() any {
+		m := get()
+		out := make(map[string]Stats, len(m))
+		for k, r := range m {
+			out[k] = StatsOf(r)
+		}
+		return out
+	}))
+}
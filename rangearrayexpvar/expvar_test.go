@@ -0,0 +1,49 @@
+package rangearrayexpvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestStatsOf(t *testing.T) {
+	var r rangearray.Uint32
+	r.UnmarshalText([]byte("5,100-199"))
+	s := StatsOf(r)
+	if s.Len != 101 || s.Runs != 2 || s.Min == nil || *s.Min != 5 || s.Max == nil || *s.Max != 199 {
+		t.Errorf("Expected StatsOf() == {101 2 5 199}, got %+v", s)
+	}
+	if s.Bytes < 24+2*12 {
+		t.Errorf("Expected StatsOf().Bytes >= 48, got %d", s.Bytes)
+	}
+
+	if s := StatsOf(rangearray.Uint32{}); s.Len != 0 || s.Runs != 0 || s.Min != nil || s.Max != nil {
+		t.Errorf("Expected empty StatsOf() to have no min or max, got %+v", s)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var r rangearray.Uint32
+	Publish("test_array", func() rangearray.Uint32 { return r })
+	r.Push(7)
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(expvar.Get("test_array").String()), &got); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	if got["len"] != 1.0 || got["min"] != 7.0 || got["max"] != 7.0 {
+		t.Errorf("Expected published stats to reflect Push(), got %v", got)
+	}
+
+	m := map[string]rangearray.Uint32{"a": r, "b": {}}
+	PublishMap("test_map", func() map[string]rangearray.Uint32 { return m })
+	var stats map[string]map[string]any
+	if err := json.Unmarshal([]byte(expvar.Get("test_map").String()), &stats); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	if len(stats) != 2 || stats["a"]["runs"] != 1.0 || stats["b"]["runs"] != 0.0 {
+		t.Errorf("Expected published map stats for a and b, got %v", stats)
+	}
+}
@@ -0,0 +1,136 @@
+// Package rangearraygrpc implements a gRPC service that answers point
+// queries, window queries, and run and gap enumeration over a registry
+// of named rangearrays.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on gRPC.
+package rangearraygrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative service.proto
+
+import (
+	"context"
+	"iter"
+	"sync"
+
+	"github/com/entrope/rangearray/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements QueryServiceServer over a set of named arrays.  It
+// is safe for concurrent use.
+type Server struct {
+	UnimplementedQueryServiceServer
+
+	mu     sync.RWMutex
+	arrays map[string]rangearray.Uint32
+}
+
+// NewServer returns a Server that holds no arrays.
+func NewServer() *Server {
+	return &Server{arrays: make(map[string]rangearray.Uint32)}
+}
+
+// Set serves r under name, replacing any array already served under
+// it.  The caller must not modify r afterwards.
+func (s *Server) Set(name string, r rangearray.Uint32) {
+	s.mu.Lock()
+	s.arrays[name] = r
+	s.mu.Unlock()
+}
+
+// Delete stops serving the array under name.
+func (s *Server) Delete(name string) {
+	s.mu.Lock()
+	delete(s.arrays, name)
+	s.mu.Unlock()
+}
+
+// Contains implements QueryServiceServer.
+func (s *Server) Contains(ctx context.Context, req *ValueRequest) (*ContainsResponse, error) {
+	r, err := s.lookup(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return &ContainsResponse{Contains: r.Contains(req.GetValue())}, nil
+}
+
+// Rank implements QueryServiceServer.
+func (s *Server) Rank(ctx context.Context, req *ValueRequest) (*RankResponse, error) {
+	r, err := s.lookup(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return &RankResponse{Rank: r.IndexOf(req.GetValue())}, nil
+}
+
+// Count implements QueryServiceServer.
+func (s *Server) Count(ctx context.Context, req *WindowRequest) (*CountResponse, error) {
+	r, first, last, err := s.window(req)
+	if err != nil {
+		return nil, err
+	}
+	return &CountResponse{Count: r.CountIn(first, last)}, nil
+}
+
+// ListRuns implements QueryServiceServer.
+func (s *Server) ListRuns(req *WindowRequest, stream grpc.ServerStreamingServer[Interval]) error {
+	r, first, last, err := s.window(req)
+	if err != nil {
+		return err
+	}
+	return sendIntervals(stream, r.IntervalsIn(first, last))
+}
+
+// ListGaps implements QueryServiceServer.
+func (s *Server) ListGaps(req *WindowRequest, stream grpc.ServerStreamingServer[Interval]) error {
+	r, first, last, err := s.window(req)
+	if err != nil {
+		return err
+	}
+	return sendIntervals(stream, r.GapsIn(first, last))
+}
+
+// lookup returns the array named name, or a NotFound error.
+func (s *Server) lookup(name string) (rangearray.Uint32, error) {
+	s.mu.RLock()
+	r, ok := s.arrays[name]
+	s.mu.RUnlock()
+	if !ok {
+		return rangearray.Uint32{}, status.Errorf(codes.NotFound, "no array named %q", name)
+	}
+	return r, nil
+}
+
+// window returns the array and inclusive window selected by req.
+func (s *Server) window(req *WindowRequest) (rangearray.Uint32, uint32, uint32, error) {
+	r, err := s.lookup(req.GetName())
+	if err != nil {
+		return rangearray.Uint32{}, 0, 0, err
+	}
+	first, last := req.GetFirst(), uint32(0xffffffff)
+	if req.Last != nil {
+		last = req.GetLast()
+	}
+	if last < first {
+		return rangearray.Uint32{}, 0, 0, status.Errorf(codes.InvalidArgument, "last (%d) is before first (%d)", last, first)
+	}
+	return r, first, last, nil
+}
+
+// sendIntervals sends each interval in seq on stream, stopping early if
+// the stream's context is done.
+func sendIntervals(stream grpc.ServerStreamingServer[Interval], seq iter.Seq[[2]uint32]) error {
+	ctx := stream.Context()
+	for iv := range seq {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err := stream.Send(&Interval{First: iv[0], Last: iv[1]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
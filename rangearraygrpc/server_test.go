@@ -0,0 +1,138 @@
+package rangearraygrpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"slices"
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testClient starts a gRPC server holding array "a" and returns a
+// client connected to it.
+func testClient(t *testing.T) QueryServiceClient {
+	t.Helper()
+	var r rangearray.Uint32
+	if err := r.UnmarshalText([]byte("5,100-199,350-449")); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer()
+	srv.Set("a", r)
+
+	lis := bufconn.Listen(1 << 16)
+	s := grpc.NewServer()
+	RegisterQueryServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewQueryServiceClient(conn)
+}
+
+// testCollect returns the intervals received from stream.
+func testCollect(t *testing.T, stream grpc.ServerStreamingClient[Interval]) ([][2]uint32, error) {
+	t.Helper()
+	var out [][2]uint32
+	for {
+		iv, err := stream.Recv()
+		if err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return out, err
+		}
+		out = append(out, [2]uint32{iv.GetFirst(), iv.GetLast()})
+	}
+}
+
+func TestPointQueries(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+
+	for _, s := range []struct {
+		value    uint32
+		contains bool
+		rank     uint32
+	}{
+		{5, true, 0},
+		{150, true, 51},
+		{200, false, 101},
+		{500, false, 201},
+	} {
+		resp, err := c.Contains(ctx, &ValueRequest{Name: "a", Value: s.value})
+		if err != nil || resp.GetContains() != s.contains {
+			t.Errorf("Expected Contains(%d) == %v, got %v, %v", s.value, s.contains, resp.GetContains(), err)
+		}
+		rank, err := c.Rank(ctx, &ValueRequest{Name: "a", Value: s.value})
+		if err != nil || rank.GetRank() != s.rank {
+			t.Errorf("Expected Rank(%d) == %d, got %d, %v", s.value, s.rank, rank.GetRank(), err)
+		}
+	}
+}
+
+func TestWindowQueries(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+	last := uint32(360)
+
+	count, err := c.Count(ctx, &WindowRequest{Name: "a"})
+	if err != nil || count.GetCount() != 201 {
+		t.Errorf("Expected Count() == 201, got %d, %v", count.GetCount(), err)
+	}
+	count, err = c.Count(ctx, &WindowRequest{Name: "a", First: 150, Last: &last})
+	if err != nil || count.GetCount() != 61 {
+		t.Errorf("Expected Count(150, 360) == 61, got %d, %v", count.GetCount(), err)
+	}
+
+	stream, err := c.ListRuns(ctx, &WindowRequest{Name: "a", First: 150, Last: &last})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := testCollect(t, stream)
+	if want := [][2]uint32{{150, 199}, {350, 360}}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("Expected ListRuns(150, 360) == %v, got %v, %v", want, got, err)
+	}
+
+	stream, err = c.ListGaps(ctx, &WindowRequest{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = testCollect(t, stream)
+	if want := [][2]uint32{{0, 4}, {6, 99}, {200, 349}, {450, 0xffffffff}}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("Expected ListGaps() == %v, got %v, %v", want, got, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := testClient(t)
+	ctx := context.Background()
+
+	if _, err := c.Contains(ctx, &ValueRequest{Name: "b"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected Contains() on a missing array to fail with NotFound, got %v", err)
+	}
+
+	last := uint32(5)
+	if _, err := c.Count(ctx, &WindowRequest{Name: "a", First: 10, Last: &last}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected Count() with last < first to fail with InvalidArgument, got %v", err)
+	}
+
+	stream, err := c.ListRuns(ctx, &WindowRequest{Name: "b"})
+	if err == nil {
+		_, err = testCollect(t, stream)
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected ListRuns() on a missing array to fail with NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: service.proto
+
+package rangearraygrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	QueryService_Contains_FullMethodName = "/rangearray.v1.QueryService/Contains"
+	QueryService_Rank_FullMethodName     = "/rangearray.v1.QueryService/Rank"
+	QueryService_Count_FullMethodName    = "/rangearray.v1.QueryService/Count"
+	QueryService_ListRuns_FullMethodName = "/rangearray.v1.QueryService/ListRuns"
+	QueryService_ListGaps_FullMethodName = "/rangearray.v1.QueryService/ListGaps"
+)
+
+// QueryServiceClient is the client API for QueryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// QueryService answers queries about a registry of named rangearrays.
+type QueryServiceClient interface {
+	// Contains reports whether a value is in an array.
+	Contains(ctx context.Context, in *ValueRequest, opts ...grpc.CallOption) (*ContainsResponse, error)
+	// Rank returns the number of values in an array that are less than a
+	// value.
+	Rank(ctx context.Context, in *ValueRequest, opts ...grpc.CallOption) (*RankResponse, error)
+	// Count returns the number of values in an array within a window.
+	Count(ctx context.Context, in *WindowRequest, opts ...grpc.CallOption) (*CountResponse, error)
+	// ListRuns streams the runs of an array within a window, clipped to
+	// the window.
+	ListRuns(ctx context.Context, in *WindowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Interval], error)
+	// ListGaps streams the maximal intervals within a window that hold
+	// no values of an array.
+	ListGaps(ctx context.Context, in *WindowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Interval], error)
+}
+
+type queryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueryServiceClient(cc grpc.ClientConnInterface) QueryServiceClient {
+	return &queryServiceClient{cc}
+}
+
+func (c *queryServiceClient) Contains(ctx context.Context, in *ValueRequest, opts ...grpc.CallOption) (*ContainsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ContainsResponse)
+	err := c.cc.Invoke(ctx, QueryService_Contains_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryServiceClient) Rank(ctx context.Context, in *ValueRequest, opts ...grpc.CallOption) (*RankResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RankResponse)
+	err := c.cc.Invoke(ctx, QueryService_Rank_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryServiceClient) Count(ctx context.Context, in *WindowRequest, opts ...grpc.CallOption) (*CountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountResponse)
+	err := c.cc.Invoke(ctx, QueryService_Count_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryServiceClient) ListRuns(ctx context.Context, in *WindowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Interval], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QueryService_ServiceDesc.Streams[0], QueryService_ListRuns_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WindowRequest, Interval]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueryService_ListRunsClient = grpc.ServerStreamingClient[Interval]
+
+func (c *queryServiceClient) ListGaps(ctx context.Context, in *WindowRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Interval], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QueryService_ServiceDesc.Streams[1], QueryService_ListGaps_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WindowRequest, Interval]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueryService_ListGapsClient = grpc.ServerStreamingClient[Interval]
+
+// QueryServiceServer is the server API for QueryService service.
+// All implementations must embed UnimplementedQueryServiceServer
+// for forward compatibility.
+//
+// QueryService answers queries about a registry of named rangearrays.
+type QueryServiceServer interface {
+	// Contains reports whether a value is in an array.
+	Contains(context.Context, *ValueRequest) (*ContainsResponse, error)
+	// Rank returns the number of values in an array that are less than a
+	// value.
+	Rank(context.Context, *ValueRequest) (*RankResponse, error)
+	// Count returns the number of values in an array within a window.
+	Count(context.Context, *WindowRequest) (*CountResponse, error)
+	// ListRuns streams the runs of an array within a window, clipped to
+	// the window.
+	ListRuns(*WindowRequest, grpc.ServerStreamingServer[Interval]) error
+	// ListGaps streams the maximal intervals within a window that hold
+	// no values of an array.
+	ListGaps(*WindowRequest, grpc.ServerStreamingServer[Interval]) error
+	mustEmbedUnimplementedQueryServiceServer()
+}
+
+// UnimplementedQueryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedQueryServiceServer struct{}
+
+func (UnimplementedQueryServiceServer) Contains(context.Context, *ValueRequest) (*ContainsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Contains not implemented")
+}
+func (UnimplementedQueryServiceServer) Rank(context.Context, *ValueRequest) (*RankResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Rank not implemented")
+}
+func (UnimplementedQueryServiceServer) Count(context.Context, *WindowRequest) (*CountResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Count not implemented")
+}
+func (UnimplementedQueryServiceServer) ListRuns(*WindowRequest, grpc.ServerStreamingServer[Interval]) error {
+	return status.Error(codes.Unimplemented, "method ListRuns not implemented")
+}
+func (UnimplementedQueryServiceServer) ListGaps(*WindowRequest, grpc.ServerStreamingServer[Interval]) error {
+	return status.Error(codes.Unimplemented, "method ListGaps not implemented")
+}
+func (UnimplementedQueryServiceServer) mustEmbedUnimplementedQueryServiceServer() {}
+func (UnimplementedQueryServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeQueryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QueryServiceServer will
+// result in compilation errors.
+type UnsafeQueryServiceServer interface {
+	mustEmbedUnimplementedQueryServiceServer()
+}
+
+func RegisterQueryServiceServer(s grpc.ServiceRegistrar, srv QueryServiceServer) {
+	// If the following call panics, it indicates UnimplementedQueryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&QueryService_ServiceDesc, srv)
+}
+
+func _QueryService_Contains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).Contains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_Contains_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).Contains(ctx, req.(*ValueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueryService_Rank_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).Rank(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_Rank_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).Rank(ctx, req.(*ValueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueryService_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WindowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_Count_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).Count(ctx, req.(*WindowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueryService_ListRuns_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WindowRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServiceServer).ListRuns(m, &grpc.GenericServerStream[WindowRequest, Interval]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueryService_ListRunsServer = grpc.ServerStreamingServer[Interval]
+
+func _QueryService_ListGaps_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WindowRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServiceServer).ListGaps(m, &grpc.GenericServerStream[WindowRequest, Interval]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueryService_ListGapsServer = grpc.ServerStreamingServer[Interval]
+
+// QueryService_ServiceDesc is the grpc.ServiceDesc for QueryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var QueryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rangearray.v1.QueryService",
+	HandlerType: (*QueryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Contains",
+			Handler:    _QueryService_Contains_Handler,
+		},
+		{
+			MethodName: "Rank",
+			Handler:    _QueryService_Rank_Handler,
+		},
+		{
+			MethodName: "Count",
+			Handler:    _QueryService_Count_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListRuns",
+			Handler:       _QueryService_ListRuns_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListGaps",
+			Handler:       _QueryService_ListGaps_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
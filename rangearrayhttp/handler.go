@@ -0,0 +1,243 @@
+// Package rangearrayhttp serves queries over named rangearrays as JSON
+// over HTTP.
+//
+// A Handler serves these endpoints, where first and last bound an
+// inclusive window and default to the whole uint32 range:
+//
+//	GET /                              names of the arrays
+//	GET /{name}                        {"len", "runs", "min", "max"}
+//	GET /{name}/contains?x=N           {"contains": bool}
+//	GET /{name}/rank?x=N               {"rank": values less than N}
+//	GET /{name}/count?first=A&last=B   {"count": values in the window}
+//	GET /{name}/runs?first=A&last=B    [[first, last], ...]
+//	GET /{name}/gaps?first=A&last=B    [[first, last], ...]
+//
+// Responses about an array carry an ETag derived from its canonical
+// encoding, and a request whose If-None-Match header matches it gets a
+// 304 Not Modified response.  Errors are reported as {"error": message}.
+package rangearrayhttp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	rangearray "github/com/entrope/rangearray/v2"
+)
+
+// Handler is an http.Handler that serves a set of named rangearrays.
+// It is safe for concurrent use.
+type Handler struct {
+	mux    *http.ServeMux
+	mu     sync.RWMutex
+	arrays map[string]entry
+}
+
+// entry is one array served by a Handler.
+type entry struct {
+	r    rangearray.Uint32
+	etag string
+}
+
+// NewHandler returns a Handler that serves no arrays.
+func NewHandler() *Handler {
+	h := &Handler{
+		mux:    http.NewServeMux(),
+		arrays: make(map[string]entry),
+	}
+	h.mux.HandleFunc("GET /{$}", h.list)
+	h.mux.HandleFunc("GET /{name}", h.array(h.stats))
+	h.mux.HandleFunc("GET /{name}/contains", h.array(h.contains))
+	h.mux.HandleFunc("GET /{name}/rank", h.array(h.rank))
+	h.mux.HandleFunc("GET /{name}/count", h.array(h.count))
+	h.mux.HandleFunc("GET /{name}/runs", h.array(h.runs))
+	h.mux.HandleFunc("GET /{name}/gaps", h.array(h.gaps))
+	return h
+}
+
+// Set serves r under name, replacing any array already served under
+// it.  The caller must not modify r afterwards.
+func (h *Handler) Set(name string, r rangearray.Uint32) {
+	b, _ := r.MarshalCanonical()
+	sum := sha256.Sum256(b)
+	e := entry{r: r, etag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+
+	h.mu.Lock()
+	h.arrays[name] = e
+	h.mu.Unlock()
+}
+
+// Delete stops serving the array under name.
+func (h *Handler) Delete(name string) {
+	h.mu.Lock()
+	delete(h.arrays, name)
+	h.mu.Unlock()
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.mux.ServeHTTP(w, req)
+}
+
+// list serves the sorted names of the arrays.
+func (h *Handler) list(w http.ResponseWriter, req *http.Request) {
+	h.mu.RLock()
+	names := make([]string, 0, len(h.arrays))
+	for name := range h.arrays {
+		names = append(names, name)
+	}
+	h.mu.RUnlock()
+
+	slices.Sort(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+// array adapts a query on one array to an http.HandlerFunc.  It looks
+// up the array, handles ETags, and writes the query's result or error.
+func (h *Handler) array(query func(rangearray.Uint32, *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		h.mu.RLock()
+		e, ok := h.arrays[req.PathValue("name")]
+		h.mu.RUnlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no array named %q", req.PathValue("name")))
+			return
+		}
+
+		w.Header().Set("ETag", e.etag)
+		if etagMatch(req.Header.Get("If-None-Match"), e.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		v, err := query(e.r, req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func (h *Handler) stats(r rangearray.Uint32, req *http.Request) (any, error) {
+	stats := struct {
+		Len  uint32  `json:"len"`
+		Runs int     `json:"runs"`
+		Min  *uint32 `json:"min,omitempty"`
+		Max  *uint32 `json:"max,omitempty"`
+	}{Len: r.Len(), Runs: r.NumRuns()}
+	if r.NumRuns() > 0 {
+		lo, hi := r.Min(), r.Max()
+		stats.Min, stats.Max = &lo, &hi
+	}
+	return stats, nil
+}
+
+func (h *Handler) contains(r rangearray.Uint32, req *http.Request) (any, error) {
+	x, err := param(req, "x", -1)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]bool{"contains": r.Contains(x)}, nil
+}
+
+func (h *Handler) rank(r rangearray.Uint32, req *http.Request) (any, error) {
+	x, err := param(req, "x", -1)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]uint32{"rank": r.IndexOf(x)}, nil
+}
+
+func (h *Handler) count(r rangearray.Uint32, req *http.Request) (any, error) {
+	first, last, err := window(req)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]uint32{"count": r.CountIn(first, last)}, nil
+}
+
+func (h *Handler) runs(r rangearray.Uint32, req *http.Request) (any, error) {
+	first, last, err := window(req)
+	if err != nil {
+		return nil, err
+	}
+	out := [][2]uint32{}
+	for iv := range r.IntervalsIn(first, last) {
+		out = append(out, iv)
+	}
+	return out, nil
+}
+
+func (h *Handler) gaps(r rangearray.Uint32, req *http.Request) (any, error) {
+	first, last, err := window(req)
+	if err != nil {
+		return nil, err
+	}
+	out := [][2]uint32{}
+	for iv := range r.GapsIn(first, last) {
+		out = append(out, iv)
+	}
+	return out, nil
+}
+
+// window returns the first and last query parameters of req.
+func window(req *http.Request) (uint32, uint32, error) {
+	first, err := param(req, "first", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	last, err := param(req, "last", 0xffffffff)
+	if err != nil {
+		return 0, 0, err
+	}
+	if last < first {
+		return 0, 0, fmt.Errorf("last (%d) is before first (%d)", last, first)
+	}
+	return first, last, nil
+}
+
+// param returns the uint32 query parameter key of req.  If it is
+// missing, param returns def, or an error if def is negative.
+func param(req *http.Request, key string, def int64) (uint32, error) {
+	s := req.URL.Query().Get(key)
+	if s == "" {
+		if def < 0 {
+			return 0, fmt.Errorf("missing parameter %q", key)
+		}
+		return uint32(def), nil
+	}
+	x, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid parameter %q: %w", key, err)
+	}
+	return uint32(x), nil
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag.
+func etagMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Del("ETag")
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
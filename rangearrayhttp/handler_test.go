@@ -0,0 +1,114 @@
+package rangearrayhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rangearray "github/com/entrope/rangearray/v2"
+)
+
+// testHandler returns a Handler serving arrays "a" and "b".
+func testHandler(t *testing.T) *Handler {
+	t.Helper()
+	h := NewHandler()
+	for name, text := range map[string]string{"a": "5,100-199,350-449", "b": ""} {
+		var r rangearray.Uint32
+		if err := r.UnmarshalText([]byte(text)); err != nil {
+			t.Fatal(err)
+		}
+		h.Set(name, r)
+	}
+	return h
+}
+
+// testGet sends a GET request for target to h.
+func testGet(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestQueries(t *testing.T) {
+	h := testHandler(t)
+	for _, s := range []struct {
+		target string
+		want   string
+	}{
+		{"/", `["a","b"]`},
+		{"/a", `{"len":201,"runs":3,"min":5,"max":449}`},
+		{"/b", `{"len":0,"runs":0}`},
+		{"/a/contains?x=150", `{"contains":true}`},
+		{"/a/contains?x=200", `{"contains":false}`},
+		{"/a/rank?x=150", `{"rank":51}`},
+		{"/a/count", `{"count":201}`},
+		{"/a/count?first=150&last=360", `{"count":61}`},
+		{"/a/runs", `[[5,5],[100,199],[350,449]]`},
+		{"/a/runs?first=150&last=360", `[[150,199],[350,360]]`},
+		{"/a/runs?first=200&last=300", `[]`},
+		{"/a/gaps?last=400", `[[0,4],[6,99],[200,349]]`},
+		{"/b/gaps", `[[0,4294967295]]`},
+	} {
+		w := testGet(h, s.target)
+		if got := strings.TrimSpace(w.Body.String()); w.Code != http.StatusOK || got != s.want {
+			t.Errorf("Expected GET %s == %s, got %d %s", s.target, s.want, w.Code, got)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected GET %s to return JSON, got %q", s.target, ct)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := testHandler(t)
+	for _, s := range []struct {
+		target string
+		code   int
+	}{
+		{"/c", http.StatusNotFound},
+		{"/c/runs", http.StatusNotFound},
+		{"/a/contains", http.StatusBadRequest},
+		{"/a/rank?x=-1", http.StatusBadRequest},
+		{"/a/count?first=10&last=5", http.StatusBadRequest},
+		{"/a/runs?first=4294967296", http.StatusBadRequest},
+	} {
+		w := testGet(h, s.target)
+		if w.Code != s.code || !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("Expected GET %s to fail with %d, got %d %s", s.target, s.code, w.Code, w.Body)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	h := testHandler(t)
+	etag := testGet(h, "/a").Header().Get("ETag")
+	if etag == "" || testGet(h, "/a/runs").Header().Get("ETag") != etag {
+		t.Fatalf("Expected a consistent ETag, got %q", etag)
+	}
+	if testGet(h, "/b").Header().Get("ETag") == etag {
+		t.Errorf("Expected different arrays to have different ETags")
+	}
+
+	w := testGet(h, "/a/runs", "If-None-Match", `"x", `+etag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected a matching If-None-Match to give 304, got %d %s", w.Code, w.Body)
+	}
+
+	var r rangearray.Uint32
+	r.UnmarshalText([]byte("5-6"))
+	h.Set("a", r)
+	w = testGet(h, "/a/runs", "If-None-Match", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("Expected a changed array to get a new ETag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	h.Delete("a")
+	if w := testGet(h, "/a"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a deleted array to be missing, got %d", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+// Package rangearrayotel records rangearray operations as
+// OpenTelemetry spans.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on OpenTelemetry.
+package rangearrayotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// RunsKey is the span attribute that holds the number of runs an
+// operation produced or consumed.
+const RunsKey = attribute.Key("rangearray.runs")
+
+// tracer implements rangearray.Tracer.
+type tracer struct {
+	ctx context.Context
+	t   trace.Tracer
+}
+
+// NewTracer returns a rangearray.Tracer that starts a span with t for
+// each operation, as a child of the span in the context passed to the
+// operation, such as the ctx of Decoder.UnmarshalContext.  Operations
+// whose context holds no span, including those whose methods take no
+// context, are children of the span in ctx instead.  Each span records
+// the number of runs and, if the operation failed, its error.
+func NewTracer(ctx context.Context, t trace.Tracer) rangearray.Tracer {
+	return tracer{ctx: ctx, t: t}
+}
+
+func (t tracer) Start(ctx context.Context, op string) func(int, error) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = t.ctx
+	}
+	_, span := t.t.Start(ctx, op)
+	return func(runs int, err error) {
+		span.SetAttributes(RunsKey.Int(runs))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
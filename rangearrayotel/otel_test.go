@@ -0,0 +1,85 @@
+package rangearrayotel
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestTracer(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	tr := NewTracer(ctx, tp.Tracer("rangearray"))
+
+	rangearray.ReadCSV(strings.NewReader("1\n2\n5\n"), rangearray.CSVOptions{Tracer: tr})
+	rangearray.ReadCSV(strings.NewReader("x\n"), rangearray.CSVOptions{Tracer: tr})
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	for i, want := range []struct {
+		runs int64
+		code codes.Code
+	}{{2, codes.Unset}, {0, codes.Error}} {
+		s := spans[i]
+		if s.Name() != rangearray.OpReadCSV {
+			t.Errorf("Expected span %d to be named %q, got %q", i, rangearray.OpReadCSV, s.Name())
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("Expected span %d to be a child of the parent span", i)
+		}
+		if s.Status().Code != want.code {
+			t.Errorf("Expected span %d to have status %v, got %v", i, want.code, s.Status().Code)
+		}
+		var runs int64 = -1
+		for _, kv := range s.Attributes() {
+			if kv.Key == RunsKey {
+				runs = kv.Value.AsInt64()
+			}
+		}
+		if runs != want.runs {
+			t.Errorf("Expected span %d to record %d runs, got %d", i, want.runs, runs)
+		}
+	}
+}
+
+func TestTracerContext(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	_, root := tp.Tracer("test").Start(context.Background(), "root")
+	ctx, request := tp.Tracer("test").Start(context.Background(), "request")
+	tr := NewTracer(trace.ContextWithSpan(context.Background(), root), tp.Tracer("rangearray"))
+
+	var r rangearray.Uint32
+	r.Push(1)
+	b, err := rangearray.Encoder{}.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	var out rangearray.Uint32
+	rangearray.Decoder{Tracer: tr}.UnmarshalContext(ctx, b, &out)
+	rangearray.Decoder{Tracer: tr}.UnmarshalContext(context.Background(), b, &out)
+	request.End()
+	root.End()
+
+	spans := rec.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	if spans[0].Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("Expected a span to be a child of the caller's span")
+	}
+	if spans[1].Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("Expected a span without a caller's span to be a child of the tracer's")
+	}
+}
@@ -0,0 +1,126 @@
+// Package rangearrayparquet builds rangearrays from integer columns of
+// Parquet files.  It reads one page at a time, so a column never needs
+// to fit in memory.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on a Parquet library.
+package rangearrayparquet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// readBatch is the number of values decoded at a time.
+const readBatch = 1024
+
+// ReadColumn returns a rangearray holding every value of the column of
+// f with the given path.  The column must hold integers that fit in a
+// uint32; null values are skipped.  Sorted columns are cheapest to
+// read, because each value is then added at the end of the rangearray.
+func ReadColumn(f *parquet.File, path ...string) (rangearray.Uint32, error) {
+	leaf, ok := f.Schema().Lookup(path...)
+	if !ok {
+		return rangearray.Uint32{}, fmt.Errorf("rangearrayparquet: no column %q", strings.Join(path, "."))
+	}
+
+	toUint32 := signedValue
+	if lt := leaf.Node.Type().LogicalType(); lt != nil {
+		if it, ok := lt.Value.(*format.IntType); ok && !it.IsSigned {
+			toUint32 = unsignedValue
+		}
+	}
+
+	var r rangearray.Uint32
+	buf := make([]parquet.Value, readBatch)
+	for i, rg := range f.RowGroups() {
+		pages := rg.ColumnChunks()[leaf.ColumnIndex].Pages()
+		err := readPages(pages, buf, toUint32, &r)
+		pages.Close()
+		if err != nil {
+			return rangearray.Uint32{}, fmt.Errorf("rangearrayparquet: row group %d: %w", i, err)
+		}
+	}
+	return r, nil
+}
+
+// readPages adds every value from pages to r.
+func readPages(pages parquet.Pages, buf []parquet.Value, toUint32 func(parquet.Value) (uint32, error), r *rangearray.Uint32) error {
+	for {
+		page, err := pages.ReadPage()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		err = readValues(page.Values(), buf, toUint32, r)
+		parquet.Release(page)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readValues adds every value from vr to r.
+func readValues(vr parquet.ValueReader, buf []parquet.Value, toUint32 func(parquet.Value) (uint32, error), r *rangearray.Uint32) error {
+	for {
+		n, err := vr.ReadValues(buf)
+		for _, v := range buf[:n] {
+			if v.IsNull() {
+				continue
+			}
+			x, err := toUint32(v)
+			if err != nil {
+				return err
+			}
+			r.Push(x)
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// signedValue converts v, from a signed integer column, to a uint32.
+func signedValue(v parquet.Value) (uint32, error) {
+	var x int64
+	switch v.Kind() {
+	case parquet.Int32:
+		x = int64(v.Int32())
+	case parquet.Int64:
+		x = v.Int64()
+	default:
+		return 0, fmt.Errorf("column has type %s, not an integer", v.Kind())
+	}
+
+	if x < 0 || x > 0xffffffff {
+		return 0, fmt.Errorf("value %d does not fit in a uint32", x)
+	}
+	return uint32(x), nil
+}
+
+// unsignedValue converts v, from an unsigned integer column, to a
+// uint32.
+func unsignedValue(v parquet.Value) (uint32, error) {
+	switch v.Kind() {
+	case parquet.Int32:
+		return v.Uint32(), nil
+	case parquet.Int64:
+		if x := v.Uint64(); x <= 0xffffffff {
+			return uint32(x), nil
+		}
+		return 0, fmt.Errorf("value %d does not fit in a uint32", v.Uint64())
+	default:
+		return 0, fmt.Errorf("column has type %s, not an integer", v.Kind())
+	}
+}
@@ -0,0 +1,69 @@
+package rangearrayparquet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+type observation struct {
+	Epoch  uint32  `parquet:"epoch"`
+	Second int64   `parquet:"second"`
+	Value  float64 `parquet:"value"`
+	Offset int32   `parquet:"offset"`
+}
+
+func testFile(t *testing.T, rows []observation) *parquet.File {
+	var buf bytes.Buffer
+	w := parquet.NewGenericWriter[observation](&buf, parquet.PageBufferSize(256), parquet.MaxRowsPerRowGroup(100))
+	if _, err := w.Write(rows); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile() failed: %v", err)
+	}
+	return f
+}
+
+func TestReadColumn(t *testing.T) {
+	var rows []observation
+	for i := uint32(0); i < 500; i++ {
+		x := i
+		if i >= 250 {
+			x += 1000
+		}
+		rows = append(rows, observation{Epoch: 0xffff0000 + x, Second: int64(x), Value: 1.5, Offset: -1})
+	}
+	f := testFile(t, rows)
+	if len(f.RowGroups()) < 2 {
+		t.Errorf("Expected several row groups, got %d", len(f.RowGroups()))
+	}
+
+	r, err := ReadColumn(f, "epoch")
+	if err != nil {
+		t.Fatalf("ReadColumn(epoch) failed: %v", err)
+	}
+	if want := "4294901760-4294902009,4294903010-4294903259"; r.String() != want {
+		t.Errorf("Expected ReadColumn(epoch) == %s, got %v", want, r)
+	}
+
+	r, err = ReadColumn(f, "second")
+	if err != nil {
+		t.Fatalf("ReadColumn(second) failed: %v", err)
+	}
+	if want := "0-249,1250-1499"; r.String() != want {
+		t.Errorf("Expected ReadColumn(second) == %s, got %v", want, r)
+	}
+
+	for _, column := range []string{"value", "offset", "missing"} {
+		if _, err := ReadColumn(f, column); err == nil {
+			t.Errorf("Expected ReadColumn(%s) to fail", column)
+		}
+	}
+}
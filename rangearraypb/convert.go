@@ -0,0 +1,45 @@
+// Package rangearraypb defines a protocol buffer message for
+// rangearrays, and converts between it and rangearray.Uint32.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on the protocol buffer runtime.
+package rangearraypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative rangearray.proto
+
+import (
+	"fmt"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// ToProto returns the protocol buffer form of r.
+func ToProto(r rangearray.Uint32) *Uint32 {
+	m := &Uint32{Runs: make([]*Uint32Run, 0, r.NumRuns())}
+	for s := range r.Runs() {
+		m.Runs = append(m.Runs, &Uint32Run{Value: s.Value, Count: s.Count})
+	}
+	return m
+}
+
+// FromProto returns the rangearray held in m.  It returns an error if
+// the runs in m are empty, out of order, or overlap, which matches
+// rangearray.ErrCorrupt.  Runs that touch are merged.
+func FromProto(m *Uint32) (rangearray.Uint32, error) {
+	runs := make([]rangearray.Uint32Run, 0, len(m.GetRuns()))
+	var end uint64
+	for i, p := range m.GetRuns() {
+		value, count := p.GetValue(), p.GetCount()
+		if count == 0 || (i > 0 && uint64(value) < end) || uint64(value)+uint64(count) > 1<<32 {
+			return rangearray.Uint32{}, fmt.Errorf("rangearraypb: invalid run %d (value %d, count %d): %w", i, value, count, rangearray.ErrCorrupt)
+		}
+
+		if n := len(runs) - 1; n >= 0 && uint64(value) == end {
+			runs[n].Count += count
+		} else {
+			runs = append(runs, rangearray.Uint32Run{Value: value, Count: count})
+		}
+		end = uint64(value) + uint64(count)
+	}
+	return rangearray.FromRuns(runs)
+}
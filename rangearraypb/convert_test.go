@@ -0,0 +1,63 @@
+package rangearraypb
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	r := &rangearray.Uint32{}
+	for i := uint32(100); i < 200; i++ {
+		r.Push(i)
+	}
+	for i := uint32(350); i < 450; i++ {
+		r.Push(i)
+	}
+	r.Push(0xffffffff)
+
+	b, err := proto.Marshal(ToProto(*r))
+	if err != nil {
+		t.Fatalf("proto.Marshal() failed: %v", err)
+	}
+	m := &Uint32{}
+	if err := proto.Unmarshal(b, m); err != nil {
+		t.Fatalf("proto.Unmarshal() failed: %v", err)
+	}
+
+	x, err := FromProto(m)
+	if err != nil {
+		t.Fatalf("FromProto() failed: %v", err)
+	}
+	if x.NumRuns() != r.NumRuns() {
+		t.Fatalf("Expected x.NumRuns() == %d, got %d", r.NumRuns(), x.NumRuns())
+	}
+	for i := range r.NumRuns() {
+		if x.Run(i) != r.Run(i) {
+			t.Errorf("Expected x.Run(%d) == %+v, got %+v", i, r.Run(i), x.Run(i))
+		}
+	}
+}
+
+func TestFromProto(t *testing.T) {
+	x, err := FromProto(&Uint32{Runs: []*Uint32Run{{Value: 1, Count: 2}, {Value: 3, Count: 4}}})
+	if err != nil || x.NumRuns() != 1 || x.Len() != 6 {
+		t.Errorf("Expected touching runs to merge, got %v, %v", x, err)
+	}
+	if x, err := FromProto(nil); err != nil || x.Len() != 0 {
+		t.Errorf("Expected FromProto(nil) to be empty, got %v, %v", x, err)
+	}
+
+	for _, runs := range [][]*Uint32Run{
+		{{Value: 1, Count: 0}},
+		{{Value: 10, Count: 5}, {Value: 12, Count: 1}},
+		{{Value: 10, Count: 5}, {Value: 1, Count: 1}},
+		{{Value: 0xffffffff, Count: 2}},
+	} {
+		if _, err := FromProto(&Uint32{Runs: runs}); err == nil {
+			t.Errorf("Expected FromProto(%v) to fail", runs)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+module github/com/entrope/rangearray/rangearraypb
+
+go 1.24
+
+require github/com/entrope/rangearray/v2 v2.0.0
+
+require google.golang.org/protobuf v1.36.12
+
+replace github/com/entrope/rangearray/v2 => ../
@@ -0,0 +1,187 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: rangearray.proto
+
+package rangearraypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Uint32 is a semi-dense array of uint32 values, stored as runs of
+// consecutive values.
+type Uint32 struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Runs are in increasing order, and neither overlap nor touch.
+	Runs          []*Uint32Run `protobuf:"bytes,1,rep,name=runs,proto3" json:"runs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Uint32) Reset() {
+	*x = Uint32{}
+	mi := &file_rangearray_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Uint32) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Uint32) ProtoMessage() {}
+
+func (x *Uint32) ProtoReflect() protoreflect.Message {
+	mi := &file_rangearray_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Uint32.ProtoReflect.Descriptor instead.
+func (*Uint32) Descriptor() ([]byte, []int) {
+	return file_rangearray_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Uint32) GetRuns() []*Uint32Run {
+	if x != nil {
+		return x.Runs
+	}
+	return nil
+}
+
+// Uint32Run is a run of consecutive values in a Uint32.  The number of
+// values before the run is not stored, since it follows from the
+// counts of the earlier runs.
+type Uint32Run struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Value is the first value in the run.
+	Value uint32 `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	// Count is the number of values in the run, which must be nonzero.
+	Count         uint32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Uint32Run) Reset() {
+	*x = Uint32Run{}
+	mi := &file_rangearray_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Uint32Run) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Uint32Run) ProtoMessage() {}
+
+func (x *Uint32Run) ProtoReflect() protoreflect.Message {
+	mi := &file_rangearray_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Uint32Run.ProtoReflect.Descriptor instead.
+func (*Uint32Run) Descriptor() ([]byte, []int) {
+	return file_rangearray_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Uint32Run) GetValue() uint32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Uint32Run) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+var File_rangearray_proto protoreflect.FileDescriptor
+
+const file_rangearray_proto_rawDesc = "" +
+	"\n" +
+	"\x10rangearray.proto\x12\rrangearray.v1\"6\n" +
+	"\x06Uint32\x12,\n" +
+	"\x04runs\x18\x01 \x03(\v2\x18.rangearray.v1.Uint32RunR\x04runs\"7\n" +
+	"\tUint32Run\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\rR\x05value\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\rR\x05countB,Z*github/com/entrope/rangearray/rangearraypbb\x06proto3"
+
+var (
+	file_rangearray_proto_rawDescOnce sync.Once
+	file_rangearray_proto_rawDescData []byte
+)
+
+func file_rangearray_proto_rawDescGZIP() []byte {
+	file_rangearray_proto_rawDescOnce.Do(func() {
+		file_rangearray_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rangearray_proto_rawDesc), len(file_rangearray_proto_rawDesc)))
+	})
+	return file_rangearray_proto_rawDescData
+}
+
+var file_rangearray_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rangearray_proto_goTypes = []any{
+	(*Uint32)(nil),    // 0: rangearray.v1.Uint32
+	(*Uint32Run)(nil), // 1: rangearray.v1.Uint32Run
+}
+var file_rangearray_proto_depIdxs = []int32{
+	1, // 0: rangearray.v1.Uint32.runs:type_name -> rangearray.v1.Uint32Run
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_rangearray_proto_init() }
+func file_rangearray_proto_init() {
+	if File_rangearray_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rangearray_proto_rawDesc), len(file_rangearray_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rangearray_proto_goTypes,
+		DependencyIndexes: file_rangearray_proto_depIdxs,
+		MessageInfos:      file_rangearray_proto_msgTypes,
+	}.Build()
+	File_rangearray_proto = out.File
+	file_rangearray_proto_goTypes = nil
+	file_rangearray_proto_depIdxs = nil
+}
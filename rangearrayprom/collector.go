@@ -0,0 +1,169 @@
+// Package rangearrayprom exports coverage metrics for rangearrays to
+// Prometheus.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on the Prometheus client library.
+package rangearrayprom
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// DefaultWindow is the sliding window used when Options.Window is zero.
+const DefaultWindow = time.Hour
+
+// Options controls how a Collector maps values to times.
+type Options struct {
+	// Namespace, if not empty, prefixes every metric name.
+	Namespace string
+
+	// Epoch is the time of value zero, and Tick is the time between
+	// consecutive values.  If Epoch is zero, values are Unix times; if
+	// Tick is zero, it is one second.
+	Epoch time.Time
+	Tick  time.Duration
+
+	// Window is the length of the sliding window, ending now, over
+	// which coverage and gaps are measured.  If it is zero,
+	// DefaultWindow is used.
+	Window time.Duration
+
+	// Now returns the current time.  If it is nil, time.Now is used.
+	Now func() time.Time
+}
+
+// Collector is a prometheus.Collector that reports, for each array
+// registered with it, labeled by array name:
+//
+//   - values: the number of values in the array
+//   - runs: the number of runs in the array
+//   - last_value_age_seconds: the time since the array's largest value
+//   - window_coverage_ratio: the fraction of values in the sliding
+//     window that are present
+//   - window_largest_gap_seconds: the duration of the longest absence
+//     within the sliding window
+//
+// The time-based metrics are omitted for empty arrays and when the
+// window starts before the epoch.  Collector is safe for concurrent
+// use.
+type Collector struct {
+	opts Options
+
+	mu      sync.Mutex
+	sources map[string]func() rangearray.Uint32
+
+	values, runs, age, coverage, gap *prometheus.Desc
+}
+
+// NewCollector returns a Collector with no arrays.
+func NewCollector(opts Options) *Collector {
+	if opts.Epoch.IsZero() {
+		opts.Epoch = time.Unix(0, 0)
+	}
+	if opts.Tick == 0 {
+		opts.Tick = time.Second
+	}
+	if opts.Window == 0 {
+		opts.Window = DefaultWindow
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(opts.Namespace, "rangearray", name), help, []string{"array"}, nil)
+	}
+	return &Collector{
+		opts:     opts,
+		sources:  make(map[string]func() rangearray.Uint32),
+		values:   desc("values", "Number of values in the array."),
+		runs:     desc("runs", "Number of runs in the array."),
+		age:      desc("last_value_age_seconds", "Time since the largest value in the array."),
+		coverage: desc("window_coverage_ratio", "Fraction of the values in the sliding window that are present."),
+		gap:      desc("window_largest_gap_seconds", "Duration of the longest absence in the sliding window."),
+	}
+}
+
+// Register adds an array to c under name.  Each collection calls get
+// for the current contents of the array, so it must be safe to call
+// concurrently with updates to the array.  Registering a name again
+// replaces its function.
+func (c *Collector) Register(name string, get func() rangearray.Uint32) {
+	c.mu.Lock()
+	c.sources[name] = get
+	c.mu.Unlock()
+}
+
+// Unregister removes the array under name from c.
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	delete(c.sources, name)
+	c.mu.Unlock()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.values
+	ch <- c.runs
+	ch <- c.age
+	ch <- c.coverage
+	ch <- c.gap
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.sources))
+	for name := range c.sources {
+		names = append(names, name)
+	}
+	sources := make([]func() rangearray.Uint32, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		sources[i] = c.sources[name]
+	}
+	c.mu.Unlock()
+
+	now := c.opts.Now()
+	for i, name := range names {
+		c.collect(ch, name, sources[i](), now)
+	}
+}
+
+// collect sends the metrics for one array.
+func (c *Collector) collect(ch chan<- prometheus.Metric, name string, r rangearray.Uint32, now time.Time) {
+	ch <- prometheus.MustNewConstMetric(c.values, prometheus.GaugeValue, float64(r.Len()), name)
+	ch <- prometheus.MustNewConstMetric(c.runs, prometheus.GaugeValue, float64(r.NumRuns()), name)
+	if r.NumRuns() == 0 {
+		return
+	}
+
+	// A time.Duration overflows past about 292 years, which a large
+	// value with a long tick can reach, so the time of the last value
+	// is found in seconds instead.
+	tick := c.opts.Tick
+	age := now.Sub(c.opts.Epoch).Seconds() - float64(r.Max())*tick.Seconds()
+	ch <- prometheus.MustNewConstMetric(c.age, prometheus.GaugeValue, age, name)
+
+	// The window holds the values whose times are in (now-Window, now].
+	end := now.Sub(c.opts.Epoch) / tick
+	start := now.Add(-c.opts.Window).Sub(c.opts.Epoch)/tick + 1
+	if start < 0 || end < start || end > 0xffffffff {
+		return
+	}
+	first, lastValue := uint32(start), uint32(end)
+	n := float64(r.CountIn(first, lastValue))
+	ch <- prometheus.MustNewConstMetric(c.coverage, prometheus.GaugeValue, n/float64(end-start+1), name)
+
+	var longest uint64
+	for iv := range r.GapsIn(first, lastValue) {
+		longest = max(longest, uint64(iv[1])-uint64(iv[0])+1)
+	}
+	ch <- prometheus.MustNewConstMetric(c.gap, prometheus.GaugeValue, float64(longest)*tick.Seconds(), name)
+}
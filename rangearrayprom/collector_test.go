@@ -0,0 +1,103 @@
+package rangearrayprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestCollector(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCollector(Options{
+		Namespace: "test",
+		Epoch:     epoch,
+		Tick:      time.Minute,
+		Window:    10 * time.Minute,
+		Now:       func() time.Time { return epoch.Add(100 * time.Minute) },
+	})
+
+	var r rangearray.Uint32
+	if err := r.UnmarshalText([]byte("0-89,92-95,97-98")); err != nil {
+		t.Fatal(err)
+	}
+	c.Register("a", func() rangearray.Uint32 { return r })
+	c.Register("empty", func() rangearray.Uint32 { return rangearray.Uint32{} })
+
+	want := `
+# HELP test_rangearray_last_value_age_seconds Time since the largest value in the array.
+# TYPE test_rangearray_last_value_age_seconds gauge
+test_rangearray_last_value_age_seconds{array="a"} 120
+# HELP test_rangearray_runs Number of runs in the array.
+# TYPE test_rangearray_runs gauge
+test_rangearray_runs{array="a"} 3
+test_rangearray_runs{array="empty"} 0
+# HELP test_rangearray_values Number of values in the array.
+# TYPE test_rangearray_values gauge
+test_rangearray_values{array="a"} 96
+test_rangearray_values{array="empty"} 0
+# HELP test_rangearray_window_coverage_ratio Fraction of the values in the sliding window that are present.
+# TYPE test_rangearray_window_coverage_ratio gauge
+test_rangearray_window_coverage_ratio{array="a"} 0.6
+# HELP test_rangearray_window_largest_gap_seconds Duration of the longest absence in the sliding window.
+# TYPE test_rangearray_window_largest_gap_seconds gauge
+test_rangearray_window_largest_gap_seconds{array="a"} 120
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+
+	c.Unregister("empty")
+	if n := testutil.CollectAndCount(c, "test_rangearray_runs"); n != 1 {
+		t.Errorf("Expected 1 runs metric after Unregister, got %d", n)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Errorf("Register() failed: %v", err)
+	}
+}
+
+func TestCollectorBeforeEpoch(t *testing.T) {
+	now := time.Unix(300, 0)
+	c := NewCollector(Options{Now: func() time.Time { return now }})
+
+	var r rangearray.Uint32
+	r.Push(10)
+	c.Register("a", func() rangearray.Uint32 { return r })
+
+	// The default hour-long window starts before the Unix epoch.
+	if n := testutil.CollectAndCount(c); n != 3 {
+		t.Errorf("Expected 3 metrics, got %d", n)
+	}
+	if n := testutil.CollectAndCount(c, "rangearray_last_value_age_seconds"); n != 1 {
+		t.Errorf("Expected 1 age metric, got %d", n)
+	}
+}
+
+func TestCollectorLongTick(t *testing.T) {
+	// 200 million minutes is more than a time.Duration can hold.
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCollector(Options{
+		Namespace: "test",
+		Epoch:     epoch,
+		Tick:      time.Minute,
+		Now:       func() time.Time { return epoch.Add(100 * time.Minute) },
+	})
+	var r rangearray.Uint32
+	r.Push(200000000)
+	c.Register("a", func() rangearray.Uint32 { return r })
+
+	want := `
+# HELP test_rangearray_last_value_age_seconds Time since the largest value in the array.
+# TYPE test_rangearray_last_value_age_seconds gauge
+test_rangearray_last_value_age_seconds{array="a"} -11999994000
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "test_rangearray_last_value_age_seconds"); err != nil {
+		t.Errorf("Unexpected metrics: %v", err)
+	}
+}
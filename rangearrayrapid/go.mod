@@ -0,0 +1,10 @@
+module github/com/entrope/rangearray/rangearrayrapid
+
+go 1.24
+
+require (
+	github/com/entrope/rangearray/v2 v2.0.0
+	pgregory.net/rapid v1.3.0
+)
+
+replace github/com/entrope/rangearray/v2 => ../
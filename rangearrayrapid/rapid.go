@@ -0,0 +1,39 @@
+// Package rangearrayrapid provides pgregory.net/rapid generators of
+// rangearrays, with the shapes that rangetest.Shape describes.  It is a
+// separate module so that the main module does not depend on rapid.
+package rangearrayrapid
+
+import (
+	"github/com/entrope/rangearray/v2"
+	"github/com/entrope/rangearray/v2/rangetest"
+	"pgregory.net/rapid"
+)
+
+// Uint32 returns a generator of arrays of shape s.  Rapid shrinks a
+// failing array toward fewer, shorter runs with smaller gaps.
+func Uint32(s rangetest.Shape) *rapid.Generator[rangearray.Uint32] {
+	return rapid.Custom(func(t *rapid.T) rangearray.Uint32 {
+		return s.Build(func(lo, hi uint32) uint32 {
+			return rapid.Uint32Range(lo, hi).Draw(t, "n")
+		})
+	})
+}
+
+// Ops returns a generator of operations with values drawn from the
+// range that opts describes, for replaying with rangetest.Replay.
+// Unlike rangetest.Ops, it draws every value uniformly, and leaves
+// appends to rapid's search.
+func Ops(opts rangetest.GenOptions) *rapid.Generator[[]rangetest.Op] {
+	if opts.Span == 0 {
+		opts.Span = 1024
+	}
+	op := rapid.Custom(func(t *rapid.T) rangetest.Op {
+		kind := rangetest.OpPush
+		if opts.Remove > 0 && rapid.Float64Range(0, 1).Draw(t, "p") < opts.Remove {
+			kind = rangetest.OpRemove
+		}
+		offset := rapid.Uint32Range(0, opts.Span-1).Draw(t, "offset")
+		return rangetest.Op{Kind: kind, Value: opts.Base + offset}
+	})
+	return rapid.SliceOf(op)
+}
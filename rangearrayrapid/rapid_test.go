@@ -0,0 +1,35 @@
+package rangearrayrapid
+
+import (
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+	"github/com/entrope/rangearray/v2/rangetest"
+	"pgregory.net/rapid"
+)
+
+func TestUint32(t *testing.T) {
+	s := rangetest.Shape{Runs: rangetest.Dist{Max: 64}, Gap: rangetest.Dist{Min: 1, Max: 2}}
+	rapid.Check(t, func(t *rapid.T) {
+		a := Uint32(s).Draw(t, "a")
+		if err := a.Validate(); err != nil {
+			t.Fatalf("Expected a valid array, got %v", err)
+		}
+		if a.NumRuns() > 64 {
+			t.Fatalf("Expected at most 64 runs, got %d", a.NumRuns())
+		}
+	})
+}
+
+func TestOps(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		a := Uint32(rangetest.Shape{}).Draw(t, "a")
+		ops := Ops(rangetest.GenOptions{Span: 200}).Draw(t, "ops")
+		err := rangetest.Replay(rangetest.ModelOf(a), ops, func(op rangetest.Op) (rangearray.Reader, bool) {
+			return a, a.Push(op.Value)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
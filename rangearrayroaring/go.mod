@@ -0,0 +1,16 @@
+module github/com/entrope/rangearray/rangearrayroaring
+
+go 1.24.0
+
+require (
+	github.com/RoaringBitmap/roaring/v2 v2.29.0
+	github/com/entrope/rangearray/v2 v2.0.0
+)
+
+require (
+	github.com/bits-and-blooms/bitset v1.24.4 // indirect
+	github.com/mschoch/smat v0.2.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+)
+
+replace github/com/entrope/rangearray/v2 => ../
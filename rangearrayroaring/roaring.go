@@ -0,0 +1,75 @@
+// Package rangearrayroaring converts between rangearrays and roaring
+// bitmaps, one run at a time rather than one value at a time.
+//
+// It is a separate module so that users of the rangearray package do
+// not depend on the roaring library.
+package rangearrayroaring
+
+import (
+	"github.com/RoaringBitmap/roaring/v2"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// ToRoaring returns a roaring bitmap holding the values in r.  The
+// bitmap is run-optimized, so runs in r become run containers.
+func ToRoaring(r rangearray.Uint32) *roaring.Bitmap {
+	rb := roaring.New()
+	for s := range r.Runs() {
+		rb.AddRange(uint64(s.Value), uint64(s.Value)+uint64(s.Count))
+	}
+	rb.RunOptimize()
+	return rb
+}
+
+// FromRoaring returns a rangearray holding the values in rb.  It finds
+// the length of each run with a logarithmic number of cardinality
+// queries, rather than visiting every value.
+func FromRoaring(rb *roaring.Bitmap) rangearray.Uint32 {
+	var runs []rangearray.Uint32Run
+	it := rb.Iterator()
+	for it.HasNext() {
+		x := uint64(it.Next())
+		n := runLength(rb, x)
+		runs = append(runs, rangearray.Uint32Run{
+			Value: uint32(x),
+			Count: uint32(min(n, rangearray.MaxLen)),
+		})
+
+		if x+n > 0xffffffff {
+			break
+		}
+		it.AdvanceIfNeeded(uint32(x + n))
+	}
+
+	// Each run is as long as it can be, and a run of every value leaves
+	// out the last, as rangearray.MaxLen says, so the runs are always
+	// valid.
+	r, _ := rangearray.FromRuns(runs)
+	return r
+}
+
+// runLength returns the number of consecutive values in rb that start
+// at x, which must be in rb.
+func runLength(rb *roaring.Bitmap, x uint64) uint64 {
+	full := func(n uint64) bool {
+		return rb.CardinalityInRange(x, x+n) == n
+	}
+
+	// Gallop until hi is too long, then search between lo and hi.
+	limit := 1<<32 - x
+	lo, hi := uint64(1), uint64(2)
+	for hi <= limit && full(hi) {
+		lo, hi = hi, 2*hi
+	}
+	hi = min(hi, limit+1)
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if full(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
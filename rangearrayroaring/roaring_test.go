@@ -0,0 +1,101 @@
+package rangearrayroaring
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring/v2"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestRoaringRoundTrip(t *testing.T) {
+	r := &rangearray.Uint32{}
+	for _, v := range [][2]uint32{{0, 9}, {100, 199}, {65530, 65545}, {70000, 200000}, {1 << 20, 1 << 20}, {0xfffffff0, 0xffffffff}} {
+		for x := v[0]; x <= v[1] && x >= v[0]; x++ {
+			r.Push(x)
+			if x == 0xffffffff {
+				break
+			}
+		}
+	}
+
+	rb := ToRoaring(*r)
+	if rb.GetCardinality() != uint64(r.Len()) {
+		t.Errorf("Expected rb.GetCardinality() == %d, got %d", r.Len(), rb.GetCardinality())
+	}
+	if !rb.HasRunCompression() {
+		t.Errorf("Expected ToRoaring() to use run containers")
+	}
+
+	x := FromRoaring(rb)
+	if x.NumRuns() != r.NumRuns() {
+		t.Fatalf("Expected x.NumRuns() == %d, got %d: %v", r.NumRuns(), x.NumRuns(), x)
+	}
+	for i := range r.NumRuns() {
+		if x.Run(i) != r.Run(i) {
+			t.Errorf("Expected x.Run(%d) == %+v, got %+v", i, r.Run(i), x.Run(i))
+		}
+	}
+}
+
+func TestFromRoaring(t *testing.T) {
+	if x := FromRoaring(roaring.New()); x.Len() != 0 {
+		t.Errorf("Expected FromRoaring() of an empty bitmap to be empty, got %v", x)
+	}
+
+	rb := roaring.BitmapOf(1, 2, 3, 5, 1<<16, 1<<16+1)
+	x := FromRoaring(rb)
+	want := []rangearray.Uint32Run{{Value: 1, Index: 0, Count: 3}, {Value: 5, Index: 3, Count: 1}, {Value: 1 << 16, Index: 4, Count: 2}}
+	if x.NumRuns() != len(want) {
+		t.Fatalf("Expected FromRoaring() == %+v, got %v", want, x.AppendRuns(nil))
+	}
+	for i := range want {
+		if x.Run(i) != want[i] {
+			t.Errorf("Expected x.Run(%d) == %+v, got %+v", i, want[i], x.Run(i))
+		}
+	}
+}
+
+func TestPortableFormat(t *testing.T) {
+	r := &rangearray.Uint32{}
+	for i := uint32(0); i < 10000; i++ {
+		r.Push(3 * i)
+	}
+	for i := uint32(1 << 20); i < 1<<21; i++ {
+		r.Push(i)
+	}
+	r.Push(0xffffffff)
+
+	// The library must read what rangearray writes...
+	b, err := r.MarshalRoaring()
+	if err != nil {
+		t.Fatalf("MarshalRoaring() failed: %v", err)
+	}
+	rb := roaring.New()
+	if _, err := rb.FromBuffer(b); err != nil {
+		t.Fatalf("FromBuffer() failed: %v", err)
+	}
+	if !rb.Equals(ToRoaring(*r)) {
+		t.Errorf("Expected the library to decode MarshalRoaring() correctly")
+	}
+
+	// ...and rangearray must read what the library writes.
+	for _, optimize := range []bool{false, true} {
+		rb := ToRoaring(*r)
+		if !optimize {
+			rb = roaring.BitmapOf(rb.ToArray()...)
+		}
+		b, err := rb.ToBytes()
+		if err != nil {
+			t.Fatalf("ToBytes() failed: %v", err)
+		}
+
+		var x rangearray.Uint32
+		if err := x.UnmarshalRoaring(b); err != nil {
+			t.Fatalf("UnmarshalRoaring() failed: %v", err)
+		}
+		if x.NumRuns() != r.NumRuns() || x.Len() != r.Len() {
+			t.Errorf("Expected UnmarshalRoaring() to match r, got %d runs and %d values", x.NumRuns(), x.Len())
+		}
+	}
+}
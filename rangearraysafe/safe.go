@@ -0,0 +1,128 @@
+// Package rangearraysafe wraps the rangearray operations that can panic
+// in functions that return errors instead, for long-running servers in
+// which a panic from library code is unacceptable.  Each function
+// checks the condition that would make the rangearray method panic,
+// then calls it, so the results are otherwise the same.
+//
+// The errors match ErrEmpty, ErrRange or ErrOverflow with errors.Is.
+package rangearraysafe
+
+import (
+	"errors"
+	"fmt"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+var (
+	// ErrEmpty matches errors for queries that need a value from an
+	// empty array.
+	ErrEmpty = errors.New("rangearraysafe: array is empty")
+
+	// ErrRange matches errors for an element or run index that is out
+	// of range.
+	ErrRange = errors.New("rangearraysafe: index out of range")
+
+	// ErrOverflow matches errors for operations whose result would not
+	// fit in the array's value type, and for an invalid Scale factor.
+	ErrOverflow = errors.New("rangearraysafe: value out of range")
+)
+
+// minMaxOK is implemented by the arrays that can report an empty array
+// without racing with a concurrent change, such as *SafeUint32.
+type minMaxOK interface {
+	MinOK() (uint32, bool)
+	MaxOK() (uint32, bool)
+}
+
+// Min returns the minimum value in r, or an error that matches ErrEmpty
+// if r is empty.
+func Min(r rangearray.Reader) (uint32, error) {
+	if m, ok := r.(minMaxOK); ok {
+		if x, ok := m.MinOK(); ok {
+			return x, nil
+		}
+		return 0, ErrEmpty
+	}
+	if r.Len() == 0 {
+		return 0, ErrEmpty
+	}
+	return r.Min(), nil
+}
+
+// Max is like Min, for the maximum value in r.
+func Max(r rangearray.Reader) (uint32, error) {
+	if m, ok := r.(minMaxOK); ok {
+		if x, ok := m.MaxOK(); ok {
+			return x, nil
+		}
+		return 0, ErrEmpty
+	}
+	if r.Len() == 0 {
+		return 0, ErrEmpty
+	}
+	return r.Max(), nil
+}
+
+// At returns r.At(i), or an error that matches ErrRange if i is not
+// less than r.Len().
+func At(r rangearray.Uint32, i uint32) (uint32, error) {
+	if i >= r.Len() {
+		return 0, fmt.Errorf("%w: element %d of %d", ErrRange, i, r.Len())
+	}
+	return r.At(i), nil
+}
+
+// Run returns r.Run(i), or an error that matches ErrRange if i is not
+// a run of r.
+func Run(r rangearray.Uint32, i int) (rangearray.Uint32Run, error) {
+	if i < 0 || i >= r.NumRuns() {
+		return rangearray.Uint32Run{}, fmt.Errorf("%w: run %d of %d", ErrRange, i, r.NumRuns())
+	}
+	return r.Run(i), nil
+}
+
+// Shift returns r.Shift(offset), or an error that matches ErrOverflow
+// if a value would leave the uint32 range.
+func Shift(r rangearray.Uint32, offset int64) (rangearray.Uint32, error) {
+	if r.NumRuns() > 0 && (int64(r.Min())+offset < 0 || int64(r.Max())+offset > 0xffffffff) {
+		return rangearray.Uint32{}, fmt.Errorf("%w: shifting %d-%d by %d", ErrOverflow, r.Min(), r.Max(), offset)
+	}
+	return r.Shift(offset), nil
+}
+
+// Scale returns r.Scale(factor), or an error that matches ErrOverflow
+// if factor is zero or a value would overflow a uint32.
+func Scale(r rangearray.Uint32, factor uint32) (rangearray.Uint32, error) {
+	if factor == 0 {
+		return rangearray.Uint32{}, fmt.Errorf("%w: scale factor is zero", ErrOverflow)
+	}
+	if r.NumRuns() > 0 && uint64(r.Max())*uint64(factor) > 0xffffffff {
+		return rangearray.Uint32{}, fmt.Errorf("%w: scaling %d by %d", ErrOverflow, r.Max(), factor)
+	}
+	return r.Scale(factor), nil
+}
+
+// Min64 is like Min, for a Uint64.
+func Min64(r rangearray.Uint64) (uint64, error) {
+	if x, ok := r.MinOK(); ok {
+		return x, nil
+	}
+	return 0, ErrEmpty
+}
+
+// Max64 is like Max, for a Uint64.
+func Max64(r rangearray.Uint64) (uint64, error) {
+	if x, ok := r.MaxOK(); ok {
+		return x, nil
+	}
+	return 0, ErrEmpty
+}
+
+// At64 is like At, for a Uint64.
+func At64(r rangearray.Uint64, i uint64) (uint64, error) {
+	if i >= r.Len() {
+		return 0, fmt.Errorf("%w: element %d of %d", ErrRange, i, r.Len())
+	}
+	return r.At(i), nil
+}
@@ -0,0 +1,82 @@
+package rangearraysafe
+
+import (
+	"errors"
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestEmpty(t *testing.T) {
+	var s rangearray.SafeUint32
+	for _, r := range []rangearray.Reader{rangearray.Uint32{}, &s, rangearray.Persistent{}} {
+		if _, err := Min(r); !errors.Is(err, ErrEmpty) {
+			t.Errorf("Expected Min(%T) to fail with ErrEmpty, got %v", r, err)
+		}
+		if _, err := Max(r); !errors.Is(err, ErrEmpty) {
+			t.Errorf("Expected Max(%T) to fail with ErrEmpty, got %v", r, err)
+		}
+	}
+	if _, err := Min64(rangearray.Uint64{}); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Expected Min64() to fail with ErrEmpty, got %v", err)
+	}
+	if _, err := Max64(rangearray.Uint64{}); !errors.Is(err, ErrEmpty) {
+		t.Errorf("Expected Max64() to fail with ErrEmpty, got %v", err)
+	}
+
+	s.Push(4)
+	s.Push(8)
+	if x, err := Min(&s); err != nil || x != 4 {
+		t.Errorf("Expected Min() == 4, got %d, %v", x, err)
+	}
+	if x, err := Max(s.Snapshot()); err != nil || x != 8 {
+		t.Errorf("Expected Max() == 8, got %d, %v", x, err)
+	}
+}
+
+func TestAt(t *testing.T) {
+	var r rangearray.Uint32
+	r.UnmarshalText([]byte("5,100-199"))
+	if x, err := At(r, 1); err != nil || x != 100 {
+		t.Errorf("Expected At(1) == 100, got %d, %v", x, err)
+	}
+	if _, err := At(r, r.Len()); !errors.Is(err, ErrRange) {
+		t.Errorf("Expected At(Len()) to fail with ErrRange, got %v", err)
+	}
+	if _, err := At64(rangearray.Uint64Of(r), 101); !errors.Is(err, ErrRange) {
+		t.Errorf("Expected At64(Len()) to fail with ErrRange, got %v", err)
+	}
+	if s, err := Run(r, 1); err != nil || s.Value != 100 {
+		t.Errorf("Expected Run(1) to start at 100, got %+v, %v", s, err)
+	}
+	for _, i := range []int{-1, 2} {
+		if _, err := Run(r, i); !errors.Is(err, ErrRange) {
+			t.Errorf("Expected Run(%d) to fail with ErrRange, got %v", i, err)
+		}
+	}
+}
+
+func TestShiftScale(t *testing.T) {
+	var r rangearray.Uint32
+	r.UnmarshalText([]byte("5,100-199"))
+	if s, err := Shift(r, -5); err != nil || s.Min() != 0 {
+		t.Errorf("Expected Shift(-5) to start at 0, got %v, %v", s, err)
+	}
+	for _, offset := range []int64{-6, 0xffffffff - 198} {
+		if _, err := Shift(r, offset); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Expected Shift(%d) to fail with ErrOverflow, got %v", offset, err)
+		}
+	}
+	if s, err := Shift(rangearray.Uint32{}, -1); err != nil || s.Len() != 0 {
+		t.Errorf("Expected Shift() of an empty array to succeed, got %v, %v", s, err)
+	}
+
+	if s, err := Scale(r, 2); err != nil || s.Max() != 398 {
+		t.Errorf("Expected Scale(2) to end at 398, got %v, %v", s, err)
+	}
+	for _, factor := range []uint32{0, 1 << 25} {
+		if _, err := Scale(r, factor); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Expected Scale(%d) to fail with ErrOverflow, got %v", factor, err)
+		}
+	}
+}
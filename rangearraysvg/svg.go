@@ -0,0 +1,166 @@
+// Package rangearraysvg renders rangearrays as SVG coverage timelines,
+// with one row per array and a bar for each run.
+package rangearraysvg
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// Row is one labeled array in a timeline.
+type Row struct {
+	Label string
+	Array rangearray.Uint32
+
+	// Color is the fill color of the row's bars.  If it is empty,
+	// Options.Color is used.
+	Color string
+}
+
+// Options controls the layout of a timeline.  The zero value gives a
+// usable layout.
+type Options struct {
+	// First and Last bound the window drawn, inclusive.  If both are
+	// zero, the window spans the values of every row.
+	First, Last uint32
+
+	// Width is the width in pixels of the bar area, and RowHeight the
+	// height of each row.  LabelWidth is the space reserved to the left
+	// for row labels.  They default to 800, 20, and 120.
+	Width, RowHeight, LabelWidth int
+
+	// Color is the default fill color of bars.  It defaults to
+	// "#4c78a8".
+	Color string
+
+	// MinGap, if not zero, annotates each gap of at least MinGap
+	// values within the window with a translucent GapColor band and a
+	// tooltip giving its range.  GapColor defaults to "#e45756".
+	MinGap   uint32
+	GapColor string
+}
+
+// defaults fills in zero fields of o from rows.
+func (o *Options) defaults(rows []Row) {
+	if o.First == 0 && o.Last == 0 {
+		o.First, o.Last = ^uint32(0), 0
+		for _, row := range rows {
+			if row.Array.NumRuns() > 0 {
+				o.First, o.Last = min(o.First, row.Array.Min()), max(o.Last, row.Array.Max())
+			}
+		}
+		if o.Last < o.First {
+			o.First, o.Last = 0, 0
+		}
+	}
+	if o.Width <= 0 {
+		o.Width = 800
+	}
+	if o.RowHeight <= 0 {
+		o.RowHeight = 20
+	}
+	if o.LabelWidth <= 0 {
+		o.LabelWidth = 120
+	}
+	if o.Color == "" {
+		o.Color = "#4c78a8"
+	}
+	if o.GapColor == "" {
+		o.GapColor = "#e45756"
+	}
+}
+
+// Write writes an SVG timeline of rows to w.  Runs too short to see are
+// widened to one pixel, and runs that fall within the same pixel are
+// drawn as one bar, so the output size is bounded by the width rather
+// than by the number of runs.
+func Write(w io.Writer, rows []Row, opts Options) error {
+	if opts.Last < opts.First {
+		return fmt.Errorf("rangearraysvg: last (%d) is before first (%d)", opts.Last, opts.First)
+	}
+	opts.defaults(rows)
+
+	bw := bufio.NewWriter(w)
+	height := len(rows)*opts.RowHeight + opts.RowHeight
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="%d">`+"\n",
+		opts.LabelWidth+opts.Width, height, opts.RowHeight*3/5)
+
+	span := float64(uint64(opts.Last) - uint64(opts.First) + 1)
+	scale := float64(opts.Width) / span
+	x := func(v uint64) float64 {
+		return float64(opts.LabelWidth) + float64(v-uint64(opts.First))*scale
+	}
+
+	for i, row := range rows {
+		y := i * opts.RowHeight
+		color := row.Color
+		if color == "" {
+			color = opts.Color
+		}
+		fmt.Fprintf(bw, `<g><text x="4" y="%d" dominant-baseline="middle">`, y+opts.RowHeight/2)
+		xml.EscapeText(bw, []byte(row.Label))
+		fmt.Fprintf(bw, "</text>\n")
+
+		// Coalesce runs that touch the same pixel into one bar.
+		var x0, x1 float64
+		open := false
+		bar := func() {
+			fmt.Fprintf(bw, `<rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s"/>`+"\n",
+				x0, y+2, max(x1-x0, 1), opts.RowHeight-4, xmlAttr(color))
+		}
+		for iv := range row.Array.IntervalsIn(opts.First, opts.Last) {
+			a, b := x(uint64(iv[0])), x(uint64(iv[1])+1)
+			if open && a <= x0+max(x1-x0, 1) {
+				x1 = max(x1, b)
+				continue
+			}
+			if open {
+				bar()
+			}
+			x0, x1, open = a, b, true
+		}
+		if open {
+			bar()
+		}
+
+		if opts.MinGap > 0 {
+			for iv := range row.Array.GapsIn(opts.First, opts.Last) {
+				if uint64(iv[1])-uint64(iv[0])+1 < uint64(opts.MinGap) {
+					continue
+				}
+				a, b := x(uint64(iv[0])), x(uint64(iv[1])+1)
+				fmt.Fprintf(bw, `<rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s" fill-opacity="0.3"><title>gap %d-%d</title></rect>`+"\n",
+					a, y, max(b-a, 1), opts.RowHeight, xmlAttr(opts.GapColor), iv[0], iv[1])
+			}
+		}
+		fmt.Fprintf(bw, "</g>\n")
+	}
+
+	axis := len(rows)*opts.RowHeight + opts.RowHeight*3/4
+	fmt.Fprintf(bw, `<text x="%d" y="%d">%d</text>`+"\n", opts.LabelWidth, axis, opts.First)
+	fmt.Fprintf(bw, `<text x="%d" y="%d" text-anchor="end">%d</text>`+"\n", opts.LabelWidth+opts.Width, axis, opts.Last)
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// xmlAttr escapes s for use in an attribute value.
+func xmlAttr(s string) string {
+	var b []byte
+	for _, c := range []byte(s) {
+		switch c {
+		case '"':
+			b = append(b, "&quot;"...)
+		case '&':
+			b = append(b, "&amp;"...)
+		case '<':
+			b = append(b, "&lt;"...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
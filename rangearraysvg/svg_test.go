@@ -0,0 +1,88 @@
+package rangearraysvg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// testElements parses svg and counts its elements by name.
+func testElements(t *testing.T, svg []byte) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return counts
+		} else if err != nil {
+			t.Fatalf("Invalid SVG: %v\n%s", err, svg)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			counts[se.Name.Local]++
+		}
+	}
+}
+
+func testArray(t *testing.T, text string) rangearray.Uint32 {
+	t.Helper()
+	var r rangearray.Uint32
+	if err := r.UnmarshalText([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestWrite(t *testing.T) {
+	rows := []Row{
+		{Label: "G01 <L1>", Array: testArray(t, "0-99,200-299")},
+		{Label: "G02", Array: testArray(t, "50-149"), Color: "green"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, rows, Options{Width: 300, MinGap: 50}); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	svg := buf.Bytes()
+
+	counts := testElements(t, svg)
+	// Three bars plus three gap bands: 100-199 in G01, 0-49 and
+	// 150-299 in G02.
+	if counts["g"] != 2 || counts["rect"] != 6 || counts["title"] != 3 {
+		t.Errorf("Expected 2 rows, 6 rects, and 3 titles, got %v", counts)
+	}
+	for _, want := range []string{`G01 &lt;L1&gt;`, `fill="green"`, `width="100.00"`, `gap 150-299`, `>299</text>`} {
+		if !bytes.Contains(svg, []byte(want)) {
+			t.Errorf("Expected SVG to contain %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestWriteCoalesces(t *testing.T) {
+	var r rangearray.Uint32
+	for i := uint32(0); i < 10000; i++ {
+		r.Push(3 * i)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, []Row{{Label: "dense", Array: r}}, Options{Width: 100}); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if n := testElements(t, buf.Bytes())["rect"]; n < 1 || n > 100 {
+		t.Errorf("Expected at most 100 rects for 10000 runs, got %d", n)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	if err := Write(io.Discard, nil, Options{First: 10, Last: 5}); err == nil {
+		t.Errorf("Expected Write() with last < first to fail")
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []Row{{Label: "empty"}}, Options{}); err != nil || !strings.Contains(buf.String(), "empty") {
+		t.Errorf("Expected Write() of an empty array to succeed, got %v", err)
+	}
+	testElements(t, buf.Bytes())
+}
@@ -0,0 +1,106 @@
+package rangetest
+
+import (
+	"fmt"
+	"math"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// Equal returns nil if r holds the same values as m, and otherwise an
+// error describing the first difference it finds.  Besides Len, Min,
+// Max and the runs themselves, including their Index fields, it probes
+// Contains, IndexOf and LowerBound at 0, the largest uint32, and each
+// end of each run and the values on either side of it.
+func Equal(r rangearray.Reader, m *Model) error {
+	if got, want := r.Len(), m.Len(); got != want {
+		return fmt.Errorf("Len() = %d, want %d", got, want)
+	}
+	values := m.values()
+	if len(values) > 0 {
+		if got, want := r.Min(), values[0]; got != want {
+			return fmt.Errorf("Min() = %d, want %d", got, want)
+		}
+		if got, want := r.Max(), values[len(values)-1]; got != want {
+			return fmt.Errorf("Max() = %d, want %d", got, want)
+		}
+	}
+
+	runs := m.Runs()
+	i := 0
+	for run := range r.Runs() {
+		if i >= len(runs) {
+			return fmt.Errorf("extra run %d: %+v", i, run)
+		}
+		if run != runs[i] {
+			return fmt.Errorf("run %d = %+v, want %+v", i, run, runs[i])
+		}
+		i++
+	}
+	if i < len(runs) {
+		return fmt.Errorf("missing run %d: %+v", i, runs[i])
+	}
+
+	i = 0
+	for x := range r.All() {
+		if i >= len(values) || x != values[i] {
+			return fmt.Errorf("All() yields %d at index %d", x, i)
+		}
+		i++
+	}
+	if i < len(values) {
+		return fmt.Errorf("All() stops at index %d, want %d values", i, len(values))
+	}
+
+	probe := func(x uint32) error {
+		if got, want := r.Contains(x), m.Contains(x); got != want {
+			return fmt.Errorf("Contains(%d) = %v, want %v", x, got, want)
+		}
+		if got, want := r.IndexOf(x), m.IndexOf(x); got != want {
+			return fmt.Errorf("IndexOf(%d) = %d, want %d", x, got, want)
+		}
+		if got, want := r.LowerBound(x), m.LowerBound(x); got != want {
+			return fmt.Errorf("LowerBound(%d) = %d, want %d", x, got, want)
+		}
+		return nil
+	}
+	if err := probe(0); err != nil {
+		return err
+	}
+	if err := probe(math.MaxUint32); err != nil {
+		return err
+	}
+	for _, run := range runs {
+		last := run.Value + (run.Count - 1)
+		for _, x := range [...]uint32{run.Value - 1, run.Value, last, last + 1} {
+			if err := probe(x); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Replay applies each of ops to m and, through apply, to the code under
+// test, which returns its array after the operation and whether the
+// operation changed it.  Replay checks each step with Equal, and also
+// that the code under test and the model agree on whether the value was
+// added or removed.  The error names the step that went wrong.
+func Replay(m *Model, ops []Op, apply func(Op) (rangearray.Reader, bool)) error {
+	for i, op := range ops {
+		var want bool
+		if op.Kind == OpRemove {
+			want = m.Remove(op.Value)
+		} else {
+			want = m.Push(op.Value)
+		}
+		r, got := apply(op)
+		if got != want {
+			return fmt.Errorf("step %d: %v reports %v, want %v", i, op, got, want)
+		}
+		if err := Equal(r, m); err != nil {
+			return fmt.Errorf("step %d: after %v: %w", i, op, err)
+		}
+	}
+	return nil
+}
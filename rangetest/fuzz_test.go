@@ -0,0 +1,106 @@
+package rangetest
+
+import (
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// seedOps adds fuzz corpus entries that cover appends, inserts that
+// merge runs, and the top of the range.
+func seedOps(f *testing.F, removes bool) {
+	seeds := [][]Op{
+		{{OpPush, 1}, {OpPush, 2}, {OpPush, 3}},
+		{{OpPush, 5}, {OpPush, 1}, {OpPush, 3}, {OpPush, 2}, {OpPush, 4}},
+		{{OpPush, 0xffffffff}, {OpPush, 0}, {OpPush, 0xfffffffe}},
+	}
+	if removes {
+		seeds = append(seeds, []Op{{OpPush, 1}, {OpPush, 2}, {OpPush, 3}, {OpRemove, 2}, {OpRemove, 1}})
+	}
+	for _, ops := range seeds {
+		f.Add(EncodeOps(ops))
+	}
+}
+
+func FuzzPush(f *testing.F) {
+	seedOps(f, false)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := DecodeOps(data, false)
+		var r rangearray.Uint32
+		b := rangearray.BackfillOf(rangearray.Uint32{})
+		l := rangearray.LeanOf(rangearray.Uint32{})
+		tr := rangearray.TreeOf(rangearray.Uint32{})
+		ti := rangearray.TieredOf(rangearray.Uint32{})
+		arrays := []struct {
+			name string
+			push func(uint32) (rangearray.Reader, bool)
+		}{
+			{"Uint32", func(x uint32) (rangearray.Reader, bool) { return r, r.Push(x) }},
+			{"Backfill", func(x uint32) (rangearray.Reader, bool) { return b, b.Push(x) }},
+			{"Lean", func(x uint32) (rangearray.Reader, bool) { return l, l.Push(x) }},
+			{"Tree", func(x uint32) (rangearray.Reader, bool) { return tr, tr.Push(x) }},
+			{"Tiered", func(x uint32) (rangearray.Reader, bool) { return ti, ti.Push(x) }},
+		}
+		for _, a := range arrays {
+			err := Replay(&Model{}, ops, func(op Op) (rangearray.Reader, bool) {
+				return a.push(op.Value)
+			})
+			if err != nil {
+				t.Fatalf("%s: %v", a.name, err)
+			}
+		}
+	})
+}
+
+func FuzzPersistent(f *testing.F) {
+	seedOps(f, true)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p rangearray.Persistent
+		err := Replay(&Model{}, DecodeOps(data, true), func(op Op) (rangearray.Reader, bool) {
+			n := p.Len()
+			if op.Kind == OpRemove {
+				p = p.Remove(op.Value)
+			} else {
+				p = p.Push(op.Value)
+			}
+			return p, p.Len() != n
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzSetOps(f *testing.F) {
+	f.Add(EncodeOps([]Op{{OpPush, 1}, {OpPush, 2}}), EncodeOps([]Op{{OpPush, 2}, {OpPush, 3}}))
+	f.Add(EncodeOps([]Op{{OpPush, 0xffffffff}, {OpPush, 5}}), EncodeOps([]Op{{OpPush, 0xfffffffe}}))
+	f.Fuzz(func(t *testing.T, da, db []byte) {
+		ma, mb := &Model{}, &Model{}
+		for _, op := range DecodeOps(da, false) {
+			ma.Push(op.Value)
+		}
+		for _, op := range DecodeOps(db, false) {
+			mb.Push(op.Value)
+		}
+		a, b := ma.Uint32(), mb.Uint32()
+
+		check := func(name string, runs []rangearray.Uint32Run, want *Model) {
+			r, err := rangearray.FromRuns(runs)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if err := Equal(r, want); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		check("AppendUnion", rangearray.AppendUnion(nil, a, b), Union(ma, mb))
+		check("AppendIntersection", rangearray.AppendIntersection(nil, a, b), Intersection(ma, mb))
+		check("AppendDifference", rangearray.AppendDifference(nil, a, b), Difference(ma, mb))
+		if err := Equal(rangearray.NewUnionView(a, b).Materialize(), Union(ma, mb)); err != nil {
+			t.Fatalf("UnionView: %v", err)
+		}
+		if err := Equal(rangearray.NewIntersectionView(a, b).Materialize(), Intersection(ma, mb)); err != nil {
+			t.Fatalf("IntersectionView: %v", err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package rangetest
+
+import (
+	"math"
+	"math/bits"
+	mathrand "math/rand"
+	"math/rand/v2"
+	"reflect"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// Dist is a distribution of counts, such as the number of runs in an
+// array or the length of each run.  Values are between Min and Max,
+// inclusive; if Max is less than Min, the value is always Min.
+type Dist struct {
+	Min, Max uint32
+
+	// Skew selects a log-uniform distribution, which draws small values
+	// about as often as each larger power of two, instead of a uniform
+	// one.  A skewed distribution of run lengths gives many short runs
+	// and a few long ones, as real data tends to.
+	Skew bool
+}
+
+// draw returns a value from d, using next to draw uniform values
+// between lo and hi, inclusive.
+func (d Dist) draw(next func(lo, hi uint32) uint32) uint32 {
+	if d.Max <= d.Min {
+		return d.Min
+	}
+	span := d.Max - d.Min
+	if !d.Skew {
+		return d.Min + next(0, span)
+	}
+	b := next(0, uint32(bits.Len32(span)))
+	if b == 0 {
+		return d.Min
+	}
+	lo := uint32(1) << (b - 1)
+	return d.Min + next(lo, min(span, lo<<1-1))
+}
+
+// Shape controls the arrays that a generator builds: how many runs
+// each has, how long they are, and how large the gaps between them
+// are.  A zero Dist selects a default, so the zero Shape gives up to
+// 16 runs with lengths and gaps up to 8.  Many runs separated by gaps
+// of one or two values make later pushes land between runs, and so
+// exercise the middle-insert paths of Push, which appends alone do not.
+type Shape struct {
+	// Runs is the number of runs.  The array has fewer if they would
+	// run past the largest uint32.
+	Runs Dist
+
+	// RunLen is the length of each run, which is at least 1.
+	RunLen Dist
+
+	// Gap is the number of missing values between runs, which is at
+	// least 1.
+	Gap Dist
+
+	// Start is the first value in the array.
+	Start Dist
+}
+
+// withDefaults returns s with its zero fields replaced by defaults.
+func (s Shape) withDefaults() Shape {
+	if s.Runs == (Dist{}) {
+		s.Runs = Dist{Max: 16}
+	}
+	if s.RunLen == (Dist{}) {
+		s.RunLen = Dist{Min: 1, Max: 8}
+	}
+	if s.Gap == (Dist{}) {
+		s.Gap = Dist{Min: 1, Max: 8}
+	}
+	s.RunLen.Min = max(s.RunLen.Min, 1)
+	s.Gap.Min = max(s.Gap.Min, 1)
+	return s
+}
+
+// Build returns an array of shape s, using next to draw uniform values
+// between lo and hi, inclusive.  It lets other property-testing
+// libraries drive the generator; Generate and QuickValues wrap it for
+// math/rand.
+func (s Shape) Build(next func(lo, hi uint32) uint32) rangearray.Uint32 {
+	s = s.withDefaults()
+	n := s.Runs.draw(next)
+	runs := make([]rangearray.Uint32Run, 0, min(n, 1024))
+	value, index := uint64(s.Start.draw(next)), uint64(0)
+	for range n {
+		count := uint64(s.RunLen.draw(next))
+		if value > math.MaxUint32 || index+count > rangearray.MaxLen {
+			break
+		}
+		count = min(count, 1<<32-value)
+		runs = append(runs, rangearray.Uint32Run{Value: uint32(value), Index: uint32(index), Count: uint32(count)})
+		value += count + uint64(s.Gap.draw(next))
+		index += count
+	}
+
+	r, err := rangearray.FromRuns(runs)
+	if err != nil {
+		panic("rangetest: generated invalid runs: " + err.Error())
+	}
+	return r
+}
+
+// Generate returns an array of shape s drawn from rng.
+func (s Shape) Generate(rng *rand.Rand) rangearray.Uint32 {
+	return s.Build(func(lo, hi uint32) uint32 {
+		return lo + uint32(rng.Uint64N(uint64(hi-lo)+1))
+	})
+}
+
+// QuickValues fills args with arrays of shape s drawn from rng.  Its
+// signature matches quick.Config.Values, for properties whose
+// arguments are all rangearray.Uint32 values.
+func (s Shape) QuickValues(args []reflect.Value, rng *mathrand.Rand) {
+	for i := range args {
+		args[i] = reflect.ValueOf(s.Build(func(lo, hi uint32) uint32 {
+			return lo + uint32(rng.Int63n(int64(hi-lo)+1))
+		}))
+	}
+}
+
+// Array is a rangearray.Uint32 that testing/quick can generate, with
+// the default Shape scaled by quick's size hint, for properties that
+// mix arrays with other arguments.
+type Array struct {
+	rangearray.Uint32
+}
+
+// Generate implements quick.Generator.
+func (Array) Generate(rng *mathrand.Rand, size int) reflect.Value {
+	s := Shape{Runs: Dist{Max: uint32(max(size, 1))}}
+	var args [1]reflect.Value
+	s.QuickValues(args[:], rng)
+	return reflect.ValueOf(Array{args[0].Interface().(rangearray.Uint32)})
+}
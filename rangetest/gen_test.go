@@ -0,0 +1,86 @@
+package rangetest
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"testing/quick"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestDist(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	next := func(lo, hi uint32) uint32 { return lo + uint32(rng.Uint64N(uint64(hi-lo)+1)) }
+	for _, d := range []Dist{{Min: 3, Max: 3}, {Min: 2, Max: 9}, {Min: 1, Max: 1000, Skew: true}, {Max: math.MaxUint32, Skew: true}} {
+		small := 0
+		for range 1000 {
+			x := d.draw(next)
+			if x < d.Min || x > d.Max {
+				t.Fatalf("Expected %+v to draw in range, got %d", d, x)
+			}
+			if x < 16 {
+				small++
+			}
+		}
+		if d.Skew && d.Max == 1000 && small < 200 {
+			t.Errorf("Expected a skewed Dist to favour small values, got %d of 1000 below 16", small)
+		}
+	}
+	if got := (Dist{Min: 7, Max: 2}).draw(next); got != 7 {
+		t.Errorf("Expected Min when Max < Min, got %d", got)
+	}
+}
+
+func TestShape(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	s := Shape{
+		Runs:   Dist{Min: 50, Max: 50},
+		RunLen: Dist{Min: 2, Max: 4},
+		Gap:    Dist{Min: 1, Max: 2},
+		Start:  Dist{Min: 100, Max: 200},
+	}
+	for range 100 {
+		r := s.Generate(rng)
+		if err := r.Validate(); err != nil {
+			t.Fatalf("Expected a valid array, got %v", err)
+		}
+		if r.NumRuns() != 50 || r.Min() < 100 || r.Min() > 200 {
+			t.Fatalf("Expected 50 runs from [100, 200], got %v", r)
+		}
+		for run := range r.Runs() {
+			if run.Count < 2 || run.Count > 4 {
+				t.Fatalf("Expected run lengths in [2, 4], got %+v", run)
+			}
+		}
+	}
+
+	top := Shape{Runs: Dist{Min: 10, Max: 10}, Start: Dist{Min: math.MaxUint32 - 20, Max: math.MaxUint32}}
+	for range 100 {
+		if err := top.Generate(rng).Validate(); err != nil {
+			t.Fatalf("Expected a valid array at the top of the range, got %v", err)
+		}
+	}
+}
+
+func TestQuick(t *testing.T) {
+	union := func(a, b rangearray.Uint32) bool {
+		want := Union(ModelOf(a), ModelOf(b))
+		r, err := rangearray.FromRuns(rangearray.AppendUnion(nil, a, b))
+		return err == nil && Equal(r, want) == nil
+	}
+	s := Shape{Runs: Dist{Max: 40}, Gap: Dist{Min: 1, Max: 3}}
+	if err := quick.Check(union, &quick.Config{Values: s.QuickValues}); err != nil {
+		t.Error(err)
+	}
+
+	push := func(a Array, x uint16) bool {
+		m := ModelOf(a)
+		m.Push(uint32(x))
+		a.Push(uint32(x))
+		return Equal(a.Uint32, m) == nil
+	}
+	if err := quick.Check(push, nil); err != nil {
+		t.Error(err)
+	}
+}
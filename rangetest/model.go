@@ -0,0 +1,170 @@
+// Package rangetest helps test code built on rangearray: a simple
+// reference model of a set of uint32 values, generators of random
+// operations and of arrays with a given Shape, and a checker that
+// compares any rangearray.Reader with the model.  Replay ties them
+// together, and works as the body of a native Go fuzz target.
+package rangetest
+
+import (
+	"slices"
+	"sort"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+// Model is a reference implementation of a set of uint32 values, kept
+// as a map and a sorted slice, so that it is obviously correct rather
+// than fast.  The zero value is an empty set ready to use.
+type Model struct {
+	set    map[uint32]bool
+	sorted []uint32
+	dirty  bool // sorted must be rebuilt from set
+}
+
+// ModelOf returns a Model holding the values in r.
+func ModelOf(r rangearray.Reader) *Model {
+	m := &Model{}
+	for x := range r.All() {
+		m.Push(x)
+	}
+	return m
+}
+
+// FromValues returns a Model holding xs, in any order.
+func FromValues(xs ...uint32) *Model {
+	m := &Model{}
+	for _, x := range xs {
+		m.Push(x)
+	}
+	return m
+}
+
+// Push adds x to m, and reports whether it was not already there.
+func (m *Model) Push(x uint32) bool {
+	if m.set[x] {
+		return false
+	}
+	if m.set == nil {
+		m.set = make(map[uint32]bool)
+	}
+	m.set[x] = true
+	if !m.dirty && (len(m.sorted) == 0 || x > m.sorted[len(m.sorted)-1]) {
+		m.sorted = append(m.sorted, x)
+	} else {
+		m.dirty = true
+	}
+	return true
+}
+
+// Remove removes x from m, and reports whether it was there.
+func (m *Model) Remove(x uint32) bool {
+	if !m.set[x] {
+		return false
+	}
+	delete(m.set, x)
+	m.dirty = true
+	return true
+}
+
+// values returns the values in m in increasing order, which the caller
+// must not modify.
+func (m *Model) values() []uint32 {
+	if m.dirty {
+		m.sorted = m.sorted[:0]
+		for x := range m.set {
+			m.sorted = append(m.sorted, x)
+		}
+		slices.Sort(m.sorted)
+		m.dirty = false
+	}
+	return m.sorted
+}
+
+// Values returns the values in m, in increasing order.
+func (m *Model) Values() []uint32 {
+	return slices.Clone(m.values())
+}
+
+// Len returns the number of values in m.
+func (m *Model) Len() uint32 {
+	return uint32(len(m.set))
+}
+
+// Contains reports whether x is in m.
+func (m *Model) Contains(x uint32) bool {
+	return m.set[x]
+}
+
+// IndexOf returns the number of values in m that are less than x.
+func (m *Model) IndexOf(x uint32) uint32 {
+	i, _ := slices.BinarySearch(m.values(), x)
+	return uint32(i)
+}
+
+// Runs returns the runs of consecutive values in m, in increasing
+// order, as a rangearray stores them.
+func (m *Model) Runs() []rangearray.Uint32Run {
+	var runs []rangearray.Uint32Run
+	for i, x := range m.values() {
+		if n := len(runs) - 1; n >= 0 && uint64(runs[n].Value)+uint64(runs[n].Count) == uint64(x) {
+			runs[n].Count++
+			continue
+		}
+		runs = append(runs, rangearray.Uint32Run{Value: x, Index: uint32(i), Count: 1})
+	}
+	return runs
+}
+
+// LowerBound returns the index of the run in m that contains x, or of
+// the run that starts after x, or the number of runs if there is none.
+func (m *Model) LowerBound(x uint32) int {
+	runs := m.Runs()
+	return sort.Search(len(runs), func(i int) bool {
+		return uint64(runs[i].Value)+uint64(runs[i].Count) > uint64(x)
+	})
+}
+
+// Uint32 returns the values in m as a rangearray.
+func (m *Model) Uint32() rangearray.Uint32 {
+	r, err := rangearray.FromRuns(m.Runs())
+	if err != nil {
+		panic("rangetest: model has invalid runs: " + err.Error())
+	}
+	return r
+}
+
+// Union returns a Model holding the values that are in a or b.
+func Union(a, b *Model) *Model {
+	out := &Model{}
+	for _, x := range a.values() {
+		out.Push(x)
+	}
+	for _, x := range b.values() {
+		out.Push(x)
+	}
+	return out
+}
+
+// Intersection returns a Model holding the values that are in both a
+// and b.
+func Intersection(a, b *Model) *Model {
+	out := &Model{}
+	for _, x := range a.values() {
+		if b.Contains(x) {
+			out.Push(x)
+		}
+	}
+	return out
+}
+
+// Difference returns a Model holding the values that are in a but not
+// in b.
+func Difference(a, b *Model) *Model {
+	out := &Model{}
+	for _, x := range a.values() {
+		if !b.Contains(x) {
+			out.Push(x)
+		}
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package rangetest
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// OpKind is the kind of an Op.
+type OpKind int
+
+const (
+	// OpPush adds a value.
+	OpPush OpKind = iota
+
+	// OpRemove removes a value.
+	OpRemove
+)
+
+// Op is one operation on a set of values.
+type Op struct {
+	Kind  OpKind
+	Value uint32
+}
+
+func (op Op) String() string {
+	if op.Kind == OpRemove {
+		return fmt.Sprintf("Remove(%d)", op.Value)
+	}
+	return fmt.Sprintf("Push(%d)", op.Value)
+}
+
+// GenOptions controls the operations that Ops generates.  The zero
+// value gives pushes of values below 1024, half of them appends.
+type GenOptions struct {
+	// Base and Span bound the values: each is at least Base and less
+	// than Base+Span, wrapping past the largest uint32.  If Span is
+	// zero, 1024 is used.  A small span makes values collide and runs
+	// merge, so that Push takes its middle-insert paths.
+	Base, Span uint32
+
+	// Append is the fraction of pushes that add the value just past
+	// the largest one pushed so far, the fast path of a rangearray.
+	// The other pushes are spread evenly over the span.  If Append is
+	// zero, 0.5 is used; a negative Append means none.
+	Append float64
+
+	// Remove is the fraction of operations that are removes, of values
+	// spread evenly over the span.
+	Remove float64
+}
+
+// Ops returns n random operations drawn from rng.
+func Ops(rng *rand.Rand, n int, opts GenOptions) []Op {
+	if opts.Span == 0 {
+		opts.Span = 1024
+	}
+	if opts.Append == 0 {
+		opts.Append = 0.5
+	}
+
+	ops := make([]Op, n)
+	next, started := uint32(0), false
+	for i := range ops {
+		offset := rng.Uint32N(opts.Span)
+		switch p := rng.Float64(); {
+		case p < opts.Remove:
+			ops[i] = Op{Kind: OpRemove, Value: opts.Base + offset}
+		case started && rng.Float64() < opts.Append:
+			ops[i] = Op{Kind: OpPush, Value: opts.Base + next}
+		default:
+			ops[i] = Op{Kind: OpPush, Value: opts.Base + offset}
+		}
+		if ops[i].Kind == OpPush && (!started || ops[i].Value-opts.Base >= next) {
+			next, started = min(ops[i].Value-opts.Base+1, opts.Span-1), true
+		}
+	}
+	return ops
+}
+
+// DecodeOps turns fuzzer input into operations, three bytes each.  The
+// low bit of the first byte selects a remove, if removes is set, and
+// the next bit counts the value down from the largest uint32 instead
+// of up from zero, to cover the top of the range.  The other two bytes
+// are the distance, little-endian.  A trailing partial operation is
+// ignored.
+func DecodeOps(data []byte, removes bool) []Op {
+	ops := make([]Op, 0, len(data)/3)
+	for ; len(data) >= 3; data = data[3:] {
+		op := Op{Value: uint32(data[1]) | uint32(data[2])<<8}
+		if removes && data[0]&1 != 0 {
+			op.Kind = OpRemove
+		}
+		if data[0]&2 != 0 {
+			op.Value = ^op.Value
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// EncodeOps returns the fuzzer input that DecodeOps turns into ops,
+// for seeding a fuzz corpus.  Values whose distance from either end of
+// the range does not fit in 16 bits are clamped.
+func EncodeOps(ops []Op) []byte {
+	b := make([]byte, 0, 3*len(ops))
+	for _, op := range ops {
+		var flags byte
+		if op.Kind == OpRemove {
+			flags |= 1
+		}
+		v := op.Value
+		if v > 1<<31 {
+			flags |= 2
+			v = ^v
+		}
+		v = min(v, 0xffff)
+		b = append(b, flags, byte(v), byte(v>>8))
+	}
+	return b
+}
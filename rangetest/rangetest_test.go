@@ -0,0 +1,118 @@
+package rangetest
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github/com/entrope/rangearray/v2"
+)
+
+func TestModel(t *testing.T) {
+	m := FromValues(5, 3, 4, 10, 4)
+	if !slices.Equal(m.Values(), []uint32{3, 4, 5, 10}) {
+		t.Errorf("Expected [3 4 5 10], got %v", m.Values())
+	}
+	want := []rangearray.Uint32Run{{Value: 3, Index: 0, Count: 3}, {Value: 10, Index: 3, Count: 1}}
+	if !slices.Equal(m.Runs(), want) {
+		t.Errorf("Expected runs %v, got %v", want, m.Runs())
+	}
+	if m.IndexOf(10) != 3 || m.LowerBound(6) != 1 || m.LowerBound(11) != 2 {
+		t.Errorf("Expected IndexOf(10)=3, LowerBound(6)=1, LowerBound(11)=2, got %d, %d, %d",
+			m.IndexOf(10), m.LowerBound(6), m.LowerBound(11))
+	}
+	if !m.Remove(4) || m.Remove(4) || m.Contains(4) || m.Len() != 3 {
+		t.Errorf("Expected Remove(4) to remove it once, got %v", m.Values())
+	}
+	if err := Equal(m.Uint32(), m); err != nil {
+		t.Errorf("Expected Uint32() to equal the model, got %v", err)
+	}
+}
+
+func TestModelSetOps(t *testing.T) {
+	a, b := FromValues(1, 2, 3, 7), FromValues(2, 3, 4)
+	cases := []struct {
+		name string
+		got  *Model
+		want []uint32
+	}{
+		{"Union", Union(a, b), []uint32{1, 2, 3, 4, 7}},
+		{"Intersection", Intersection(a, b), []uint32{2, 3}},
+		{"Difference", Difference(a, b), []uint32{1, 7}},
+	}
+	for _, c := range cases {
+		if !slices.Equal(c.got.Values(), c.want) {
+			t.Errorf("Expected %s %v, got %v", c.name, c.want, c.got.Values())
+		}
+	}
+}
+
+func TestEqualFindsDifferences(t *testing.T) {
+	var r rangearray.Uint32
+	for _, x := range []uint32{1, 2, 3, 8} {
+		r.Push(x)
+	}
+	if err := Equal(r, FromValues(1, 2, 3, 8)); err != nil {
+		t.Errorf("Expected equal arrays, got %v", err)
+	}
+	for _, m := range []*Model{FromValues(1, 2, 3), FromValues(1, 2, 3, 9), FromValues(0, 2, 3, 8)} {
+		if Equal(r, m) == nil {
+			t.Errorf("Expected a difference from %v", m.Values())
+		}
+	}
+}
+
+func TestOps(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	opts := GenOptions{Base: 1000, Span: 50, Remove: 0.25}
+	removes := 0
+	for _, op := range Ops(rng, 1000, opts) {
+		if op.Value < 1000 || op.Value >= 1050 {
+			t.Fatalf("Expected values in [1000, 1050), got %v", op)
+		}
+		if op.Kind == OpRemove {
+			removes++
+		}
+	}
+	if removes < 150 || removes > 350 {
+		t.Errorf("Expected about 250 removes, got %d", removes)
+	}
+
+	var m Model
+	for _, op := range Ops(rng, 100, GenOptions{Append: 1}) {
+		if op.Kind != OpPush || !m.Push(op.Value) {
+			t.Fatalf("Expected only new pushes, got %v", op)
+		}
+	}
+	if runs := m.Runs(); len(runs) != 1 {
+		t.Errorf("Expected appends to make one run, got %v", runs)
+	}
+}
+
+func TestDecodeOps(t *testing.T) {
+	ops := []Op{{OpPush, 7}, {OpRemove, 0x1234}, {OpPush, 0xfffffffe}}
+	if got := DecodeOps(EncodeOps(ops), true); !slices.Equal(got, ops) {
+		t.Errorf("Expected %v, got %v", ops, got)
+	}
+	if got := DecodeOps(append(EncodeOps(ops), 1), false); len(got) != 3 || got[1].Kind != OpPush {
+		t.Errorf("Expected three pushes, got %v", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var r rangearray.Uint32
+	ops := Ops(rand.New(rand.NewPCG(3, 4)), 500, GenOptions{Span: 64})
+	err := Replay(&Model{}, ops, func(op Op) (rangearray.Reader, bool) {
+		return r, r.Push(op.Value)
+	})
+	if err != nil {
+		t.Errorf("Expected Uint32 to match the model, got %v", err)
+	}
+
+	err = Replay(&Model{}, ops, func(op Op) (rangearray.Reader, bool) {
+		return rangearray.Uint32{}, true
+	})
+	if err == nil {
+		t.Errorf("Expected an empty array to differ from the model")
+	}
+}
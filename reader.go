@@ -0,0 +1,52 @@
+package rangearray
+
+import "iter"
+
+// Reader is the read-only query interface shared by every form of
+// rangearray: Uint32, *Array, *SafeUint32, *Backfill, *Buffered, *Tree,
+// *Lean, *Tiered, *Retained, *AppendOnly, *Frozen and Snapshot
+// snapshots, Persistent versions, and FlatView wrappers around
+// serialized (possibly memory-mapped) data.  Functions that only query
+// an array can accept a Reader to work with any of them.
+type Reader interface {
+	// Len returns the number of elements.
+	Len() uint32
+
+	// Min and Max return the minimum and maximum elements.  They panic
+	// if the array is empty.
+	Min() uint32
+	Max() uint32
+
+	// IndexOf returns the number of elements that are less than x.
+	IndexOf(x uint32) uint32
+
+	// LowerBound returns the index of the run that contains x, or of
+	// the run that starts after x.
+	LowerBound(x uint32) int
+
+	// Contains reports whether x is an element.
+	Contains(x uint32) bool
+
+	// All returns an iterator over the elements, in increasing order.
+	All() iter.Seq[uint32]
+
+	// Runs returns an iterator over the runs, in increasing order.
+	Runs() iter.Seq[Uint32Run]
+}
+
+var (
+	_ Reader = Uint32{}
+	_ Reader = (*Array)(nil)
+	_ Reader = (*SafeUint32)(nil)
+	_ Reader = (*Frozen)(nil)
+	_ Reader = FlatView{}
+	_ Reader = Persistent{}
+	_ Reader = Snapshot{}
+	_ Reader = (*Backfill)(nil)
+	_ Reader = (*Buffered)(nil)
+	_ Reader = (*Tree)(nil)
+	_ Reader = (*Lean)(nil)
+	_ Reader = (*Tiered)(nil)
+	_ Reader = (*Retained)(nil)
+	_ Reader = (*AppendOnly)(nil)
+)
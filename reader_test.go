@@ -0,0 +1,38 @@
+package rangearray
+
+import (
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	r := testEncodingArray()
+	var safe SafeUint32
+	for s := range r.Runs() {
+		for i := range s.Count {
+			safe.Push(s.Value + i)
+		}
+	}
+	flat, err := NewFlatView(r.AppendFlat(nil))
+	if err != nil {
+		t.Fatalf("NewFlatView() failed: %v", err)
+	}
+
+	for name, rd := range map[string]Reader{"Uint32": r, "SafeUint32": &safe, "Frozen": r.Freeze(), "FlatView": flat, "Persistent": PersistentOf(r)} {
+		if rd.Len() != 202 || rd.Min() != 100 || rd.Max() != 0xffffffff {
+			t.Errorf("Expected %s to have 202 values from 100 to 4294967295, got %d from %d to %d", name, rd.Len(), rd.Min(), rd.Max())
+		}
+		if rd.IndexOf(400) != 150 || rd.LowerBound(300) != 1 || !rd.Contains(1000) || rd.Contains(999) {
+			t.Errorf("Expected %s queries to match Uint32", name)
+		}
+		n, runs := 0, 0
+		for range rd.All() {
+			n++
+		}
+		for range rd.Runs() {
+			runs++
+		}
+		if n != 202 || runs != 4 {
+			t.Errorf("Expected %s to iterate 202 values in 4 runs, got %d in %d", name, n, runs)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package rangearray
+
+import (
+	"iter"
+	"sort"
+	"time"
+)
+
+// RetentionOptions says which values a Retained keeps.  If MaxValues
+// and MaxAge are both zero, it keeps every value.
+type RetentionOptions struct {
+	// MaxValues, if not zero, is the number of values to keep; a Push
+	// that makes the array longer drops its smallest values.
+	MaxValues uint32
+
+	// MaxAge, if not zero, drops the values that stand for times more
+	// than MaxAge before Now.  Tick maps a time to the value that
+	// stands for it, and must be set if MaxAge is.
+	MaxAge time.Duration
+	Tick   func(time.Time) uint32
+
+	// Now returns the current time.  If it is nil, time.Now is used.
+	Now func() time.Time
+
+	// OnTrim, if not nil, is called with each range of values, first
+	// through last inclusive, that retention drops, in increasing
+	// order.
+	OnTrim func(first, last uint32)
+}
+
+// Retained is a rangearray for long-running monitors, which keeps its
+// memory bounded by dropping its oldest values as Push adds new ones,
+// as the options say.  Indexes count only the values that are kept, so
+// IndexOf(Min()) is always zero.
+//
+// Each value is dropped once, so retention adds O(1) time per Push on
+// average.  Like Uint32, a Retained is not safe for concurrent use
+// while it is being modified.
+type Retained struct {
+	opts RetentionOptions
+	r    Uint32 // with indexes that count the dropped values too
+}
+
+// NewRetained returns a Retained whose array is initially empty.
+func NewRetained(opts RetentionOptions) *Retained {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Retained{opts: opts}
+}
+
+// RetainedOf returns a Retained holding the values in r that the
+// options keep.
+func RetainedOf(r Uint32, opts RetentionOptions) *Retained {
+	rt := NewRetained(opts)
+	rt.r = r.Fork()
+	rt.Trim()
+	return rt
+}
+
+// base returns the number of dropped values that the indexes in rt.r
+// count.
+func (rt *Retained) base() uint32 {
+	if len(rt.r.runs) == 0 {
+		return 0
+	}
+	return rt.r.runs[0].Index
+}
+
+// Push adds x to rt, then drops any values that the options no longer
+// keep, which may include x.  It reports whether x was added, as
+// Uint32.Push does, even if x was then dropped.
+func (rt *Retained) Push(x uint32) bool {
+	added := rt.r.push(x)
+	rt.Trim()
+	if debugChecks {
+		checkReader("Retained.Push", rt)
+	}
+	return added
+}
+
+// Trim drops any values that the options no longer keep.  Push does
+// this itself, but with MaxAge, values also expire as time passes
+// without any pushes.
+func (rt *Retained) Trim() {
+	if rt.opts.MaxAge > 0 {
+		rt.trimBefore(rt.opts.Tick(rt.opts.Now().Add(-rt.opts.MaxAge)))
+	}
+	if n, keep := rt.Len(), rt.opts.MaxValues; keep > 0 && n > keep {
+		// Find the first value to keep, by its index counting the
+		// dropped values.
+		index := rt.base() + n - keep
+		s := rt.r.runs
+		i := sort.Search(len(s), func(i int) bool {
+			return s[i].Index+s[i].Count > index
+		})
+		rt.trimBefore(s[i].Value + (index - s[i].Index))
+	}
+}
+
+// trimBefore drops the values in rt that are less than x, leaving the
+// indexes of the others as they were.
+func (rt *Retained) trimBefore(x uint32) {
+	r := &rt.r
+	k := 0
+	for k < len(r.runs) && uint64(r.runs[k].Value)+uint64(r.runs[k].Count) <= uint64(x) {
+		rt.trimmed(r.runs[k].Value, r.runs[k].Value+r.runs[k].Count-1)
+		k++
+	}
+	partial := k < len(r.runs) && r.runs[k].Value < x
+	if k == 0 && !partial {
+		return
+	}
+
+	r.own()
+	r.runs = r.runs[k:]
+	if partial {
+		r.unpin()
+		rt.trimmed(r.runs[0].Value, x-1)
+		d := x - r.runs[0].Value
+		r.runs[0].Value += d
+		r.runs[0].Index += d
+		r.runs[0].Count -= d
+	}
+	r.dropIndex()
+	if debugChecks {
+		checkReader("Retained.Trim", rt)
+	}
+}
+
+// trimmed reports that first through last have been dropped.
+func (rt *Retained) trimmed(first, last uint32) {
+	if rt.opts.OnTrim != nil {
+		rt.opts.OnTrim(first, last)
+	}
+}
+
+// Min returns the minimum value in rt.  Panics if rt is empty.
+func (rt *Retained) Min() uint32 {
+	return rt.r.Min()
+}
+
+// Max returns the maximum value in rt.  Panics if rt is empty.
+func (rt *Retained) Max() uint32 {
+	return rt.r.Max()
+}
+
+// Len returns the number of elements in rt.
+func (rt *Retained) Len() uint32 {
+	return rt.r.Len() - rt.base()
+}
+
+// NumRuns returns the number of runs in rt.
+func (rt *Retained) NumRuns() int {
+	return len(rt.r.runs)
+}
+
+// IndexOf returns the number of elements in rt that are less than x.
+func (rt *Retained) IndexOf(x uint32) uint32 {
+	return rt.r.IndexOf(x) - rt.base()
+}
+
+// LowerBound returns the index of the run in rt that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (rt *Retained) LowerBound(x uint32) int {
+	return rt.r.LowerBound(x)
+}
+
+// Contains reports whether x is in rt.
+func (rt *Retained) Contains(x uint32) bool {
+	return rt.r.Contains(x)
+}
+
+// All returns an iterator over the values in rt, in increasing order.
+func (rt *Retained) All() iter.Seq[uint32] {
+	return rt.r.All()
+}
+
+// Runs returns an iterator over the runs in rt, in increasing order.
+func (rt *Retained) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		base := rt.base()
+		for _, s := range rt.r.runs {
+			s.Index -= base
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// Uint32 returns a copy of rt as an ordinary array.
+func (rt *Retained) Uint32() Uint32 {
+	out := Uint32{runs: make([]Uint32Run, 0, len(rt.r.runs))}
+	for s := range rt.Runs() {
+		out.runs = append(out.runs, s)
+	}
+	return out
+}
+
+// String returns rt in the format of Uint32.String.
+func (rt *Retained) String() string {
+	return rt.Uint32().String()
+}
@@ -0,0 +1,101 @@
+package rangearray
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetainedMaxValues(t *testing.T) {
+	var trimmed [][2]uint32
+	rt := NewRetained(RetentionOptions{
+		MaxValues: 5,
+		OnTrim: func(first, last uint32) {
+			trimmed = append(trimmed, [2]uint32{first, last})
+		},
+	})
+	for _, x := range []uint32{1, 2, 3, 10, 11, 20, 21, 22} {
+		rt.Push(x)
+	}
+	want := Uint32{runs: []Uint32Run{{10, 0, 2}, {20, 2, 3}}}
+	testEqualUint32(t, "rt", rt.Uint32(), want)
+	if len(trimmed) != 3 || trimmed[0] != [2]uint32{1, 1} || trimmed[2] != [2]uint32{3, 3} {
+		t.Errorf("Expected 1, 2 and 3 to be trimmed, got %v", trimmed)
+	}
+	if rt.Len() != 5 || rt.IndexOf(20) != 2 || rt.IndexOf(0) != 0 || rt.IndexOf(30) != 5 {
+		t.Errorf("Expected Len 5 and IndexOf 2, 0, 5, got %d, %d, %d, %d", rt.Len(), rt.IndexOf(20), rt.IndexOf(0), rt.IndexOf(30))
+	}
+
+	// Trimming can split a run.
+	trimmed = nil
+	rt.Push(23)
+	rt.Push(24)
+	want = Uint32{runs: []Uint32Run{{20, 0, 5}}}
+	testEqualUint32(t, "rt", rt.Uint32(), want)
+	if len(trimmed) != 2 || trimmed[0] != [2]uint32{10, 10} || trimmed[1] != [2]uint32{11, 11} {
+		t.Errorf("Expected 10 and 11 to be trimmed, got %v", trimmed)
+	}
+	rt.Push(25)
+	want = Uint32{runs: []Uint32Run{{21, 0, 5}}}
+	testEqualUint32(t, "rt", rt.Uint32(), want)
+
+	// A value before the retained ones is the oldest, so it is
+	// trimmed at once.
+	trimmed = nil
+	rt.Push(5)
+	testEqualUint32(t, "rt", rt.Uint32(), want)
+	if len(trimmed) != 1 || trimmed[0] != [2]uint32{5, 5} {
+		t.Errorf("Expected 5 to be trimmed, got %v", trimmed)
+	}
+}
+
+func TestRetainedMaxAge(t *testing.T) {
+	start := time.Unix(1000, 0)
+	now := start
+	var trimmed [][2]uint32
+	rt := NewRetained(RetentionOptions{
+		MaxAge: 10 * time.Second,
+		Tick: func(t time.Time) uint32 {
+			return uint32(t.Sub(start) / time.Second)
+		},
+		Now: func() time.Time { return now },
+		OnTrim: func(first, last uint32) {
+			trimmed = append(trimmed, [2]uint32{first, last})
+		},
+	})
+	for tick := uint32(0); tick < 30; tick += 3 {
+		now = start.Add(time.Duration(tick) * time.Second)
+		rt.Push(tick)
+	}
+	want := Uint32{runs: []Uint32Run{{18, 0, 1}, {21, 1, 1}, {24, 2, 1}, {27, 3, 1}}}
+	testEqualUint32(t, "rt", rt.Uint32(), want)
+	if len(trimmed) != 6 || trimmed[5] != [2]uint32{15, 15} {
+		t.Errorf("Expected 0 through 15 to be trimmed, got %v", trimmed)
+	}
+
+	now = start.Add(time.Minute)
+	rt.Trim()
+	if rt.Len() != 0 || rt.NumRuns() != 0 {
+		t.Errorf("Expected rt to be empty, got %v", rt)
+	}
+	rt.Push(60)
+	testEqualUint32(t, "rt", rt.Uint32(), Uint32{runs: []Uint32Run{{60, 0, 1}}})
+}
+
+func TestRetainedOf(t *testing.T) {
+	var r Uint32
+	for x := uint32(0); x < 100; x++ {
+		r.Push(x * 2)
+	}
+	rt := RetainedOf(r, RetentionOptions{MaxValues: 10})
+	if rt.Len() != 10 || rt.Min() != 180 || rt.Max() != 198 {
+		t.Errorf("Expected 10 values from 180 to 198, got %v", rt)
+	}
+	if r.Len() != 100 {
+		t.Errorf("Expected r to be unchanged, got %d values", r.Len())
+	}
+	for s := range rt.Runs() {
+		if s.Index != (s.Value-180)/2 {
+			t.Errorf("Expected run %v to be rebased", s)
+		}
+	}
+}
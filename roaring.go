@@ -0,0 +1,292 @@
+package rangearray
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// The portable Roaring format is the serialization shared by the C,
+// Java, Go, and other Roaring bitmap libraries, documented at
+// https://github.com/RoaringBitmap/RoaringFormatSpec.  It splits the
+// values into containers, one for each distinct value of the upper 16
+// bits, and stores each container as a sorted array of its lower 16
+// bits, a 65536-bit bitset, or a list of runs.
+
+const (
+	roaringCookie          = 12347
+	roaringCookieNoRuns    = 12346
+	roaringNoOffsetLimit   = 4
+	roaringMaxArray        = 4096
+	roaringBitsetLen       = 8192
+	roaringContainerValues = 1 << 16
+)
+
+var errBadRoaring = corruptf("rangearray: invalid portable Roaring bitmap")
+
+// roaringContainer holds the runs in one Roaring container, as pairs of
+// (first value, count) within the container.
+type roaringContainer struct {
+	key  uint16
+	card uint32
+	runs [][2]uint32
+}
+
+// size returns the number of bytes needed to store c, and whether c
+// should be stored as runs.
+func (c *roaringContainer) size() (int, bool) {
+	runSize := 2 + 4*len(c.runs)
+	other := roaringBitsetLen
+	if c.card <= roaringMaxArray {
+		other = 2 * int(c.card)
+	}
+	if runSize < other {
+		return runSize, true
+	}
+	return other, false
+}
+
+// MarshalRoaring returns r in the portable Roaring format.  For each
+// container, it uses whichever of the array, bitset, and run forms is
+// smallest.
+func (r Uint32) MarshalRoaring() ([]byte, error) {
+	var cs []roaringContainer
+	for _, s := range r.runs {
+		lo, hi := uint64(s.Value), uint64(s.Value)+uint64(s.Count)
+		for lo < hi {
+			key := uint16(lo >> 16)
+			end := min(hi, (lo>>16+1)<<16)
+			if len(cs) == 0 || cs[len(cs)-1].key != key {
+				cs = append(cs, roaringContainer{key: key})
+			}
+			c := &cs[len(cs)-1]
+			c.runs = append(c.runs, [2]uint32{uint32(lo & 0xffff), uint32(end - lo)})
+			c.card += uint32(end - lo)
+			lo = end
+		}
+	}
+
+	hasRuns := false
+	for i := range cs {
+		if _, isRun := cs[i].size(); isRun {
+			hasRuns = true
+		}
+	}
+
+	// Write the cookie, and the bitset of run containers if needed.
+	var b []byte
+	n := len(cs)
+	hasOffsets := !hasRuns || n >= roaringNoOffsetLimit
+	if hasRuns {
+		b = binary.LittleEndian.AppendUint32(b, roaringCookie|uint32(n-1)<<16)
+		runBits := make([]byte, (n+7)/8)
+		for i := range cs {
+			if _, isRun := cs[i].size(); isRun {
+				runBits[i/8] |= 1 << (i % 8)
+			}
+		}
+		b = append(b, runBits...)
+	} else {
+		b = binary.LittleEndian.AppendUint32(b, roaringCookieNoRuns)
+		b = binary.LittleEndian.AppendUint32(b, uint32(n))
+	}
+
+	// Write the key and cardinality of each container, then offsets.
+	for i := range cs {
+		b = binary.LittleEndian.AppendUint16(b, cs[i].key)
+		b = binary.LittleEndian.AppendUint16(b, uint16(cs[i].card-1))
+	}
+	if hasOffsets {
+		offset := len(b) + 4*n
+		for i := range cs {
+			b = binary.LittleEndian.AppendUint32(b, uint32(offset))
+			size, _ := cs[i].size()
+			offset += size
+		}
+	}
+
+	// Write the containers.
+	for i := range cs {
+		c := &cs[i]
+		size, isRun := c.size()
+		switch {
+		case isRun:
+			b = binary.LittleEndian.AppendUint16(b, uint16(len(c.runs)))
+			for _, run := range c.runs {
+				b = binary.LittleEndian.AppendUint16(b, uint16(run[0]))
+				b = binary.LittleEndian.AppendUint16(b, uint16(run[1]-1))
+			}
+		case size == roaringBitsetLen:
+			var words [roaringContainerValues / 64]uint64
+			for _, run := range c.runs {
+				for x := run[0]; x < run[0]+run[1]; x++ {
+					words[x/64] |= 1 << (x % 64)
+				}
+			}
+			for _, w := range words {
+				b = binary.LittleEndian.AppendUint64(b, w)
+			}
+		default:
+			for _, run := range c.runs {
+				for x := run[0]; x < run[0]+run[1]; x++ {
+					b = binary.LittleEndian.AppendUint16(b, uint16(x))
+				}
+			}
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalRoaring replaces the contents of r with the bitmap in data,
+// which must be in the portable Roaring format and have nothing after
+// the bitmap.
+func (r *Uint32) UnmarshalRoaring(data []byte) error {
+	if len(data) < 4 {
+		return errBadRoaring
+	}
+
+	var n, pos int
+	var runBits []byte
+	hasOffsets := true
+	cookie := binary.LittleEndian.Uint32(data)
+	switch {
+	case cookie&0xffff == roaringCookie:
+		n = int(cookie>>16) + 1
+		pos = 4 + (n+7)/8
+		if len(data) < pos {
+			return errBadRoaring
+		}
+		runBits = data[4:pos]
+		hasOffsets = n >= roaringNoOffsetLimit
+	case cookie == roaringCookieNoRuns:
+		if len(data) < 8 {
+			return errBadRoaring
+		}
+		n = int(binary.LittleEndian.Uint32(data[4:]))
+		pos = 8
+		if n > roaringContainerValues {
+			return errBadRoaring
+		}
+	default:
+		return errBadRoaring
+	}
+
+	desc := pos
+	pos += 4 * n
+	if hasOffsets {
+		pos += 4 * n
+	}
+	if len(data) < pos {
+		return errBadRoaring
+	}
+
+	var out Uint32
+	for i := 0; i < n; i++ {
+		key := uint32(binary.LittleEndian.Uint16(data[desc+4*i:]))
+		card := uint32(binary.LittleEndian.Uint16(data[desc+4*i+2:])) + 1
+		if i > 0 && key <= uint32(binary.LittleEndian.Uint16(data[desc+4*i-4:])) {
+			return corruptf("rangearray: Roaring container %d is out of order", i)
+		}
+
+		var err error
+		isRun := runBits != nil && runBits[i/8]&(1<<(i%8)) != 0
+		switch {
+		case isRun:
+			pos, err = out.appendRoaringRuns(data, pos, key<<16, card)
+		case card <= roaringMaxArray:
+			pos, err = out.appendRoaringArray(data, pos, key<<16, card)
+		default:
+			pos, err = out.appendRoaringBitset(data, pos, key<<16, card)
+		}
+		if err != nil {
+			return fmt.Errorf("rangearray: Roaring container %d: %w", i, err)
+		}
+	}
+	if pos != len(data) {
+		return errTrailing
+	}
+
+	*r = out
+	return nil
+}
+
+// appendRoaringRuns appends the run container at data[pos:], whose
+// values start at base and which should hold card values, to r.  It
+// returns the position after the container.
+func (r *Uint32) appendRoaringRuns(data []byte, pos int, base, card uint32) (int, error) {
+	if len(data) < pos+2 {
+		return 0, errBadRoaring
+	}
+	n := int(binary.LittleEndian.Uint16(data[pos:]))
+	pos += 2
+	if len(data) < pos+4*n {
+		return 0, errBadRoaring
+	}
+
+	var total uint32
+	for i := 0; i < n; i++ {
+		first := uint32(binary.LittleEndian.Uint16(data[pos+4*i:]))
+		count := uint32(binary.LittleEndian.Uint16(data[pos+4*i+2:])) + 1
+		if first+count > roaringContainerValues || !r.appendRun(base+first, count) {
+			return 0, errBadRoaring
+		}
+		total += count
+	}
+	if total != card {
+		return 0, errBadRoaring
+	}
+	return pos + 4*n, nil
+}
+
+// appendRoaringArray is like appendRoaringRuns, for array containers.
+func (r *Uint32) appendRoaringArray(data []byte, pos int, base, card uint32) (int, error) {
+	if len(data) < pos+2*int(card) {
+		return 0, errBadRoaring
+	}
+
+	for i := 0; i < int(card); i++ {
+		x := uint32(binary.LittleEndian.Uint16(data[pos+2*i:]))
+		if !r.appendRun(base+x, 1) {
+			return 0, errBadRoaring
+		}
+	}
+	return pos + 2*int(card), nil
+}
+
+// appendRoaringBitset is like appendRoaringRuns, for bitset containers.
+func (r *Uint32) appendRoaringBitset(data []byte, pos int, base, card uint32) (int, error) {
+	if len(data) < pos+roaringBitsetLen {
+		return 0, errBadRoaring
+	}
+
+	var total uint32
+	var start, count uint32
+	for i := 0; i < roaringBitsetLen/8; i++ {
+		w := binary.LittleEndian.Uint64(data[pos+8*i:])
+		total += uint32(bits.OnesCount64(w))
+		for w != 0 {
+			// Find the next run of set bits in w.
+			lo := uint32(bits.TrailingZeros64(w))
+			n := uint32(bits.TrailingZeros64(^(w >> lo)))
+			if count > 0 && start+count == uint32(64*i)+lo {
+				count += n
+			} else {
+				if count > 0 && !r.appendRun(base+start, count) {
+					return 0, errBadRoaring
+				}
+				start, count = uint32(64*i)+lo, n
+			}
+			if lo+n == 64 {
+				break
+			}
+			w &^= (1<<n - 1) << lo
+		}
+	}
+	if count > 0 && !r.appendRun(base+start, count) {
+		return 0, errBadRoaring
+	}
+	if total != card {
+		return 0, errBadRoaring
+	}
+	return pos + roaringBitsetLen, nil
+}
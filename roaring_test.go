@@ -0,0 +1,72 @@
+package rangearray
+
+import (
+	"testing"
+)
+
+func testRoaringArray() Uint32 {
+	r := &Uint32{}
+	// A run container,
+	pushRange(r, 100, 199)
+	pushRange(r, 350, 449)
+	// an array container,
+	for i := uint32(0); i < 100; i++ {
+		r.Push(1<<16 + 3*i)
+	}
+	// a bitset container,
+	for i := uint32(0); i < 10000; i++ {
+		r.Push(2<<16 + 3*i)
+	}
+	// and a run that spans containers.
+	pushRange(r, 5<<16-10, 7<<16+10)
+	r.Push(0xffffffff)
+	return *r
+}
+
+func TestRoaringUint32(t *testing.T) {
+	for _, r := range []Uint32{{}, testEncodingArray(), testRoaringArray()} {
+		b, err := r.MarshalRoaring()
+		if err != nil {
+			t.Fatalf("MarshalRoaring() failed: %v", err)
+		}
+
+		var x Uint32
+		if err := x.UnmarshalRoaring(b); err != nil {
+			t.Fatalf("UnmarshalRoaring() failed: %v", err)
+		}
+		testEqualUint32(t, "x", x, r)
+	}
+}
+
+func TestRoaringUint32NoRuns(t *testing.T) {
+	// Sparse values are stored without any run containers.
+	r := &Uint32{}
+	for i := uint32(0); i < 10; i++ {
+		r.Push(i * 1000)
+	}
+	b, _ := r.MarshalRoaring()
+	if b[0] != roaringCookieNoRuns&0xff {
+		t.Errorf("Expected MarshalRoaring() to use the no-run cookie")
+	}
+
+	var x Uint32
+	if err := x.UnmarshalRoaring(b); err != nil {
+		t.Fatalf("UnmarshalRoaring() failed: %v", err)
+	}
+	testEqualUint32(t, "x", x, *r)
+}
+
+func TestRoaringUint32Errors(t *testing.T) {
+	good, _ := testRoaringArray().MarshalRoaring()
+	for _, s := range [][]byte{
+		nil,
+		{1, 2, 3, 4},
+		good[:len(good)-1],
+		append(good, 0),
+	} {
+		var x Uint32
+		if err := x.UnmarshalRoaring(s); err == nil {
+			t.Errorf("Expected UnmarshalRoaring(%d bytes) to fail", len(s))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package rangearray
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+// SafeUint32 is a Uint32 protected by a read-write mutex, so that Push
+// and queries may be called concurrently from several goroutines.  The
+// zero value is an empty array ready to use.  A SafeUint32 must not be
+// copied after first use.
+type SafeUint32 struct {
+	mu    sync.RWMutex
+	r     Uint32
+	cache *indexCache
+}
+
+// Push adds x to s, and reports whether x was added, as Uint32.Push
+// does.
+func (s *SafeUint32) Push(x uint32) bool {
+	s.mu.Lock()
+	added := s.r.Push(x)
+	if s.cache != nil && added {
+		s.cache.inserted(x)
+	}
+	s.mu.Unlock()
+	return added
+}
+
+// CacheIndexOf makes s remember its n most recent IndexOf answers, for
+// servers whose queries repeat a few hot values, such as the latest
+// epochs.  A Push forgets only the answers that it changes, those for
+// values after the one pushed.  CacheIndexOf(0) turns the cache off.
+func (s *SafeUint32) CacheIndexOf(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = nil
+	if n > 0 {
+		s.cache = newIndexCache(n)
+	}
+}
+
+// Snapshot returns a copy of the current contents of s, which later
+// changes to s do not affect.
+func (s *SafeUint32) Snapshot() Uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Uint32{runs: slices.Clone(s.r.runs)}
+}
+
+// Capture returns a Snapshot of the current contents of s, as with
+// Uint32.Capture.  The Snapshot can be read without locking while other
+// goroutines go on pushing to s.
+func (s *SafeUint32) Capture() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Capture()
+}
+
+// Min returns the minimum value in s.  Panics if s is empty.
+func (s *SafeUint32) Min() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.Min()
+}
+
+// Max returns the maximum value in s.  Panics if s is empty.
+func (s *SafeUint32) Max() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.Max()
+}
+
+// MinOK returns the minimum value in s and true, or zero and false if
+// s is empty.  Unlike checking Len before calling Min, it cannot race
+// with a concurrent change.
+func (s *SafeUint32) MinOK() (uint32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.MinOK()
+}
+
+// MaxOK is like MinOK, for the maximum value in s.
+func (s *SafeUint32) MaxOK() (uint32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.MaxOK()
+}
+
+// Len returns the number of elements in s.
+func (s *SafeUint32) Len() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.Len()
+}
+
+// IndexOf returns the number of elements in s that are less than x.
+func (s *SafeUint32) IndexOf(x uint32) uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cache == nil {
+		return s.r.IndexOf(x)
+	}
+	if index, ok := s.cache.get(x); ok {
+		return index
+	}
+	index := s.r.IndexOf(x)
+	s.cache.put(x, index)
+	return index
+}
+
+// LowerBound returns the index of the run in s that contains x, or of
+// the run that starts after x, as Uint32.LowerBound does.  The result
+// may be stale as soon as LowerBound returns.
+func (s *SafeUint32) LowerBound(x uint32) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.LowerBound(x)
+}
+
+// Contains reports whether x is in s.
+func (s *SafeUint32) Contains(x uint32) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.Contains(x)
+}
+
+// All returns an iterator over the values in s, in increasing order.
+// The iterator holds the read lock while it runs, so the loop body must
+// not call Push on s.
+func (s *SafeUint32) All() iter.Seq[uint32] {
+	return valuesOf(s.Runs())
+}
+
+// Runs returns an iterator over the runs in s, in increasing order.
+// The iterator holds the read lock while it runs, so the loop body must
+// not call Push on s.
+func (s *SafeUint32) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		for _, run := range s.r.runs {
+			if !yield(run) {
+				return
+			}
+		}
+	}
+}
+
+// String returns s in the format of Uint32.String.
+func (s *SafeUint32) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r.String()
+}
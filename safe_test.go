@@ -0,0 +1,87 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+func TestSafeUint32(t *testing.T) {
+	var s SafeUint32
+	if s.Len() != 0 || s.Contains(0) {
+		t.Errorf("Expected an empty SafeUint32, got %v", &s)
+	}
+	if _, ok := s.MinOK(); ok {
+		t.Errorf("Expected MinOK() of an empty SafeUint32 to fail")
+	}
+	if _, ok := s.MaxOK(); ok {
+		t.Errorf("Expected MaxOK() of an empty SafeUint32 to fail")
+	}
+
+	var wg sync.WaitGroup
+	for w := uint32(0); w < 4; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := uint32(0); i < 1000; i++ {
+				s.Push(4*i + w)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := uint32(0); i < 1000; i++ {
+				s.Contains(i)
+				s.IndexOf(i)
+				for range s.Runs() {
+					break
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s.Len() != 4000 || s.Min() != 0 || s.Max() != 3999 || s.IndexOf(100) != 100 {
+		t.Errorf("Expected SafeUint32 == 0-3999, got %v", &s)
+	}
+	if x, ok := s.MaxOK(); !ok || x != 3999 {
+		t.Errorf("Expected MaxOK() == 3999, true, got %d, %v", x, ok)
+	}
+	if s.LowerBound(5000) != 1 || !s.Contains(3999) {
+		t.Errorf("Expected one run in SafeUint32, got %v", &s)
+	}
+
+	snap := s.Snapshot()
+	s.Push(5000)
+	if snap.Len() != 4000 || s.Len() != 4001 {
+		t.Errorf("Expected Snapshot() to be independent, got %v and %v", snap, &s)
+	}
+
+	n := 0
+	for range s.All() {
+		n++
+	}
+	if n != 4001 {
+		t.Errorf("Expected All() to yield 4001 values, got %d", n)
+	}
+}
+
+func TestSafeUint32CacheIndexOf(t *testing.T) {
+	var s SafeUint32
+	var want Uint32
+	s.CacheIndexOf(4)
+	rng := rand.New(rand.NewPCG(13, 14))
+	for i := 0; i < 2000; i++ {
+		x := rng.Uint32N(500)
+		s.Push(x)
+		want.Push(x)
+		for _, y := range []uint32{490, 491, 492, rng.Uint32N(510)} {
+			if got := s.IndexOf(y); got != want.IndexOf(y) {
+				t.Fatalf("Expected IndexOf(%d) = %d after pushing %d, got %d", y, want.IndexOf(y), x, got)
+			}
+		}
+	}
+	s.CacheIndexOf(0)
+	if s.IndexOf(495) != want.IndexOf(495) {
+		t.Errorf("Expected IndexOf(495) = %d without a cache, got %d", want.IndexOf(495), s.IndexOf(495))
+	}
+}
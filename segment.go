@@ -0,0 +1,485 @@
+package rangearray
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Each segment of a SegmentStore is a block file in the store's
+// directory, named for the numbers of the first and last appended
+// segments that it holds, as two 16-digit hexadecimal numbers and the
+// suffix ".seg", like "0000000000000004-0000000000000007.seg".
+// Compaction writes a merged segment before removing the ones it
+// replaces, so after a crash, OpenSegmentStore removes any segment
+// whose numbers are covered by another one.
+
+const (
+	// DefaultFanIn is the number of segments that compaction merges at
+	// a time, if SegmentOptions.FanIn is zero.
+	DefaultFanIn = 4
+
+	// DefaultMaxMergeRuns is the number of runs at which a segment
+	// stops being merged, if SegmentOptions.MaxMergeRuns is zero.
+	DefaultMaxMergeRuns = 1 << 20
+)
+
+var errSegmentOrder = errors.New("rangearray: segment does not come after the existing segments")
+
+// SegmentOptions configures a SegmentStore.
+type SegmentOptions struct {
+	// FanIn is the number of adjacent segments of about the same size
+	// that compaction merges into one.  If it is zero, DefaultFanIn is
+	// used.
+	FanIn int
+
+	// MaxMergeRuns is the number of runs at which a segment is large
+	// enough that compaction leaves it alone.  If it is zero,
+	// DefaultMaxMergeRuns is used.
+	MaxMergeRuns int
+
+	// Encoder sets how segments are stored, and Decoder how they are
+	// read.
+	Encoder Encoder
+	Decoder Decoder
+}
+
+// SegmentStore is a rangearray for continuously growing archives, kept
+// as a directory of segment files.  Each call to Append writes a closed
+// segment, such as the values for one hour, as a new file, so a value
+// is written once when it arrives.  A background goroutine then merges
+// runs of FanIn adjacent segments of about the same size into one, as
+// in log-structured storage, so each value is rewritten only about
+// log(MaxMergeRuns)/log(FanIn) times, while the number of files stays
+// small.  Queries span every segment, and read at most one block from
+// one of them.
+//
+// A SegmentStore is safe for concurrent use.
+type SegmentStore struct {
+	dir  string
+	opts SegmentOptions
+
+	appending sync.Mutex // held by Append
+	merging   sync.Mutex // held while merging segments
+
+	mu   sync.RWMutex
+	segs []*segment
+	next uint64 // number of the next appended segment
+	err  error  // from background compaction
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once // closes stop
+	done     chan struct{}
+}
+
+// segment is one segment file of a SegmentStore.
+type segment struct {
+	first, last uint64 // numbers of the appended segments it holds
+	path        string
+	f           *os.File
+	bf          *BlockFile
+	index       uint32 // number of values before the segment
+	runs        int
+}
+
+// OpenSegmentStore opens the segment store in dir, creating dir if it
+// does not exist, and starts its background compaction.
+func OpenSegmentStore(dir string, opts SegmentOptions) (*SegmentStore, error) {
+	if opts.FanIn < 2 {
+		opts.FanIn = DefaultFanIn
+	}
+	if opts.MaxMergeRuns <= 0 {
+		opts.MaxMergeRuns = DefaultMaxMergeRuns
+	}
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SegmentStore{
+		dir:  dir,
+		opts: opts,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	var segs []*segment
+	for _, ent := range entries {
+		name := ent.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		var seg segment
+		if n, _ := fmt.Sscanf(name, "%016x-%016x.seg", &seg.first, &seg.last); n != 2 || seg.first > seg.last {
+			continue
+		}
+		seg.path = filepath.Join(dir, name)
+		segs = append(segs, &seg)
+	}
+
+	// Sort the segments by their first number, then drop any whose
+	// numbers another one covers.
+	sort.Slice(segs, func(i, j int) bool {
+		if segs[i].first != segs[j].first {
+			return segs[i].first < segs[j].first
+		}
+		return segs[i].last > segs[j].last
+	})
+	for _, seg := range segs {
+		if seg.last < s.next {
+			if err := os.Remove(seg.path); err != nil {
+				s.closeFiles()
+				return nil, err
+			}
+			continue
+		}
+		if err := s.openSegment(seg); err != nil {
+			s.closeFiles()
+			return nil, err
+		}
+		s.segs = append(s.segs, seg)
+		s.next = seg.last + 1
+	}
+	s.reindex()
+
+	go s.run()
+	return s, nil
+}
+
+// openSegment opens the file for seg.
+func (s *SegmentStore) openSegment(seg *segment) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err == nil {
+		seg.bf, err = s.opts.Decoder.OpenBlockFile(f, fi.Size())
+	}
+	if err == nil && len(seg.bf.dir) == 0 {
+		err = errBadBlockFile
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("rangearray: segment %s: %w", seg.path, err)
+	}
+	seg.f = f
+	seg.runs = 0
+	for _, ent := range seg.bf.dir {
+		seg.runs += int(ent.runs)
+	}
+	return nil
+}
+
+// reindex recomputes the index of each segment.  The caller must hold
+// s.mu for writing, or be the only user of s.
+func (s *SegmentStore) reindex() {
+	var index uint32
+	for _, seg := range s.segs {
+		seg.index = index
+		index += seg.bf.Len()
+	}
+}
+
+// closeFiles closes the file of every segment.
+func (s *SegmentStore) closeFiles() {
+	for _, seg := range s.segs {
+		seg.f.Close()
+	}
+}
+
+// writeSegment writes r to a new segment file holding the appended
+// segments first through last, and opens it.
+func (s *SegmentStore) writeSegment(r Uint32, first, last uint64) (*segment, error) {
+	seg := &segment{
+		first: first,
+		last:  last,
+		path:  filepath.Join(s.dir, fmt.Sprintf("%016x-%016x.seg", first, last)),
+	}
+	tmp := seg.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(f)
+	_, err = s.opts.Encoder.EncodeBlocks(bw, r)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, seg.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	// Compaction removes the segments this one replaces, so the rename
+	// must survive a crash first.
+	if err := syncDir(seg.path); err != nil {
+		return nil, err
+	}
+	if err := s.openSegment(seg); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+// Append writes r as a new segment after the existing ones.  Every
+// value in r must be greater than Max, and s must not come to hold
+// more than MaxLen values.  Appending an empty array does nothing.
+func (s *SegmentStore) Append(r Uint32) error {
+	if len(r.runs) == 0 {
+		return nil
+	}
+	s.appending.Lock()
+	defer s.appending.Unlock()
+
+	s.mu.RLock()
+	n := len(s.segs)
+	ok, full := true, false
+	if n > 0 {
+		last := s.segs[n-1]
+		ok = r.Min() > last.bf.dir[len(last.bf.dir)-1].max
+		full = uint64(last.index)+uint64(last.bf.Len())+uint64(r.Len()) > MaxLen
+	}
+	next := s.next
+	s.mu.RUnlock()
+	if !ok {
+		return errSegmentOrder
+	}
+	if full {
+		return errFull
+	}
+
+	seg, err := s.writeSegment(r, next, next)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.segs = append(s.segs, seg)
+	s.next++
+	s.reindex()
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run does background compaction until Close.
+func (s *SegmentStore) run() {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		}
+		for {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+			merged, err := s.compactOnce()
+			if err != nil {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+				return
+			}
+			if !merged {
+				break
+			}
+		}
+	}
+}
+
+// Compact merges segments, as background compaction does, until there
+// are none left to merge.
+func (s *SegmentStore) Compact() error {
+	for {
+		merged, err := s.compactOnce()
+		if err != nil || !merged {
+			return err
+		}
+	}
+}
+
+// level returns the size class of a segment with n runs.  Compaction
+// merges only segments of the same class.
+func (s *SegmentStore) level(n int) int {
+	l := 0
+	for ; n >= s.opts.FanIn; n /= s.opts.FanIn {
+		l++
+	}
+	return l
+}
+
+// compactOnce merges the first FanIn adjacent segments of the same size
+// class that are small enough to merge, if there are any, and reports
+// whether it did.
+func (s *SegmentStore) compactOnce() (bool, error) {
+	s.merging.Lock()
+	defer s.merging.Unlock()
+
+	// Appends only add segments at the end, so while s.merging is held,
+	// the segments chosen stay at the same positions.
+	s.mu.RLock()
+	fanIn, start := s.opts.FanIn, -1
+	for i, k := 0, 0; i < len(s.segs); i++ {
+		if s.segs[i].runs >= s.opts.MaxMergeRuns {
+			k = 0
+			continue
+		}
+		if k > 0 && s.level(s.segs[i].runs) != s.level(s.segs[i-1].runs) {
+			k = 0
+		}
+		if k++; k == fanIn {
+			start = i - fanIn + 1
+			break
+		}
+	}
+	var group []*segment
+	if start >= 0 {
+		group = append(group, s.segs[start:start+fanIn]...)
+	}
+	s.mu.RUnlock()
+	if group == nil {
+		return false, nil
+	}
+
+	var merged Uint32
+	for _, seg := range group {
+		r, err := seg.bf.ReadAll()
+		if err != nil {
+			return false, err
+		}
+		for _, run := range r.runs {
+			merged.appendRun(run.Value, run.Count)
+		}
+	}
+	seg, err := s.writeSegment(merged, group[0].first, group[fanIn-1].last)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.segs = append(s.segs[:start], append([]*segment{seg}, s.segs[start+fanIn:]...)...)
+	s.reindex()
+	s.mu.Unlock()
+
+	// No query can still be reading the old segments.
+	for _, old := range group {
+		old.f.Close()
+		if err := os.Remove(old.path); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// Close stops background compaction and closes every segment file.  It
+// returns any error that background compaction had.  Calling Close
+// again does nothing more.
+func (s *SegmentStore) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeFiles()
+	s.segs = nil
+	return s.err
+}
+
+// Segments returns the number of segment files in s.
+func (s *SegmentStore) Segments() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.segs)
+}
+
+// Len returns the number of elements in s.
+func (s *SegmentStore) Len() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.segs) == 0 {
+		return 0
+	}
+	last := s.segs[len(s.segs)-1]
+	return last.index + last.bf.Len()
+}
+
+// search returns the index of the first segment in s whose maximum is
+// at least x, or len(s.segs) if there is none.  The caller must hold
+// s.mu.
+func (s *SegmentStore) search(x uint32) int {
+	return sort.Search(len(s.segs), func(i int) bool {
+		dir := s.segs[i].bf.dir
+		return x <= dir[len(dir)-1].max
+	})
+}
+
+// IndexOf returns the number of elements in s that are less than x.
+// It reads at most one block from one segment.
+func (s *SegmentStore) IndexOf(x uint32) (uint32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.search(x)
+	if i == len(s.segs) {
+		if i == 0 {
+			return 0, nil
+		}
+		return s.segs[i-1].index + s.segs[i-1].bf.Len(), nil
+	}
+	n, err := s.segs[i].bf.IndexOf(x)
+	return s.segs[i].index + n, err
+}
+
+// Contains reports whether x is in s.  It reads at most one block from
+// one segment.
+func (s *SegmentStore) Contains(x uint32) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.search(x)
+	if i == len(s.segs) {
+		return false, nil
+	}
+	return s.segs[i].bf.Contains(x)
+}
+
+// ReadAll reads every segment and returns the whole rangearray.
+func (s *SegmentStore) ReadAll() (Uint32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out Uint32
+	for _, seg := range s.segs {
+		r, err := seg.bf.ReadAll()
+		if err != nil {
+			return Uint32{}, err
+		}
+		for _, run := range r.runs {
+			out.appendRun(run.Value, run.Count)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package rangearray
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSegment returns the values for the i'th appended segment in the
+// segment store tests.
+func testSegment(i int) Uint32 {
+	var r Uint32
+	for x := uint32(0); x < 8; x++ {
+		r.Push(uint32(i)*100 + x*3)
+	}
+	return r
+}
+
+// openForeground opens the segment store in dir, and stops its
+// background compaction so that a test sees each merge.
+func openForeground(t *testing.T, dir string, opts SegmentOptions) *SegmentStore {
+	t.Helper()
+	s, err := OpenSegmentStore(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = make(chan struct{})
+	return s
+}
+
+func TestSegmentStore(t *testing.T) {
+	dir := t.TempDir()
+	s := openForeground(t, dir, SegmentOptions{FanIn: 2})
+
+	var want Uint32
+	for i := range 7 {
+		if err := s.Append(testSegment(i)); err != nil {
+			t.Fatal(err)
+		}
+		for _, run := range testSegment(i).runs {
+			want.appendRun(run.Value, run.Count)
+		}
+	}
+	if err := s.Append(testSegment(3)); err != errSegmentOrder {
+		t.Errorf("Expected errSegmentOrder, got %v", err)
+	}
+	if s.Segments() != 7 {
+		t.Errorf("Expected 7 segments, got %d", s.Segments())
+	}
+
+	// Segments of 8 runs merge in pairs to 16 runs, then 32; the
+	// segment left over stays as it is.
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Segments() != 3 {
+		t.Errorf("Expected 3 segments after Compact, got %d", s.Segments())
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 3 {
+		t.Errorf("Expected 3 files after Compact, got %v", files)
+	}
+
+	got, err := s.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "ReadAll", got, want)
+	if s.Len() != want.Len() {
+		t.Errorf("Expected Len() == %d, got %d", want.Len(), s.Len())
+	}
+	for x := uint32(0); x < 800; x++ {
+		n, err := s.IndexOf(x)
+		if err != nil || n != want.IndexOf(x) {
+			t.Fatalf("Expected IndexOf(%d) == %d, got %d, %v", x, want.IndexOf(x), n, err)
+		}
+		ok, err := s.Contains(x)
+		if err != nil || ok != want.Contains(x) {
+			t.Fatalf("Expected Contains(%d) == %v, got %v, %v", x, want.Contains(x), ok, err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash after a merged segment is written, but before the ones
+	// it replaces are removed, leaves a covered segment behind.
+	stale := filepath.Join(dir, "0000000000000004-0000000000000004.seg")
+	if err := os.WriteFile(stale, []byte("stale"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	s = openForeground(t, dir, SegmentOptions{FanIn: 2})
+	defer s.Close()
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected the covered segment to be removed, got %v", err)
+	}
+	got, err = s.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEqualUint32(t, "reopened", got, want)
+	if err := s.Append(testSegment(7)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSegmentStoreBackground(t *testing.T) {
+	s, err := OpenSegmentStore(t.TempDir(), SegmentOptions{FanIn: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 16 {
+		if err := s.Append(testSegment(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Segments() > 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if s.Segments() != 1 {
+		t.Errorf("Expected background compaction to leave 1 segment, got %d", s.Segments())
+	}
+	if n := s.Len(); n != 16*8 {
+		t.Errorf("Expected 128 values, got %d", n)
+	}
+	if err := s.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected a second Close() to do nothing, got %v", err)
+	}
+}
+
+func TestSegmentStoreFull(t *testing.T) {
+	s := openForeground(t, t.TempDir(), SegmentOptions{})
+	defer s.Close()
+	for _, run := range []Uint32Run{{Value: 0, Count: 0x80000000}, {Value: 0x80000000, Count: 0x7fffffff}} {
+		if err := s.Append(Uint32{runs: []Uint32Run{run}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Append(Uint32{runs: []Uint32Run{{Value: 0xffffffff, Count: 1}}}); err != errFull {
+		t.Errorf("Expected errFull past MaxLen values, got %v", err)
+	}
+	if s.Len() != MaxLen || s.Segments() != 2 {
+		t.Errorf("Expected %d values in 2 segments, got %d in %d", uint32(MaxLen), s.Len(), s.Segments())
+	}
+}
@@ -0,0 +1,93 @@
+package rangearray
+
+// AppendUnion appends the runs of the union of a and b to dst and
+// returns the extended slice, like the append built-in.  Reusing dst
+// across calls, as in
+//
+//	buf = AppendUnion(buf[:0], a, b)
+//	r, _ := WrapRuns(buf)
+//
+// makes repeated set operations allocate nothing once buf is large
+// enough.  The result must start after the last run in dst, and dst
+// must not share storage with a or b.
+func AppendUnion(dst []Uint32Run, a, b Uint32) []Uint32Run {
+	i, j := 0, 0
+	for i < len(a.runs) || j < len(b.runs) {
+		var s Uint32Run
+		if j == len(b.runs) || i < len(a.runs) && a.runs[i].Value <= b.runs[j].Value {
+			s, i = a.runs[i], i+1
+		} else {
+			s, j = b.runs[j], j+1
+		}
+		dst = appendRunTo(dst, uint64(s.Value), uint64(s.Value)+uint64(s.Count))
+	}
+	return dst
+}
+
+// AppendIntersection appends the runs of the intersection of a and b
+// to dst, as AppendUnion does for the union.
+func AppendIntersection(dst []Uint32Run, a, b Uint32) []Uint32Run {
+	i, j := 0, 0
+	for i < len(a.runs) && j < len(b.runs) {
+		aEnd := uint64(a.runs[i].Value) + uint64(a.runs[i].Count)
+		bEnd := uint64(b.runs[j].Value) + uint64(b.runs[j].Count)
+		lo := uint64(max(a.runs[i].Value, b.runs[j].Value))
+		hi := min(aEnd, bEnd)
+		if lo < hi {
+			dst = appendRunTo(dst, lo, hi)
+		}
+		if aEnd == hi {
+			i++
+		}
+		if bEnd == hi {
+			j++
+		}
+	}
+	return dst
+}
+
+// AppendDifference appends the runs of the values in a that are not in
+// b to dst, as AppendUnion does for the union.
+func AppendDifference(dst []Uint32Run, a, b Uint32) []Uint32Run {
+	j := 0
+	for _, s := range a.runs {
+		lo, end := uint64(s.Value), uint64(s.Value)+uint64(s.Count)
+		for ; j < len(b.runs); j++ {
+			bLo := uint64(b.runs[j].Value)
+			bEnd := bLo + uint64(b.runs[j].Count)
+			if bLo >= end {
+				break
+			}
+			if bLo > lo {
+				dst = appendRunTo(dst, lo, bLo)
+			}
+			lo = max(lo, bEnd)
+			if bEnd > end {
+				break
+			}
+		}
+		if lo < end {
+			dst = appendRunTo(dst, lo, end)
+		}
+	}
+	return dst
+}
+
+// appendRunTo appends the values in [lo, hi) to runs, merging them into
+// its last run if they overlap or touch it.  It panics if lo is before
+// the start of the last run.  A run that would hold every value leaves
+// out 0xffffffff, as MaxLen says.
+func appendRunTo(runs []Uint32Run, lo, hi uint64) []Uint32Run {
+	if n := len(runs) - 1; n >= 0 {
+		last := &runs[n]
+		if lo < uint64(last.Value) {
+			panic("rangearray: appended runs start before the runs in dst")
+		}
+		if end := uint64(last.Value) + uint64(last.Count); lo <= end {
+			last.Count = uint32(min(max(end, hi)-uint64(last.Value), MaxLen))
+			return runs
+		}
+		return append(runs, Uint32Run{Value: uint32(lo), Index: last.Index + last.Count, Count: uint32(hi - lo)})
+	}
+	return append(runs, Uint32Run{Value: uint32(lo), Count: uint32(min(hi-lo, MaxLen))})
+}
@@ -0,0 +1,77 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// testSetArray returns a random array with values below limit.
+func testSetArray(rng *rand.Rand, limit uint32) Uint32 {
+	var r Uint32
+	for x := rng.Uint32N(20); x < limit; x += 1 + rng.Uint32N(20) {
+		pushRange(&r, x, min(x+rng.Uint32N(15), limit-1))
+		x += 15
+	}
+	return r
+}
+
+func TestAppendSetOps(t *testing.T) {
+	rng := rand.New(rand.NewPCG(10, 11))
+	var buf []Uint32Run
+	for range 50 {
+		a, b := testSetArray(rng, 2000), testSetArray(rng, 2000)
+
+		buf = AppendUnion(buf[:0], a, b)
+		testEqualUint32(t, "AppendUnion()", Uint32{runs: buf}, NewUnionView(a, b).Materialize())
+		buf = AppendIntersection(buf[:0], a, b)
+		testEqualUint32(t, "AppendIntersection()", Uint32{runs: buf}, NewIntersectionView(a, b).Materialize())
+
+		var want Uint32
+		for x := range a.All() {
+			if !b.Contains(x) {
+				want.Push(x)
+			}
+		}
+		buf = AppendDifference(buf[:0], a, b)
+		testEqualUint32(t, "AppendDifference()", Uint32{runs: buf}, want)
+	}
+
+	a, b := testSetArray(rng, 2000), testSetArray(rng, 2000)
+	buf = AppendUnion(buf[:0], a, b)
+	allocs := testing.AllocsPerRun(10, func() {
+		buf = AppendUnion(buf[:0], a, b)
+		buf = AppendIntersection(buf[:0], a, b)
+		buf = AppendDifference(buf[:0], a, b)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations when reusing dst, got %v", allocs)
+	}
+
+	// Appending continues the indexes of dst.
+	top := Uint32{runs: []Uint32Run{{Value: 0xfffffff0, Count: 16}}}
+	low := Uint32{runs: []Uint32Run{{Value: 5, Count: 2}}}
+	got := AppendUnion(AppendUnion(nil, low, Uint32{}), Uint32{}, top)
+	testEqualUint32(t, "AppendUnion() after dst", Uint32{runs: got}, Uint32{runs: []Uint32Run{
+		{Value: 5, Count: 2},
+		{Value: 0xfffffff0, Index: 2, Count: 16},
+	}})
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a union before dst to panic")
+		}
+	}()
+	AppendUnion(got, low, Uint32{})
+}
+
+func TestSetOpsFull(t *testing.T) {
+	var lo, hi Uint32
+	lo.appendRun(0, 1<<31)
+	hi.appendRun(1<<31, 1<<31)
+	want := "0-4294967294"
+	if got := (Uint32{runs: AppendUnion(nil, lo, hi)}); got.String() != want || got.Validate() != nil {
+		t.Errorf("Expected AppendUnion() == %s, got %v", want, got)
+	}
+	if got := NewUnionView(lo, hi).Materialize(); got.String() != want || got.Validate() != nil {
+		t.Errorf("Expected the union view == %s, got %v", want, got)
+	}
+}
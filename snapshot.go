@@ -0,0 +1,132 @@
+package rangearray
+
+import (
+	"iter"
+	"slices"
+)
+
+// Snapshot is a read-only view of a rangearray as it was when Capture
+// was called.  Taking a Snapshot copies nothing: it shares the runs of
+// the array, except for a copy of the last run, which is the only one
+// that appending to the array changes in place.  Values pushed after
+// the capture are not visible through the Snapshot.  The zero value is
+// empty.
+//
+// A Snapshot taken under a lock, as by SafeUint32.Capture, may be read
+// without the lock while the array goes on growing.
+type Snapshot struct {
+	head Uint32
+	last Uint32Run
+	ok   bool
+}
+
+// Capture returns a Snapshot of the current contents of r.  Pushing
+// values after the end of r keeps updating its runs in place; any other
+// change first copies them, so that the Snapshot is not disturbed.
+func (r *Uint32) Capture() Snapshot {
+	n := len(r.runs)
+	if n == 0 {
+		return Snapshot{}
+	}
+	r.pinned = true
+	return Snapshot{head: Uint32{runs: r.runs[: n-1 : n-1]}, last: r.runs[n-1], ok: true}
+}
+
+// run returns the i'th run of s.
+func (s Snapshot) run(i int) Uint32Run {
+	if i == len(s.head.runs) && s.ok {
+		return s.last
+	}
+	return s.head.runs[i]
+}
+
+// NumRuns returns the number of runs in s.
+func (s Snapshot) NumRuns() int {
+	if !s.ok {
+		return 0
+	}
+	return len(s.head.runs) + 1
+}
+
+// Min returns the minimum value in s.  Panics if s is empty.
+func (s Snapshot) Min() uint32 {
+	return s.run(0).Value
+}
+
+// Max returns the maximum value in s.  Panics if s is empty.
+func (s Snapshot) Max() uint32 {
+	last := s.run(s.NumRuns() - 1)
+	return last.Value + last.Count - 1
+}
+
+// Len returns the number of elements in s.
+func (s Snapshot) Len() uint32 {
+	if !s.ok {
+		return 0
+	}
+	return s.last.Index + s.last.Count
+}
+
+// IndexOf returns the number of elements in s that are less than x.
+func (s Snapshot) IndexOf(x uint32) uint32 {
+	i := s.LowerBound(x)
+	if i < s.NumRuns() {
+		run := s.run(i)
+		if x <= run.Value {
+			return run.Index
+		}
+		return x - run.Value + run.Index
+	}
+	return s.Len()
+}
+
+// LowerBound returns the index of the run in s that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x.  If x is after s.Max(), returns s.NumRuns().
+func (s Snapshot) LowerBound(x uint32) int {
+	if i := s.head.LowerBound(x); i < len(s.head.runs) {
+		return i
+	}
+	if s.ok && uint64(x) >= uint64(s.last.Value)+uint64(s.last.Count) {
+		return len(s.head.runs) + 1
+	}
+	return len(s.head.runs)
+}
+
+// Contains reports whether x is in s.
+func (s Snapshot) Contains(x uint32) bool {
+	i := s.LowerBound(x)
+	return i < s.NumRuns() && x >= s.run(i).Value
+}
+
+// All returns an iterator over the values in s, in increasing order.
+func (s Snapshot) All() iter.Seq[uint32] {
+	return valuesOf(s.Runs())
+}
+
+// Runs returns an iterator over the runs in s, in increasing order.
+func (s Snapshot) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		for _, run := range s.head.runs {
+			if !yield(run) {
+				return
+			}
+		}
+		if s.ok {
+			yield(s.last)
+		}
+	}
+}
+
+// Uint32 returns a mutable copy of s.
+func (s Snapshot) Uint32() Uint32 {
+	if !s.ok {
+		return Uint32{}
+	}
+	return Uint32{runs: append(slices.Clip(slices.Clone(s.head.runs)), s.last)}
+}
+
+// String returns s in the format of Uint32.String.
+func (s Snapshot) String() string {
+	return s.Uint32().String()
+}
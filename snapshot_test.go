@@ -0,0 +1,84 @@
+package rangearray
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	var empty Snapshot
+	if empty.Len() != 0 || empty.NumRuns() != 0 || empty.Contains(0) || empty.IndexOf(5) != 0 {
+		t.Errorf("Expected an empty snapshot, got %v", empty)
+	}
+
+	var r Uint32
+	pushRange(&r, 10, 19)
+	pushRange(&r, 30, 34)
+	s := r.Capture()
+	pushRange(&r, 35, 40)
+	r.Push(50)
+
+	expected := Uint32{runs: []Uint32Run{{Value: 10, Count: 10}, {Value: 30, Index: 10, Count: 5}}}
+	testEqualUint32(t, "Capture()", s.Uint32(), expected)
+	if s.Len() != 15 || s.Min() != 10 || s.Max() != 34 || s.NumRuns() != 2 {
+		t.Errorf("Expected 15 values in 10-34, got %v", s)
+	}
+	for _, c := range []struct {
+		x       uint32
+		index   uint32
+		bound   int
+		present bool
+	}{
+		{5, 0, 0, false},
+		{15, 5, 0, true},
+		{25, 10, 1, false},
+		{34, 14, 1, true},
+		{35, 15, 2, false},
+		{50, 15, 2, false},
+	} {
+		if s.IndexOf(c.x) != c.index || s.LowerBound(c.x) != c.bound || s.Contains(c.x) != c.present {
+			t.Errorf("Expected %d to give (%d, %d, %v), got (%d, %d, %v)", c.x, c.index, c.bound, c.present,
+				s.IndexOf(c.x), s.LowerBound(c.x), s.Contains(c.x))
+		}
+	}
+
+	// Inserting before the end must not disturb the snapshot.
+	r.Push(25)
+	r.Push(20)
+	testEqualUint32(t, "Capture() after insert", s.Uint32(), expected)
+	if got := slices.Collect(s.All()); len(got) != 15 || got[14] != 34 {
+		t.Errorf("Expected All() to stop at 34, got %v", got)
+	}
+	if r.Len() != 24 {
+		t.Errorf("Expected the array to keep growing, got %v", r)
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	var s SafeUint32
+	for x := uint32(0); x < 1000; x += 2 {
+		s.Push(x)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		snap := s.Capture()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := uint32(0)
+			for range snap.All() {
+				n++
+			}
+			if n != snap.Len() {
+				t.Errorf("Expected %d values, got %d", snap.Len(), n)
+			}
+		}()
+		start := s.Max() + 1
+		for x := start; x < start+100; x++ {
+			s.Push(x)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package rangearray
+
+import "strings"
+
+// sparkLevels are the characters Sparkline draws, from empty to full.
+var sparkLevels = []rune(" ▁▂▃▄▅▆▇█")
+
+// bucketBounds returns the start and end (exclusive) of bucket i when
+// [first, last] is split into n buckets.  Buckets differ in size by at
+// most one value; if there are more buckets than values, each bucket
+// holds at least one value, so some values appear in several buckets.
+func bucketBounds(first, last uint32, n, i int) (uint64, uint64) {
+	span := uint64(last) - uint64(first) + 1
+	lo := uint64(first) + uint64(i)*span/uint64(n)
+	hi := uint64(first) + uint64(i+1)*span/uint64(n)
+	return lo, max(hi, lo+1)
+}
+
+// CountBuckets splits [first, last] into n buckets of nearly equal
+// size, as Sparkline does, and returns the number of values of r in
+// each bucket.  It takes O(n log(runs)) time.  Panics if last < first
+// or n <= 0.
+func (r Uint32) CountBuckets(first, last uint32, n int) []uint32 {
+	if last < first || n <= 0 {
+		panic("rangearray: invalid CountBuckets window")
+	}
+
+	out := make([]uint32, n)
+	for i := range out {
+		lo, hi := bucketBounds(first, last, n, i)
+		out[i] = r.CountIn(uint32(lo), uint32(hi-1))
+	}
+	return out
+}
+
+// Sparkline draws the coverage of [first, last] by r as width Unicode
+// block characters, one per bucket as split by CountBuckets.  Each
+// character's height shows the fraction of its bucket that is present:
+// a space for none, "█" for all, and "▁" through "▇" in between, so
+// that any presence is visible.  Panics if last < first or width <= 0.
+func (r Uint32) Sparkline(first, last uint32, width int) string {
+	counts := r.CountBuckets(first, last, width)
+	top := uint64(len(sparkLevels) - 1)
+
+	var b strings.Builder
+	b.Grow(3 * width)
+	for i, c := range counts {
+		lo, hi := bucketBounds(first, last, width, i)
+		// Round up, so only an empty bucket gets level zero.
+		level := (uint64(c)*top + (hi - lo) - 1) / (hi - lo)
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package rangearray
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCountBuckets(t *testing.T) {
+	r := testEncodingArray()
+	got := r.CountBuckets(0, 499, 5)
+	if want := []uint32{0, 100, 0, 50, 50}; !slices.Equal(got, want) {
+		t.Errorf("Expected CountBuckets(0, 499, 5) == %v, got %v", want, got)
+	}
+
+	got = r.CountBuckets(99, 101, 6)
+	if want := []uint32{0, 0, 1, 1, 1, 1}; !slices.Equal(got, want) {
+		t.Errorf("Expected CountBuckets(99, 101, 6) == %v, got %v", want, got)
+	}
+
+	got = Uint32{runs: r.runs[:3]}.CountBuckets(0, 1999, 2)
+	if want := []uint32{200, 1}; !slices.Equal(got, want) {
+		t.Errorf("Expected CountBuckets(0, 1999, 2) == %v, got %v", want, got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	var r Uint32
+	pushRange(&r, 0, 7)
+	pushRange(&r, 16, 19)
+	pushRange(&r, 31, 31)
+	for _, s := range []struct {
+		first, last uint32
+		width       int
+		want        string
+	}{
+		{0, 39, 5, "█ ▄▁ "},
+		{0, 7, 8, "████████"},
+		{16, 19, 1, "█"},
+		{100, 199, 3, "   "},
+		{0, 1, 4, "████"},
+	} {
+		if got := r.Sparkline(s.first, s.last, s.width); got != s.want {
+			t.Errorf("Expected Sparkline(%d, %d, %d) == %q, got %q", s.first, s.last, s.width, s.want, got)
+		}
+	}
+}
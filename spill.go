@@ -0,0 +1,257 @@
+package rangearray
+
+import (
+	"errors"
+	"io"
+)
+
+// SpillFile is the file that a Spilled writes its old runs to, such as
+// an *os.File.
+type SpillFile interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// SpillOptions configures a Spilled.
+type SpillOptions struct {
+	// HotRuns is the number of recent runs to keep in memory.  If it
+	// is zero, DefaultHotRuns is used.
+	HotRuns int
+
+	// Encoder sets how spilled blocks are stored, and its BlockRuns
+	// sets how many runs each block holds.
+	Encoder Encoder
+
+	// Decoder reads spilled blocks back for queries.  It must know
+	// any Codec that Encoder uses.
+	Decoder Decoder
+}
+
+var errSpilledValue = errors.New("rangearray: value is before the end of the spilled runs")
+
+// Spilled is a rangearray for continuous collection over long periods,
+// which keeps only its most recent runs in memory.  Older runs are
+// written to a file, one block at a time, in the block file format,
+// and queries read them back as needed, one block at a time, so that
+// memory use is bounded by SpillOptions.HotRuns plus one block.
+//
+// Values can only be added after the spilled runs; a Push of a value
+// that is before, or just after, the last spilled value fails.  Finish
+// spills every run and completes the file, which OpenBlockFile can then
+// read.  A Spilled is not safe for concurrent use, even by readers,
+// since queries cache the last block they read.
+type Spilled struct {
+	opts      SpillOptions
+	w         io.WriterAt
+	file      *BlockFile
+	end       int64
+	blockRuns int
+	hot       Uint32 // with indexes that count the spilled values
+
+	cached int // number of the block in cache, or -1
+	cache  Uint32
+}
+
+// NewSpilled returns an empty Spilled that spills runs to f, starting
+// by writing a block file header at the start of f.
+func NewSpilled(f SpillFile, opts SpillOptions) (*Spilled, error) {
+	h := opts.Encoder.header("RB")
+	e, flags, err := opts.Decoder.checkHeader(h, "RB")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteAt(h, 0); err != nil {
+		return nil, err
+	}
+
+	s := &Spilled{
+		opts:      opts,
+		w:         f,
+		file:      &BlockFile{r: f, d: opts.Decoder, encoding: e, flags: flags},
+		end:       int64(len(h)),
+		blockRuns: opts.Encoder.BlockRuns,
+		cached:    -1,
+	}
+	if s.blockRuns <= 0 {
+		s.blockRuns = DefaultBlockRuns
+	}
+	if s.opts.HotRuns <= 0 {
+		s.opts.HotRuns = DefaultHotRuns
+	}
+	return s, nil
+}
+
+// spilledMax returns the last spilled value, and whether there is one.
+func (s *Spilled) spilledMax() (uint32, bool) {
+	if len(s.file.dir) == 0 {
+		return 0, false
+	}
+	return s.file.dir[len(s.file.dir)-1].max, true
+}
+
+// inHot reports whether queries for x go to the runs in memory.
+func (s *Spilled) inHot(x uint32) bool {
+	last, ok := s.spilledMax()
+	return !ok || x > last
+}
+
+// Push adds x to s, spilling a block of old runs to the file once there
+// are enough of them, and reports whether x was added: false if x was
+// already in s, or if s already holds MaxLen values.  Values at or
+// before the end of the spilled runs that are not already in s cannot
+// be added, and Push returns an error for them.  If spilling fails,
+// Push returns true with the error, since x was added.
+func (s *Spilled) Push(x uint32) (bool, error) {
+	if last, ok := s.spilledMax(); ok && uint64(x) <= uint64(last)+1 {
+		if found, err := s.Contains(x); err != nil || found {
+			return false, err
+		}
+		return false, errSpilledValue
+	}
+	if !s.hot.push(x) {
+		return false, nil
+	}
+	if debugChecks && len(s.hot.runs) > 0 {
+		s.hot.check("Spilled.Push", 0, s.hot.runs[0].Index)
+	}
+
+	for len(s.hot.runs) >= s.opts.HotRuns+s.blockRuns {
+		if err := s.spill(s.blockRuns); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// spill writes the first n runs in memory to the file as a block.
+func (s *Spilled) spill(n int) error {
+	block := s.hot.runs[:n]
+	buf, _, err := s.opts.Encoder.encodeBlock(nil, nil, block)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.WriteAt(buf, s.end); err != nil {
+		return err
+	}
+
+	s.file.dir = append(s.file.dir, newBlockDirEntry(block, len(buf), s.end))
+	s.end += int64(len(buf))
+	k := copy(s.hot.runs, s.hot.runs[n:])
+	s.hot.runs = s.hot.runs[:k]
+	s.hot.dropIndex()
+	return nil
+}
+
+// Finish spills every run in memory, then writes the directory and
+// footer of the block file.  s must not be used afterwards.  It returns
+// the size of the file.
+func (s *Spilled) Finish() (int64, error) {
+	for len(s.hot.runs) > 0 {
+		if err := s.spill(min(s.blockRuns, len(s.hot.runs))); err != nil {
+			return s.end, err
+		}
+	}
+
+	var dir []byte
+	for _, ent := range s.file.dir {
+		dir = ent.append(dir)
+	}
+	trailer := s.opts.Encoder.blockTrailer(dir, s.end)
+	if _, err := s.w.WriteAt(trailer, s.end); err != nil {
+		return s.end, err
+	}
+	return s.end + int64(len(trailer)), nil
+}
+
+// block returns the i'th spilled block, with indexes that count from
+// the start of the block.
+func (s *Spilled) block(i int) (Uint32, error) {
+	if s.cached != i {
+		b, err := s.file.Block(i)
+		if err != nil {
+			return Uint32{}, err
+		}
+		s.cached, s.cache = i, b
+	}
+	return s.cache, nil
+}
+
+// Spilled returns the number of runs that have been written to the
+// file.
+func (s *Spilled) Spilled() int {
+	var n int
+	for _, ent := range s.file.dir {
+		n += int(ent.runs)
+	}
+	return n
+}
+
+// Min returns the minimum value in s.  Panics if s is empty.
+func (s *Spilled) Min() uint32 {
+	if len(s.file.dir) > 0 {
+		return s.file.dir[0].min
+	}
+	return s.hot.Min()
+}
+
+// Max returns the maximum value in s.  Panics if s is empty.
+func (s *Spilled) Max() uint32 {
+	return s.hot.Max()
+}
+
+// Len returns the number of elements in s.
+func (s *Spilled) Len() uint32 {
+	return s.hot.Len()
+}
+
+// NumRuns returns the number of runs in s.
+func (s *Spilled) NumRuns() int {
+	return s.Spilled() + len(s.hot.runs)
+}
+
+// IndexOf returns the number of elements in s that are less than x.
+// It reads at most one block from the file.
+func (s *Spilled) IndexOf(x uint32) (uint32, error) {
+	if s.inHot(x) {
+		return s.hot.IndexOf(x), nil
+	}
+	i := s.file.search(x)
+	ent := s.file.dir[i]
+	if x <= ent.min {
+		return ent.index, nil
+	}
+	b, err := s.block(i)
+	if err != nil {
+		return 0, err
+	}
+	return ent.index + b.IndexOf(x), nil
+}
+
+// Contains reports whether x is in s.  It reads at most one block from
+// the file.
+func (s *Spilled) Contains(x uint32) (bool, error) {
+	if s.inHot(x) {
+		return s.hot.Contains(x), nil
+	}
+	i := s.file.search(x)
+	if x < s.file.dir[i].min {
+		return false, nil
+	}
+	b, err := s.block(i)
+	if err != nil {
+		return false, err
+	}
+	return b.Contains(x), nil
+}
+
+// ReadAll reads every spilled block and returns the whole rangearray.
+func (s *Spilled) ReadAll() (Uint32, error) {
+	out, err := s.file.ReadAll()
+	if err != nil {
+		return Uint32{}, err
+	}
+	for _, run := range s.hot.runs {
+		out.appendRun(run.Value, run.Count)
+	}
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpilled(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "spill"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	opts := SpillOptions{HotRuns: 50, Encoder: Encoder{BlockRuns: 30, Encoding: Varint, Checksum: true}}
+	s, err := NewSpilled(f, opts)
+	if err != nil {
+		t.Fatalf("NewSpilled() failed: %v", err)
+	}
+	rng := rand.New(rand.NewPCG(17, 18))
+	var want Uint32
+	for i := uint32(0); i < 2000; i++ {
+		x := 5*i + rng.Uint32N(3)
+		added, err := s.Push(x)
+		if err != nil {
+			t.Fatalf("Push(%d) failed: %v", x, err)
+		}
+		if added != want.Push(x) {
+			t.Errorf("Expected Push(%d) to report %v, got %v", x, !added, added)
+		}
+	}
+	if s.Spilled() == 0 || s.NumRuns()-s.Spilled() >= 80 {
+		t.Errorf("Expected at most 80 runs in memory, got %d of %d", s.NumRuns()-s.Spilled(), s.NumRuns())
+	}
+	if added, err := s.Push(7); added || err != errSpilledValue {
+		t.Errorf("Expected Push() before the spilled runs to fail, got %v, %v", added, err)
+	}
+	if added, err := s.Push(want.Min()); added || err != nil {
+		t.Errorf("Expected Push() of a spilled value to report a duplicate, got %v, %v", added, err)
+	}
+	if added, err := s.Push(want.Max()); added || err != nil {
+		t.Errorf("Expected Push() of a value in memory to report a duplicate, got %v, %v", added, err)
+	}
+
+	if s.Len() != want.Len() || s.NumRuns() != len(want.runs) || s.Min() != want.Min() || s.Max() != want.Max() {
+		t.Errorf("Expected %d values in %d runs, got %d in %d", want.Len(), len(want.runs), s.Len(), s.NumRuns())
+	}
+	for x := uint32(0); x < 10010; x++ {
+		found, err := s.Contains(x)
+		index, err2 := s.IndexOf(x)
+		if err != nil || err2 != nil || found != want.Contains(x) || index != want.IndexOf(x) {
+			t.Fatalf("Expected queries for %d to match, got %v, %d, %v, %v", x, found, index, err, err2)
+		}
+	}
+	all, err := s.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() failed: %v", err)
+	}
+	testEqualUint32(t, "ReadAll()", all, want)
+
+	size, err := s.Finish()
+	if err != nil {
+		t.Fatalf("Finish() failed: %v", err)
+	}
+	bf, err := OpenBlockFile(f, size)
+	if err != nil {
+		t.Fatalf("OpenBlockFile() failed: %v", err)
+	}
+	got, err := bf.ReadAll()
+	if err != nil {
+		t.Fatalf("BlockFile.ReadAll() failed: %v", err)
+	}
+	testEqualUint32(t, "finished file", got, want)
+}
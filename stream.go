@@ -0,0 +1,235 @@
+package rangearray
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// streamChunkRuns is the number of runs that are buffered at a
+	// time when streaming the binary format, and the number of runs
+	// in each compressed chunk.
+	streamChunkRuns = 512
+
+	// maxChunkLen is the largest compressed chunk that a Decoder
+	// accepts.
+	maxChunkLen = 1 << 20
+
+	// contextCheckInterval is how many bytes a countingReader reads,
+	// or runs a union produces, between checks of a context.
+	contextCheckInterval = 4096
+)
+
+// Encode writes the binary encoding of r to w, without building the
+// whole encoding in memory.  It returns the number of bytes written.
+func (e Encoder) Encode(w io.Writer, r Uint32) (int64, error) {
+	return e.EncodeContext(context.Background(), w, r)
+}
+
+// EncodeContext is like Encode, but stops with ctx's error if ctx is
+// done before the encoding is written.
+func (e Encoder) EncodeContext(ctx context.Context, w io.Writer, r Uint32) (_ int64, err error) {
+	done := startTrace(ctx, e.Tracer, OpEncode)
+	defer func() { done(len(r.runs), err) }()
+
+	pr := e.Progress.start(OpEncode, int64(len(r.runs)))
+	cw := &countingWriter{ctx: ctx, w: w, hash: e.Checksum}
+	sc := getScratch()
+	buf, packed, frame := sc.buf, sc.packed, sc.frame
+	defer func() { sc.release(buf, packed, frame) }()
+	buf = append(buf, e.header("RA")...)
+	buf = binary.AppendUvarint(buf, uint64(len(r.runs)))
+	if e.Codec != nil {
+		cw.Write(buf)
+		buf = buf[:0]
+	}
+
+	var end uint32
+	for i := 0; i < len(r.runs) && cw.err == nil; {
+		chunk := r.runs[i:min(i+streamChunkRuns, len(r.runs))]
+		for _, s := range chunk {
+			buf = appendRun(buf, e.Encoding, end, s)
+			end = s.Value + s.Count
+		}
+
+		if e.Codec != nil {
+			var err error
+			if packed, err = e.Codec.Compress(packed[:0], buf); err != nil {
+				return cw.n, err
+			}
+			frame = binary.AppendUvarint(frame[:0], uint64(len(chunk)))
+			frame = binary.AppendUvarint(frame, uint64(len(packed)))
+			cw.Write(frame)
+			cw.Write(packed)
+		} else {
+			cw.Write(buf)
+		}
+		buf = buf[:0]
+		i += len(chunk)
+		pr.add(len(chunk))
+	}
+
+	if len(buf) > 0 {
+		cw.Write(buf)
+	}
+	if e.Checksum {
+		cw.writeChecksum()
+	}
+	if cw.err == nil {
+		pr.finish()
+	}
+	return cw.n, cw.err
+}
+
+// WriteTo implements io.WriterTo.  It writes r in the format used by
+// MarshalBinary, without building the whole encoding in memory.
+func (r Uint32) WriteTo(w io.Writer) (int64, error) {
+	return Encoder{}.Encode(w, r)
+}
+
+// Decode reads one binary rangearray from rd and replaces the contents
+// of r.  It returns the number of bytes read.  Decode does not read
+// past the end of the rangearray, and returns io.EOF if rd is at EOF
+// before the rangearray starts.
+func (d Decoder) Decode(rd io.Reader, r *Uint32) (int64, error) {
+	return d.DecodeContext(context.Background(), rd, r)
+}
+
+// DecodeContext is like Decode, but stops with ctx's error if ctx is
+// done before the rangearray is read.  It cannot interrupt a Read call
+// on rd that blocks; wrap rd if that is needed.
+func (d Decoder) DecodeContext(ctx context.Context, rd io.Reader, r *Uint32) (_ int64, err error) {
+	done := startTrace(ctx, d.Tracer, OpDecode)
+	defer func() { done(len(r.runs), err) }()
+
+	cr := &countingReader{ctx: ctx, r: rd}
+	out, err := d.read(cr, streamChunkRuns)
+	if err != nil {
+		return cr.n, err
+	}
+
+	*r = out
+	return cr.n, nil
+}
+
+// ReadFrom implements io.ReaderFrom.  It reads one rangearray in the
+// binary format and replaces the contents of r.
+//
+// Unlike most implementations of io.ReaderFrom, ReadFrom stops at the
+// end of the rangearray rather than reading until EOF, so several
+// rangearrays can be read back to back from one stream.  It returns
+// io.EOF if rd is at EOF before the rangearray starts.
+func (r *Uint32) ReadFrom(rd io.Reader) (int64, error) {
+	return Decoder{}.Decode(rd, r)
+}
+
+// countingReader counts the bytes read from r, and updates crc with
+// them while hash is set.  It implements io.ByteReader so that it never
+// reads ahead of its caller.  If ctx is not nil, reads fail once it is
+// done.
+type countingReader struct {
+	ctx   context.Context
+	r     io.Reader
+	n     int64
+	hash  bool
+	crc   uint32
+	b     [1]byte
+	check int64
+}
+
+// done returns the error of c.ctx, checking it only once every
+// contextCheckInterval bytes so that reading a byte at a time stays
+// cheap.
+func (c *countingReader) done() error {
+	if c.ctx == nil || c.n < c.check {
+		return nil
+	}
+	c.check = c.n + contextCheckInterval
+	return c.ctx.Err()
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if err := c.done(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.hash {
+		c.crc = crc32.Update(c.crc, crcTable, p[:n])
+	}
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	if err := c.done(); err != nil {
+		return 0, err
+	}
+	if br, ok := c.r.(io.ByteReader); ok {
+		b, err := br.ReadByte()
+		if err == nil {
+			c.n++
+			if c.hash {
+				c.b[0] = b
+				c.crc = crc32.Update(c.crc, crcTable, c.b[:])
+			}
+		}
+		return b, err
+	}
+
+	_, err := io.ReadFull(c, c.b[:])
+	return c.b[0], err
+}
+
+// countingWriter counts the bytes written to w, and updates crc with
+// them while hash is set.  It remembers the first error so that callers
+// can check it once at the end.  If ctx is not nil, writes fail once
+// it is done.
+type countingWriter struct {
+	ctx  context.Context
+	w    io.Writer
+	n    int64
+	hash bool
+	crc  uint32
+	err  error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.ctx != nil {
+		if c.err = c.ctx.Err(); c.err != nil {
+			return 0, c.err
+		}
+	}
+
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	if c.hash {
+		c.crc = crc32.Update(c.crc, crcTable, p[:n])
+	}
+	c.err = err
+	return n, err
+}
+
+// writeChecksum writes the checksum of everything written since hash
+// was set, and starts a new checksum.
+func (c *countingWriter) writeChecksum() {
+	sum := binary.LittleEndian.AppendUint32(nil, c.crc)
+	c.hash = false
+	c.Write(sum)
+	c.hash = true
+	c.crc = 0
+}
+
+// unexpectedEOF converts io.EOF and io.ErrUnexpectedEOF to an error
+// that matches ErrTruncated, for reads that start partway through a
+// rangearray.
+func unexpectedEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errTruncated
+	}
+	return err
+}
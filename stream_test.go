@@ -0,0 +1,145 @@
+package rangearray
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// oneByteReader returns at most one byte per Read, and does not
+// implement io.ByteReader.
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o oneByteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return o.r.Read(p)
+}
+
+func TestStreamUint32(t *testing.T) {
+	big := &Uint32{}
+	for i := uint32(0); i < 3*streamChunkRuns; i++ {
+		big.Push(3 * i)
+	}
+	arrays := []Uint32{testEncodingArray(), {}, *big}
+
+	var buf bytes.Buffer
+	for _, r := range arrays {
+		n, err := r.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("WriteTo() failed: %v", err)
+		}
+		b, _ := r.MarshalBinary()
+		if n != int64(len(b)) {
+			t.Errorf("Expected WriteTo() to write %d bytes, got %d", len(b), n)
+		}
+	}
+
+	for _, rd := range []io.Reader{bytes.NewReader(buf.Bytes()), oneByteReader{bytes.NewReader(buf.Bytes())}} {
+		for i, want := range arrays {
+			var x Uint32
+			if _, err := x.ReadFrom(rd); err != nil {
+				t.Fatalf("ReadFrom() of array %d failed: %v", i, err)
+			}
+			testEqualUint32(t, "x", x, want)
+		}
+		var x Uint32
+		if _, err := x.ReadFrom(rd); err != io.EOF {
+			t.Errorf("Expected ReadFrom() at end of stream to return io.EOF, got %v", err)
+		}
+	}
+}
+
+func TestStreamUint32Truncated(t *testing.T) {
+	b, _ := testEncodingArray().MarshalBinary()
+	for _, n := range []int{3, binaryHeaderLen, len(b) - 1} {
+		var x Uint32
+		if _, err := x.ReadFrom(bytes.NewReader(b[:n])); !errors.Is(err, ErrTruncated) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Expected ReadFrom() of %d bytes to return ErrTruncated, got %v", n, err)
+		}
+	}
+}
+
+func TestStreamVarintUint32(t *testing.T) {
+	e := Encoder{Encoding: Varint}
+	var buf bytes.Buffer
+	for _, r := range []Uint32{testEncodingArray(), testEncodingArray()} {
+		n, err := e.Encode(&buf, r)
+		if err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+		b, _ := e.Marshal(r)
+		if n != int64(len(b)) {
+			t.Errorf("Expected Encode() to write %d bytes, got %d", len(b), n)
+		}
+	}
+
+	rd := oneByteReader{&buf}
+	for i := 0; i < 2; i++ {
+		var x Uint32
+		if _, err := x.ReadFrom(rd); err != nil {
+			t.Fatalf("ReadFrom() of array %d failed: %v", i, err)
+		}
+		testEqualUint32(t, "x", x, testEncodingArray())
+	}
+}
+
+func TestStreamContext(t *testing.T) {
+	big := &Uint32{}
+	for i := uint32(0); i < 20*contextCheckInterval; i++ {
+		big.Push(3 * i)
+	}
+	var buf bytes.Buffer
+	if _, err := (Encoder{}).EncodeContext(context.Background(), &buf, *big); err != nil {
+		t.Fatalf("EncodeContext() failed: %v", err)
+	}
+	data := buf.Bytes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (Encoder{}).EncodeContext(ctx, io.Discard, *big); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected EncodeContext() to be canceled, got %v", err)
+	}
+	var r Uint32
+	if err := (Decoder{}).UnmarshalContext(ctx, data, &r); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected UnmarshalContext() to be canceled, got %v", err)
+	}
+
+	// Cancel partway through the stream.
+	ctx, cancel = context.WithCancel(context.Background())
+	rd := &cancelReader{r: bytes.NewReader(data), n: len(data) / 2, cancel: cancel}
+	if _, err := (Decoder{}).DecodeContext(ctx, rd, &r); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected DecodeContext() to be canceled, got %v", err)
+	}
+	if err := (Decoder{}).UnmarshalContext(context.Background(), data, &r); err != nil {
+		t.Errorf("Expected UnmarshalContext() to decode, got %v", err)
+	}
+	testEqualUint32(t, "UnmarshalContext()", r, *big)
+}
+
+// cancelReader calls cancel once n bytes have been read from r.
+type cancelReader struct {
+	r      *bytes.Reader
+	n      int
+	cancel func()
+}
+
+func (c *cancelReader) ReadByte() (byte, error) {
+	if c.n--; c.n == 0 {
+		c.cancel()
+	}
+	return c.r.ReadByte()
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if c.n -= n; c.n <= 0 {
+		c.cancel()
+	}
+	return n, err
+}
@@ -0,0 +1,107 @@
+package rangearray
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// stringMaxRuns is the most runs that String writes in full.
+	stringMaxRuns = 8
+
+	// stringHeadRuns is the number of runs that String writes before
+	// summarizing the rest.
+	stringHeadRuns = 4
+)
+
+// MarshalText implements encoding.TextMarshaler.  A rangearray is
+// written as a comma-separated list of runs, each either a single value
+// or an inclusive "first-last" range, such as "5,100-199,350-449".  An
+// empty rangearray is written as an empty string.
+func (r Uint32) MarshalText() ([]byte, error) {
+	return r.appendText(make([]byte, 0, 22*len(r.runs))), nil
+}
+
+// AppendText implements encoding.TextAppender.  It appends the format
+// written by MarshalText to b.
+func (r Uint32) AppendText(b []byte) ([]byte, error) {
+	return r.appendText(b), nil
+}
+
+// String returns r in the format written by MarshalText, except that
+// a rangearray with many runs is summarized, such as
+// "100-199,350-449,… (12 runs, 1043 values)".
+func (r Uint32) String() string {
+	if len(r.runs) <= stringMaxRuns {
+		return string(r.appendText(nil))
+	}
+
+	b := Uint32{runs: r.runs[:stringHeadRuns]}.appendText(nil)
+	return fmt.Sprintf("%s,… (%d runs, %d values)", b, len(r.runs), r.Len())
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.  It accepts the
+// format written by MarshalText, and replaces the contents of r.  The
+// runs must be in increasing order and must not overlap; adjacent runs
+// are merged.
+func (r *Uint32) UnmarshalText(text []byte) error {
+	out, err := parseText(string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = out
+	return nil
+}
+
+// appendText appends the text form of r to b.
+func (r Uint32) appendText(b []byte) []byte {
+	for i, s := range r.runs {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendTextRun(b, s)
+	}
+	return b
+}
+
+// appendTextRun appends the text form of s to b.
+func appendTextRun(b []byte, s Uint32Run) []byte {
+	b = strconv.AppendUint(b, uint64(s.Value), 10)
+	if s.Count > 1 {
+		b = append(b, '-')
+		b = strconv.AppendUint(b, uint64(s.Value+s.Count-1), 10)
+	}
+	return b
+}
+
+// parseText parses the text form of a rangearray.
+func parseText(text string) (Uint32, error) {
+	var out Uint32
+	if text == "" {
+		return out, nil
+	}
+
+	for i, part := range strings.Split(text, ",") {
+		firstText, lastText, isRange := strings.Cut(part, "-")
+		first, err := strconv.ParseUint(firstText, 10, 32)
+		if err != nil {
+			return Uint32{}, corruptf("rangearray: invalid run %d %q: %w", i, part, err)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.ParseUint(lastText, 10, 32); err != nil {
+				return Uint32{}, corruptf("rangearray: invalid run %d %q: %w", i, part, err)
+			}
+		}
+
+		if last < first {
+			return Uint32{}, corruptf("rangearray: run %d %q is backwards", i, part)
+		}
+		if !out.appendRun(uint32(first), uint32(last-first+1)) {
+			return Uint32{}, corruptf("rangearray: run %d %q overlaps or precedes the previous run", i, part)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package rangearray
+
+import (
+	"encoding"
+	"testing"
+)
+
+func TestTextUint32(t *testing.T) {
+	r := testEncodingArray()
+	b, err := r.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() failed: %v", err)
+	}
+	if want := "100-199,350-449,1000,4294967295"; string(b) != want {
+		t.Errorf("Expected r.MarshalText() == %q, got %q", want, b)
+	}
+
+	var x Uint32
+	if err := x.UnmarshalText(b); err != nil {
+		t.Fatalf("UnmarshalText() failed: %v", err)
+	}
+	testEqualUint32(t, "x", x, r)
+
+	if err := x.UnmarshalText(nil); err != nil || x.Len() != 0 {
+		t.Errorf("Expected UnmarshalText(\"\") to give an empty array, got %+v, %v", x.runs, err)
+	}
+	if err := x.UnmarshalText([]byte("1-2,3-4,5")); err != nil || len(x.runs) != 1 || x.Len() != 5 {
+		t.Errorf("Expected adjacent runs to merge, got %+v, %v", x.runs, err)
+	}
+}
+
+func TestAppendText(t *testing.T) {
+	var _ encoding.TextAppender = Uint32{}
+
+	r := testEncodingArray()
+	b, err := r.AppendText([]byte("epochs="))
+	if want := "epochs=100-199,350-449,1000,4294967295"; err != nil || string(b) != want {
+		t.Errorf("Expected AppendText() == %q, got %q, %v", want, b, err)
+	}
+	if n := testing.AllocsPerRun(10, func() { r.AppendText(b[:0]) }); n != 0 {
+		t.Errorf("Expected AppendText() into a large buffer not to allocate, got %v allocations", n)
+	}
+}
+
+func TestTextUint32Errors(t *testing.T) {
+	for _, s := range []string{
+		",",
+		"1,",
+		" 1",
+		"1-",
+		"-1",
+		"a",
+		"1-b",
+		"5-4",
+		"4294967296",
+		"10-20,15",
+		"10-20,5",
+		"10-20,20-30",
+		"1--2",
+	} {
+		var x Uint32
+		if err := x.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("Expected UnmarshalText(%q) to fail", s)
+		}
+	}
+}
+
+func TestStringUint32(t *testing.T) {
+	r := testEncodingArray()
+	if want := "100-199,350-449,1000,4294967295"; r.String() != want {
+		t.Errorf("Expected r.String() == %q, got %q", want, r.String())
+	}
+	if s := (Uint32{}).String(); s != "" {
+		t.Errorf("Expected Uint32{}.String() == \"\", got %q", s)
+	}
+
+	big := &Uint32{}
+	for i := uint32(0); i < 12; i++ {
+		pushRange(big, 20*i, 20*i+i)
+	}
+	if want := "0,20-21,40-42,60-63,… (12 runs, 78 values)"; big.String() != want {
+		t.Errorf("Expected big.String() == %q, got %q", want, big.String())
+	}
+}
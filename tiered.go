@@ -0,0 +1,279 @@
+package rangearray
+
+import (
+	"encoding/binary"
+	"iter"
+	"slices"
+	"sort"
+)
+
+// DefaultHotRuns is the number of recent runs that a Tiered keeps
+// uncompressed, if HotRuns is zero.
+const DefaultHotRuns = 4096
+
+// coldBlockRuns is the number of runs in each compressed block.
+const coldBlockRuns = 256
+
+// Tiered is a rangearray for long-lived indexes that are mostly
+// appended to.  It keeps its most recent HotRuns runs in an ordinary
+// Uint32, and compresses older runs into blocks of delta-encoded
+// varints, which typically take a quarter of the memory or less.
+// Queries span both tiers transparently; a query in the cold tier
+// decodes one block of coldBlockRuns runs.
+//
+// A Push before the hot tier decompresses the cold blocks from that
+// point on back into the hot tier, so it is much more expensive than
+// one to the hot tier.  The zero value is empty and uses
+// DefaultHotRuns.  Like Uint32, a Tiered is not safe for concurrent
+// use while it is being modified.
+type Tiered struct {
+	// HotRuns is the number of runs to keep uncompressed.  If it is
+	// zero, DefaultHotRuns is used.
+	HotRuns int
+
+	cold []coldBlock
+	hot  Uint32 // with indexes that count the cold values
+}
+
+// coldBlock is a block of compressed runs in a Tiered.  Its data holds
+// a uvarint pair for each run: the gap from the end of the previous
+// run (or from first, for the first run), and the count.
+type coldBlock struct {
+	first  uint32 // value of the first run
+	index  uint32 // number of values before the block
+	run    int    // number of runs before the block
+	n      int    // number of runs in the block
+	values uint32 // number of values in the block
+	end    uint64 // value after the end of the last run
+	data   []byte
+}
+
+// TieredOf returns a Tiered holding the values in r, with all but the
+// last DefaultHotRuns runs compressed.
+func TieredOf(r Uint32) *Tiered {
+	t := &Tiered{}
+	n := 0
+	for ; len(r.runs)-n >= DefaultHotRuns+coldBlockRuns; n += coldBlockRuns {
+		t.cold = append(t.cold, newColdBlock(r.runs[n:n+coldBlockRuns], n))
+	}
+	t.hot = Uint32{runs: slices.Clone(r.runs[n:])}
+	return t
+}
+
+// newColdBlock compresses runs, which must not be empty, into a block
+// that follows run other runs.
+func newColdBlock(runs []Uint32Run, run int) coldBlock {
+	b := coldBlock{first: runs[0].Value, index: runs[0].Index, run: run, n: len(runs)}
+	end := uint64(b.first)
+	for _, s := range runs {
+		b.data = binary.AppendUvarint(b.data, uint64(s.Value)-end)
+		b.data = binary.AppendUvarint(b.data, uint64(s.Count))
+		end = uint64(s.Value) + uint64(s.Count)
+		b.values += s.Count
+	}
+	b.end = end
+	b.data = slices.Clip(b.data)
+	return b
+}
+
+// runs calls yield for each run in b, until yield returns false.  It
+// returns false if yield did.
+func (b *coldBlock) runs(yield func(Uint32Run) bool) bool {
+	end, index := uint64(b.first), b.index
+	for pos := 0; pos < len(b.data); {
+		gap, n := binary.Uvarint(b.data[pos:])
+		pos += n
+		count, n := binary.Uvarint(b.data[pos:])
+		pos += n
+		s := Uint32Run{Value: uint32(end + gap), Index: index, Count: uint32(count)}
+		if !yield(s) {
+			return false
+		}
+		end = uint64(s.Value) + count
+		index += s.Count
+	}
+	return true
+}
+
+// search returns the position in b of the run that contains x, or of
+// the run that starts after x, along with that run.  If x is after the
+// end of b, it returns b.n and an empty run at b.end.
+func (b *coldBlock) search(x uint32) (int, Uint32Run) {
+	i := 0
+	found := Uint32Run{Value: uint32(b.end), Index: b.index + b.values}
+	b.runs(func(s Uint32Run) bool {
+		if uint64(x) < uint64(s.Value)+uint64(s.Count) {
+			found = s
+			return false
+		}
+		i++
+		return true
+	})
+	return i, found
+}
+
+// hotRuns returns the number of runs to keep in the hot tier.
+func (t *Tiered) hotRuns() int {
+	if t.HotRuns <= 0 {
+		return DefaultHotRuns
+	}
+	return t.HotRuns
+}
+
+// coldEnd returns the value after the last cold value, or 0 if there
+// are none.
+func (t *Tiered) coldEnd() uint64 {
+	if len(t.cold) == 0 {
+		return 0
+	}
+	return t.cold[len(t.cold)-1].end
+}
+
+// coldRuns returns the number of runs in the cold tier.
+func (t *Tiered) coldRuns() int {
+	if len(t.cold) == 0 {
+		return 0
+	}
+	last := t.cold[len(t.cold)-1]
+	return last.run + last.n
+}
+
+// block returns the index of the cold block that x belongs in: the
+// last one that starts at or before x, or the first if x is before
+// them all.
+func (t *Tiered) block(x uint32) int {
+	i := sort.Search(len(t.cold), func(i int) bool {
+		return t.cold[i].first > x
+	})
+	return max(i-1, 0)
+}
+
+// Push adds x to t, and reports whether x was added, as Uint32.Push
+// does.
+func (t *Tiered) Push(x uint32) bool {
+	if len(t.cold) > 0 && uint64(x) <= t.coldEnd() {
+		if t.Contains(x) {
+			return false
+		}
+		t.thaw(t.block(x))
+	}
+	if !t.hot.push(x) {
+		return false
+	}
+
+	// Compress the oldest hot runs once there are enough for a block.
+	for len(t.hot.runs) >= t.hotRuns()+coldBlockRuns {
+		t.cold = append(t.cold, newColdBlock(t.hot.runs[:coldBlockRuns], t.coldRuns()))
+		n := copy(t.hot.runs, t.hot.runs[coldBlockRuns:])
+		t.hot.runs = t.hot.runs[:n]
+		t.hot.dropIndex()
+	}
+	if debugChecks {
+		checkReader("Tiered.Push", t)
+	}
+	return true
+}
+
+// thaw moves the cold blocks from the i'th on back into the hot tier.
+func (t *Tiered) thaw(i int) {
+	var runs []Uint32Run
+	for _, b := range t.cold[i:] {
+		b.runs(func(s Uint32Run) bool {
+			runs = append(runs, s)
+			return true
+		})
+	}
+	t.hot = Uint32{runs: append(runs, t.hot.runs...)}
+	t.cold = t.cold[:i]
+}
+
+// Min returns the minimum value in t.  Panics if t is empty.
+func (t *Tiered) Min() uint32 {
+	if len(t.cold) > 0 {
+		return t.cold[0].first
+	}
+	return t.hot.Min()
+}
+
+// Max returns the maximum value in t.  Panics if t is empty.
+func (t *Tiered) Max() uint32 {
+	return t.hot.Max()
+}
+
+// Len returns the number of elements in t.
+func (t *Tiered) Len() uint32 {
+	return t.hot.Len()
+}
+
+// NumRuns returns the number of runs in t.
+func (t *Tiered) NumRuns() int {
+	return t.coldRuns() + len(t.hot.runs)
+}
+
+// IndexOf returns the number of elements in t that are less than x.
+func (t *Tiered) IndexOf(x uint32) uint32 {
+	if uint64(x) >= t.coldEnd() {
+		return t.hot.IndexOf(x)
+	}
+	_, s := t.cold[t.block(x)].search(x)
+	if x <= s.Value {
+		return s.Index
+	}
+	return min(x-s.Value, s.Count) + s.Index
+}
+
+// LowerBound returns the index of the run in t that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (t *Tiered) LowerBound(x uint32) int {
+	if uint64(x) >= t.coldEnd() {
+		return t.coldRuns() + t.hot.LowerBound(x)
+	}
+	b := &t.cold[t.block(x)]
+	i, _ := b.search(x)
+	return b.run + i
+}
+
+// Contains reports whether x is in t.
+func (t *Tiered) Contains(x uint32) bool {
+	if uint64(x) >= t.coldEnd() {
+		return t.hot.Contains(x)
+	}
+	_, s := t.cold[t.block(x)].search(x)
+	return x >= s.Value && uint64(x) < uint64(s.Value)+uint64(s.Count)
+}
+
+// All returns an iterator over the values in t, in increasing order.
+func (t *Tiered) All() iter.Seq[uint32] {
+	return valuesOf(t.Runs())
+}
+
+// Runs returns an iterator over the runs in t, in increasing order.
+func (t *Tiered) Runs() iter.Seq[Uint32Run] {
+	return func(yield func(Uint32Run) bool) {
+		for i := range t.cold {
+			if !t.cold[i].runs(yield) {
+				return
+			}
+		}
+		for _, s := range t.hot.runs {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// Uint32 returns a copy of t as an ordinary array.
+func (t *Tiered) Uint32() Uint32 {
+	out := Uint32{runs: make([]Uint32Run, 0, t.NumRuns())}
+	for s := range t.Runs() {
+		out.runs = append(out.runs, s)
+	}
+	return out
+}
+
+// String returns t in the format of Uint32.String.
+func (t *Tiered) String() string {
+	return t.Uint32().String()
+}
@@ -0,0 +1,67 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+	"unsafe"
+)
+
+func TestTiered(t *testing.T) {
+	rng := rand.New(rand.NewPCG(15, 16))
+	tr := Tiered{HotRuns: 100}
+	var want Uint32
+	for i := 0; i < 5000; i++ {
+		x := uint32(10*i) + rng.Uint32N(5)
+		if i%500 == 499 {
+			// An occasional late value thaws part of the cold tier.
+			x = rng.Uint32N(uint32(10 * i))
+		}
+		tr.Push(x)
+		want.Push(x)
+	}
+	if len(tr.cold) == 0 || len(tr.hot.runs) >= 100+coldBlockRuns {
+		t.Errorf("Expected cold blocks and a bounded hot tier, got %d blocks and %d hot runs", len(tr.cold), len(tr.hot.runs))
+	}
+
+	testEqualUint32(t, "tr", tr.Uint32(), want)
+	if tr.Len() != want.Len() || tr.NumRuns() != len(want.runs) || tr.Min() != want.Min() || tr.Max() != want.Max() {
+		t.Errorf("Expected %d values in %d runs, got %d in %d", want.Len(), len(want.runs), tr.Len(), tr.NumRuns())
+	}
+	for x := uint32(0); x < 50010; x++ {
+		if tr.Contains(x) != want.Contains(x) || tr.IndexOf(x) != want.IndexOf(x) || tr.LowerBound(x) != want.LowerBound(x) {
+			t.Fatalf("Expected queries for %d to match, got %v, %d, %d", x, tr.Contains(x), tr.IndexOf(x), tr.LowerBound(x))
+		}
+	}
+
+	var cold int
+	for _, b := range tr.cold {
+		cold += len(b.data)
+	}
+	runs := tr.NumRuns() - len(tr.hot.runs)
+	if full := runs * int(unsafe.Sizeof(Uint32Run{})); 4*cold > full {
+		t.Errorf("Expected %d cold runs to take under a quarter of %d bytes, got %d", runs, full, cold)
+	}
+	if tr.String() != want.String() {
+		t.Errorf("Expected %v, got %v", want, &tr)
+	}
+
+	big := Uint32{}
+	for x := uint32(0); x < 3*DefaultHotRuns; x++ {
+		big.Push(2 * x)
+	}
+	of := TieredOf(big)
+	if len(of.cold) == 0 {
+		t.Errorf("Expected TieredOf() to compress old runs")
+	}
+	testEqualUint32(t, "TieredOf()", of.Uint32(), big)
+	if of.IndexOf(1001) != 501 || of.LowerBound(1001) != 501 {
+		t.Errorf("Expected IndexOf(1001) and LowerBound(1001) to be 501, got %d and %d", of.IndexOf(1001), of.LowerBound(1001))
+	}
+}
+
+func TestTieredEdges(t *testing.T) {
+	var tr Tiered
+	if tr.Len() != 0 || tr.NumRuns() != 0 || tr.Contains(0) || tr.IndexOf(5) != 0 || tr.LowerBound(5) != 0 {
+		t.Errorf("Expected an empty Tiered, got %v", &tr)
+	}
+}
@@ -0,0 +1,40 @@
+package rangearray
+
+import "context"
+
+// Tracer observes long-running operations, such as bulk loads, unions,
+// and decodes, so that callers can record them as tracing spans with
+// durations.  Options structs and views that accept a Tracer call it
+// only when it is not nil.  The rangearrayotel module adapts an
+// OpenTelemetry tracer to this interface.
+type Tracer interface {
+	// Start is called when the operation op begins, with the context
+	// passed to the method that began it, or context.Background() if
+	// the method takes none, so that a span can be a child of the
+	// caller's.  The function it returns is called when the operation
+	// ends, with the number of runs the operation produced or consumed
+	// and the error that ended it.
+	Start(ctx context.Context, op string) func(runs int, err error)
+}
+
+// Operation names passed to Tracer.Start.
+const (
+	OpEncode       = "rangearray.Encode"
+	OpEncodeBlocks = "rangearray.EncodeBlocks"
+	OpDecode       = "rangearray.Decode"
+	OpReadBlocks   = "rangearray.ReadBlocks"
+	OpReadCSV      = "rangearray.ReadCSV"
+	OpReadNDJSON   = "rangearray.ReadNDJSON"
+	OpLoadFiles    = "rangearray.LoadFiles"
+	OpUnion        = "rangearray.Union"
+	OpIntersection = "rangearray.Intersection"
+)
+
+// startTrace starts op on t, which may be nil, within ctx, and returns
+// the function that ends it.
+func startTrace(ctx context.Context, t Tracer, op string) func(runs int, err error) {
+	if t == nil {
+		return func(int, error) {}
+	}
+	return t.Start(ctx, op)
+}
@@ -0,0 +1,95 @@
+package rangearray
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testTracer records the operations it observes.
+type testTracer struct {
+	events []string
+	ctxs   []context.Context
+}
+
+func (t *testTracer) Start(ctx context.Context, op string) func(int, error) {
+	t.ctxs = append(t.ctxs, ctx)
+	return func(runs int, err error) {
+		t.events = append(t.events, fmt.Sprintf("%s %d %v", op, runs, err != nil))
+	}
+}
+
+func (t *testTracer) expect(tt *testing.T, want ...string) {
+	tt.Helper()
+	if strings.Join(t.events, "; ") != strings.Join(want, "; ") {
+		tt.Errorf("Expected trace events %q, got %q", want, t.events)
+	}
+	t.events = nil
+	t.ctxs = nil
+}
+
+func TestTracer(t *testing.T) {
+	tr := &testTracer{}
+	r := testEncodingArray()
+
+	e := Encoder{Tracer: tr}
+	b, _ := e.Marshal(r)
+	var buf bytes.Buffer
+	e.Encode(&buf, r)
+	e.EncodeBlocks(&buf, r)
+	tr.expect(t, OpEncode+" 4 false", OpEncode+" 4 false", OpEncodeBlocks+" 4 false")
+
+	d := Decoder{Tracer: tr}
+	var out Uint32
+	d.Unmarshal(b, &out)
+	d.Unmarshal(b[:len(b)-1], &out)
+	d.Decode(bytes.NewReader(b), &out)
+	tr.expect(t, OpDecode+" 4 false", OpDecode+" 4 true", OpDecode+" 4 false")
+
+	buf.Reset()
+	Encoder{}.EncodeBlocks(&buf, r)
+	f, err := d.OpenBlockFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("OpenBlockFile() failed: %v", err)
+	}
+	f.ReadAll()
+	tr.expect(t, OpReadBlocks+" 4 false")
+
+	ReadCSV(strings.NewReader("1\n2\n5\n"), CSVOptions{Tracer: tr})
+	ReadCSV(strings.NewReader("1\nx\n"), CSVOptions{Tracer: tr})
+	ReadNDJSON(strings.NewReader(`{"t":1}`), NDJSONOptions{Field: "t", Tracer: tr})
+	tr.expect(t, OpReadCSV+" 2 false", OpReadCSV+" 0 true", OpReadNDJSON+" 1 false")
+
+	u := NewUnionView(r, r)
+	u.SetTracer(tr)
+	u.Len()
+	u.Materialize()
+	u.Materialize()
+	v := NewIntersectionView(r)
+	v.SetTracer(tr)
+	v.MemoizeAfter(1)
+	v.Len()
+	tr.expect(t, OpUnion+" 4 false", OpIntersection+" 4 false")
+}
+
+func TestTracerContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	tr := &testTracer{}
+	r := testEncodingArray()
+	b, _ := Encoder{}.Marshal(r)
+
+	var out Uint32
+	Decoder{Tracer: tr}.UnmarshalContext(ctx, b, &out)
+	Decoder{Tracer: tr}.DecodeContext(ctx, bytes.NewReader(b), &out)
+	Encoder{Tracer: tr}.EncodeContext(ctx, io.Discard, r)
+	ReadCSV(strings.NewReader("1\n"), CSVOptions{Tracer: tr})
+	for i, want := range []any{"request", "request", "request", nil} {
+		if got := tr.ctxs[i].Value(key{}); got != want {
+			t.Errorf("Expected operation %d to be traced with context value %v, got %v", i, want, got)
+		}
+	}
+}
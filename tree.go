@@ -0,0 +1,172 @@
+package rangearray
+
+import "iter"
+
+// Tree is a mutable rangearray that keeps its runs in a balanced
+// order-statistic tree, where each node counts the values and runs in
+// its subtree.  A Push anywhere takes O(log n) expected time, against
+// O(n) for a Push before the end of a Uint32, so a Tree suits values
+// that arrive in arbitrary order.  In exchange, iteration chases
+// pointers and each run takes several times the memory of a Uint32Run.
+//
+// The tree is the same treap that Persistent uses, but Tree changes its
+// nodes in place rather than copying them.  The zero value is empty.
+// Like Uint32, a Tree is not safe for concurrent use while it is being
+// modified.
+type Tree struct {
+	root *pnode
+}
+
+// TreeOf returns a Tree holding the values in r.
+func TreeOf(r Uint32) *Tree {
+	return &Tree{root: PersistentOf(r).root}
+}
+
+// update recomputes the subtree totals of n from its children.
+func (n *pnode) update() {
+	n.values, n.runs = uint64(n.count), 1
+	if n.left != nil {
+		n.values += n.left.values
+		n.runs += n.left.runs
+	}
+	if n.right != nil {
+		n.values += n.right.values
+		n.runs += n.right.runs
+	}
+}
+
+// tsplit splits t, in place, into the runs that start before value and
+// the rest.
+func tsplit(t *pnode, value uint64) (*pnode, *pnode) {
+	if t == nil {
+		return nil, nil
+	}
+	if uint64(t.value) < value {
+		l, r := tsplit(t.right, value)
+		t.right = l
+		t.update()
+		return t, r
+	}
+	l, r := tsplit(t.left, value)
+	t.left = r
+	t.update()
+	return l, t
+}
+
+// tmerge joins a and b in place, where every run in a is before every
+// run in b.
+func tmerge(a, b *pnode) *pnode {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.prio >= b.prio:
+		a.right = tmerge(a.right, b)
+		a.update()
+		return a
+	default:
+		b.left = tmerge(a, b.left)
+		b.update()
+		return b
+	}
+}
+
+// persistent returns a read-only view of t's runs, for queries.
+func (t *Tree) persistent() Persistent {
+	return Persistent{root: t.root}
+}
+
+// Push adds x to t, and reports whether x was added, as Uint32.Push
+// does.
+func (t *Tree) Push(x uint32) bool {
+	if t.Len() == MaxLen || t.Contains(x) {
+		return false
+	}
+
+	// Detach the runs that end just before x and start just after it,
+	// as Persistent.Push does, and reuse one of their nodes (or a new
+	// one) for the merged run.
+	value, end := uint64(x), uint64(x)+1
+	var mid *pnode
+	l, r := tsplit(t.root, uint64(x))
+	if last := plast(l); last != nil && last.end() == value {
+		l, mid = tsplit(l, uint64(last.value))
+		value = uint64(last.value)
+	}
+	if first := pfirst(r); first != nil && uint64(first.value) == end {
+		var next *pnode
+		next, r = tsplit(r, uint64(first.value)+1)
+		end = next.end()
+		if mid == nil {
+			mid = next
+		}
+	}
+	if mid == nil {
+		mid = &pnode{}
+	}
+	*mid = pnode{value: uint32(value), count: uint32(end - value), prio: pnodePrio(uint32(value))}
+	mid.update()
+	t.root = tmerge(tmerge(l, mid), r)
+	if debugChecks {
+		checkReader("Tree.Push", t)
+	}
+	return true
+}
+
+// Min returns the minimum value in t.  Panics if t is empty.
+func (t *Tree) Min() uint32 {
+	return t.persistent().Min()
+}
+
+// Max returns the maximum value in t.  Panics if t is empty.
+func (t *Tree) Max() uint32 {
+	return t.persistent().Max()
+}
+
+// Len returns the number of elements in t.
+func (t *Tree) Len() uint32 {
+	return t.persistent().Len()
+}
+
+// NumRuns returns the number of runs in t.
+func (t *Tree) NumRuns() int {
+	return t.persistent().NumRuns()
+}
+
+// IndexOf returns the number of elements in t that are less than x.
+func (t *Tree) IndexOf(x uint32) uint32 {
+	return t.persistent().IndexOf(x)
+}
+
+// LowerBound returns the index of the run in t that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x, or NumRuns() if there is none.
+func (t *Tree) LowerBound(x uint32) int {
+	return t.persistent().LowerBound(x)
+}
+
+// Contains reports whether x is in t.
+func (t *Tree) Contains(x uint32) bool {
+	return t.persistent().Contains(x)
+}
+
+// All returns an iterator over the values in t, in increasing order.
+func (t *Tree) All() iter.Seq[uint32] {
+	return t.persistent().All()
+}
+
+// Runs returns an iterator over the runs in t, in increasing order.
+func (t *Tree) Runs() iter.Seq[Uint32Run] {
+	return t.persistent().Runs()
+}
+
+// Uint32 returns a copy of t as an ordinary array.
+func (t *Tree) Uint32() Uint32 {
+	return t.persistent().Uint32()
+}
+
+// String returns t in the format of Uint32.String.
+func (t *Tree) String() string {
+	return t.persistent().String()
+}
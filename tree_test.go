@@ -0,0 +1,50 @@
+package rangearray
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	var tr Tree
+	var want Uint32
+	for i := 0; i < 5000; i++ {
+		x := rng.Uint32N(6000)
+		tr.Push(x)
+		want.Push(x)
+		if i%500 == 0 {
+			testEqualUint32(t, "tr", tr.Uint32(), want)
+		}
+	}
+
+	testEqualUint32(t, "tr", tr.Uint32(), want)
+	if tr.Len() != want.Len() || tr.NumRuns() != len(want.runs) || tr.Min() != want.Min() || tr.Max() != want.Max() {
+		t.Errorf("Expected %d values in %d runs, got %d in %d", want.Len(), len(want.runs), tr.Len(), tr.NumRuns())
+	}
+	for x := uint32(0); x < 6010; x++ {
+		if tr.Contains(x) != want.Contains(x) || tr.IndexOf(x) != want.IndexOf(x) || tr.LowerBound(x) != want.LowerBound(x) {
+			t.Fatalf("Expected queries for %d to match, got %v, %d, %d", x, tr.Contains(x), tr.IndexOf(x), tr.LowerBound(x))
+		}
+	}
+
+	u := TreeOf(want)
+	u.Push(7000)
+	want.Push(7000)
+	testEqualUint32(t, "TreeOf()", u.Uint32(), want)
+	if u.String() != want.String() {
+		t.Errorf("Expected %v, got %v", want, u)
+	}
+
+	// Values that fill gaps merge runs.
+	var gaps Tree
+	for x := uint32(0); x < 100; x += 2 {
+		gaps.Push(x)
+	}
+	for x := uint32(1); x < 100; x += 2 {
+		gaps.Push(x)
+	}
+	if gaps.NumRuns() != 1 || gaps.Len() != 100 {
+		t.Errorf("Expected one run of 100 values, got %v", &gaps)
+	}
+}
@@ -0,0 +1,380 @@
+package rangearray
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Uint64Run is an RLE entry in a Uint64 rangearray.
+type Uint64Run struct {
+	// Value is the starting value of this run.
+	Value uint64
+
+	// Index is the number of elements before this run.
+	Index uint64
+
+	// Count is the number of consecutive elements inside this run.
+	Count uint64
+}
+
+// last returns the last value in s, which unlike the end of the run
+// cannot overflow.
+func (s Uint64Run) last() uint64 {
+	return s.Value + (s.Count - 1)
+}
+
+// Uint64 is a semi-dense array of uint64 values, for data sets with
+// more elements than a uint32 can count, such as several years of a
+// 50 Hz series.  Its values, Len, IndexOf, At and each run's Index are
+// all 64 bits wide.  It offers the core of Uint32's methods, and stores
+// its runs the same way.  The zero value is an empty rangearray.
+//
+// Like Uint32, a Uint64 is not safe for concurrent use while it is
+// being modified.
+type Uint64 struct {
+	// runs holds the runs of the array, in increasing order.  Each
+	// run's Index is the sum of the Counts before it.
+	runs []Uint64Run
+}
+
+// MaxLen64 is the largest number of values that a Uint64 can hold, so
+// that Len fits in a uint64.
+const MaxLen64 = math.MaxUint64
+
+// Uint64Of returns a Uint64 holding the values in r.
+func Uint64Of(r Uint32) Uint64 {
+	out := Uint64{runs: make([]Uint64Run, len(r.runs))}
+	for i, s := range r.runs {
+		out.runs[i] = Uint64Run{Value: uint64(s.Value), Index: uint64(s.Index), Count: uint64(s.Count)}
+	}
+	return out
+}
+
+// Uint64FromRuns returns a Uint64 holding the given runs, which must
+// be in increasing order, as Runs returns them.  It is the bulk
+// constructor for arrays too large to build with Push.  The Index of
+// each run is ignored and computed again.  Uint64FromRuns returns an
+// *InvalidRun64Error if the runs break the other invariants that
+// Validate checks.  The result does not share memory with runs.
+func Uint64FromRuns(runs []Uint64Run) (Uint64, error) {
+	r := Uint64{runs: slices.Clone(runs)}
+	var index uint64
+	for i := range r.runs {
+		r.runs[i].Index = index
+		index += r.runs[i].Count
+	}
+	if err := r.Validate(); err != nil {
+		return Uint64{}, err
+	}
+	return r, nil
+}
+
+// InvalidRun64Error reports a run of a Uint64 that breaks one of its
+// invariants, as InvalidRunError does for a Uint32.
+type InvalidRun64Error struct {
+	// Pos is the position of the first run that is wrong, and Run is
+	// that run.
+	Pos int
+	Run Uint64Run
+
+	// Reason says which invariant the run breaks.
+	Reason string
+}
+
+// Is reports whether target is ErrCorrupt.
+func (e *InvalidRun64Error) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+func (e *InvalidRun64Error) Error() string {
+	return fmt.Sprintf("rangearray: invalid run %d %+v: %s", e.Pos, e.Run, e.Reason)
+}
+
+// Validate checks the invariants of r, as Uint32.Validate does: each
+// run has a nonzero Count and ends at or before the largest uint64,
+// each run starts after the end of the one before it, with a gap
+// between them, and each Index is the number of values in the runs
+// before it.  It returns nil if r is valid, or an *InvalidRun64Error
+// for the first run that is not.
+func (r Uint64) Validate() error {
+	return r.validate(0)
+}
+
+// validate checks the invariants of the runs of r from lo on, given
+// that the runs before lo are valid.
+func (r Uint64) validate(lo int) error {
+	var index uint64
+	if lo = max(lo, 0); lo > 0 && lo <= len(r.runs) {
+		index = r.runs[lo-1].Index + r.runs[lo-1].Count
+	}
+	for i := lo; i < len(r.runs); i++ {
+		s := r.runs[i]
+		reason := ""
+		switch {
+		case s.Count == 0:
+			reason = "Count is zero"
+		case s.Count-1 > math.MaxUint64-s.Value:
+			reason = "run extends past the largest uint64"
+		case i > 0 && s.Value <= r.runs[i-1].last():
+			reason = fmt.Sprintf("Value overlaps or precedes run %d", i-1)
+		case i > 0 && s.Value == r.runs[i-1].last()+1:
+			reason = fmt.Sprintf("run is adjacent to run %d and should be merged with it", i-1)
+		case s.Index != index:
+			reason = fmt.Sprintf("Index should be %d", index)
+		}
+		if reason != "" {
+			return &InvalidRun64Error{Pos: i, Run: s, Reason: reason}
+		}
+		index += s.Count
+	}
+	return nil
+}
+
+// check panics with a description of r if the runs of r from lo on
+// break an invariant, as Uint32.check does.
+func (r *Uint64) check(op string, lo int) {
+	err := r.validate(lo)
+	if err == nil {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %v\n", op, err)
+	fmt.Fprintf(&b, "array has %d runs\n", len(r.runs))
+	pos := err.(*InvalidRun64Error).Pos
+	for i := max(pos-debugContext, 0); i < min(pos+debugContext+1, len(r.runs)); i++ {
+		mark := " "
+		if i == pos {
+			mark = ">"
+		}
+		fmt.Fprintf(&b, "%s run %d: %+v\n", mark, i, r.runs[i])
+	}
+	panic(b.String())
+}
+
+// Min returns the minimum value in r.  Panics if r is empty; MinOK
+// does not.
+func (r Uint64) Min() uint64 {
+	return r.runs[0].Value
+}
+
+// Max returns the maximum value in r.  Panics if r is empty; MaxOK
+// does not.
+func (r Uint64) Max() uint64 {
+	return r.runs[len(r.runs)-1].last()
+}
+
+// MinOK returns the minimum value in r and true, or zero and false if
+// r is empty.
+func (r Uint64) MinOK() (uint64, bool) {
+	if len(r.runs) == 0 {
+		return 0, false
+	}
+	return r.Min(), true
+}
+
+// MaxOK returns the maximum value in r and true, or zero and false if
+// r is empty.
+func (r Uint64) MaxOK() (uint64, bool) {
+	if len(r.runs) == 0 {
+		return 0, false
+	}
+	return r.Max(), true
+}
+
+// Len returns the number of elements in r.
+func (r Uint64) Len() uint64 {
+	if len(r.runs) == 0 {
+		return 0
+	}
+
+	n := len(r.runs) - 1
+	return r.runs[n].Index + r.runs[n].Count
+}
+
+// IndexOf returns the number of elements in r that are less than x.
+func (r Uint64) IndexOf(x uint64) uint64 {
+	i := r.LowerBound(x)
+	if i < len(r.runs) {
+		if x <= r.runs[i].Value {
+			return r.runs[i].Index
+		}
+		return x - r.runs[i].Value + r.runs[i].Index
+	}
+	return r.Len()
+}
+
+// At returns the i'th smallest value in r, counting from zero, which
+// is the value x for which IndexOf(x) is i.  Panics if i is not less
+// than r.Len().
+func (r Uint64) At(i uint64) uint64 {
+	if i >= r.Len() {
+		panic(fmt.Sprintf("rangearray: Uint64.At index %d out of range [0:%d]", i, r.Len()))
+	}
+	n := sort.Search(len(r.runs), func(k int) bool {
+		return r.runs[k].Index+r.runs[k].Count > i
+	})
+	return r.runs[n].Value + (i - r.runs[n].Index)
+}
+
+// LowerBound returns the index of the run in r that contains x.  If no
+// run contains x, LowerBound returns the index of the run that starts
+// after x.  If x is after r.Max(), returns r.NumRuns().
+func (r Uint64) LowerBound(x uint64) int {
+	return sort.Search(len(r.runs), func(i int) bool {
+		return r.runs[i].last() >= x
+	})
+}
+
+// Contains reports whether x is in r.
+func (r Uint64) Contains(x uint64) bool {
+	i := r.LowerBound(x)
+	return i < len(r.runs) && x >= r.runs[i].Value
+}
+
+// All returns an iterator over the values in r, in increasing order.
+func (r Uint64) All() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for _, s := range r.runs {
+			for x := s.Value; ; x++ {
+				if !yield(x) {
+					return
+				}
+				if x == s.last() {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Runs returns an iterator over the runs in r, in increasing order.
+func (r Uint64) Runs() iter.Seq[Uint64Run] {
+	return func(yield func(Uint64Run) bool) {
+		for _, s := range r.runs {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// NumRuns returns the number of runs in r.
+func (r Uint64) NumRuns() int {
+	return len(r.runs)
+}
+
+// Run returns the i'th run of r.  Panics if i is out of range.
+func (r Uint64) Run(i int) Uint64Run {
+	return r.runs[i]
+}
+
+// appendRun adds the count values starting at value to the end of r.
+// It returns false, leaving r unchanged, if count is zero, the run
+// runs past the largest uint64 or does not come after every value
+// already in r, or r would hold more than MaxLen64 values.
+func (r *Uint64) appendRun(value, count uint64) bool {
+	if count == 0 || count-1 > math.MaxUint64-value || count > MaxLen64-r.Len() {
+		return false
+	}
+
+	n := len(r.runs) - 1
+	if n >= 0 {
+		last := r.runs[n].last()
+		if value <= last {
+			return false
+		}
+		if value == last+1 {
+			r.runs[n].Count += count
+			if debugChecks {
+				r.check("appendRun", n)
+			}
+			return true
+		}
+	}
+	r.runs = append(r.runs, Uint64Run{Value: value, Index: r.Len(), Count: count})
+	if debugChecks {
+		r.check("appendRun", len(r.runs)-1)
+	}
+	return true
+}
+
+// Push adds x to r, and reports whether x was added: false if x was
+// already in r, or if r already holds MaxLen64 values.  Like
+// Uint32.Push, it is fastest when x is past r.Max().
+func (r *Uint64) Push(x uint64) bool {
+	added := r.push(x)
+	if debugChecks {
+		// Push changes only the runs from the one before x on.
+		r.check("Push", r.LowerBound(x)-1)
+	}
+	return added
+}
+
+// push implements Push, without checking the invariants of r.
+func (r *Uint64) push(x uint64) bool {
+	if r.Len() == MaxLen64 {
+		return false
+	}
+
+	n := len(r.runs) - 1
+	if n < 0 || x > r.runs[n].last() {
+		return r.appendRun(x, 1)
+	}
+
+	// Find the insertion point.
+	n = r.LowerBound(x)
+	if x >= r.runs[n].Value {
+		return false
+	}
+
+	afterNm1 := n > 0 && x == r.runs[n-1].last()+1
+	if x+1 == r.runs[n].Value {
+		if afterNm1 {
+			// Merge r.runs[n] into r.runs[n-1] and shrink the rest.
+			r.runs[n-1].Count += r.runs[n].Count + 1
+			r.runs = append(r.runs[:n], r.runs[n+1:]...)
+			n--
+		} else {
+			r.runs[n].Value--
+			r.runs[n].Count++
+		}
+	} else if afterNm1 {
+		r.runs[n-1].Count++
+		n--
+	} else {
+		r.runs = append(r.runs, Uint64Run{})
+		copy(r.runs[n+1:], r.runs[n:])
+		r.runs[n] = Uint64Run{Value: x, Index: r.runs[n+1].Index, Count: 1}
+	}
+
+	for n+1 < len(r.runs) {
+		n++
+		r.runs[n].Index++
+	}
+	return true
+}
+
+// String returns r in the format of Uint32.String.
+func (r Uint64) String() string {
+	var b []byte
+	for i, s := range r.runs {
+		if i == stringHeadRuns && len(r.runs) > stringMaxRuns {
+			return fmt.Sprintf("%s,… (%d runs, %d values)", b, len(r.runs), r.Len())
+		}
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendUint(b, s.Value, 10)
+		if s.Count > 1 {
+			b = append(b, '-')
+			b = strconv.AppendUint(b, s.last(), 10)
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,133 @@
+package rangearray
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUint64(t *testing.T) {
+	var r Uint64
+	if _, ok := r.MinOK(); ok || r.Len() != 0 || r.IndexOf(5) != 0 || r.Contains(0) {
+		t.Errorf("Expected an empty Uint64, got %v", r)
+	}
+
+	for _, x := range []uint64{10, 11, 12, 20, 5, 14, 13, 1 << 40} {
+		if !r.Push(x) {
+			t.Errorf("Expected Push(%d) to add it", x)
+		}
+	}
+	if r.Push(13) {
+		t.Errorf("Expected Push(13) to report a duplicate")
+	}
+	want := []Uint64Run{{5, 0, 1}, {10, 1, 5}, {20, 6, 1}, {1 << 40, 7, 1}}
+	if got := slices.Collect(r.Runs()); !slices.Equal(got, want) {
+		t.Errorf("Expected runs %v, got %v", want, got)
+	}
+	if s := r.String(); s != "5,10-14,20,1099511627776" {
+		t.Errorf("Expected String() == \"5,10-14,20,1099511627776\", got %q", s)
+	}
+
+	values := slices.Collect(r.All())
+	for i, x := range values {
+		if got := r.IndexOf(x); got != uint64(i) {
+			t.Errorf("Expected IndexOf(%d) == %d, got %d", x, i, got)
+		}
+		if got := r.At(uint64(i)); got != x {
+			t.Errorf("Expected At(%d) == %d, got %d", i, x, got)
+		}
+	}
+	if r.IndexOf(15) != 6 || r.IndexOf(math.MaxUint64) != 8 || r.LowerBound(15) != 2 {
+		t.Errorf("Expected IndexOf(15) == 6 and LowerBound(15) == 2, got %d and %d", r.IndexOf(15), r.LowerBound(15))
+	}
+}
+
+func TestUint64BeyondUint32(t *testing.T) {
+	// Five years at 50 Hz, with an hour missing in the middle.
+	const n = 5 * 365 * 86400 * 50
+	r, err := Uint64FromRuns([]Uint64Run{{Value: 0, Count: n / 2}, {Value: n/2 + 3600*50, Count: n / 2}})
+	if err != nil {
+		t.Fatalf("Uint64FromRuns() failed: %v", err)
+	}
+	if r.Len() != n || r.Len() <= MaxLen {
+		t.Fatalf("Expected Len() == %d, got %d", uint64(n), r.Len())
+	}
+	if x := r.At(n - 1); x != n-1+3600*50 {
+		t.Errorf("Expected At(%d) == %d, got %d", uint64(n-1), uint64(n-1+3600*50), x)
+	}
+	if i := r.IndexOf(r.Max()); i != n-1 {
+		t.Errorf("Expected IndexOf(Max()) == %d, got %d", uint64(n-1), i)
+	}
+	if !r.Push(n/2) || r.Run(1).Index != n/2+1 {
+		t.Errorf("Expected Push in the gap to shift the next Index, got %+v", r.Run(1))
+	}
+}
+
+func TestUint64TopOfRange(t *testing.T) {
+	var r Uint64
+	r.Push(math.MaxUint64)
+	r.Push(math.MaxUint64 - 1)
+	if r.NumRuns() != 1 || r.Max() != math.MaxUint64 || !r.Contains(math.MaxUint64) {
+		t.Errorf("Expected one run ending at the largest uint64, got %v", r)
+	}
+	if r.appendRun(0, 1) || r.appendRun(math.MaxUint64, 2) {
+		t.Errorf("Expected appendRun() before the end or past the range to fail")
+	}
+	if got := slices.Collect(r.All()); len(got) != 2 {
+		t.Errorf("Expected All() to stop at the largest uint64, got %v", got)
+	}
+}
+
+func TestUint64Of(t *testing.T) {
+	r := testEncodingArray()
+	w := Uint64Of(r)
+	if w.Len() != uint64(r.Len()) || w.NumRuns() != r.NumRuns() || w.Max() != uint64(r.Max()) {
+		t.Errorf("Expected Uint64Of() to match %v, got %v", r, w)
+	}
+	for i := range r.Len() {
+		if x := r.At(i); uint64(x) != w.At(uint64(i)) || r.IndexOf(x) != i {
+			t.Fatalf("Expected At(%d) to agree, got %d and %d", i, x, w.At(uint64(i)))
+		}
+	}
+}
+
+func TestUint64FromRuns(t *testing.T) {
+	runs := []Uint64Run{{Value: 5, Index: 9, Count: 1}, {Value: 10, Count: 5}, {Value: 1 << 40, Count: 1 << 35}}
+	r, err := Uint64FromRuns(runs)
+	if err != nil {
+		t.Fatalf("Uint64FromRuns() failed: %v", err)
+	}
+	want := []Uint64Run{{5, 0, 1}, {10, 1, 5}, {1 << 40, 6, 1 << 35}}
+	if got := slices.Collect(r.Runs()); !slices.Equal(got, want) {
+		t.Errorf("Expected runs %v, got %v", want, got)
+	}
+	runs[0].Count++
+	if r.Run(0).Count != 1 {
+		t.Errorf("Expected Uint64FromRuns() not to share its argument")
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Expected a valid array, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		runs   []Uint64Run
+		run    int
+		reason string
+	}{
+		{[]Uint64Run{{1, 0, 0}}, 0, "zero"},
+		{[]Uint64Run{{math.MaxUint64 - 5, 0, 7}}, 0, "past"},
+		{[]Uint64Run{{10, 0, 5}, {12, 0, 1}}, 1, "overlaps"},
+		{[]Uint64Run{{10, 0, 5}, {15, 0, 1}}, 1, "adjacent"},
+	} {
+		_, err := Uint64FromRuns(tc.runs)
+		var ire *InvalidRun64Error
+		if !errors.As(err, &ire) || ire.Pos != tc.run || !strings.Contains(ire.Reason, tc.reason) || !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected run %d to fail with %q for %v, got %v", tc.run, tc.reason, tc.runs, err)
+		}
+	}
+	if err := (Uint64{runs: []Uint64Run{{10, 1, 5}}}).Validate(); err == nil || !strings.Contains(err.Error(), "Index should be 0") {
+		t.Errorf("Expected Validate() to catch a wrong Index, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package rangearray
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InvalidRunError reports a run of a Uint32 that breaks one of its
+// invariants.
+type InvalidRunError struct {
+	// Pos is the position of the first run that is wrong, and Run is
+	// that run.
+	Pos int
+	Run Uint32Run
+
+	// Reason says which invariant the run breaks.
+	Reason string
+}
+
+// Is reports whether target is ErrCorrupt, so that decoders can return
+// an *InvalidRunError for runs that break the invariants.
+func (e *InvalidRunError) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+func (e *InvalidRunError) Error() string {
+	return fmt.Sprintf("rangearray: invalid run %d %+v: %s", e.Pos, e.Run, e.Reason)
+}
+
+// Validate checks the invariants of r that every method relies on: each
+// run has a nonzero Count and ends at or before 1<<32, each run starts
+// after the end of the one before it, with a gap between them, and each
+// Index is the number of values in the runs before it.  Push and the
+// other methods maintain these, so Validate is mostly for tests, and
+// for arrays that unsafe code such as WrapFlat builds.  It returns nil
+// if r is valid, or an *InvalidRunError for the first run that is not.
+//
+// Building with the rangearray_debug tag makes the methods that modify
+// an array check these invariants themselves, and panic with a
+// description of the array if they break one.
+func (r Uint32) Validate() error {
+	return r.validate(0, 0)
+}
+
+// validate checks the invariants of the runs of r from lo on, given
+// that the runs before lo are valid, and that the first run's Index is
+// base rather than zero.
+func (r Uint32) validate(lo int, base uint32) error {
+	var end uint64
+	index := uint64(base)
+	if lo = max(lo, 0); lo > 0 && lo <= len(r.runs) {
+		prev := r.runs[lo-1]
+		end = uint64(prev.Value) + uint64(prev.Count)
+		index = uint64(prev.Index) + uint64(prev.Count)
+	}
+	for i := lo; i < len(r.runs); i++ {
+		s := r.runs[i]
+		reason := ""
+		switch {
+		case s.Count == 0:
+			reason = "Count is zero"
+		case uint64(s.Value)+uint64(s.Count) > 1<<32:
+			reason = "run extends past the largest uint32"
+		case i > 0 && uint64(s.Value) < end:
+			reason = fmt.Sprintf("Value overlaps or precedes run %d", i-1)
+		case i > 0 && uint64(s.Value) == end:
+			reason = fmt.Sprintf("run is adjacent to run %d and should be merged with it", i-1)
+		case uint64(s.Index) != index:
+			reason = fmt.Sprintf("Index should be %d", index)
+		}
+		if reason != "" {
+			return &InvalidRunError{Pos: i, Run: s, Reason: reason}
+		}
+		end = uint64(s.Value) + uint64(s.Count)
+		index += uint64(s.Count)
+	}
+	return nil
+}
+
+// check panics with a description of r if the runs of r from lo on
+// break an invariant, with the first run's Index at base.  op names the
+// operation that just modified r.  Callers guard it with debugChecks,
+// so that it costs nothing unless the rangearray_debug tag is set.
+func (r *Uint32) check(op string, lo int, base uint32) {
+	err := r.validate(lo, base)
+	if err == nil {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %v\n", op, err)
+	fmt.Fprintf(&b, "array has %d runs, shared %v, pinned %v, sampled index %v\n",
+		len(r.runs), r.shared, r.pinned, r.sampled() != nil)
+	pos := err.(*InvalidRunError).Pos
+	for i := max(pos-debugContext, 0); i < min(pos+debugContext+1, len(r.runs)); i++ {
+		mark := " "
+		if i == pos {
+			mark = ">"
+		}
+		fmt.Fprintf(&b, "%s run %d: %+v\n", mark, i, r.runs[i])
+	}
+	panic(b.String())
+}
+
+// checkReader is check for the other forms of rangearray: it panics if
+// the runs of r break an invariant, or disagree with r.Len().
+func checkReader(op string, r Reader) {
+	var u Uint32
+	for s := range r.Runs() {
+		u.runs = append(u.runs, s)
+	}
+	u.check(op, 0, 0)
+	if u.Len() != r.Len() {
+		panic(fmt.Sprintf("%s: Len is %d, but the runs hold %d values", op, r.Len(), u.Len()))
+	}
+}
+
+// debugContext is the number of runs on each side of the invalid one
+// that check describes.
+const debugContext = 3
+
+// FromRuns returns an array holding the given runs, which must be in
+// increasing order, as AppendRuns returns them.  Use Normalize for runs
+// that may not be.  The Index of each run is ignored and computed
+// again.  FromRuns returns an *InvalidRunError if the runs break the
+// other invariants that Validate checks.  The result does not share
+// memory with runs.
+func FromRuns(runs []Uint32Run) (Uint32, error) {
+	r := Uint32{runs: slices.Clone(runs)}
+	var index uint32
+	for i := range r.runs {
+		r.runs[i].Index = index
+		index += r.runs[i].Count
+	}
+	if err := r.Validate(); err != nil {
+		return Uint32{}, err
+	}
+	return r, nil
+}
+
+// WrapRuns returns an array that uses runs as its storage, without
+// copying them, so that a buffer filled by AppendUnion or another
+// Append set operation can be reused from one call to the next.  The
+// runs must be valid as they are, including each Index; WrapRuns
+// returns an *InvalidRunError if they are not.  The array copies runs
+// before it is first modified, but sees any later change through the
+// caller's slice, so runs must not be changed while the array is in
+// use.
+func WrapRuns(runs []Uint32Run) (Uint32, error) {
+	r := Uint32{runs: runs, shared: true}
+	if err := r.Validate(); err != nil {
+		return Uint32{}, err
+	}
+	return r, nil
+}
+
+// Normalize returns an array holding every value in the given runs,
+// which may be in any order, overlap, touch or be empty, such as runs
+// assembled by hand or concatenated from shards.  It sorts the runs,
+// drops the empty ones, merges the rest where they overlap or touch,
+// and computes each Index again.  A run that extends past the largest
+// uint32 is cut short there, and, as with the set operations,
+// 0xffffffff is left out if the result would otherwise hold every
+// value.  The result does not share memory with runs.
+func Normalize(runs []Uint32Run) Uint32 {
+	sorted := slices.Clone(runs)
+	slices.SortFunc(sorted, func(a, b Uint32Run) int {
+		return cmp.Compare(a.Value, b.Value)
+	})
+
+	// Merge in place: each merged run is written no later than the
+	// first run it was merged from.
+	out := sorted[:0]
+	var value, end, index uint64
+	for _, s := range sorted {
+		if s.Count == 0 {
+			continue
+		}
+		v := uint64(s.Value)
+		e := min(v+uint64(s.Count), 1<<32)
+		if end > value && v <= end {
+			end = max(end, e)
+			continue
+		}
+		if end > value {
+			out = append(out, Uint32Run{Value: uint32(value), Index: uint32(index), Count: uint32(end - value)})
+			index += end - value
+		}
+		value, end = v, e
+	}
+	if end > value {
+		count := min(end-value, MaxLen)
+		out = append(out, Uint32Run{Value: uint32(value), Index: uint32(index), Count: uint32(count)})
+	}
+
+	if len(out) == 0 {
+		return Uint32{}
+	}
+	r := Uint32{runs: out}
+	if debugChecks {
+		r.check("Normalize", 0, 0)
+	}
+	return r
+}
@@ -0,0 +1,146 @@
+package rangearray
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := testEncodingArray().Validate(); err != nil {
+		t.Errorf("Expected a valid array, got %v", err)
+	}
+	if err := (Uint32{}).Validate(); err != nil {
+		t.Errorf("Expected an empty array to be valid, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		runs   []Uint32Run
+		run    int
+		reason string
+	}{
+		{[]Uint32Run{{1, 0, 0}}, 0, "zero"},
+		{[]Uint32Run{{0xfffffff0, 0, 17}}, 0, "past"},
+		{[]Uint32Run{{10, 0, 5}, {12, 5, 1}}, 1, "overlaps"},
+		{[]Uint32Run{{10, 0, 5}, {5, 5, 1}}, 1, "precedes"},
+		{[]Uint32Run{{10, 0, 5}, {15, 5, 1}}, 1, "adjacent"},
+		{[]Uint32Run{{10, 1, 5}}, 0, "Index should be 0"},
+		{[]Uint32Run{{10, 0, 5}, {20, 6, 1}}, 1, "Index should be 5"},
+	} {
+		err := Uint32{runs: tc.runs}.Validate()
+		var ire *InvalidRunError
+		if !errors.As(err, &ire) || ire.Pos != tc.run || !strings.Contains(ire.Reason, tc.reason) {
+			t.Errorf("Expected run %d to fail with %q for %v, got %v", tc.run, tc.reason, tc.runs, err)
+		}
+	}
+}
+
+func TestFromRuns(t *testing.T) {
+	r := testEncodingArray()
+	runs := r.AppendRuns(nil)
+	for i := range runs {
+		runs[i].Index = 0
+	}
+	x, err := FromRuns(runs)
+	if err != nil {
+		t.Fatalf("FromRuns() failed: %v", err)
+	}
+	testEqualUint32(t, "x", x, r)
+
+	runs[0].Count++
+	if x.Run(0) == runs[0] {
+		t.Errorf("Expected FromRuns() not to share its argument")
+	}
+
+	var ire *InvalidRunError
+	if _, err := FromRuns([]Uint32Run{{10, 0, 5}, {12, 0, 1}}); !errors.As(err, &ire) || ire.Pos != 1 {
+		t.Errorf("Expected FromRuns() to reject overlapping runs, got %v", err)
+	}
+}
+
+func TestWrapRuns(t *testing.T) {
+	a := testEncodingArray()
+	var b Uint32
+	b.Push(1)
+	b.Push(1 << 20)
+	buf := AppendUnion(nil, a, b)
+	allocs := testing.AllocsPerRun(10, func() {
+		buf = AppendUnion(buf[:0], a, b)
+		if _, err := WrapRuns(buf); err != nil {
+			t.Fatalf("WrapRuns() failed: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Expected WrapRuns() to reuse its buffer, got %v allocations", allocs)
+	}
+	r, err := WrapRuns(buf)
+	if err != nil {
+		t.Fatalf("WrapRuns() failed: %v", err)
+	}
+	testEqualUint32(t, "r", r, NewUnionView(a, b).Materialize())
+	if &r.runs[0] != &buf[0] {
+		t.Errorf("Expected WrapRuns() to share its argument")
+	}
+
+	want := slices.Clone(buf)
+	r.Push(r.Max() + 1)
+	r.Push(0)
+	if !slices.Equal(buf, want) {
+		t.Errorf("Expected Push() on a wrapped array to leave the buffer alone")
+	}
+
+	var ire *InvalidRunError
+	if _, err := WrapRuns([]Uint32Run{{10, 0, 5}, {20, 0, 1}}); !errors.As(err, &ire) || ire.Pos != 1 {
+		t.Errorf("Expected WrapRuns() to reject a wrong Index, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r := testEncodingArray()
+	r.check("test", 0, 0)
+
+	r.runs[3].Index++
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "test: ") || !strings.Contains(msg, "> run 3: ") {
+			t.Errorf("Expected check() to describe run 3, got %q", msg)
+		}
+	}()
+	r.check("test", 0, 0)
+	t.Errorf("Expected check() to panic")
+}
+
+func TestNormalize(t *testing.T) {
+	want := testEncodingArray()
+	runs := want.AppendRuns(nil)
+	// Split the first run, overlap the second, and add empty and
+	// duplicate runs, then shuffle them all.
+	runs = append(runs, Uint32Run{Value: 150, Count: 50}, Uint32Run{Value: 400, Count: 20},
+		Uint32Run{Value: 7}, Uint32Run{Value: 1000, Count: 1}, Uint32Run{Value: 449, Index: 99, Count: 1})
+	runs[0].Count = 50
+	slices.Reverse(runs)
+	got := Normalize(runs)
+	if err := got.Validate(); err != nil {
+		t.Fatalf("Expected Normalize() to return a valid array, got %v", err)
+	}
+	testEqualUint32(t, "Normalize()", got, want)
+
+	if r := Normalize([]Uint32Run{{Value: 5}}); r.NumRuns() != 0 {
+		t.Errorf("Expected Normalize() of empty runs to be empty, got %v", r)
+	}
+	if r := Normalize(nil); r.Len() != 0 {
+		t.Errorf("Expected Normalize(nil) to be empty, got %v", r)
+	}
+
+	// Runs past the top of the range are cut short, and a result with
+	// every value leaves out the last.
+	top := Normalize([]Uint32Run{{Value: 0xfffffff0, Count: 100}, {Value: 10, Count: 5}})
+	if top.NumRuns() != 2 || top.Max() != 0xffffffff || top.Len() != 21 {
+		t.Errorf("Expected Normalize() to stop at the largest uint32, got %v", top)
+	}
+	full := Normalize([]Uint32Run{{Value: 0x80000000, Count: 0x80000000}, {Value: 0, Count: 0x80000001}})
+	if full.Len() != MaxLen || full.Contains(0xffffffff) || full.Validate() != nil {
+		t.Errorf("Expected Normalize() of every value to hold MaxLen values, got %v", full)
+	}
+}
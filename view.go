@@ -0,0 +1,340 @@
+package rangearray
+
+import (
+	"context"
+	"iter"
+	"math"
+	"slices"
+)
+
+// UnionView is the union of several rangearrays, evaluated lazily.
+// Each query walks the inputs rather than building the union, which
+// is cheaper when a large union is only probed a few times.
+//
+// A UnionView reads its inputs on every query, so they must not be
+// modified while the view is in use.  Queries may update the view's
+// memoization state, so a UnionView is not safe for concurrent use.
+type UnionView struct {
+	arrays []Uint32
+	memo   memo
+}
+
+// NewUnionView returns a view of the union of arrays.
+func NewUnionView(arrays ...Uint32) *UnionView {
+	return &UnionView{arrays: arrays, memo: memo{op: OpUnion}}
+}
+
+// Contains reports whether x is in any of v's inputs.
+func (v *UnionView) Contains(x uint32) bool {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.Contains(x)
+	}
+
+	for _, a := range v.arrays {
+		if a.Contains(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexOf returns the number of elements in v that are less than x.
+func (v *UnionView) IndexOf(x uint32) uint32 {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.IndexOf(x)
+	}
+	return indexOfRuns(v.runs, x)
+}
+
+// Len returns the number of elements in v.
+func (v *UnionView) Len() uint32 {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.Len()
+	}
+	return lenOfRuns(v.runs)
+}
+
+// All returns an iterator over the values in v, in increasing order.
+func (v *UnionView) All() iter.Seq[uint32] {
+	return valuesOf(v.Runs())
+}
+
+// Materialize returns the contents of v as a concrete rangearray.  v
+// keeps its own copy and answers later queries from that.
+func (v *UnionView) Materialize() Uint32 {
+	return Uint32{runs: slices.Clone(v.memo.materialize(v.runs).runs)}
+}
+
+// MemoizeAfter makes v materialize itself once it has answered n
+// queries.  If n is zero, v only materializes when asked to.
+func (v *UnionView) MemoizeAfter(n int) {
+	v.memo.after = n
+}
+
+// SetTracer makes t observe each time v materializes itself.
+func (v *UnionView) SetTracer(t Tracer) {
+	v.memo.tracer = t
+}
+
+// SetProgress makes p report the runs produced each time v
+// materializes itself.
+func (v *UnionView) SetProgress(p Progress) {
+	v.memo.progress = p
+}
+
+// Runs returns an iterator over the runs in v, in increasing order.
+func (v *UnionView) Runs() iter.Seq[Uint32Run] {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.Runs()
+	}
+	return v.runs
+}
+
+// runs computes the runs of v from its inputs.
+func (v *UnionView) runs(yield func(Uint32Run) bool) {
+	unionRuns(v.arrays, yield)
+}
+
+// IntersectionView is the intersection of several rangearrays,
+// evaluated lazily.  The intersection of no rangearrays is empty.
+//
+// An IntersectionView reads its inputs on every query, so they must
+// not be modified while the view is in use.  Queries may update the
+// view's memoization state, so an IntersectionView is not safe for
+// concurrent use.
+type IntersectionView struct {
+	arrays []Uint32
+	memo   memo
+}
+
+// NewIntersectionView returns a view of the intersection of arrays.
+func NewIntersectionView(arrays ...Uint32) *IntersectionView {
+	return &IntersectionView{arrays: arrays, memo: memo{op: OpIntersection}}
+}
+
+// Contains reports whether x is in all of v's inputs.
+func (v *IntersectionView) Contains(x uint32) bool {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.Contains(x)
+	}
+
+	for _, a := range v.arrays {
+		if !a.Contains(x) {
+			return false
+		}
+	}
+	return len(v.arrays) > 0
+}
+
+// IndexOf returns the number of elements in v that are less than x.
+func (v *IntersectionView) IndexOf(x uint32) uint32 {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.IndexOf(x)
+	}
+	return indexOfRuns(v.runs, x)
+}
+
+// Len returns the number of elements in v.
+func (v *IntersectionView) Len() uint32 {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.Len()
+	}
+	return lenOfRuns(v.runs)
+}
+
+// All returns an iterator over the values in v, in increasing order.
+func (v *IntersectionView) All() iter.Seq[uint32] {
+	return valuesOf(v.Runs())
+}
+
+// Materialize returns the contents of v as a concrete rangearray.  v
+// keeps its own copy and answers later queries from that.
+func (v *IntersectionView) Materialize() Uint32 {
+	return Uint32{runs: slices.Clone(v.memo.materialize(v.runs).runs)}
+}
+
+// MemoizeAfter makes v materialize itself once it has answered n
+// queries.  If n is zero, v only materializes when asked to.
+func (v *IntersectionView) MemoizeAfter(n int) {
+	v.memo.after = n
+}
+
+// SetTracer makes t observe each time v materializes itself.
+func (v *IntersectionView) SetTracer(t Tracer) {
+	v.memo.tracer = t
+}
+
+// SetProgress makes p report the runs produced each time v
+// materializes itself.
+func (v *IntersectionView) SetProgress(p Progress) {
+	v.memo.progress = p
+}
+
+// Runs returns an iterator over the runs in v, in increasing order.
+func (v *IntersectionView) Runs() iter.Seq[Uint32Run] {
+	if r := v.memo.query(v.runs); r != nil {
+		return r.Runs()
+	}
+	return v.runs
+}
+
+// runs computes the runs of v from its inputs.
+func (v *IntersectionView) runs(yield func(Uint32Run) bool) {
+	intersectRuns(v.arrays, yield)
+}
+
+// memo tracks when a lazy view should be materialized.  op names the
+// materialization for tracer and progress.
+type memo struct {
+	after    int
+	queries  int
+	r        *Uint32
+	op       string
+	tracer   Tracer
+	progress Progress
+}
+
+// query counts a query against a view with the given runs, and returns
+// the materialized view if there is one.
+func (m *memo) query(runs iter.Seq[Uint32Run]) *Uint32 {
+	if m.r == nil && m.after > 0 {
+		m.queries++
+		if m.queries >= m.after {
+			m.materialize(runs)
+		}
+	}
+	return m.r
+}
+
+// materialize builds and keeps a concrete copy of runs.
+func (m *memo) materialize(runs iter.Seq[Uint32Run]) *Uint32 {
+	if m.r == nil {
+		done := startTrace(context.Background(), m.tracer, m.op)
+		pr := m.progress.start(m.op, -1)
+		m.r = &Uint32{}
+		for s := range runs {
+			m.r.runs = append(m.r.runs, s)
+			pr.add(1)
+		}
+		pr.finish()
+		done(len(m.r.runs), nil)
+		if debugChecks {
+			m.r.check("Materialize", 0, 0)
+		}
+	}
+	return m.r
+}
+
+// unionRuns calls yield for each run in the union of arrays, in
+// increasing order, until yield returns false.
+func unionRuns(arrays []Uint32, yield func(Uint32Run) bool) {
+	pos := make([]int, len(arrays))
+	var index uint32
+	for {
+		// Find the earliest run that has not been consumed.
+		best := -1
+		for i, a := range arrays {
+			if pos[i] < len(a.runs) && (best < 0 || a.runs[pos[i]].Value < arrays[best].runs[pos[best]].Value) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return
+		}
+
+		// Absorb every run that overlaps or touches [lo, hi).
+		lo := uint64(arrays[best].runs[pos[best]].Value)
+		hi := lo
+		for grew := true; grew; {
+			grew = false
+			for i, a := range arrays {
+				for pos[i] < len(a.runs) && uint64(a.runs[pos[i]].Value) <= hi {
+					hi = max(hi, uint64(a.runs[pos[i]].Value)+uint64(a.runs[pos[i]].Count))
+					pos[i]++
+					grew = true
+				}
+			}
+		}
+
+		s := Uint32Run{Value: uint32(lo), Index: index, Count: uint32(min(hi-lo, MaxLen))}
+		if !yield(s) {
+			return
+		}
+		index += s.Count
+	}
+}
+
+// intersectRuns calls yield for each run in the intersection of
+// arrays, in increasing order, until yield returns false.
+func intersectRuns(arrays []Uint32, yield func(Uint32Run) bool) {
+	if len(arrays) == 0 {
+		return
+	}
+
+	pos := make([]int, len(arrays))
+	var index uint32
+	for {
+		// Intersect the current run of each input.
+		lo, hi := uint64(0), uint64(math.MaxUint64)
+		for i, a := range arrays {
+			if pos[i] >= len(a.runs) {
+				return
+			}
+			lo = max(lo, uint64(a.runs[pos[i]].Value))
+			hi = min(hi, uint64(a.runs[pos[i]].Value)+uint64(a.runs[pos[i]].Count))
+		}
+
+		if lo < hi {
+			s := Uint32Run{Value: uint32(lo), Index: index, Count: uint32(hi - lo)}
+			if !yield(s) {
+				return
+			}
+			index += s.Count
+		}
+
+		// Runs that end at hi cannot intersect anything later.
+		for i, a := range arrays {
+			if uint64(a.runs[pos[i]].Value)+uint64(a.runs[pos[i]].Count) == hi {
+				pos[i]++
+			}
+		}
+	}
+}
+
+// indexOfRuns returns the number of elements in runs that are less
+// than x.  It stops reading runs once it finds the answer.
+func indexOfRuns(runs iter.Seq[Uint32Run], x uint32) uint32 {
+	var n uint32
+	for s := range runs {
+		if uint64(x) < uint64(s.Value)+uint64(s.Count) {
+			if x <= s.Value {
+				return s.Index
+			}
+			return x - s.Value + s.Index
+		}
+		n = s.Index + s.Count
+	}
+	return n
+}
+
+// lenOfRuns returns the number of elements in runs.
+func lenOfRuns(runs iter.Seq[Uint32Run]) uint32 {
+	var n uint32
+	for s := range runs {
+		n = s.Index + s.Count
+	}
+	return n
+}
+
+// valuesOf returns an iterator over each value in runs.
+func valuesOf(runs iter.Seq[Uint32Run]) iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		for s := range runs {
+			for i := uint32(0); i < s.Count; i++ {
+				if !yield(s.Value + i) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package rangearray
+
+import (
+	"slices"
+	"testing"
+)
+
+func testViewArrays() []Uint32 {
+	a, b, c := &Uint32{}, &Uint32{}, &Uint32{}
+	pushRange(a, 100, 199)
+	pushRange(a, 350, 449)
+	pushRange(b, 150, 249)
+	pushRange(b, 400, 409)
+	pushRange(b, 450, 459)
+	pushRange(c, 0, 9)
+	pushRange(c, 180, 420)
+	return []Uint32{*a, *b, *c}
+}
+
+type queryView interface {
+	Contains(x uint32) bool
+	IndexOf(x uint32) uint32
+	Len() uint32
+}
+
+func testView(t *testing.T, name string, v queryView, want []uint32) {
+	if x := v.Len(); x != uint32(len(want)) {
+		t.Errorf("Expected %s.Len() == %d, got %d", name, len(want), x)
+	}
+	for x := uint32(0); x < 500; x++ {
+		_, found := slices.BinarySearch(want, x)
+		if v.Contains(x) != found {
+			t.Errorf("Expected %s.Contains(%d) == %v", name, x, found)
+		}
+		idx, _ := slices.BinarySearch(want, x)
+		if y := v.IndexOf(x); y != uint32(idx) {
+			t.Errorf("Expected %s.IndexOf(%d) == %d, got %d", name, x, idx, y)
+		}
+	}
+}
+
+func TestUnionView(t *testing.T) {
+	arrays := testViewArrays()
+	var want []uint32
+	for x := uint32(0); x < 500; x++ {
+		if arrays[0].Contains(x) || arrays[1].Contains(x) || arrays[2].Contains(x) {
+			want = append(want, x)
+		}
+	}
+
+	v := NewUnionView(arrays...)
+	testView(t, "UnionView", v, want)
+	if got := slices.Collect(v.All()); !slices.Equal(got, want) {
+		t.Errorf("Expected UnionView.All() == %v, got %v", want, got)
+	}
+	if x := NewUnionView().Len(); x != 0 {
+		t.Errorf("Expected NewUnionView().Len() == 0, got %d", x)
+	}
+}
+
+func TestIntersectionView(t *testing.T) {
+	arrays := testViewArrays()
+	var want []uint32
+	for x := uint32(0); x < 500; x++ {
+		if arrays[0].Contains(x) && arrays[1].Contains(x) && arrays[2].Contains(x) {
+			want = append(want, x)
+		}
+	}
+
+	v := NewIntersectionView(arrays...)
+	testView(t, "IntersectionView", v, want)
+	if got := slices.Collect(v.All()); !slices.Equal(got, want) {
+		t.Errorf("Expected IntersectionView.All() == %v, got %v", want, got)
+	}
+	if NewIntersectionView().Contains(0) {
+		t.Errorf("Expected NewIntersectionView().Contains(0) == false")
+	}
+}
+
+func TestMaterializeView(t *testing.T) {
+	arrays := testViewArrays()
+	v := NewUnionView(arrays...)
+	r := v.Materialize()
+	if x := r.Len(); x != v.Len() {
+		t.Errorf("Expected Materialize().Len() == %d, got %d", v.Len(), x)
+	}
+	if len(r.runs) != 2 {
+		t.Errorf("Expected len(Materialize().runs) == 2, got %d", len(r.runs))
+	}
+
+	// Changing the result must not affect the view.
+	r.Push(1000)
+	if v.Contains(1000) {
+		t.Errorf("Expected UnionView.Contains(1000) == false after changing Materialize() result")
+	}
+}
+
+func TestMemoizeAfter(t *testing.T) {
+	arrays := testViewArrays()
+	v := NewIntersectionView(arrays...)
+	v.MemoizeAfter(3)
+	want := v.Len()
+	if v.memo.r != nil {
+		t.Errorf("Expected no memoization after 1 query")
+	}
+	v.Contains(200)
+	v.IndexOf(200)
+	if v.memo.r == nil {
+		t.Errorf("Expected memoization after 3 queries")
+	}
+	if x := v.Len(); x != want {
+		t.Errorf("Expected memoized Len() == %d, got %d", want, x)
+	}
+	testView(t, "memoized IntersectionView", v, slices.Collect(v.All()))
+}
@@ -0,0 +1,286 @@
+package rangearray
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+// A write-ahead log file starts with the magic bytes "RWAL", followed
+// by one nine-byte record per change: an operation byte (walPush), the
+// value as a little-endian uint32, and the CRC-32C checksum of those
+// five bytes, also as a little-endian uint32.  The checkpoint beside
+// it, with the suffix ".ckpt", is in the binary format.
+
+const (
+	walPush      = 'P'
+	walRecordLen = 9
+
+	// DefaultCompactEvery is the number of records after which a WAL
+	// writes a checkpoint and truncates its log, if
+	// WALOptions.CompactEvery is zero.
+	DefaultCompactEvery = 1 << 20
+)
+
+var errBadWAL = corruptf("rangearray: not a rangearray write-ahead log")
+
+// WALOptions configures a WAL.
+type WALOptions struct {
+	// SyncEvery is the number of records to write between calls to
+	// fsync, and SyncInterval the longest time between them.  A Push
+	// syncs the log when either is reached, so with both zero, every
+	// Push is durable before it returns.  Records written since the
+	// last sync may be lost in a crash.
+	SyncEvery    int
+	SyncInterval time.Duration
+
+	// CompactEvery is the number of records after which Push writes a
+	// checkpoint and empties the log.  If it is zero,
+	// DefaultCompactEvery is used; if it is negative, only explicit
+	// calls to Checkpoint do.
+	CompactEvery int
+
+	// Encoder sets how checkpoints are stored, and Decoder how they
+	// are read.  Checkpoints always have checksums.
+	Encoder Encoder
+	Decoder Decoder
+}
+
+// WAL is a Uint32 whose changes are logged to a file, so that Recover
+// and OpenWAL can rebuild it after a crash or restart.  The log is
+// compacted by writing the whole array to a checkpoint file and
+// starting a new, empty log.  A WAL is not safe for concurrent use.
+type WAL struct {
+	opts     WALOptions
+	path     string
+	f        *os.File
+	w        *bufio.Writer
+	r        Uint32
+	records  int       // records in the log
+	unsynced int       // records since the last sync
+	synced   time.Time // time of the last sync
+	err      error
+}
+
+// OpenWAL recovers the array logged at path, as Recover does, and
+// returns a WAL that goes on logging changes to it.  It creates the log
+// if it does not exist, and drops any partial record that a crash left
+// at its end.
+func OpenWAL(path string, opts WALOptions) (*WAL, error) {
+	r, records, size, err := recoverWAL(path, opts.Decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		_, err = f.Write([]byte("RWAL"))
+		size = 4
+	}
+	if err == nil {
+		err = f.Truncate(size)
+	}
+	if err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &WAL{
+		opts:    opts,
+		path:    path,
+		f:       f,
+		w:       bufio.NewWriter(f),
+		r:       r,
+		records: records,
+		synced:  time.Now(),
+	}, nil
+}
+
+// Recover rebuilds the array logged at path from its checkpoint and
+// the records logged since.  It ignores a partial record at the end of
+// the log, as a crash can leave.  If neither file exists, the array is
+// empty.
+func Recover(path string, d Decoder) (Uint32, error) {
+	r, _, _, err := recoverWAL(path, d)
+	return r, err
+}
+
+// recoverWAL implements Recover, and also returns the number of whole
+// records in the log and the size of the log up to the last of them.
+func recoverWAL(path string, d Decoder) (r Uint32, records int, size int64, err error) {
+	if f, err := os.Open(path + ".ckpt"); err == nil {
+		_, err = d.Decode(bufio.NewReader(f), &r)
+		f.Close()
+		if err != nil {
+			return Uint32{}, 0, 0, err
+		}
+	} else if !os.IsNotExist(err) {
+		return Uint32{}, 0, 0, err
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(b) == 0) {
+		return r, 0, 0, nil
+	} else if err != nil {
+		return Uint32{}, 0, 0, err
+	}
+	if len(b) < 4 || string(b[:4]) != "RWAL" {
+		return Uint32{}, 0, 0, errBadWAL
+	}
+
+	pos := 4
+	for ; pos+walRecordLen <= len(b); pos += walRecordLen {
+		rec := b[pos : pos+walRecordLen]
+		if rec[0] != walPush || binary.LittleEndian.Uint32(rec[5:]) != crc32.Checksum(rec[:5], crcTable) {
+			break
+		}
+		r.Push(binary.LittleEndian.Uint32(rec[1:]))
+		records++
+	}
+	return r, records, int64(pos), nil
+}
+
+// Push adds x to w, and logs it, and reports whether x was added:
+// false if x was already in w, or if w already holds MaxLen values, in
+// which case nothing is logged.  If the options call for it, Push then
+// syncs the log, or writes a checkpoint, and returns true with any
+// error from doing so.  An error from writing the log is returned by
+// this and every later call.
+func (w *WAL) Push(x uint32) (bool, error) {
+	if w.err != nil {
+		return false, w.err
+	}
+	if w.r.Len() == MaxLen || w.r.Contains(x) {
+		return false, nil
+	}
+	var rec [walRecordLen]byte
+	rec[0] = walPush
+	binary.LittleEndian.PutUint32(rec[1:], x)
+	binary.LittleEndian.PutUint32(rec[5:], crc32.Checksum(rec[:5], crcTable))
+	if _, err := w.w.Write(rec[:]); err != nil {
+		w.err = err
+		return false, err
+	}
+	w.r.Push(x)
+	w.records++
+	w.unsynced++
+
+	compact := w.opts.CompactEvery
+	if compact == 0 {
+		compact = DefaultCompactEvery
+	}
+	if compact > 0 && w.records >= compact {
+		return true, w.Checkpoint()
+	}
+	if w.syncDue() {
+		return true, w.Sync()
+	}
+	return true, nil
+}
+
+// syncDue reports whether the options call for syncing the log now.
+func (w *WAL) syncDue() bool {
+	every, interval := w.opts.SyncEvery, w.opts.SyncInterval
+	switch {
+	case every <= 0 && interval <= 0:
+		return true
+	case every > 0 && w.unsynced >= every:
+		return true
+	default:
+		return interval > 0 && time.Since(w.synced) >= interval
+	}
+}
+
+// Sync writes any buffered records to the log and syncs it to disk.
+func (w *WAL) Sync() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.w.Flush(); err != nil {
+		w.err = err
+		return err
+	}
+	if err := w.f.Sync(); err != nil {
+		w.err = err
+		return err
+	}
+	w.unsynced = 0
+	w.synced = time.Now()
+	return nil
+}
+
+// Checkpoint writes the whole array to the checkpoint file, then
+// empties the log.  The new checkpoint replaces the old one atomically,
+// so a crash at any point leaves a checkpoint and log that recover
+// every change that was synced.
+func (w *WAL) Checkpoint() error {
+	if err := w.Sync(); err != nil {
+		return err
+	}
+
+	tmp := w.path + ".ckpt.tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	e := w.opts.Encoder
+	e.Checksum = true
+	bw := bufio.NewWriter(f)
+	_, err = e.Encode(bw, w.r)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, w.path+".ckpt")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// Replaying records that the checkpoint already holds would be
+	// harmless, so the log can be emptied once the rename is durable.
+	if err := syncDir(w.path); err != nil {
+		return err
+	}
+	if err := w.f.Truncate(4); err != nil {
+		w.err = err
+		return err
+	}
+	if _, err := w.f.Seek(4, io.SeekStart); err != nil {
+		w.err = err
+		return err
+	}
+	w.w.Reset(w.f)
+	w.records = 0
+	return nil
+}
+
+// Uint32 returns a copy of the array in w.
+func (w *WAL) Uint32() Uint32 {
+	return w.r.Fork()
+}
+
+// Close syncs the log and closes it.
+func (w *WAL) Close() error {
+	err := w.Sync()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
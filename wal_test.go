@@ -0,0 +1,73 @@
+package rangearray
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	w, err := OpenWAL(path, WALOptions{SyncEvery: 10, CompactEvery: 250})
+	if err != nil {
+		t.Fatalf("OpenWAL() failed: %v", err)
+	}
+	var want Uint32
+	for i := uint32(0); i < 1000; i++ {
+		x := (i * 7919) % 1500
+		added, err := w.Push(x)
+		if err != nil {
+			t.Fatalf("Push(%d) failed: %v", x, err)
+		}
+		if added != want.Push(x) {
+			t.Errorf("Expected Push(%d) to report %v, got %v", x, !added, added)
+		}
+	}
+	records := w.records
+	if added, err := w.Push(0); added || err != nil || w.records != records {
+		t.Errorf("Expected Push() of a duplicate to report false and log nothing, got %v, %v", added, err)
+	}
+	if err := w.Sync(); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	testEqualUint32(t, "w.Uint32()", w.Uint32(), want)
+	if _, err := os.Stat(path + ".ckpt"); err != nil {
+		t.Errorf("Expected a checkpoint after 250 records, got %v", err)
+	}
+
+	// Simulate a crash that tears the last record.
+	w.f.Write([]byte{walPush, 1, 2})
+	got, err := Recover(path, Decoder{})
+	if err != nil {
+		t.Fatalf("Recover() failed: %v", err)
+	}
+	testEqualUint32(t, "Recover()", got, want)
+	w.f.Close()
+
+	w, err = OpenWAL(path, WALOptions{})
+	if err != nil {
+		t.Fatalf("OpenWAL() of an existing log failed: %v", err)
+	}
+	testEqualUint32(t, "reopened", w.Uint32(), want)
+	w.Push(5000)
+	want.Push(5000)
+	if err := w.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint() failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 4 {
+		t.Errorf("Expected Checkpoint() to empty the log, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() failed: %v", err)
+	}
+	got, err = Recover(path, Decoder{})
+	if err != nil {
+		t.Fatalf("Recover() after Close() failed: %v", err)
+	}
+	testEqualUint32(t, "Recover() after Close()", got, want)
+
+	os.WriteFile(path, []byte("junk"), 0o666)
+	if _, err := Recover(path, Decoder{}); err != errBadWAL {
+		t.Errorf("Expected Recover() of a bad log to fail, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package rangearray
+
+import "iter"
+
+// CountIn returns the number of values in r between first and last,
+// inclusive.
+func (r Uint32) CountIn(first, last uint32) uint32 {
+	if last < first {
+		return 0
+	}
+	n := r.IndexOf(last) - r.IndexOf(first)
+	if r.Contains(last) {
+		n++
+	}
+	return n
+}
+
+// IntervalsIn returns an iterator over the runs of r between first and
+// last, inclusive, as [first, last] pairs.  Runs that cross the window
+// boundaries are clipped.
+func (r Uint32) IntervalsIn(first, last uint32) iter.Seq[[2]uint32] {
+	return func(yield func([2]uint32) bool) {
+		if last < first {
+			return
+		}
+		for i := r.LowerBound(first); i < len(r.runs) && r.runs[i].Value <= last; i++ {
+			s := r.runs[i]
+			if !yield([2]uint32{max(s.Value, first), min(s.Value+(s.Count-1), last)}) {
+				return
+			}
+		}
+	}
+}
+
+// GapsIn returns an iterator over the maximal intervals between first
+// and last, inclusive, that contain no values in r, as [first, last]
+// pairs.
+func (r Uint32) GapsIn(first, last uint32) iter.Seq[[2]uint32] {
+	return func(yield func([2]uint32) bool) {
+		if last < first {
+			return
+		}
+		next := uint64(first)
+		for iv := range r.IntervalsIn(first, last) {
+			if uint64(iv[0]) > next && !yield([2]uint32{uint32(next), iv[0] - 1}) {
+				return
+			}
+			next = uint64(iv[1]) + 1
+		}
+		if next <= uint64(last) {
+			yield([2]uint32{uint32(next), last})
+		}
+	}
+}
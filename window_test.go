@@ -0,0 +1,60 @@
+package rangearray
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCountIn(t *testing.T) {
+	r := testEncodingArray()
+	for _, s := range []struct {
+		first, last, want uint32
+	}{
+		{0, 0xffffffff, 202},
+		{150, 400, 101},
+		{199, 350, 2},
+		{200, 349, 0},
+		{1000, 1000, 1},
+		{400, 300, 0},
+	} {
+		if got := r.CountIn(s.first, s.last); got != s.want {
+			t.Errorf("Expected CountIn(%d, %d) == %d, got %d", s.first, s.last, s.want, got)
+		}
+	}
+}
+
+func TestIntervalsIn(t *testing.T) {
+	r := testEncodingArray()
+	got := slices.Collect(r.IntervalsIn(150, 1000))
+	want := [][2]uint32{{150, 199}, {350, 449}, {1000, 1000}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected IntervalsIn(150, 1000) == %v, got %v", want, got)
+	}
+
+	got = slices.Collect(r.IntervalsIn(360, 370))
+	if want := [][2]uint32{{360, 370}}; !slices.Equal(got, want) {
+		t.Errorf("Expected IntervalsIn(360, 370) == %v, got %v", want, got)
+	}
+	if got := slices.Collect(r.IntervalsIn(200, 349)); len(got) != 0 {
+		t.Errorf("Expected IntervalsIn(200, 349) to be empty, got %v", got)
+	}
+}
+
+func TestGapsIn(t *testing.T) {
+	r := testEncodingArray()
+	got := slices.Collect(r.GapsIn(0, 0xffffffff))
+	want := [][2]uint32{{0, 99}, {200, 349}, {450, 999}, {1001, 0xfffffffe}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected GapsIn() == %v, got %v", want, got)
+	}
+
+	got = slices.Collect(r.GapsIn(150, 360))
+	if want := [][2]uint32{{200, 349}}; !slices.Equal(got, want) {
+		t.Errorf("Expected GapsIn(150, 360) == %v, got %v", want, got)
+	}
+
+	got = slices.Collect(Uint32{}.GapsIn(0, 0xffffffff))
+	if want := [][2]uint32{{0, 0xffffffff}}; !slices.Equal(got, want) {
+		t.Errorf("Expected GapsIn() of an empty array == %v, got %v", want, got)
+	}
+}